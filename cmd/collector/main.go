@@ -141,6 +141,10 @@ func main() {
 					fmt.Printf("      Usage:   CPU: %4dm | Mem: %4dMi\n", container.UsageCPU, container.UsageMemory)
 					fmt.Printf("      Request: CPU: %4dm | Mem: %4dMi\n", container.RequestCPU, container.RequestMemory)
 					fmt.Printf("      Limit:   CPU: %4dm | Mem: %4dMi\n", container.LimitCPU, container.LimitMemory)
+					if container.UsageEphemeralStorage > 0 || container.RequestEphemeralStorage > 0 {
+						fmt.Printf("      Storage: Used: %4dMi | Request: %4dMi\n",
+							container.UsageEphemeralStorage/(1024*1024), container.RequestEphemeralStorage/(1024*1024))
+					}
 				}
 			}
 
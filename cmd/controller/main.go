@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"flag"
+	"net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
@@ -11,6 +12,11 @@ import (
 
 	"intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
 	"intelligent-cluster-optimizer/pkg/controller"
+	"intelligent-cluster-optimizer/pkg/metrics"
+	"intelligent-cluster-optimizer/pkg/rollback"
+	"intelligent-cluster-optimizer/pkg/safety"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -34,6 +40,15 @@ var (
 	leaseDuration time.Duration
 	renewDeadline time.Duration
 	retryPeriod   time.Duration
+	metricsAddr   string
+
+	rollbackHistoryRetention int
+
+	maxConcurrentRollouts          int
+	maxChangesPerHour              int
+	namespaceMaxConcurrentRollouts int
+	namespaceMaxChangesPerHour     int
+	changeBudgetPriority           string
 )
 
 func main() {
@@ -47,8 +62,26 @@ func main() {
 	flag.DurationVar(&leaseDuration, "lease-duration", 15*time.Second, "Lease duration")
 	flag.DurationVar(&renewDeadline, "renew-deadline", 10*time.Second, "Renew deadline")
 	flag.DurationVar(&retryPeriod, "retry-period", 2*time.Second, "Retry period")
+	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "Address to serve Prometheus metrics on (empty to disable)")
+	flag.IntVar(&rollbackHistoryRetention, "rollback-history-retention", rollback.MaxHistoryPerWorkload,
+		"Rollback revisions kept per workload in its history ConfigMap")
+	flag.IntVar(&maxConcurrentRollouts, "max-concurrent-rollouts", 0,
+		"Workloads all OptimizerConfigs may be rolling out changes to at once (0 for unlimited)")
+	flag.IntVar(&maxChangesPerHour, "max-changes-per-hour", 0,
+		"Workloads all OptimizerConfigs may change in any hour (0 for unlimited)")
+	flag.IntVar(&namespaceMaxConcurrentRollouts, "namespace-max-concurrent-rollouts", 0,
+		"Workloads of one namespace that may be rolling out changes at once (0 for unlimited)")
+	flag.IntVar(&namespaceMaxChangesPerHour, "namespace-max-changes-per-hour", 0,
+		"Workloads of one namespace that may be changed in any hour (0 for unlimited)")
+	flag.StringVar(&changeBudgetPriority, "change-budget-priority", string(controller.BudgetPrioritySavings),
+		"Which changes consume the change budget first: savings or oom-risk")
 	flag.Parse()
 
+	budgetPriority, err := controller.ParseBudgetPriority(changeBudgetPriority)
+	if err != nil {
+		klog.Fatalf("Invalid --change-budget-priority: %v", err)
+	}
+
 	if err := v1alpha1.AddToScheme(scheme.Scheme); err != nil {
 		klog.Fatalf("Failed to register OptimizerConfig scheme: %v", err)
 	}
@@ -79,6 +112,15 @@ func main() {
 	})
 
 	reconciler := controller.NewReconciler(kubeClient, eventRecorder)
+	reconciler.SetRollbackHistoryRetention(rollbackHistoryRetention)
+	reconciler.SetChangeBudget(safety.NewChangeBudget(
+		safety.BudgetLimits{MaxConcurrentRollouts: maxConcurrentRollouts, MaxChangesPerHour: maxChangesPerHour},
+		safety.BudgetLimits{MaxConcurrentRollouts: namespaceMaxConcurrentRollouts, MaxChangesPerHour: namespaceMaxChangesPerHour},
+	), budgetPriority)
+	if metricsAddr != "" {
+		reconciler.SetMetricsExporter(metrics.NewPrometheusExporter("intelligent_optimizer"))
+		go serveMetrics(metricsAddr)
+	}
 	ctrl := controller.NewOptimizerController(kubeClient, optimizerClient, reconciler, eventRecorder, namespace)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -142,3 +184,12 @@ func main() {
 		},
 	})
 }
+
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	klog.Infof("Serving metrics on %s/metrics", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil && err != http.ErrServerClosed {
+		klog.Errorf("Metrics server failed: %v", err)
+	}
+}
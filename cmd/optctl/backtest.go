@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/backtest"
+	"intelligent-cluster-optimizer/pkg/cost"
+	"intelligent-cluster-optimizer/pkg/models"
+	"intelligent-cluster-optimizer/pkg/storage"
+)
+
+// handleBacktest replays a metric history file written by the collector through the
+// recommendation engine and compares the strategies side by side
+func handleBacktest(metricsFile, namespace string) error {
+	strategies, err := parseBacktestStrategies(backtestStrategies, backtestPercentiles)
+	if err != nil {
+		return err
+	}
+
+	store := storage.NewStorage()
+	if _, err := os.Stat(metricsFile); err != nil {
+		return fmt.Errorf("failed to read metric history: %v", err)
+	}
+	if err := store.LoadFromFile(metricsFile); err != nil {
+		return fmt.Errorf("failed to load metric history: %v", err)
+	}
+
+	var history []models.PodMetric
+	for _, metrics := range store.GetAllMetrics() {
+		history = append(history, metrics...)
+	}
+
+	opts := backtest.Options{
+		Interval: backtestInterval,
+		Pricing:  cost.DefaultPricingModels[pricingModel],
+	}
+	if namespace != "" {
+		opts.Namespaces = []string{namespace}
+	}
+
+	report, err := backtest.NewBacktester(history).Run(opts, strategies)
+	if err != nil {
+		return err
+	}
+
+	if outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("Backtest %s - %s (%d reconciles every %s, pricing: %s)\n",
+		report.Start.Format("2006-01-02 15:04"), report.End.Format("2006-01-02 15:04"),
+		report.Reconciles, report.Interval, pricingModel)
+	fmt.Println(strings.Repeat("-", 100))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STRATEGY\tSAMPLES\tCPU > REQ\tMEM > REQ\tTHROTTLED\tOOM\tCPU UNUSED\tMEM UNUSED\tCOST/MONTH")
+	for _, result := range append([]backtest.Result{report.Baseline}, report.Strategies...) {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%.1f%%\t%d\t%d\t%.0f%%\t%.0f%%\t$%.2f\n",
+			result.Name,
+			result.Samples,
+			result.Percent(result.CPURequestExceeded),
+			result.Percent(result.MemoryRequestExceeded),
+			result.CPULimitExceeded,
+			result.MemoryLimitExceeded,
+			result.CPUOverProvisionPercent,
+			result.MemoryOverProvisionPercent,
+			result.CostPerMonth)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("THROTTLED and OOM count samples whose usage was above the CPU or memory limit.")
+	return nil
+}
+
+// parseBacktestStrategies builds one strategy for every combination of the given
+// strategy names and percentiles, e.g. "balanced,conservative" and "90,95"
+func parseBacktestStrategies(names, percentiles string) ([]backtest.Strategy, error) {
+	var parsedPercentiles []int
+	for _, p := range strings.Split(percentiles, ",") {
+		p = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(p)), "p")
+		if p == "" {
+			continue
+		}
+		value, err := strconv.Atoi(p)
+		if err != nil || value < 50 || value > 99 {
+			return nil, fmt.Errorf("invalid percentile %q (must be 50-99)", p)
+		}
+		parsedPercentiles = append(parsedPercentiles, value)
+	}
+
+	var strategies []backtest.Strategy
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		strategy := optimizerv1alpha1.OptimizationStrategy(name)
+		switch strategy {
+		case optimizerv1alpha1.StrategyAggressive, optimizerv1alpha1.StrategyBalanced, optimizerv1alpha1.StrategyConservative:
+		default:
+			return nil, fmt.Errorf("unknown strategy %q (aggressive, balanced, conservative)", name)
+		}
+
+		if len(parsedPercentiles) == 0 {
+			strategies = append(strategies, backtest.Strategy{Name: name, Strategy: strategy})
+			continue
+		}
+		for _, p := range parsedPercentiles {
+			strategies = append(strategies, backtest.Strategy{
+				Name:     fmt.Sprintf("%s/p%d", name, p),
+				Strategy: strategy,
+				Recommendations: &optimizerv1alpha1.RecommendationConfig{
+					CPUPercentile:    p,
+					MemoryPercentile: p,
+				},
+			})
+		}
+	}
+	if len(strategies) == 0 {
+		return nil, fmt.Errorf("no strategies to compare")
+	}
+	return strategies, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// breaker is the JSON form of one circuit in the breakers command
+type breaker struct {
+	OptimizerConfig string                                 `json:"optimizerConfig"`
+	Circuit         optimizerv1alpha1.CircuitBreakerStatus `json:"circuit"`
+}
+
+// handleBreakers lists the workload and namespace circuit breakers the controller keeps
+// in the status of every OptimizerConfig, optionally limited to one namespace
+func handleBreakers(restConfig *rest.Config, namespace string) error {
+	optimizerClient, err := optimizerv1alpha1.NewOptimizerConfigClient(restConfig, metav1.NamespaceAll)
+	if err != nil {
+		return fmt.Errorf("failed to create optimizer client: %v", err)
+	}
+	configs, err := optimizerClient.List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list OptimizerConfigs: %v", err)
+	}
+
+	var found []breaker
+	for _, config := range configs.Items {
+		for _, circuit := range config.Status.CircuitBreakers {
+			if namespace == "" || circuit.Namespace == namespace {
+				found = append(found, breaker{OptimizerConfig: config.Namespace + "/" + config.Name, Circuit: circuit})
+			}
+		}
+	}
+
+	if outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(found)
+	}
+
+	if len(found) == 0 {
+		fmt.Println("No circuit breaker has recorded failed changes.")
+		return nil
+	}
+
+	now := time.Now()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONFIG\tSCOPE\tTARGET\tSTATE\tERRORS\tTRIPS\tTRIAL IN\tLAST ERROR")
+	for _, b := range found {
+		c := b.Circuit
+		target := c.Namespace
+		if c.Scope == optimizerv1alpha1.CircuitScopeWorkload {
+			target = c.Namespace + "/" + c.Kind + "/" + c.Name
+		}
+		trial := "-"
+		if c.State == optimizerv1alpha1.CircuitStateOpen && c.RetryAt != nil {
+			trial = formatAge(c.RetryAt.Sub(now))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			b.OptimizerConfig, c.Scope, target, c.State, c.ConsecutiveErrors, c.Trips, trial, c.LastError)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+// explanation is the JSON form of the explain command
+type explanation struct {
+	OptimizerConfig string                              `json:"optimizerConfig"`
+	Decision        *optimizerv1alpha1.WorkloadDecision `json:"decision"`
+}
+
+// handleExplain prints the decision trace the controller recorded for a workload in the
+// status of the OptimizerConfigs that target it
+func handleExplain(restConfig *rest.Config, resource string) error {
+	parts := strings.Split(resource, "/")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid resource format, expected: namespace/kind/name")
+	}
+	namespace, kind, name := parts[0], parts[1], parts[2]
+
+	optimizerClient, err := optimizerv1alpha1.NewOptimizerConfigClient(restConfig, metav1.NamespaceAll)
+	if err != nil {
+		return fmt.Errorf("failed to create optimizer client: %v", err)
+	}
+	configs, err := optimizerClient.List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list OptimizerConfigs: %v", err)
+	}
+
+	var found []explanation
+	var omitted []string // configs targeting the namespace that dropped traces
+	for _, config := range configs.Items {
+		if config.Status.DecisionsOmitted > 0 && targetsNamespace(&config, namespace) {
+			omitted = append(omitted, fmt.Sprintf("%s/%s omitted %d", config.Namespace, config.Name, config.Status.DecisionsOmitted))
+		}
+		for i := range config.Status.Decisions {
+			decision := &config.Status.Decisions[i]
+			if decision.Namespace == namespace && strings.EqualFold(decision.Kind, kind) && decision.Name == name {
+				found = append(found, explanation{
+					OptimizerConfig: config.Namespace + "/" + config.Name,
+					Decision:        filterContainers(decision, container),
+				})
+			}
+		}
+	}
+
+	var truncated string
+	if len(found) == 0 && len(omitted) > 0 {
+		truncated = fmt.Sprintf("No decision recorded for %s. Traces are capped per OptimizerConfig and unchanged workloads are left out first: %s.\n",
+			resource, strings.Join(omitted, ", "))
+	}
+
+	if outputJSON {
+		// Keep stdout parseable; the truncation note goes to stderr
+		fmt.Fprint(os.Stderr, truncated)
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(found)
+	}
+
+	if truncated != "" {
+		fmt.Print(truncated)
+		return nil
+	}
+	if len(found) == 0 {
+		fmt.Printf("No decision recorded for %s. Is it in the target namespaces of an OptimizerConfig?\n", resource)
+		return nil
+	}
+	for i, e := range found {
+		if i > 0 {
+			fmt.Println()
+		}
+		if err := printDecision(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func targetsNamespace(config *optimizerv1alpha1.OptimizerConfig, namespace string) bool {
+	for _, ns := range config.Spec.TargetNamespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// filterContainers returns the decision limited to the named container ("" keeps all)
+func filterContainers(decision *optimizerv1alpha1.WorkloadDecision, containerName string) *optimizerv1alpha1.WorkloadDecision {
+	if containerName == "" {
+		return decision
+	}
+	filtered := decision.DeepCopy()
+	filtered.Containers = nil
+	for _, c := range decision.Containers {
+		if c.ContainerName == containerName {
+			filtered.Containers = append(filtered.Containers, c)
+		}
+	}
+	return filtered
+}
+
+func printDecision(e explanation) error {
+	d := e.Decision
+	fmt.Printf("%s/%s/%s (OptimizerConfig %s)\n", d.Namespace, d.Kind, d.Name, e.OptimizerConfig)
+	fmt.Println(strings.Repeat("-", 80))
+	fmt.Printf("Decision:  %s - %s\n", d.Action, d.Reason)
+	fmt.Printf("Decided:   %s (%s ago)\n", d.DecidedAt.Format("2006-01-02 15:04:05"),
+		formatAge(time.Since(d.DecidedAt.Time)))
+
+	if len(d.Gates) > 0 {
+		fmt.Println("\nWorkload gates:")
+		if err := printGates(d.Gates); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range d.Containers {
+		fmt.Printf("\nContainer %s: %s", c.ContainerName, c.Action)
+		if c.Reason != "" {
+			fmt.Printf(" - %s", c.Reason)
+		}
+		fmt.Println()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if c.RecommendationID != "" {
+			fmt.Fprintf(w, "  Recommendation:\t%s\n", c.RecommendationID)
+		}
+		fmt.Fprintf(w, "  Samples:\t%d (confidence %.1f%%)\n", c.SampleCount, c.Confidence)
+		fmt.Fprintf(w, "  CPU:\tP%d usage %s x %.2f margin -> %s (current %s)\n",
+			c.CPUPercentile, c.CPUUsage, c.SafetyMargin, c.RecommendedCPU, c.CurrentCPU)
+		fmt.Fprintf(w, "  Memory:\tP%d usage %s x %.2f margin -> %s (current %s)\n",
+			c.MemoryPercentile, c.MemoryUsage, c.SafetyMargin, c.RecommendedMemory, c.CurrentMemory)
+		if c.StartupCPU != "" {
+			fmt.Fprintf(w, "  Startup CPU:\t%s while warming up\n", c.StartupCPU)
+		}
+		if c.OOMBoost > 0 {
+			fmt.Fprintf(w, "  OOM boost:\t%.2fx\n", c.OOMBoost)
+		}
+		if c.Thresholds != "" {
+			fmt.Fprintf(w, "  Thresholds:\t%s\n", c.Thresholds)
+		}
+		for _, adjustment := range c.Adjustments {
+			fmt.Fprintf(w, "  Adjustment:\t%s\n", adjustment)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+
+		if len(c.Gates) > 0 {
+			fmt.Println("  Gates:")
+			if err := printGates(c.Gates); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func printGates(gates []optimizerv1alpha1.GateVerdict) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, g := range gates {
+		fmt.Fprintf(w, "    %s\t%s\t%s\n", g.Gate, g.Result, g.Message)
+	}
+	return w.Flush()
+}
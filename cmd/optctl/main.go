@@ -11,6 +11,7 @@ import (
 	"text/tabwriter"
 	"time"
 
+	"intelligent-cluster-optimizer/pkg/backtest"
 	"intelligent-cluster-optimizer/pkg/cost"
 	"intelligent-cluster-optimizer/pkg/rollback"
 
@@ -28,18 +29,32 @@ var (
 	outputJSON    bool
 	pricingModel  string
 	allNamespaces bool
-)
 
-const defaultHistoryFile = "/var/lib/optimizer/rollback-history.json"
+	rollbackRevision   int64
+	rollbackToTime     string
+	rollbackToOriginal bool
+	dryRun             bool
+
+	backtestStrategies  string
+	backtestPercentiles string
+	backtestInterval    time.Duration
+)
 
 func main() {
 	klog.InitFlags(nil)
 	flag.StringVar(&kubeconfig, "kubeconfig", filepath.Join(os.Getenv("HOME"), ".kube", "config"), "Path to kubeconfig")
 	flag.StringVar(&container, "container", "", "Container name (default: all containers)")
-	flag.StringVar(&historyFile, "history-file", defaultHistoryFile, "Path to rollback history file")
+	flag.StringVar(&historyFile, "history-file", "", "Read rollback history from a JSON file instead of the cluster")
 	flag.BoolVar(&outputJSON, "json", false, "Output in JSON format")
 	flag.StringVar(&pricingModel, "pricing", "default", "Pricing model (aws-us-east-1, gcp-us-central1, azure-eastus, default)")
 	flag.BoolVar(&allNamespaces, "all-namespaces", false, "List across all namespaces (for cost command)")
+	flag.Int64Var(&rollbackRevision, "revision", 0, "Revision to roll back to (for rollback command)")
+	flag.StringVar(&rollbackToTime, "to-time", "", "Roll back to the state at this RFC3339 time (for rollback command)")
+	flag.BoolVar(&rollbackToOriginal, "to-original", false, "Restore the resources from before the first optimization (for rollback command)")
+	flag.BoolVar(&dryRun, "dry-run", false, "Preview the rollback without applying it")
+	flag.StringVar(&backtestStrategies, "strategies", "aggressive,balanced,conservative", "Strategies to compare (for backtest command)")
+	flag.StringVar(&backtestPercentiles, "percentiles", "", "CPU and memory percentiles to compare, e.g. 90,95 (for backtest command)")
+	flag.DurationVar(&backtestInterval, "interval", backtest.DefaultInterval, "Simulated reconcile interval (for backtest command)")
 	flag.Parse()
 
 	if len(flag.Args()) < 1 {
@@ -52,8 +67,23 @@ func main() {
 	// Commands that don't need kubernetes client
 	switch command {
 	case "history":
-		if err := handleHistory(); err != nil {
-			klog.Fatalf("History command failed: %v", err)
+		if historyFile != "" {
+			if err := handleHistory(nil); err != nil {
+				klog.Fatalf("History command failed: %v", err)
+			}
+			return
+		}
+	case "backtest":
+		if len(flag.Args()) < 2 {
+			printUsage()
+			os.Exit(1)
+		}
+		namespace := ""
+		if len(flag.Args()) > 2 {
+			namespace = flag.Args()[2]
+		}
+		if err := handleBacktest(flag.Args()[1], namespace); err != nil {
+			klog.Fatalf("Backtest failed: %v", err)
 		}
 		return
 	case "cost":
@@ -75,6 +105,10 @@ func main() {
 	}
 
 	switch command {
+	case "history":
+		if err := handleHistory(kubeClient); err != nil {
+			klog.Fatalf("History command failed: %v", err)
+		}
 	case "rollback":
 		if len(flag.Args()) < 2 {
 			printUsage()
@@ -95,6 +129,38 @@ func main() {
 		if err := handleDashboard(kubeClient); err != nil {
 			klog.Fatalf("Dashboard failed: %v", err)
 		}
+	case "simulate":
+		namespace := ""
+		if len(flag.Args()) > 1 {
+			namespace = flag.Args()[1]
+		}
+		if err := handleSimulate(kubeClient, config, namespace); err != nil {
+			klog.Fatalf("Simulation failed: %v", err)
+		}
+	case "provenance":
+		if len(flag.Args()) < 2 {
+			printUsage()
+			os.Exit(1)
+		}
+		if err := handleProvenance(kubeClient, flag.Args()[1]); err != nil {
+			klog.Fatalf("Provenance failed: %v", err)
+		}
+	case "explain":
+		if len(flag.Args()) < 2 {
+			printUsage()
+			os.Exit(1)
+		}
+		if err := handleExplain(config, flag.Args()[1]); err != nil {
+			klog.Fatalf("Explain failed: %v", err)
+		}
+	case "breakers":
+		namespace := ""
+		if len(flag.Args()) > 1 {
+			namespace = flag.Args()[1]
+		}
+		if err := handleBreakers(config, namespace); err != nil {
+			klog.Fatalf("Breakers failed: %v", err)
+		}
 	default:
 		klog.Fatalf("Unknown command: %s", command)
 	}
@@ -107,14 +173,26 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "  cost [namespace]                      Calculate resource costs and savings\n")
 	fmt.Fprintf(os.Stderr, "  cost pricing                          Show available pricing models\n")
 	fmt.Fprintf(os.Stderr, "  history [resource]                    Show optimization history\n")
-	fmt.Fprintf(os.Stderr, "  rollback <namespace/kind/name>        Rollback workload to previous config\n")
+	fmt.Fprintf(os.Stderr, "  simulate [namespace]                  Show nodes reclaimable by re-packing with recommendations\n")
+	fmt.Fprintf(os.Stderr, "  backtest <metrics-file> [namespace]   Replay collected metrics to compare strategies\n")
+	fmt.Fprintf(os.Stderr, "  explain <namespace/kind/name>         Show why a workload was resized or skipped\n")
+	fmt.Fprintf(os.Stderr, "  breakers [namespace]                  Show workload and namespace circuit breakers\n")
+	fmt.Fprintf(os.Stderr, "  rollback <namespace/kind/name>        Rollback workload to a previous revision\n")
+	fmt.Fprintf(os.Stderr, "  provenance <namespace/kind/name>      Show what the optimizer changed on a workload\n")
 	fmt.Fprintf(os.Stderr, "\nOptions:\n")
 	fmt.Fprintf(os.Stderr, "  --kubeconfig      Path to kubeconfig (default: ~/.kube/config)\n")
 	fmt.Fprintf(os.Stderr, "  --container       Container name (default: all containers)\n")
 	fmt.Fprintf(os.Stderr, "  --pricing         Pricing model (default: default)\n")
 	fmt.Fprintf(os.Stderr, "  --all-namespaces  Calculate costs across all namespaces\n")
-	fmt.Fprintf(os.Stderr, "  --history-file    Path to history file (default: %s)\n", defaultHistoryFile)
+	fmt.Fprintf(os.Stderr, "  --history-file    Read rollback history from a file instead of the cluster\n")
 	fmt.Fprintf(os.Stderr, "  --json            Output in JSON format\n")
+	fmt.Fprintf(os.Stderr, "  --revision        Revision to roll back to (default: undo the latest change)\n")
+	fmt.Fprintf(os.Stderr, "  --to-time         Roll back to the state at an RFC3339 time\n")
+	fmt.Fprintf(os.Stderr, "  --to-original     Restore the resources from before the first optimization\n")
+	fmt.Fprintf(os.Stderr, "  --dry-run         Preview the rollback diff without applying it\n")
+	fmt.Fprintf(os.Stderr, "  --strategies      Strategies to backtest (default: aggressive,balanced,conservative)\n")
+	fmt.Fprintf(os.Stderr, "  --percentiles     Percentiles to backtest with each strategy, e.g. 90,95\n")
+	fmt.Fprintf(os.Stderr, "  --interval        Simulated reconcile interval for backtest (default: 1h)\n")
 	fmt.Fprintf(os.Stderr, "\nExamples:\n")
 	fmt.Fprintf(os.Stderr, "  optctl dashboard                                # Show cluster dashboard\n")
 	fmt.Fprintf(os.Stderr, "  optctl cost default                             # Cost for namespace\n")
@@ -122,7 +200,14 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "  optctl cost pricing                             # Show pricing models\n")
 	fmt.Fprintf(os.Stderr, "  optctl --pricing=aws-us-east-1 cost default     # Use AWS pricing\n")
 	fmt.Fprintf(os.Stderr, "  optctl history                                  # Show all history\n")
-	fmt.Fprintf(os.Stderr, "  optctl rollback default/Deployment/nginx        # Rollback workload\n")
+	fmt.Fprintf(os.Stderr, "  optctl simulate production                      # Reclaimable nodes per pool\n")
+	fmt.Fprintf(os.Stderr, "  optctl explain production/deployment/api        # Decision trace of a workload\n")
+	fmt.Fprintf(os.Stderr, "  optctl breakers production                      # Circuit breakers in a namespace\n")
+	fmt.Fprintf(os.Stderr, "  optctl --percentiles=90,95 backtest metrics_data_default.json  # P90 vs P95\n")
+	fmt.Fprintf(os.Stderr, "  optctl history default/Deployment/nginx         # List revisions of a workload\n")
+	fmt.Fprintf(os.Stderr, "  optctl rollback default/Deployment/nginx        # Undo the latest change\n")
+	fmt.Fprintf(os.Stderr, "  optctl --revision=2 --dry-run rollback default/Deployment/nginx  # Preview revision 2\n")
+	fmt.Fprintf(os.Stderr, "  optctl provenance default/Deployment/nginx      # Original and previous resources\n")
 }
 
 func showPricingModels() {
@@ -130,7 +215,7 @@ func showPricingModels() {
 	fmt.Println(strings.Repeat("-", 70))
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "MODEL\tPROVIDER\tREGION\tCPU/CORE/HR\tMEM/GB/HR\tTIER")
+	fmt.Fprintln(w, "MODEL\tPROVIDER\tREGION\tCPU/CORE/HR\tMEM/GB/HR\tSTORAGE/GB/HR\tTIER")
 
 	// Sort keys for consistent output
 	var keys []string
@@ -141,12 +226,13 @@ func showPricingModels() {
 
 	for _, name := range keys {
 		model := cost.DefaultPricingModels[name]
-		fmt.Fprintf(w, "%s\t%s\t%s\t$%.4f\t$%.4f\t%s\n",
+		fmt.Fprintf(w, "%s\t%s\t%s\t$%.4f\t$%.4f\t$%.5f\t%s\n",
 			name,
 			model.Provider,
 			model.Region,
 			model.CPUPerCoreHour,
 			model.MemoryPerGBHour,
+			model.EphemeralStoragePerGBHour,
 			model.Tier)
 	}
 	_ = w.Flush()
@@ -394,24 +480,51 @@ func formatMemory(bytes int64) string {
 	return fmt.Sprintf("%d B", bytes)
 }
 
-func handleHistory() error {
-	manager := rollback.NewRollbackManager(nil)
+// loadHistory returns a rollback manager holding the history from --history-file when
+// set, otherwise the history ConfigMaps the controller keeps in the namespace ("" for all)
+func loadHistory(kubeClient kubernetes.Interface, namespace string) (*rollback.RollbackManager, error) {
+	if historyFile != "" {
+		manager := rollback.NewRollbackManager(kubeClient)
+		manager.SetStore(nil)
+		if err := manager.LoadFromFile(historyFile); err != nil {
+			return nil, fmt.Errorf("failed to load history file %s: %v", historyFile, err)
+		}
+		return manager, nil
+	}
+
+	manager := rollback.NewRollbackManager(kubeClient)
+	if err := manager.LoadFromCluster(context.Background(), namespace); err != nil {
+		return nil, fmt.Errorf("failed to load history: %v", err)
+	}
+	return manager, nil
+}
+
+func handleHistory(kubeClient kubernetes.Interface) error {
+	namespace := metav1.NamespaceAll
+	if len(flag.Args()) > 1 {
+		namespace = strings.Split(flag.Args()[1], "/")[0]
+	}
 
-	// Load history from file
-	if err := manager.LoadFromFile(historyFile); err != nil {
-		return fmt.Errorf("failed to load history: %v", err)
+	manager, err := loadHistory(kubeClient, namespace)
+	if err != nil {
+		return err
 	}
 
 	history := manager.GetAllHistory()
 
 	if len(history) == 0 {
 		fmt.Println("No optimization history found.")
-		fmt.Printf("History file: %s\n", historyFile)
+		if historyFile != "" {
+			fmt.Printf("History file: %s\n", historyFile)
+		}
 		return nil
 	}
 
 	// Check if specific workload requested
 	if len(flag.Args()) > 1 {
+		if kubeClient != nil && len(strings.Split(flag.Args()[1], "/")) == 3 {
+			return showRevisions(manager, flag.Args()[1])
+		}
 		return showWorkloadHistory(manager, flag.Args()[1])
 	}
 
@@ -518,54 +631,16 @@ func showWorkloadHistory(manager *rollback.RollbackManager, resource string) err
 	}
 
 	fmt.Println()
-	fmt.Printf("Total entries: %d (max: %d per workload)\n", len(configs), rollback.MaxHistoryPerWorkload)
+	fmt.Printf("Total entries: %d\n", len(configs))
 
-	if len(configs) >= 2 {
-		fmt.Println("\nTo rollback to previous configuration:")
+	if len(configs) >= 1 {
+		fmt.Println("\nTo rollback to the configuration before the latest change:")
 		fmt.Printf("  optctl rollback %s/%s/%s --container=%s\n", namespace, kind, name, containerName)
 	}
 
 	return nil
 }
 
-func handleRollback(kubeClient kubernetes.Interface, resource string) error {
-	parts := strings.Split(resource, "/")
-	if len(parts) != 3 {
-		return fmt.Errorf("invalid resource format, expected: namespace/kind/name")
-	}
-
-	namespace := parts[0]
-	kind := parts[1]
-	name := parts[2]
-
-	if container == "" {
-		container = "app"
-		klog.Infof("No container specified, using default: %s", container)
-	}
-
-	manager := rollback.NewRollbackManager(kubeClient)
-
-	// Load existing history
-	if err := manager.LoadFromFile(historyFile); err != nil {
-		klog.Warningf("Could not load history file: %v", err)
-	}
-
-	klog.Infof("Rolling back %s/%s/%s container=%s", namespace, kind, name, container)
-
-	ctx := context.Background()
-	if err := manager.RollbackWorkload(ctx, namespace, kind, name, container); err != nil {
-		return err
-	}
-
-	// Save updated history
-	if err := manager.SaveToFile(historyFile); err != nil {
-		klog.Warningf("Could not save history file: %v", err)
-	}
-
-	fmt.Printf("Successfully rolled back %s/%s/%s\n", namespace, kind, name)
-	return nil
-}
-
 func formatAge(d time.Duration) string {
 	if d < time.Minute {
 		return fmt.Sprintf("%ds", int(d.Seconds()))
@@ -626,10 +701,14 @@ func handleDashboard(kubeClient kubernetes.Interface) error {
 	totalCost := calculator.CalculateCost(totalCPUMillis, totalMemBytes)
 
 	// Load history
-	historyManager := rollback.NewRollbackManager(nil)
-	_ = historyManager.LoadFromFile(historyFile)
-	history := historyManager.GetAllHistory()
-	historyCount := historyManager.GetHistoryCount()
+	var history map[string][]rollback.WorkloadConfig
+	historyCount := 0
+	if historyManager, err := loadHistory(kubeClient, metav1.NamespaceAll); err != nil {
+		klog.Warningf("Could not load optimization history: %v", err)
+	} else {
+		history = historyManager.GetAllHistory()
+		historyCount = historyManager.GetHistoryCount()
+	}
 
 	// Print dashboard
 	printDashboardHeader()
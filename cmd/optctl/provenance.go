@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/provenance"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// handleProvenance shows what the optimizer recorded on a workload's annotations: which
+// OptimizerConfig and recommendation changed it, when, and its original and previous resources
+func handleProvenance(kubeClient kubernetes.Interface, resource string) error {
+	namespace, kind, name, err := parseWorkload(resource)
+	if err != nil {
+		return err
+	}
+
+	record, err := provenance.Get(context.Background(), kubeClient, namespace, kind, name)
+	if err != nil {
+		return err
+	}
+
+	if outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(record)
+	}
+
+	if record.IsEmpty() {
+		fmt.Printf("%s has not been changed by the optimizer\n", resource)
+		return nil
+	}
+
+	fmt.Printf("Provenance of %s\n", resource)
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("Last optimized:  %s (%s ago)\n", record.LastOptimized.Local().Format("2006-01-02 15:04:05"),
+		formatAge(time.Since(record.LastOptimized)))
+	fmt.Printf("OptimizerConfig: %s\n", orDash(record.OptimizerConfig))
+	fmt.Printf("Recommendation:  %s\n", orDash(record.RecommendationID))
+
+	names := make([]string, 0, len(record.Original))
+	for containerName := range record.Original {
+		if container == "" || containerName == container {
+			names = append(names, containerName)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CONTAINER\tORIGINAL\tPREVIOUS")
+	for _, containerName := range names {
+		previous := record.Previous[containerName]
+		fmt.Fprintf(w, "%s\t%s\t%s\n", containerName,
+			describeResources(record.Original[containerName]), describeResources(previous))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nTo restore the original resources:")
+	fmt.Printf("  optctl --to-original --dry-run rollback %s\n", resource)
+	return nil
+}
+
+// describeResources renders requests and limits as "cpu=100m/200m mem=256Mi/512Mi"
+func describeResources(r provenance.Resources) string {
+	parts := []string{
+		fmt.Sprintf("cpu=%s/%s", orDash(r.CPU), orDash(r.CPULimit)),
+		fmt.Sprintf("mem=%s/%s", orDash(r.Memory), orDash(r.MemoryLimit)),
+	}
+	if r.EphemeralStorage != "" {
+		parts = append(parts, "ephemeral="+r.EphemeralStorage)
+	}
+	return strings.Join(parts, " ")
+}
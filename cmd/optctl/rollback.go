@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/rollback"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// handleRollback rolls a workload back to a revision, a point in time or its original
+// resources. All containers change in one update unless --container limits it, and
+// the diff is printed first; --dry-run stops after the preview.
+func handleRollback(kubeClient kubernetes.Interface, resource string) error {
+	namespace, kind, name, err := parseWorkload(resource)
+	if err != nil {
+		return err
+	}
+
+	target := rollback.RollbackTarget{Revision: rollbackRevision, Original: rollbackToOriginal}
+	if rollbackToTime != "" {
+		if target.Time, err = time.Parse(time.RFC3339, rollbackToTime); err != nil {
+			return fmt.Errorf("invalid --to-time %q, expected RFC3339 (e.g. 2025-12-27T10:00:00Z): %v", rollbackToTime, err)
+		}
+	}
+	targets := 0
+	for _, set := range []bool{target.Revision > 0, !target.Time.IsZero(), target.Original} {
+		if set {
+			targets++
+		}
+	}
+	if targets > 1 {
+		return fmt.Errorf("--revision, --to-time and --to-original are mutually exclusive")
+	}
+	if container != "" {
+		target.Containers = []string{container}
+	}
+
+	manager, err := loadHistory(kubeClient, namespace)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	plan, err := manager.PlanRollback(ctx, namespace, kind, name, target)
+	if err != nil {
+		return err
+	}
+
+	if outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(plan); err != nil {
+			return err
+		}
+	} else {
+		printRollbackPlan(plan)
+	}
+
+	if len(plan.Changes) == 0 || dryRun {
+		if dryRun && !outputJSON {
+			fmt.Println("\nDry run: no changes applied.")
+		}
+		return nil
+	}
+
+	if err := manager.ExecuteRollback(ctx, plan); err != nil {
+		return err
+	}
+
+	// A history file is updated in place; cluster history is updated by the rollback itself
+	if historyFile != "" {
+		if err := manager.SaveToFile(historyFile); err != nil {
+			klog.Warningf("Could not save history file: %v", err)
+		}
+	}
+
+	if !outputJSON {
+		fmt.Printf("\nSuccessfully rolled back %s/%s/%s to %s\n", namespace, kind, name, plan.Target)
+	}
+	return nil
+}
+
+// parseWorkload splits a namespace/kind/name reference
+func parseWorkload(resource string) (string, string, string, error) {
+	parts := strings.Split(resource, "/")
+	if len(parts) != 3 {
+		return "", "", "", fmt.Errorf("invalid resource format, expected: namespace/kind/name")
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+func printRollbackPlan(plan *rollback.RollbackPlan) {
+	fmt.Printf("Rollback %s/%s/%s to %s\n", plan.Namespace, plan.Kind, plan.Name, plan.Target)
+	fmt.Println(strings.Repeat("-", 60))
+	if len(plan.Changes) == 0 {
+		fmt.Println("Resources already match the target; nothing to do.")
+		return
+	}
+	for _, change := range plan.Changes {
+		fmt.Printf("Container %s:\n", change.ContainerName)
+		for _, line := range change.Diff() {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+}
+
+// showRevisions lists the revisions a workload can be rolled back to
+func showRevisions(manager *rollback.RollbackManager, resource string) error {
+	namespace, kind, name, err := parseWorkload(resource)
+	if err != nil {
+		return err
+	}
+
+	revisions, err := manager.ListRevisions(context.Background(), namespace, kind, name)
+	if err != nil {
+		return err
+	}
+
+	if outputJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(revisions)
+	}
+
+	if len(revisions) == 0 {
+		fmt.Printf("No revisions recorded for %s\n", resource)
+		return nil
+	}
+
+	fmt.Printf("Revisions of %s (state before each change)\n", resource)
+	fmt.Println(strings.Repeat("-", 80))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tCHANGED\tAGE\tCHANGED CONTAINER\tRESOURCES")
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		var resources []string
+		for _, c := range revision.Containers {
+			if container != "" && c.ContainerName != container {
+				continue
+			}
+			resources = append(resources, fmt.Sprintf("%s=%s/%s", c.ContainerName, orDash(c.CPU), orDash(c.Memory)))
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n",
+			revision.Number,
+			revision.ChangedAt.Format("2006-01-02 15:04:05"),
+			formatAge(time.Since(revision.ChangedAt)),
+			revision.ChangedContainer,
+			strings.Join(resources, " "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nTo preview a rollback to a revision:")
+	fmt.Printf("  optctl --revision=%d --dry-run rollback %s\n", revisions[len(revisions)-1].Number, resource)
+	return nil
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
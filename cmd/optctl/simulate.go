@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/simulation"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// simulationReport is the JSON form of the simulate command
+type simulationReport struct {
+	Baseline *simulation.Result                                    `json:"baseline"`
+	Configs  map[string]*optimizerv1alpha1.PackingSimulationStatus `json:"configs"`
+}
+
+// handleSimulate shows the bin-packing simulation the controller recorded for each
+// OptimizerConfig, next to a live re-pack of the cluster at its current requests
+func handleSimulate(kubeClient kubernetes.Interface, restConfig *rest.Config, namespace string) error {
+	ctx := context.Background()
+
+	nodes, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %v", err)
+	}
+	pods, err := kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list pods: %v", err)
+	}
+	baseline := simulation.NewSimulator().Simulate(nodes.Items, pods.Items, nil)
+
+	optimizerClient, err := optimizerv1alpha1.NewOptimizerConfigClient(restConfig, namespace)
+	if err != nil {
+		return fmt.Errorf("failed to create optimizer client: %v", err)
+	}
+	configs, err := optimizerClient.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list OptimizerConfigs: %v", err)
+	}
+
+	if outputJSON {
+		report := simulationReport{
+			Baseline: baseline,
+			Configs:  make(map[string]*optimizerv1alpha1.PackingSimulationStatus),
+		}
+		for _, config := range configs.Items {
+			report.Configs[config.Namespace+"/"+config.Name] = config.Status.PackingSimulation
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+
+	fmt.Printf("Cluster Re-pack at Current Requests (%d nodes, %d needed)\n", baseline.Nodes, baseline.NodesAfterRepack)
+	fmt.Println(strings.Repeat("-", 80))
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POOL\tNODES\tNEEDED\tIDLE\tCPU FRAG\tMEM FRAG")
+	for _, pool := range baseline.Pools {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.0f%%\t%.0f%%\n",
+			pool.Pool,
+			pool.Nodes,
+			pool.NodesAfterRepack,
+			pool.Nodes-pool.NodesAfterRepack,
+			pool.CPUFragmentation*100,
+			pool.MemoryFragmentation*100)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, config := range configs.Items {
+		fmt.Println()
+		status := config.Status.PackingSimulation
+		if status == nil {
+			fmt.Printf("%s/%s: no simulation recorded yet\n", config.Namespace, config.Name)
+			continue
+		}
+
+		fmt.Printf("%s/%s: %d nodes reclaimable with recommendations (simulated %s ago)\n",
+			config.Namespace, config.Name, status.ReclaimableNodes, formatAge(time.Since(status.SimulatedAt.Time)))
+		fmt.Println(strings.Repeat("-", 80))
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "POOL\tNODES\tREPACKED\tRECOMMENDED\tRECLAIMABLE\tUNSCHEDULABLE\tCPU FRAG\tMEM FRAG")
+		for _, pool := range status.Pools {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d%%\t%d%%\n",
+				pool.Pool,
+				pool.Nodes,
+				pool.NodesAfterRepack,
+				pool.NodesAfterRecommendations,
+				pool.ReclaimableNodes,
+				pool.UnschedulablePods,
+				pool.CPUFragmentationPercent,
+				pool.MemoryFragmentationPercent)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -58,6 +59,13 @@ type OptimizerConfigSpec struct {
 	// +kubebuilder:default=false
 	DryRun bool `json:"dryRun"`
 
+	// AllowQoSClassChange lets changes move a workload to another pod QoS class.
+	// By default the class is kept: limits of Guaranteed workloads move with their
+	// requests, and changes that would still alter the class are skipped.
+	// +optional
+	// +kubebuilder:default=false
+	AllowQoSClassChange bool `json:"allowQoSClassChange,omitempty"`
+
 	// MaintenanceWindows defines when updates are allowed to be applied
 	// +optional
 	MaintenanceWindows []MaintenanceWindow `json:"maintenanceWindows,omitempty"`
@@ -82,6 +90,16 @@ type OptimizerConfigSpec struct {
 	// +optional
 	PDBAwareness *PDBAwareness `json:"pdbAwareness,omitempty"`
 
+	// NodeFitAwareness checks raised requests against node allocatable before applying.
+	// The check is disabled when not set.
+	// +optional
+	NodeFitAwareness *NodeFitAwareness `json:"nodeFitAwareness,omitempty"`
+
+	// QuotaAwareness checks changes against namespace ResourceQuotas and LimitRanges.
+	// The check is disabled when not set.
+	// +optional
+	QuotaAwareness *QuotaAwareness `json:"quotaAwareness,omitempty"`
+
 	// CircuitBreaker configures the circuit breaker for failure protection
 	// +optional
 	CircuitBreaker *CircuitBreakerConfig `json:"circuitBreaker,omitempty"`
@@ -90,6 +108,21 @@ type OptimizerConfigSpec struct {
 	// +optional
 	GitOpsExport *GitOpsExportConfig `json:"gitOpsExport,omitempty"`
 
+	// TimeBasedScaling switches workloads between time-of-day resource profiles
+	// derived from their detected usage patterns
+	// +optional
+	TimeBasedScaling *TimeBasedScalingConfig `json:"timeBasedScaling,omitempty"`
+
+	// OOMFastPath bumps memory immediately when a container is OOMKilled repeatedly,
+	// without waiting for the next reconcile
+	// +optional
+	OOMFastPath *OOMFastPathConfig `json:"oomFastPath,omitempty"`
+
+	// MarginTuning adjusts each workload's safety margin from the outcomes of the
+	// changes applied to it
+	// +optional
+	MarginTuning *MarginTuningConfig `json:"marginTuning,omitempty"`
+
 	// TargetResources defines which resource types to optimize
 	// +optional
 	// +kubebuilder:default={deployments,statefulsets}
@@ -155,6 +188,15 @@ type ProfileOverrides struct {
 	// DryRun overrides whether to start in dry-run mode
 	// +optional
 	DryRun *bool `json:"dryRun,omitempty"`
+
+	// RollbackOnError overrides whether changes are rolled back automatically when
+	// SLA health degrades after they were applied
+	// +optional
+	RollbackOnError *bool `json:"rollbackOnError,omitempty"`
+
+	// RollbackCooldown overrides how long rolled-back workloads are left alone (e.g., "12h")
+	// +optional
+	RollbackCooldown string `json:"rollbackCooldown,omitempty"`
 }
 
 // MaintenanceWindow defines a time window when updates are allowed
@@ -182,6 +224,10 @@ type ResourceThresholds struct {
 	// Memory threshold configuration
 	// +optional
 	Memory *ResourceLimit `json:"memory,omitempty"`
+
+	// EphemeralStorage threshold configuration
+	// +optional
+	EphemeralStorage *ResourceLimit `json:"ephemeralStorage,omitempty"`
 }
 
 // ResourceLimit defines min and max for a resource
@@ -230,23 +276,225 @@ type RecommendationConfig struct {
 	// +optional
 	// +kubebuilder:default="24h"
 	HistoryDuration string `json:"historyDuration,omitempty"`
+
+	// Algorithm selects how usage history is turned into a recommendation
+	// +optional
+	// +kubebuilder:validation:Enum=percentile;decaying-histogram
+	// +kubebuilder:default=percentile
+	Algorithm RecommendationAlgorithm `json:"algorithm,omitempty"`
+
+	// CPURecommender is the name of the recommender used for CPU, overriding Algorithm;
+	// any recommender registered with the engine can be selected
+	// +optional
+	CPURecommender string `json:"cpuRecommender,omitempty"`
+
+	// MemoryRecommender is the name of the recommender used for memory, overriding Algorithm;
+	// any recommender registered with the engine can be selected
+	// +optional
+	MemoryRecommender string `json:"memoryRecommender,omitempty"`
+
+	// HalfLife is how quickly old samples lose weight in the decaying histogram (e.g., "24h")
+	// +optional
+	// +kubebuilder:default="24h"
+	HalfLife string `json:"halfLife,omitempty"`
+
+	// MemoryPeakInterval is the window whose peak memory usage is recorded as a
+	// single histogram sample (e.g., "24h")
+	// +optional
+	// +kubebuilder:default="24h"
+	MemoryPeakInterval string `json:"memoryPeakInterval,omitempty"`
+
+	// Forecast enables forecast-driven sizing on top of the historical percentile
+	// +optional
+	Forecast *ForecastConfig `json:"forecast,omitempty"`
+
+	// WarmUp excludes samples taken while containers warm up after a start
+	// +optional
+	WarmUp *WarmUpConfig `json:"warmUp,omitempty"`
+}
+
+// WarmUpConfig configures how samples from container warm-up are handled. Samples
+// taken within Duration of a container start, or before the container became ready,
+// are left out of the usage percentiles and sized separately as startup CPU.
+type WarmUpConfig struct {
+	// Enabled controls whether warm-up samples are excluded
+	// +optional
+	// +kubebuilder:default=false
+	Enabled bool `json:"enabled"`
+
+	// Duration is how long after a container start samples count as warm-up (e.g., "5m")
+	// +optional
+	// +kubebuilder:default="5m"
+	Duration string `json:"duration,omitempty"`
+
+	// StartupCPURatio is how many times the steady-state CPU recommendation the
+	// warm-up CPU must reach before a dedicated startup CPU recommendation is kept
+	// +optional
+	// +kubebuilder:validation:Minimum=1.0
+	// +kubebuilder:default=2.0
+	StartupCPURatio float64 `json:"startupCPURatio,omitempty"`
+}
+
+// ForecastConfig configures forecast-driven sizing. Forecasts can only raise a
+// recommendation; the historical percentile is always the floor.
+type ForecastConfig struct {
+	// Enabled controls whether forecasts feed into recommendations
+	// +optional
+	// +kubebuilder:default=false
+	Enabled bool `json:"enabled"`
+
+	// Horizon is how far ahead to forecast (e.g., "24h")
+	// +optional
+	// +kubebuilder:default="24h"
+	Horizon string `json:"horizon,omitempty"`
+
+	// Interval is the resampling interval for the forecast model (e.g., "1h")
+	// +optional
+	// +kubebuilder:default="1h"
+	Interval string `json:"interval,omitempty"`
+
+	// Target selects whether the forecast peak or its upper prediction bound is used
+	// +optional
+	// +kubebuilder:validation:Enum=UpperBound;Peak
+	// +kubebuilder:default=UpperBound
+	Target ForecastTarget `json:"target,omitempty"`
+
+	// MinIncreasePercent is how much the forecast must raise a target before it is reported
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=5
+	MinIncreasePercent float64 `json:"minIncreasePercent,omitempty"`
+}
+
+// TimeBasedScalingConfig configures time-of-day resource profiles. The controller
+// detects business-hours, night-batch and weekend patterns per workload and scales
+// the recommendation by the multiplier of the schedule entry active at the time.
+type TimeBasedScalingConfig struct {
+	// Enabled controls whether time-of-day profiles are applied
+	// +optional
+	// +kubebuilder:default=false
+	Enabled bool `json:"enabled"`
+
+	// Timezone used for pattern detection and schedule evaluation (e.g., "America/New_York")
+	// +optional
+	// +kubebuilder:default="UTC"
+	Timezone string `json:"timezone,omitempty"`
+
+	// HistoryDuration is how much history is analyzed for patterns (e.g., "168h")
+	// +optional
+	// +kubebuilder:default="168h"
+	HistoryDuration string `json:"historyDuration,omitempty"`
+}
+
+// OOMFastPathConfig configures the pod-event-driven memory bump for OOMKilled containers.
+// Memory is raised by the OOM boost factor for the container's restart count and
+// capped by ResourceThresholds.
+type OOMFastPathConfig struct {
+	// Enabled controls whether OOMKilled containers get an immediate memory bump
+	// +optional
+	// +kubebuilder:default=false
+	Enabled bool `json:"enabled"`
+
+	// AllowOutsideMaintenanceWindow lets the fast path apply outside maintenance windows
+	// +optional
+	// +kubebuilder:default=false
+	AllowOutsideMaintenanceWindow bool `json:"allowOutsideMaintenanceWindow,omitempty"`
+
+	// MinRestarts is how many restarts an OOMKilled container needs before it is bumped
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=2
+	MinRestarts int32 `json:"minRestarts,omitempty"`
+
+	// MinInterval is the minimum time between two fast-path bumps of the same container (e.g., "15m")
+	// +optional
+	// +kubebuilder:default="15m"
+	MinInterval string `json:"minInterval,omitempty"`
+}
+
+// MarginTuningConfig configures closed-loop tuning of per-workload safety margins.
+// After a change is applied the workload is watched for OOM kills, restarts, CPU
+// throttling and SLA degradation. The margin tightens by Step after TightenAfter
+// consecutive good observation windows and widens by twice Step after an incident,
+// always within the bounds.
+type MarginTuningConfig struct {
+	// Enabled controls whether safety margins are tuned from post-apply outcomes
+	// +optional
+	// +kubebuilder:default=false
+	Enabled bool `json:"enabled"`
+
+	// MinSafetyMargin is the tightest margin tuning may reach (default: from the profile)
+	// +optional
+	// +kubebuilder:validation:Minimum=1.0
+	// +kubebuilder:validation:Maximum=3.0
+	MinSafetyMargin float64 `json:"minSafetyMargin,omitempty"`
+
+	// MaxSafetyMargin is the widest margin tuning may reach (default: from the profile)
+	// +optional
+	// +kubebuilder:validation:Minimum=1.0
+	// +kubebuilder:validation:Maximum=3.0
+	MaxSafetyMargin float64 `json:"maxSafetyMargin,omitempty"`
+
+	// ObservationWindow is how long a workload must run without incidents for the
+	// window to count as good (e.g., "6h")
+	// +optional
+	// +kubebuilder:default="6h"
+	ObservationWindow string `json:"observationWindow,omitempty"`
+
+	// TightenAfter is how many consecutive good windows tighten the margin
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=3
+	TightenAfter int32 `json:"tightenAfter,omitempty"`
+
+	// Step is how much the margin tightens per adjustment (e.g., 0.05)
+	// +optional
+	// +kubebuilder:validation:Minimum=0.01
+	// +kubebuilder:validation:Maximum=0.5
+	Step float64 `json:"step,omitempty"`
 }
 
+// ForecastTarget defines which forecast value feeds the recommendation
+// +kubebuilder:validation:Enum=UpperBound;Peak
+type ForecastTarget string
+
+const (
+	// ForecastTargetUpperBound uses the upper bound of the prediction interval
+	ForecastTargetUpperBound ForecastTarget = "UpperBound"
+	// ForecastTargetPeak uses the forecasted peak value
+	ForecastTargetPeak ForecastTarget = "Peak"
+)
+
+// RecommendationAlgorithm defines how usage samples are aggregated
+// +kubebuilder:validation:Enum=percentile;decaying-histogram
+type RecommendationAlgorithm string
+
+const (
+	// AlgorithmPercentile uses raw percentiles over the history window
+	AlgorithmPercentile RecommendationAlgorithm = "percentile"
+	// AlgorithmDecayingHistogram uses exponentially decaying histograms that favor recent usage
+	AlgorithmDecayingHistogram RecommendationAlgorithm = "decaying-histogram"
+)
+
 // UpdateStrategy defines how updates are applied
 type UpdateStrategy struct {
 	// Type is the update strategy type
 	// +optional
-	// +kubebuilder:validation:Enum=InPlace;RollingUpdate
+	// +kubebuilder:validation:Enum=InPlace;RollingUpdate;Canary
 	// +kubebuilder:default=InPlace
 	Type UpdateStrategyType `json:"type,omitempty"`
 
 	// RollingUpdate configuration (only used if Type=RollingUpdate)
 	// +optional
 	RollingUpdate *RollingUpdateConfig `json:"rollingUpdate,omitempty"`
+
+	// Canary configuration (only used if Type=Canary)
+	// +optional
+	Canary *CanaryConfig `json:"canary,omitempty"`
 }
 
 // UpdateStrategyType defines the update strategy
-// +kubebuilder:validation:Enum=InPlace;RollingUpdate
+// +kubebuilder:validation:Enum=InPlace;RollingUpdate;Canary
 type UpdateStrategyType string
 
 const (
@@ -254,6 +502,9 @@ const (
 	UpdateStrategyInPlace UpdateStrategyType = "InPlace"
 	// UpdateStrategyRollingUpdate performs a rolling update
 	UpdateStrategyRollingUpdate UpdateStrategyType = "RollingUpdate"
+	// UpdateStrategyCanary resizes a few pods first and promotes the change to the rest
+	// only if they stay as healthy as the others for the soak period
+	UpdateStrategyCanary UpdateStrategyType = "Canary"
 )
 
 // RollingUpdateConfig defines rolling update parameters
@@ -269,6 +520,27 @@ type RollingUpdateConfig struct {
 	MaxSurge string `json:"maxSurge,omitempty"`
 }
 
+// CanaryConfig defines canary rollout parameters
+type CanaryConfig struct {
+	// Replicas is the number of pods that get the change first
+	// +optional
+	// +kubebuilder:default=1
+	// +kubebuilder:validation:Minimum=1
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// SoakPeriod is how long canary pods are compared against the baseline pods
+	// before the change is promoted (e.g., "10m")
+	// +optional
+	// +kubebuilder:default="10m"
+	SoakPeriod string `json:"soakPeriod,omitempty"`
+
+	// MaxRestarts is how many more container restarts per pod the canary pods may
+	// have than the baseline pods during the soak period
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxRestarts int32 `json:"maxRestarts,omitempty"`
+}
+
 // HPAAwareness configures HPA conflict detection
 type HPAAwareness struct {
 	// Enabled controls whether to check for HPA conflicts
@@ -309,6 +581,56 @@ type PDBAwareness struct {
 	RespectMinAvailable bool `json:"respectMinAvailable,omitempty"`
 }
 
+// NodeFitAwareness configures the node-shape feasibility check
+type NodeFitAwareness struct {
+	// Enabled controls whether raised requests are checked against node allocatable
+	// +optional
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+
+	// Policy defines how to handle requests that no eligible node can fit
+	// +optional
+	// +kubebuilder:validation:Enum=Clamp;Block
+	// +kubebuilder:default=Clamp
+	Policy NodeFitPolicy `json:"policy,omitempty"`
+}
+
+// NodeFitPolicy defines how to handle requests that would leave pods Pending
+// +kubebuilder:validation:Enum=Clamp;Block
+type NodeFitPolicy string
+
+const (
+	// NodeFitPolicyClamp lowers raised requests to the largest size that schedules
+	NodeFitPolicyClamp NodeFitPolicy = "Clamp"
+	// NodeFitPolicyBlock skips the container's recommendation
+	NodeFitPolicyBlock NodeFitPolicy = "Block"
+)
+
+// QuotaAwareness configures the ResourceQuota and LimitRange check
+type QuotaAwareness struct {
+	// Enabled controls whether changes are checked against quotas and LimitRanges
+	// +optional
+	// +kubebuilder:default=true
+	Enabled bool `json:"enabled"`
+
+	// Policy is what to do with a change that a quota or LimitRange would reject
+	// +optional
+	// +kubebuilder:validation:Enum=Clamp;Block
+	// +kubebuilder:default=Clamp
+	Policy QuotaPolicy `json:"policy,omitempty"`
+}
+
+// QuotaPolicy defines how to handle changes that exceed a quota or LimitRange
+// +kubebuilder:validation:Enum=Clamp;Block
+type QuotaPolicy string
+
+const (
+	// QuotaPolicyClamp moves the requests into the range the namespace allows
+	QuotaPolicyClamp QuotaPolicy = "Clamp"
+	// QuotaPolicyBlock skips the container's recommendation
+	QuotaPolicyBlock QuotaPolicy = "Block"
+)
+
 // CircuitBreakerConfig defines circuit breaker parameters
 type CircuitBreakerConfig struct {
 	// Enabled controls whether the circuit breaker is active
@@ -316,24 +638,40 @@ type CircuitBreakerConfig struct {
 	// +kubebuilder:default=true
 	Enabled bool `json:"enabled"`
 
-	// ErrorThreshold is the number of consecutive errors before opening
+	// ErrorThreshold is the number of consecutive failed changes to a workload before
+	// its circuit opens
 	// +optional
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=20
 	// +kubebuilder:default=5
 	ErrorThreshold int `json:"errorThreshold,omitempty"`
 
-	// SuccessThreshold is the number of consecutive successes to close
+	// NamespaceErrorThreshold is the number of consecutive failed changes across the
+	// workloads of a namespace before the namespace's circuit opens
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=10
+	NamespaceErrorThreshold int `json:"namespaceErrorThreshold,omitempty"`
+
+	// SuccessThreshold is the number of consecutive verified changes that close a
+	// half-open circuit
 	// +optional
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=10
 	// +kubebuilder:default=3
 	SuccessThreshold int `json:"successThreshold,omitempty"`
 
-	// Timeout is how long to wait before attempting half-open state
+	// Timeout is how long an opened circuit waits before letting a trial change through
+	// (half-open). The wait doubles each time the circuit opens again without closing.
 	// +optional
 	// +kubebuilder:default="5m"
 	Timeout string `json:"timeout,omitempty"`
+
+	// MaxTimeout caps the doubled half-open wait
+	// +optional
+	// +kubebuilder:default="1h"
+	MaxTimeout string `json:"maxTimeout,omitempty"`
 }
 
 // GitOpsExportConfig defines GitOps export configuration
@@ -449,15 +787,18 @@ type OptimizerConfigStatus struct {
 	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
 
 	// CircuitState is the current circuit breaker state
+	// Deprecated: circuit breakers are kept per workload and namespace in CircuitBreakers
 	// +optional
 	// +kubebuilder:default=Closed
 	CircuitState CircuitState `json:"circuitState,omitempty"`
 
 	// ConsecutiveErrors tracks consecutive error count
+	// Deprecated: see CircuitBreakers
 	// +optional
 	ConsecutiveErrors int `json:"consecutiveErrors,omitempty"`
 
 	// ConsecutiveSuccesses tracks consecutive success count
+	// Deprecated: see CircuitBreakers
 	// +optional
 	ConsecutiveSuccesses int `json:"consecutiveSuccesses,omitempty"`
 
@@ -484,6 +825,509 @@ type OptimizerConfigStatus struct {
 	// NextMaintenanceWindow is when the next maintenance window starts
 	// +optional
 	NextMaintenanceWindow *metav1.Time `json:"nextMaintenanceWindow,omitempty"`
+
+	// TimeProfiles lists the time-of-day profile active for each workload
+	// +optional
+	TimeProfiles []WorkloadTimeProfile `json:"timeProfiles,omitempty"`
+
+	// MemoryLeaks lists containers with a suspected memory leak
+	// +optional
+	MemoryLeaks []MemoryLeakStatus `json:"memoryLeaks,omitempty"`
+
+	// PackingSimulation summarizes how many nodes the current recommendations would free
+	// +optional
+	PackingSimulation *PackingSimulationStatus `json:"packingSimulation,omitempty"`
+
+	// LearnedMargins lists the safety margins tuned from post-apply outcomes
+	// +optional
+	LearnedMargins []LearnedSafetyMargin `json:"learnedMargins,omitempty"`
+
+	// Decisions records how the last reconcile decided on each workload's recommendation
+	// +optional
+	Decisions []WorkloadDecision `json:"decisions,omitempty"`
+
+	// DecisionsOmitted is how many workloads of the last reconcile have no trace in
+	// Decisions because the list is capped; unchanged workloads are left out first
+	// +optional
+	DecisionsOmitted int32 `json:"decisionsOmitted,omitempty"`
+
+	// Quarantined lists workloads excluded from optimization after an automatic rollback
+	// +optional
+	Quarantined []QuarantinedWorkload `json:"quarantined,omitempty"`
+
+	// Rollouts lists applied changes that are still rolling out or being verified
+	// +optional
+	Rollouts []WorkloadRollout `json:"rollouts,omitempty"`
+
+	// CircuitBreakers lists the workload and namespace circuits that have recorded failed
+	// changes and have not closed since
+	// +optional
+	CircuitBreakers []CircuitBreakerStatus `json:"circuitBreakers,omitempty"`
+}
+
+// CircuitScope is what a circuit breaker protects
+// +kubebuilder:validation:Enum=Workload;Namespace
+type CircuitScope string
+
+const (
+	// CircuitScopeWorkload circuits open after failed changes to one workload
+	CircuitScopeWorkload CircuitScope = "Workload"
+	// CircuitScopeNamespace circuits open after failed changes across a namespace
+	CircuitScopeNamespace CircuitScope = "Namespace"
+)
+
+// CircuitBreakerStatus is the state of one workload or namespace circuit
+type CircuitBreakerStatus struct {
+	// Scope is whether the circuit protects a workload or a namespace
+	Scope CircuitScope `json:"scope"`
+
+	// Namespace of the workload, or the protected namespace
+	Namespace string `json:"namespace"`
+
+	// Kind of the workload; empty for namespace circuits
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the workload; empty for namespace circuits
+	// +optional
+	Name string `json:"name,omitempty"`
+
+	// State is the circuit state
+	State CircuitState `json:"state"`
+
+	// ConsecutiveErrors counts failed changes since the last verified one
+	// +optional
+	ConsecutiveErrors int `json:"consecutiveErrors,omitempty"`
+
+	// ConsecutiveSuccesses counts verified changes while half-open
+	// +optional
+	ConsecutiveSuccesses int `json:"consecutiveSuccesses,omitempty"`
+
+	// Trips counts how often the circuit opened since it last closed; each trip doubles
+	// the wait before the next half-open trial
+	// +optional
+	Trips int `json:"trips,omitempty"`
+
+	// OpenedAt is when the circuit last opened
+	// +optional
+	OpenedAt *metav1.Time `json:"openedAt,omitempty"`
+
+	// RetryAt is when an open circuit lets a trial change through
+	// +optional
+	RetryAt *metav1.Time `json:"retryAt,omitempty"`
+
+	// LastError describes the last failed change
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+}
+
+// RolloutPhase is the stage of an applied change
+type RolloutPhase string
+
+const (
+	// RolloutPhaseCanary means the change runs on canary pods that are compared against the baseline
+	RolloutPhaseCanary RolloutPhase = "Canary"
+	// RolloutPhaseProgressing means the change is rolling out to all pods
+	RolloutPhaseProgressing RolloutPhase = "Progressing"
+	// RolloutPhaseVerifying means the rollout completed and SLA health is checked against the baseline
+	RolloutPhaseVerifying RolloutPhase = "Verifying"
+)
+
+// WorkloadRollout tracks changes to a workload from the apply until their health is verified
+type WorkloadRollout struct {
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+
+	// Kind of the workload (Deployment, StatefulSet, DaemonSet)
+	Kind string `json:"kind"`
+
+	// Name of the workload
+	Name string `json:"name"`
+
+	// Containers lists the changed containers in the order they were changed
+	Containers []string `json:"containers"`
+
+	// Phase is the current stage of the rollout
+	Phase RolloutPhase `json:"phase"`
+
+	// StartedAt is when the first change was applied
+	StartedAt metav1.Time `json:"startedAt"`
+
+	// PhaseStartedAt is when the rollout entered its current phase
+	PhaseStartedAt metav1.Time `json:"phaseStartedAt"`
+
+	// BaselineHealthScore is the SLA health score before the changes; verification is
+	// skipped when it is unknown
+	// +optional
+	BaselineHealthScore *float64 `json:"baselineHealthScore,omitempty"`
+
+	// BaselineViolations lists the SLAs already violated before the changes
+	// +optional
+	BaselineViolations []string `json:"baselineViolations,omitempty"`
+
+	// Message describes the progress of the rollout
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Canary is the canary under analysis while Phase is Canary
+	// +optional
+	Canary *CanaryRollout `json:"canary,omitempty"`
+}
+
+// CanaryRollout is the state of a canary, kept so its analysis can resume on later reconciles
+type CanaryRollout struct {
+	// Container is the container under test
+	Container string `json:"container"`
+
+	// RecommendationID identifies the recommendation behind the change
+	// +optional
+	RecommendationID string `json:"recommendationId,omitempty"`
+
+	// CPU, Memory, CPULimit, MemoryLimit and EphemeralStorage are the resources under
+	// test; empty limits and ephemeral storage are left unchanged on promotion
+	CPU              string `json:"cpu"`
+	Memory           string `json:"memory"`
+	CPULimit         string `json:"cpuLimit,omitempty"`
+	MemoryLimit      string `json:"memoryLimit,omitempty"`
+	EphemeralStorage string `json:"ephemeralStorage,omitempty"`
+
+	// PreserveQoS keeps the pod QoS class on promotion
+	// +optional
+	PreserveQoS bool `json:"preserveQoS,omitempty"`
+
+	// Replicas is the number of canary pods
+	Replicas int32 `json:"replicas"`
+
+	// SoakPeriod is how long the canary pods are compared against the baseline
+	SoakPeriod string `json:"soakPeriod"`
+
+	// MaxRestarts is how many more restarts per pod the canary pods may have
+	// +optional
+	MaxRestarts int32 `json:"maxRestarts,omitempty"`
+
+	// Partition and OriginalPartition are the canary and the restored StatefulSet partitions
+	// +optional
+	Partition int32 `json:"partition,omitempty"`
+	// +optional
+	OriginalPartition int32 `json:"originalPartition,omitempty"`
+
+	// ReplicaSet is the name of a Deployment's canary ReplicaSet
+	// +optional
+	ReplicaSet string `json:"replicaSet,omitempty"`
+
+	// PreviousResources are the container's resources before the change
+	// +optional
+	PreviousResources corev1.ResourceRequirements `json:"previousResources,omitempty"`
+
+	// BaselineRestarts maps baseline pods to their restart count when the canary started
+	// +optional
+	BaselineRestarts map[string]int32 `json:"baselineRestarts,omitempty"`
+}
+
+// QuarantinedWorkload is a workload left alone after its changes were rolled back
+type QuarantinedWorkload struct {
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+
+	// Kind of the workload (Deployment, StatefulSet, DaemonSet)
+	Kind string `json:"kind"`
+
+	// Name of the workload
+	Name string `json:"name"`
+
+	// Reason explains why the workload was rolled back
+	Reason string `json:"reason"`
+
+	// RolledBackAt is when the workload was rolled back
+	RolledBackAt metav1.Time `json:"rolledBackAt"`
+
+	// Until is when the workload may be optimized again
+	Until metav1.Time `json:"until"`
+}
+
+// DecisionAction is the final outcome of a recommendation
+type DecisionAction string
+
+const (
+	// DecisionApplied means the recommendation was applied
+	DecisionApplied DecisionAction = "Applied"
+	// DecisionDryRun means the recommendation would have been applied in live mode
+	DecisionDryRun DecisionAction = "DryRun"
+	// DecisionSkipped means a safety gate withheld the recommendation
+	DecisionSkipped DecisionAction = "Skipped"
+	// DecisionUnchanged means the recommendation matched the current resources
+	DecisionUnchanged DecisionAction = "Unchanged"
+	// DecisionFailed means applying the recommendation failed
+	DecisionFailed DecisionAction = "Failed"
+)
+
+// GateResult is a safety gate's verdict on a recommendation
+type GateResult string
+
+const (
+	// GatePassed means the gate let the recommendation through unchanged
+	GatePassed GateResult = "Passed"
+	// GateAdjusted means the gate changed the recommended values
+	GateAdjusted GateResult = "Adjusted"
+	// GateWarned means the gate found a problem but let the recommendation through
+	GateWarned GateResult = "Warned"
+	// GateBlocked means the gate withheld the recommendation
+	GateBlocked GateResult = "Blocked"
+)
+
+// GateVerdict records one safety gate's verdict
+type GateVerdict struct {
+	// Gate is the name of the safety gate (e.g., "HPA", "NodeFit", "MaxChangePercent")
+	Gate string `json:"gate"`
+
+	// Result is the gate's verdict
+	Result GateResult `json:"result"`
+
+	// Message explains the verdict
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// WorkloadDecision is the decision trace of a workload's recommendation: the gates it
+// passed through and what was finally done with it
+type WorkloadDecision struct {
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+
+	// Kind of the workload (Deployment, StatefulSet, DaemonSet)
+	Kind string `json:"kind"`
+
+	// Name of the workload
+	Name string `json:"name"`
+
+	// Action is the final outcome for the workload
+	Action DecisionAction `json:"action"`
+
+	// Reason explains the action
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Gates lists the verdicts of workload-level safety gates (HPA, PDB, anomalies)
+	// +optional
+	Gates []GateVerdict `json:"gates,omitempty"`
+
+	// Containers lists the decision trace of each container
+	// +optional
+	Containers []ContainerDecision `json:"containers,omitempty"`
+
+	// DecidedAt is when the decision was made
+	DecidedAt metav1.Time `json:"decidedAt"`
+}
+
+// ContainerDecision is the decision trace of a container's recommendation
+type ContainerDecision struct {
+	// ContainerName is the name of the container
+	ContainerName string `json:"containerName"`
+
+	// RecommendationID identifies the recommendation; an applied change stamps it on the
+	// workload's recommendation-id annotation
+	// +optional
+	RecommendationID string `json:"recommendationId,omitempty"`
+
+	// SampleCount is the number of usage samples the recommendation is based on
+	SampleCount int32 `json:"sampleCount"`
+
+	// CPUPercentile is the percentile used for CPU
+	CPUPercentile int32 `json:"cpuPercentile"`
+
+	// CPUUsage is the CPU used at CPUPercentile (e.g., "180m")
+	CPUUsage string `json:"cpuUsage"`
+
+	// MemoryPercentile is the percentile used for memory
+	MemoryPercentile int32 `json:"memoryPercentile"`
+
+	// MemoryUsage is the memory used at MemoryPercentile (e.g., "300Mi")
+	MemoryUsage string `json:"memoryUsage"`
+
+	// SafetyMargin is the multiplier applied on top of the percentiles
+	SafetyMargin float64 `json:"safetyMargin"`
+
+	// OOMBoost is the memory multiplier applied due to OOM history
+	// +optional
+	OOMBoost float64 `json:"oomBoost,omitempty"`
+
+	// Confidence is the 0-100 confidence score of the recommendation
+	Confidence float64 `json:"confidence"`
+
+	// Thresholds summarizes the configured resource thresholds (e.g., "cpu 100m-4")
+	// +optional
+	Thresholds string `json:"thresholds,omitempty"`
+
+	// CurrentCPU is the current CPU request
+	CurrentCPU string `json:"currentCPU"`
+
+	// RecommendedCPU is the CPU request after all gates
+	RecommendedCPU string `json:"recommendedCPU"`
+
+	// CurrentMemory is the current memory request
+	CurrentMemory string `json:"currentMemory"`
+
+	// RecommendedMemory is the memory request after all gates
+	RecommendedMemory string `json:"recommendedMemory"`
+
+	// StartupCPU is the CPU the container needs while warming up, set only when warm-up
+	// dominates the steady-state recommendation
+	// +optional
+	StartupCPU string `json:"startupCPU,omitempty"`
+
+	// Adjustments lists why the recommendation moved away from usage times the margin
+	// (forecasts, OOM floors, thresholds, time profiles)
+	// +optional
+	Adjustments []string `json:"adjustments,omitempty"`
+
+	// Gates lists the verdicts of container-level safety gates in the order they ran
+	// +optional
+	Gates []GateVerdict `json:"gates,omitempty"`
+
+	// Action is the final outcome for the container
+	Action DecisionAction `json:"action"`
+
+	// Reason explains the action
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// LearnedSafetyMargin is a workload's safety margin tuned from the outcomes of the
+// changes applied to it
+type LearnedSafetyMargin struct {
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+
+	// WorkloadKind is the kind of the workload (Deployment, StatefulSet, DaemonSet)
+	// +optional
+	WorkloadKind string `json:"workloadKind,omitempty"`
+
+	// WorkloadName is the name of the workload
+	WorkloadName string `json:"workloadName"`
+
+	// SafetyMargin is the margin used for the workload's next recommendation
+	SafetyMargin float64 `json:"safetyMargin"`
+
+	// GoodWindows is the number of consecutive observation windows without incidents
+	// +optional
+	GoodWindows int32 `json:"goodWindows,omitempty"`
+
+	// Incidents is the total number of incidents observed after applied changes
+	// +optional
+	Incidents int32 `json:"incidents,omitempty"`
+
+	// LastOutcome describes the most recent observation (e.g., "good", "2 OOM kills")
+	// +optional
+	LastOutcome string `json:"lastOutcome,omitempty"`
+
+	// ObservingSince is when the current observation window started
+	// +optional
+	ObservingSince *metav1.Time `json:"observingSince,omitempty"`
+
+	// LastAdjusted is when the margin last changed
+	// +optional
+	LastAdjusted *metav1.Time `json:"lastAdjusted,omitempty"`
+}
+
+// PackingSimulationStatus is the outcome of re-packing the cluster's pods with the
+// recommended requests using a first-fit-decreasing scheduler model
+type PackingSimulationStatus struct {
+	// Nodes is the number of schedulable nodes simulated
+	Nodes int32 `json:"nodes"`
+
+	// NodesAfterRepack is the nodes needed to re-pack pods at their current requests
+	NodesAfterRepack int32 `json:"nodesAfterRepack"`
+
+	// NodesAfterRecommendations is the nodes needed with the recommended requests
+	NodesAfterRecommendations int32 `json:"nodesAfterRecommendations"`
+
+	// ReclaimableNodes is the number of nodes the recommendations would free beyond
+	// re-packing at current requests
+	ReclaimableNodes int32 `json:"reclaimableNodes"`
+
+	// UnschedulablePods could not be placed with the recommended requests
+	// +optional
+	UnschedulablePods int32 `json:"unschedulablePods,omitempty"`
+
+	// Pools breaks the simulation down per node pool
+	// +optional
+	Pools []NodePoolPacking `json:"pools,omitempty"`
+
+	// SimulatedAt is when the simulation ran
+	SimulatedAt metav1.Time `json:"simulatedAt"`
+}
+
+// NodePoolPacking is the packing outcome for one node pool
+type NodePoolPacking struct {
+	// Pool is the node pool name
+	Pool string `json:"pool"`
+
+	// Nodes is the number of schedulable nodes in the pool
+	Nodes int32 `json:"nodes"`
+
+	// NodesAfterRepack is the nodes needed at current requests
+	NodesAfterRepack int32 `json:"nodesAfterRepack"`
+
+	// NodesAfterRecommendations is the nodes needed with the recommended requests
+	NodesAfterRecommendations int32 `json:"nodesAfterRecommendations"`
+
+	// ReclaimableNodes is the number of nodes the recommendations would free in this pool
+	// beyond re-packing at current requests
+	ReclaimableNodes int32 `json:"reclaimableNodes"`
+
+	// UnschedulablePods could not be placed in this pool with the recommended requests
+	// +optional
+	UnschedulablePods int32 `json:"unschedulablePods,omitempty"`
+
+	// CPUFragmentationPercent is how scattered free CPU is over the remaining nodes (0-100)
+	CPUFragmentationPercent int32 `json:"cpuFragmentationPercent"`
+
+	// MemoryFragmentationPercent is how scattered free memory is over the remaining nodes (0-100)
+	MemoryFragmentationPercent int32 `json:"memoryFragmentationPercent"`
+}
+
+// MemoryLeakStatus describes a container whose memory usage shows a sustained leak
+type MemoryLeakStatus struct {
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+
+	// WorkloadName is the name of the workload
+	WorkloadName string `json:"workloadName"`
+
+	// ContainerName is the name of the leaking container
+	ContainerName string `json:"containerName"`
+
+	// Severity of the suspected leak (low, medium, high, critical)
+	Severity string `json:"severity"`
+
+	// GrowthBytesPerHour is the fitted memory growth rate
+	GrowthBytesPerHour int64 `json:"growthBytesPerHour"`
+
+	// TimeToLimit is the projected time until the memory limit is reached (e.g., "5h30m0s")
+	// +optional
+	TimeToLimit string `json:"timeToLimit,omitempty"`
+
+	// DetectedAt is when the leak was last confirmed
+	DetectedAt metav1.Time `json:"detectedAt"`
+}
+
+// WorkloadTimeProfile is the time-of-day profile currently applied to a workload
+type WorkloadTimeProfile struct {
+	// Namespace of the workload
+	Namespace string `json:"namespace"`
+
+	// WorkloadName is the name of the workload
+	WorkloadName string `json:"workloadName"`
+
+	// Pattern is the detected usage pattern (e.g., business_hours, night_batch)
+	Pattern string `json:"pattern"`
+
+	// Profile is the name of the active schedule entry, or "base" when none applies
+	Profile string `json:"profile"`
+
+	// NextSwitch is when the next schedule boundary is reached
+	// +optional
+	NextSwitch *metav1.Time `json:"nextSwitch,omitempty"`
 }
 
 // OptimizerPhase represents the current operational phase
@@ -540,7 +1384,7 @@ type OptimizerCondition struct {
 }
 
 // OptimizerConditionType represents condition types
-// +kubebuilder:validation:Enum=Ready;HPAConflict;PDBViolation;MaintenanceWindow;CircuitBreakerOpen;MetricsAvailable
+// +kubebuilder:validation:Enum=Ready;HPAConflict;PDBViolation;MaintenanceWindow;CircuitBreakerOpen;MetricsAvailable;MemoryLeakSuspected
 type OptimizerConditionType string
 
 const (
@@ -556,6 +1400,10 @@ const (
 	ConditionTypeCircuitBreakerOpen OptimizerConditionType = "CircuitBreakerOpen"
 	// ConditionTypeMetricsAvailable indicates metrics are available
 	ConditionTypeMetricsAvailable OptimizerConditionType = "MetricsAvailable"
+	// ConditionTypeMemoryLeakSuspected indicates a container shows a sustained memory leak
+	ConditionTypeMemoryLeakSuspected OptimizerConditionType = "MemoryLeakSuspected"
+	// ConditionTypeRolledBack indicates changes were rolled back after SLA degradation
+	ConditionTypeRolledBack OptimizerConditionType = "RolledBack"
 )
 
 // ConditionStatus represents the status of a condition
@@ -9,6 +9,46 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryConfig) DeepCopyInto(out *CanaryConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryConfig.
+func (in *CanaryConfig) DeepCopy() *CanaryConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryRollout) DeepCopyInto(out *CanaryRollout) {
+	*out = *in
+	in.PreviousResources.DeepCopyInto(&out.PreviousResources)
+	if in.BaselineRestarts != nil {
+		in, out := &in.BaselineRestarts, &out.BaselineRestarts
+		*out = make(map[string]int32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryRollout.
+func (in *CanaryRollout) DeepCopy() *CanaryRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CircuitBreakerConfig) DeepCopyInto(out *CircuitBreakerConfig) {
 	*out = *in
@@ -25,6 +65,88 @@ func (in *CircuitBreakerConfig) DeepCopy() *CircuitBreakerConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CircuitBreakerStatus) DeepCopyInto(out *CircuitBreakerStatus) {
+	*out = *in
+	if in.OpenedAt != nil {
+		in, out := &in.OpenedAt, &out.OpenedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.RetryAt != nil {
+		in, out := &in.RetryAt, &out.RetryAt
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CircuitBreakerStatus.
+func (in *CircuitBreakerStatus) DeepCopy() *CircuitBreakerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CircuitBreakerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerDecision) DeepCopyInto(out *ContainerDecision) {
+	*out = *in
+	if in.Adjustments != nil {
+		in, out := &in.Adjustments, &out.Adjustments
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gates != nil {
+		in, out := &in.Gates, &out.Gates
+		*out = make([]GateVerdict, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerDecision.
+func (in *ContainerDecision) DeepCopy() *ContainerDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForecastConfig) DeepCopyInto(out *ForecastConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForecastConfig.
+func (in *ForecastConfig) DeepCopy() *ForecastConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ForecastConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GateVerdict) DeepCopyInto(out *GateVerdict) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GateVerdict.
+func (in *GateVerdict) DeepCopy() *GateVerdict {
+	if in == nil {
+		return nil
+	}
+	out := new(GateVerdict)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HPAAwareness) DeepCopyInto(out *HPAAwareness) {
 	*out = *in
@@ -41,6 +163,30 @@ func (in *HPAAwareness) DeepCopy() *HPAAwareness {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LearnedSafetyMargin) DeepCopyInto(out *LearnedSafetyMargin) {
+	*out = *in
+	if in.ObservingSince != nil {
+		in, out := &in.ObservingSince, &out.ObservingSince
+		*out = (*in).DeepCopy()
+	}
+	if in.LastAdjusted != nil {
+		in, out := &in.LastAdjusted, &out.LastAdjusted
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LearnedSafetyMargin.
+func (in *LearnedSafetyMargin) DeepCopy() *LearnedSafetyMargin {
+	if in == nil {
+		return nil
+	}
+	out := new(LearnedSafetyMargin)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
 	*out = *in
@@ -57,6 +203,87 @@ func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MarginTuningConfig) DeepCopyInto(out *MarginTuningConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MarginTuningConfig.
+func (in *MarginTuningConfig) DeepCopy() *MarginTuningConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MarginTuningConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MemoryLeakStatus) DeepCopyInto(out *MemoryLeakStatus) {
+	*out = *in
+	in.DetectedAt.DeepCopyInto(&out.DetectedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MemoryLeakStatus.
+func (in *MemoryLeakStatus) DeepCopy() *MemoryLeakStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MemoryLeakStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeFitAwareness) DeepCopyInto(out *NodeFitAwareness) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeFitAwareness.
+func (in *NodeFitAwareness) DeepCopy() *NodeFitAwareness {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeFitAwareness)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolPacking) DeepCopyInto(out *NodePoolPacking) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolPacking.
+func (in *NodePoolPacking) DeepCopy() *NodePoolPacking {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolPacking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OOMFastPathConfig) DeepCopyInto(out *OOMFastPathConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OOMFastPathConfig.
+func (in *OOMFastPathConfig) DeepCopy() *OOMFastPathConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OOMFastPathConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OptimizerConfig) DeepCopyInto(out *OptimizerConfig) {
 	*out = *in
@@ -139,7 +366,7 @@ func (in *OptimizerConfigSpec) DeepCopyInto(out *OptimizerConfigSpec) {
 	if in.Recommendations != nil {
 		in, out := &in.Recommendations, &out.Recommendations
 		*out = new(RecommendationConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.UpdateStrategy != nil {
 		in, out := &in.UpdateStrategy, &out.UpdateStrategy
@@ -156,11 +383,36 @@ func (in *OptimizerConfigSpec) DeepCopyInto(out *OptimizerConfigSpec) {
 		*out = new(PDBAwareness)
 		**out = **in
 	}
+	if in.NodeFitAwareness != nil {
+		in, out := &in.NodeFitAwareness, &out.NodeFitAwareness
+		*out = new(NodeFitAwareness)
+		**out = **in
+	}
+	if in.QuotaAwareness != nil {
+		in, out := &in.QuotaAwareness, &out.QuotaAwareness
+		*out = new(QuotaAwareness)
+		**out = **in
+	}
 	if in.CircuitBreaker != nil {
 		in, out := &in.CircuitBreaker, &out.CircuitBreaker
 		*out = new(CircuitBreakerConfig)
 		**out = **in
 	}
+	if in.TimeBasedScaling != nil {
+		in, out := &in.TimeBasedScaling, &out.TimeBasedScaling
+		*out = new(TimeBasedScalingConfig)
+		**out = **in
+	}
+	if in.OOMFastPath != nil {
+		in, out := &in.OOMFastPath, &out.OOMFastPath
+		*out = new(OOMFastPathConfig)
+		**out = **in
+	}
+	if in.MarginTuning != nil {
+		in, out := &in.MarginTuning, &out.MarginTuning
+		*out = new(MarginTuningConfig)
+		**out = **in
+	}
 	if in.TargetResources != nil {
 		in, out := &in.TargetResources, &out.TargetResources
 		*out = make([]TargetResourceType, len(*in))
@@ -206,6 +458,60 @@ func (in *OptimizerConfigStatus) DeepCopyInto(out *OptimizerConfigStatus) {
 		in, out := &in.NextMaintenanceWindow, &out.NextMaintenanceWindow
 		*out = (*in).DeepCopy()
 	}
+	if in.TimeProfiles != nil {
+		in, out := &in.TimeProfiles, &out.TimeProfiles
+		*out = make([]WorkloadTimeProfile, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MemoryLeaks != nil {
+		in, out := &in.MemoryLeaks, &out.MemoryLeaks
+		*out = make([]MemoryLeakStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PackingSimulation != nil {
+		in, out := &in.PackingSimulation, &out.PackingSimulation
+		*out = new(PackingSimulationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LearnedMargins != nil {
+		in, out := &in.LearnedMargins, &out.LearnedMargins
+		*out = make([]LearnedSafetyMargin, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Decisions != nil {
+		in, out := &in.Decisions, &out.Decisions
+		*out = make([]WorkloadDecision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Quarantined != nil {
+		in, out := &in.Quarantined, &out.Quarantined
+		*out = make([]QuarantinedWorkload, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Rollouts != nil {
+		in, out := &in.Rollouts, &out.Rollouts
+		*out = make([]WorkloadRollout, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CircuitBreakers != nil {
+		in, out := &in.CircuitBreakers, &out.CircuitBreakers
+		*out = make([]CircuitBreakerStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -252,9 +558,75 @@ func (in *PDBAwareness) DeepCopy() *PDBAwareness {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PackingSimulationStatus) DeepCopyInto(out *PackingSimulationStatus) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]NodePoolPacking, len(*in))
+		copy(*out, *in)
+	}
+	in.SimulatedAt.DeepCopyInto(&out.SimulatedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PackingSimulationStatus.
+func (in *PackingSimulationStatus) DeepCopy() *PackingSimulationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PackingSimulationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuarantinedWorkload) DeepCopyInto(out *QuarantinedWorkload) {
+	*out = *in
+	in.RolledBackAt.DeepCopyInto(&out.RolledBackAt)
+	in.Until.DeepCopyInto(&out.Until)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuarantinedWorkload.
+func (in *QuarantinedWorkload) DeepCopy() *QuarantinedWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(QuarantinedWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaAwareness) DeepCopyInto(out *QuotaAwareness) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaAwareness.
+func (in *QuotaAwareness) DeepCopy() *QuotaAwareness {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaAwareness)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RecommendationConfig) DeepCopyInto(out *RecommendationConfig) {
 	*out = *in
+	if in.Forecast != nil {
+		in, out := &in.Forecast, &out.Forecast
+		*out = new(ForecastConfig)
+		**out = **in
+	}
+	if in.WarmUp != nil {
+		in, out := &in.WarmUp, &out.WarmUp
+		*out = new(WarmUpConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -297,6 +669,11 @@ func (in *ResourceThresholds) DeepCopyInto(out *ResourceThresholds) {
 		*out = new(ResourceLimit)
 		**out = **in
 	}
+	if in.EphemeralStorage != nil {
+		in, out := &in.EphemeralStorage, &out.EphemeralStorage
+		*out = new(ResourceLimit)
+		**out = **in
+	}
 	return
 }
 
@@ -326,6 +703,22 @@ func (in *RollingUpdateConfig) DeepCopy() *RollingUpdateConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TimeBasedScalingConfig) DeepCopyInto(out *TimeBasedScalingConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TimeBasedScalingConfig.
+func (in *TimeBasedScalingConfig) DeepCopy() *TimeBasedScalingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TimeBasedScalingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
 	*out = *in
@@ -334,6 +727,11 @@ func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
 		*out = new(RollingUpdateConfig)
 		**out = **in
 	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryConfig)
+		**out = **in
+	}
 	return
 }
 
@@ -346,3 +744,106 @@ func (in *UpdateStrategy) DeepCopy() *UpdateStrategy {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WarmUpConfig) DeepCopyInto(out *WarmUpConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WarmUpConfig.
+func (in *WarmUpConfig) DeepCopy() *WarmUpConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WarmUpConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadDecision) DeepCopyInto(out *WorkloadDecision) {
+	*out = *in
+	if in.Gates != nil {
+		in, out := &in.Gates, &out.Gates
+		*out = make([]GateVerdict, len(*in))
+		copy(*out, *in)
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ContainerDecision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.DecidedAt.DeepCopyInto(&out.DecidedAt)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadDecision.
+func (in *WorkloadDecision) DeepCopy() *WorkloadDecision {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadDecision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadRollout) DeepCopyInto(out *WorkloadRollout) {
+	*out = *in
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	in.PhaseStartedAt.DeepCopyInto(&out.PhaseStartedAt)
+	if in.BaselineHealthScore != nil {
+		in, out := &in.BaselineHealthScore, &out.BaselineHealthScore
+		*out = new(float64)
+		**out = **in
+	}
+	if in.BaselineViolations != nil {
+		in, out := &in.BaselineViolations, &out.BaselineViolations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryRollout)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadRollout.
+func (in *WorkloadRollout) DeepCopy() *WorkloadRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadTimeProfile) DeepCopyInto(out *WorkloadTimeProfile) {
+	*out = *in
+	if in.NextSwitch != nil {
+		in, out := &in.NextSwitch, &out.NextSwitch
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadTimeProfile.
+func (in *WorkloadTimeProfile) DeepCopy() *WorkloadTimeProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadTimeProfile)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -2,12 +2,15 @@ package applier
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
+	"intelligent-cluster-optimizer/pkg/provenance"
+	"intelligent-cluster-optimizer/pkg/qos"
 	"intelligent-cluster-optimizer/pkg/rollback"
 	"intelligent-cluster-optimizer/pkg/scaler"
 
-	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -30,6 +33,43 @@ func NewApplier(kubeClient kubernetes.Interface, eventRecorder record.EventRecor
 	}
 }
 
+// SetMetricsSource sets where canary analysis reads CPU usage samples from
+func (a *Applier) SetMetricsSource(source scaler.MetricsSource) {
+	a.verticalScaler.SetMetricsSource(source)
+}
+
+// RollbackManager returns the manager holding the configs saved before each live change
+func (a *Applier) RollbackManager() *rollback.RollbackManager {
+	return a.rollbackManager
+}
+
+// Scaler returns the scaler that applies changes, to follow rollouts and canaries it started
+func (a *Applier) Scaler() *scaler.VerticalScaler {
+	return a.verticalScaler
+}
+
+// PromoteCanary rolls a canary's change out to all pods and records it in the rollback
+// history and on the workload's provenance, which are only written once the change
+// reaches the workload itself; an aborted canary leaves neither behind
+func (a *Applier) PromoteCanary(ctx context.Context, canary *scaler.Canary, optimizerConfig, recommendationID string) error {
+	req := &canary.Request
+	if err := a.verticalScaler.PromoteCanary(ctx, canary); err != nil {
+		return err
+	}
+	if err := a.rollbackManager.SaveConfig(ctx, req.Namespace, req.WorkloadKind, req.WorkloadName,
+		req.ContainerName, canary.PreviousResources); err != nil {
+		klog.Warningf("Failed to save rollback config: %v", err)
+	}
+	a.stampProvenance(ctx, req.Namespace, req.WorkloadKind, req.WorkloadName, provenance.Change{
+		ContainerName:    req.ContainerName,
+		Before:           provenance.FromContainer(&corev1.Container{Resources: canary.PreviousResources}),
+		OptimizerConfig:  optimizerConfig,
+		RecommendationID: recommendationID,
+		AppliedAt:        time.Now(),
+	})
+	return nil
+}
+
 func (a *Applier) Apply(ctx context.Context, recommendation *ResourceRecommendation, dryRun bool) (*ApplyResult, error) {
 	if dryRun {
 		return a.DryRunApply(ctx, recommendation)
@@ -69,6 +109,49 @@ func (a *Applier) DryRunApply(ctx context.Context, recommendation *ResourceRecom
 			recommendation.ContainerName, change)
 	}
 
+	if recommendation.CurrentMemoryLimit != recommendation.RecommendedMemoryLimit {
+		change := fmt.Sprintf("Memory limit: %s -> %s", recommendation.CurrentMemoryLimit, recommendation.RecommendedMemoryLimit)
+		result.Changes = append(result.Changes, change)
+		klog.Infof("[DRY-RUN] Would change %s/%s/%s container=%s: %s",
+			recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName,
+			recommendation.ContainerName, change)
+	}
+
+	if recommendation.CurrentCPULimit != recommendation.RecommendedCPULimit {
+		change := fmt.Sprintf("CPU limit: %s -> %s", recommendation.CurrentCPULimit, recommendation.RecommendedCPULimit)
+		result.Changes = append(result.Changes, change)
+		klog.Infof("[DRY-RUN] Would change %s/%s/%s container=%s: %s",
+			recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName,
+			recommendation.ContainerName, change)
+	}
+
+	if recommendation.CurrentEphemeralStorage != recommendation.RecommendedEphemeralStorage {
+		change := fmt.Sprintf("Ephemeral storage: %s -> %s", recommendation.CurrentEphemeralStorage, recommendation.RecommendedEphemeralStorage)
+		result.Changes = append(result.Changes, change)
+		klog.Infof("[DRY-RUN] Would change %s/%s/%s container=%s: %s",
+			recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName,
+			recommendation.ContainerName, change)
+	}
+
+	// Call out QoS class changes, which also change eviction priority
+	qosClass, newQoSClass, err := a.predictQoSClass(ctx, recommendation)
+	if err != nil {
+		klog.V(3).Infof("[DRY-RUN] Could not determine QoS class of %s/%s/%s: %v",
+			recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName, err)
+	} else {
+		result.QoSClass, result.NewQoSClass = qosClass, newQoSClass
+	}
+	if result.QoSClassChanged() {
+		change := fmt.Sprintf("QoS class: %s -> %s", qosClass, newQoSClass)
+		if recommendation.PreserveQoS {
+			change += " (blocked: QoS class changes are not allowed)"
+		}
+		result.Changes = append(result.Changes, change)
+		klog.Warningf("[DRY-RUN] Would change %s/%s/%s container=%s: %s",
+			recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName,
+			recommendation.ContainerName, change)
+	}
+
 	klog.V(2).Infof("[DRY-RUN] Total changes for %s/%s: %d",
 		recommendation.WorkloadKind, recommendation.WorkloadName, len(result.Changes))
 
@@ -91,12 +174,30 @@ func (a *Applier) LiveApply(ctx context.Context, recommendation *ResourceRecomme
 		return result, nil
 	}
 
+	if recommendation.PreserveQoS {
+		qosClass, newQoSClass, err := a.predictQoSClass(ctx, recommendation)
+		if err != nil {
+			result.Error = err
+			return result, err
+		}
+		result.QoSClass, result.NewQoSClass = qosClass, newQoSClass
+		if result.QoSClassChanged() {
+			err := fmt.Errorf("%w: %s/%s container=%s would move from %s to %s",
+				ErrQoSClassChange, recommendation.WorkloadKind, recommendation.WorkloadName,
+				recommendation.ContainerName, qosClass, newQoSClass)
+			result.Error = err
+			return result, err
+		}
+	}
+
 	klog.Infof("[LIVE] Applying changes to %s/%s/%s",
 		recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName)
 
-	if err := a.rollbackManager.SavePreviousConfig(ctx, recommendation.Namespace, recommendation.WorkloadKind,
-		recommendation.WorkloadName, recommendation.ContainerName); err != nil {
-		klog.Warningf("Failed to save rollback config: %v", err)
+	before, err := a.containerResources(ctx, recommendation)
+	if err != nil {
+		klog.Warningf("Failed to read resources of %s/%s/%s container=%s before the change: %v",
+			recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName,
+			recommendation.ContainerName, err)
 	}
 
 	scaleReq := &scaler.ScaleRequest{
@@ -107,9 +208,37 @@ func (a *Applier) LiveApply(ctx context.Context, recommendation *ResourceRecomme
 		NewCPU:        recommendation.RecommendedCPU,
 		NewMemory:     recommendation.RecommendedMemory,
 		Strategy:      scaler.StrategyRolling,
+		PreserveQoS:   recommendation.PreserveQoS,
+	}
+	if recommendation.CurrentMemoryLimit != recommendation.RecommendedMemoryLimit {
+		scaleReq.NewMemoryLimit = recommendation.RecommendedMemoryLimit
+	}
+	if recommendation.CurrentCPULimit != recommendation.RecommendedCPULimit {
+		scaleReq.NewCPULimit = recommendation.RecommendedCPULimit
+	}
+	if recommendation.CurrentEphemeralStorage != recommendation.RecommendedEphemeralStorage {
+		scaleReq.NewEphemeralStorage = recommendation.RecommendedEphemeralStorage
 	}
 
-	if err := a.verticalScaler.Scale(ctx, scaleReq); err != nil {
+	// A canary only changes a few pods; the rest follow when the controller promotes it,
+	// which also saves the rollback revision
+	if recommendation.Canary != nil {
+		scaleReq.Canary = recommendation.Canary
+		result.Canary, err = a.verticalScaler.StartCanary(ctx, scaleReq)
+		if errors.Is(err, scaler.ErrCanaryNotApplicable) {
+			klog.Warningf("[LIVE] Canary not possible for %s/%s/%s (%v), rolling out to all pods",
+				recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName, err)
+			err = nil
+		}
+	}
+	if err == nil && result.Canary == nil {
+		if err := a.rollbackManager.SavePreviousConfig(ctx, recommendation.Namespace, recommendation.WorkloadKind,
+			recommendation.WorkloadName, recommendation.ContainerName); err != nil {
+			klog.Warningf("Failed to save rollback config: %v", err)
+		}
+		err = a.verticalScaler.Scale(ctx, scaleReq)
+	}
+	if err != nil {
 		result.Error = err
 		return result, err
 	}
@@ -122,12 +251,42 @@ func (a *Applier) LiveApply(ctx context.Context, recommendation *ResourceRecomme
 		change := fmt.Sprintf("Memory: %s -> %s", recommendation.CurrentMemory, recommendation.RecommendedMemory)
 		result.Changes = append(result.Changes, change)
 	}
+	if scaleReq.NewMemoryLimit != "" {
+		change := fmt.Sprintf("Memory limit: %s -> %s", recommendation.CurrentMemoryLimit, recommendation.RecommendedMemoryLimit)
+		result.Changes = append(result.Changes, change)
+	}
+	if scaleReq.NewCPULimit != "" {
+		change := fmt.Sprintf("CPU limit: %s -> %s", recommendation.CurrentCPULimit, recommendation.RecommendedCPULimit)
+		result.Changes = append(result.Changes, change)
+	}
+	if scaleReq.NewEphemeralStorage != "" {
+		change := fmt.Sprintf("Ephemeral storage: %s -> %s", recommendation.CurrentEphemeralStorage, recommendation.RecommendedEphemeralStorage)
+		result.Changes = append(result.Changes, change)
+	}
+
+	// Record on the workload itself what changed it and from what
+	if before != nil && result.Canary == nil {
+		a.stampProvenance(ctx, recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName,
+			provenance.Change{
+				ContainerName:    recommendation.ContainerName,
+				Before:           *before,
+				OptimizerConfig:  recommendation.OptimizerConfig,
+				RecommendationID: recommendation.RecommendationID,
+				AppliedAt:        time.Now(),
+			})
+	}
 
 	result.Applied = true
 	klog.Infof("[LIVE] Successfully applied %d changes to %s/%s", len(result.Changes), recommendation.WorkloadKind, recommendation.WorkloadName)
 	return result, nil
 }
 
+func (a *Applier) stampProvenance(ctx context.Context, namespace, kind, name string, change provenance.Change) {
+	if err := provenance.Stamp(ctx, a.kubeClient, namespace, kind, name, change); err != nil {
+		klog.Warningf("Failed to stamp provenance on %s/%s/%s: %v", namespace, kind, name, err)
+	}
+}
+
 func (a *Applier) GetCurrentResources(ctx context.Context, namespace, kind, name, containerName string) (*ResourceRecommendation, error) {
 	rec := &ResourceRecommendation{
 		Namespace:     namespace,
@@ -136,49 +295,121 @@ func (a *Applier) GetCurrentResources(ctx context.Context, namespace, kind, name
 		ContainerName: containerName,
 	}
 
-	var containers []corev1.Container
-	var err error
+	podSpec, err := a.GetPodSpec(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, container := range podSpec.Containers {
+		if container.Name == containerName {
+			if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+				rec.CurrentCPU = cpu.String()
+			}
+			if mem, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+				rec.CurrentMemory = mem.String()
+			}
+			if limit, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+				rec.CurrentMemoryLimit = limit.String()
+				rec.RecommendedMemoryLimit = rec.CurrentMemoryLimit
+			}
+			if limit, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+				rec.CurrentCPULimit = limit.String()
+				rec.RecommendedCPULimit = rec.CurrentCPULimit
+			}
+			if storage, ok := container.Resources.Requests[corev1.ResourceEphemeralStorage]; ok {
+				rec.CurrentEphemeralStorage = storage.String()
+				rec.RecommendedEphemeralStorage = rec.CurrentEphemeralStorage
+			}
+			return rec, nil
+		}
+	}
+
+	return rec, nil
+}
 
+// GetPodSpec returns the pod template spec of a Deployment, StatefulSet or DaemonSet
+func (a *Applier) GetPodSpec(ctx context.Context, namespace, kind, name string) (*corev1.PodSpec, error) {
 	switch kind {
 	case "Deployment":
-		var deploy *appsv1.Deployment
-		deploy, err = a.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err == nil {
-			containers = deploy.Spec.Template.Spec.Containers
+		deploy, err := a.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
 		}
+		return &deploy.Spec.Template.Spec, nil
 	case "StatefulSet":
-		var sts *appsv1.StatefulSet
-		sts, err = a.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err == nil {
-			containers = sts.Spec.Template.Spec.Containers
+		sts, err := a.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
 		}
+		return &sts.Spec.Template.Spec, nil
 	case "DaemonSet":
-		var ds *appsv1.DaemonSet
-		ds, err = a.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
-		if err == nil {
-			containers = ds.Spec.Template.Spec.Containers
+		ds, err := a.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
 		}
+		return &ds.Spec.Template.Spec, nil
 	default:
 		return nil, fmt.Errorf("unsupported kind: %s", kind)
 	}
+}
 
+// containerResources returns the current resources of the recommendation's container
+func (a *Applier) containerResources(ctx context.Context, recommendation *ResourceRecommendation) (*provenance.Resources, error) {
+	podSpec, err := a.GetPodSpec(ctx, recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName)
 	if err != nil {
 		return nil, err
 	}
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == recommendation.ContainerName {
+			resources := provenance.FromContainer(&podSpec.Containers[i])
+			return &resources, nil
+		}
+	}
+	return nil, fmt.Errorf("container %s not found", recommendation.ContainerName)
+}
 
-	for _, container := range containers {
-		if container.Name == containerName {
-			if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
-				rec.CurrentCPU = cpu.String()
+// predictQoSClass returns the pod QoS class of the workload before and after the change
+func (a *Applier) predictQoSClass(ctx context.Context, recommendation *ResourceRecommendation) (corev1.PodQOSClass, corev1.PodQOSClass, error) {
+	podSpec, err := a.GetPodSpec(ctx, recommendation.Namespace, recommendation.WorkloadKind, recommendation.WorkloadName)
+	if err != nil {
+		return "", "", err
+	}
+	before := qos.PodClass(podSpec)
+
+	updated := podSpec.DeepCopy()
+	for i := range updated.Containers {
+		container := &updated.Containers[i]
+		if container.Name != recommendation.ContainerName {
+			continue
+		}
+		if container.Resources.Requests == nil {
+			container.Resources.Requests = corev1.ResourceList{}
+		}
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		for _, q := range []struct {
+			list  corev1.ResourceList
+			name  corev1.ResourceName
+			value string
+		}{
+			{container.Resources.Requests, corev1.ResourceCPU, recommendation.RecommendedCPU},
+			{container.Resources.Requests, corev1.ResourceMemory, recommendation.RecommendedMemory},
+			{container.Resources.Limits, corev1.ResourceCPU, recommendation.RecommendedCPULimit},
+			{container.Resources.Limits, corev1.ResourceMemory, recommendation.RecommendedMemoryLimit},
+		} {
+			if q.value == "" {
+				continue
 			}
-			if mem, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
-				rec.CurrentMemory = mem.String()
+			quantity, err := resource.ParseQuantity(q.value)
+			if err != nil {
+				return "", "", fmt.Errorf("invalid quantity %s: %v", q.value, err)
 			}
-			return rec, nil
+			q.list[q.name] = quantity
 		}
+		return before, qos.PodClass(updated), nil
 	}
-
-	return rec, nil
+	return "", "", fmt.Errorf("container %s not found", recommendation.ContainerName)
 }
 
 func ParseResourceQuantity(value string) (resource.Quantity, error) {
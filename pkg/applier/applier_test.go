@@ -0,0 +1,84 @@
+package applier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/scaler"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+)
+
+func canaryStatefulSet() *appsv1.StatefulSet {
+	replicas := int32(3)
+	labels := map[string]string{"app": "db"}
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{
+					Name: "app",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+					},
+				}}},
+			},
+		},
+	}
+}
+
+func TestLiveApply_CanarySavesRevisionOnPromote(t *testing.T) {
+	client := fake.NewSimpleClientset(canaryStatefulSet())
+	a := NewApplier(client, record.NewFakeRecorder(10))
+	ctx := context.Background()
+	recommendation := &ResourceRecommendation{
+		Namespace:      "default",
+		WorkloadKind:   "StatefulSet",
+		WorkloadName:   "db",
+		ContainerName:  "app",
+		CurrentCPU:     "500m",
+		RecommendedCPU: "300m",
+		Canary:         &scaler.CanaryConfig{Replicas: 1, SoakPeriod: time.Minute},
+	}
+
+	// An aborted canary leaves no revision behind
+	result, err := a.LiveApply(ctx, recommendation)
+	if err != nil || result.Canary == nil {
+		t.Fatalf("Expected a canary to start, got %+v, %v", result, err)
+	}
+	if revisions, _ := a.RollbackManager().ListRevisions(ctx, "default", "StatefulSet", "db"); len(revisions) != 0 {
+		t.Errorf("Expected no revision while the canary soaks, got %+v", revisions)
+	}
+	if err := a.Scaler().AbortCanary(ctx, result.Canary, "test"); err != nil {
+		t.Fatalf("AbortCanary failed: %v", err)
+	}
+	if revisions, _ := a.RollbackManager().ListRevisions(ctx, "default", "StatefulSet", "db"); len(revisions) != 0 {
+		t.Errorf("Expected no revision after the abort, got %+v", revisions)
+	}
+
+	// A promoted canary records the resources from before it, although the template
+	// already carries the new ones
+	result, err = a.LiveApply(ctx, recommendation)
+	if err != nil || result.Canary == nil {
+		t.Fatalf("Expected a canary to start, got %+v, %v", result, err)
+	}
+	if err := a.PromoteCanary(ctx, result.Canary, "default/test-config", "rec-1"); err != nil {
+		t.Fatalf("PromoteCanary failed: %v", err)
+	}
+	revisions, err := a.RollbackManager().ListRevisions(ctx, "default", "StatefulSet", "db")
+	if err != nil {
+		t.Fatalf("ListRevisions failed: %v", err)
+	}
+	if len(revisions) != 1 || revisions[0].Containers[0].CPU != "500m" {
+		t.Errorf("Expected one revision at 500m, got %+v", revisions)
+	}
+}
@@ -1,9 +1,17 @@
 package applier
 
 import (
+	"errors"
+
+	"intelligent-cluster-optimizer/pkg/scaler"
+
 	corev1 "k8s.io/api/core/v1"
 )
 
+// ErrQoSClassChange is returned when a change would move a workload to another pod QoS
+// class while the QoS class must be preserved
+var ErrQoSClassChange = errors.New("change would alter pod QoS class")
+
 type ResourceRecommendation struct {
 	Namespace         string
 	WorkloadKind      string
@@ -13,6 +21,26 @@ type ResourceRecommendation struct {
 	RecommendedCPU    string
 	CurrentMemory     string
 	RecommendedMemory string
+	// CurrentMemoryLimit and RecommendedMemoryLimit are only set when the
+	// memory limit is changed along with the request
+	CurrentMemoryLimit     string
+	RecommendedMemoryLimit string
+	// CurrentCPULimit and RecommendedCPULimit are only set when the CPU limit is
+	// changed along with the request
+	CurrentCPULimit     string
+	RecommendedCPULimit string
+	// CurrentEphemeralStorage and RecommendedEphemeralStorage are only set when the
+	// ephemeral-storage request is changed
+	CurrentEphemeralStorage     string
+	RecommendedEphemeralStorage string
+	// PreserveQoS refuses changes that would move the pod to another QoS class
+	PreserveQoS bool
+	// OptimizerConfig (namespace/name) and RecommendationID are stamped on the
+	// workload's provenance annotations when the change is applied
+	OptimizerConfig  string
+	RecommendationID string
+	// Canary rolls the change out through canary pods first when set
+	Canary *scaler.CanaryConfig
 }
 
 type ApplyResult struct {
@@ -23,10 +51,25 @@ type ApplyResult struct {
 	Namespace    string
 	Changes      []string
 	Error        error
+
+	// QoSClass and NewQoSClass are the pod QoS class before and after the change
+	QoSClass    corev1.PodQOSClass
+	NewQoSClass corev1.PodQOSClass
+
+	// Canary is set when the change was applied to canary pods only and still has to be
+	// analyzed and then promoted or aborted
+	Canary *scaler.Canary
+}
+
+// QoSClassChanged reports whether the change moves the pod to another QoS class
+func (r *ApplyResult) QoSClassChanged() bool {
+	return r.QoSClass != "" && r.NewQoSClass != "" && r.QoSClass != r.NewQoSClass
 }
 
 func (r *ResourceRecommendation) HasChanges() bool {
-	return r.CurrentCPU != r.RecommendedCPU || r.CurrentMemory != r.RecommendedMemory
+	return r.CurrentCPU != r.RecommendedCPU || r.CurrentMemory != r.RecommendedMemory ||
+		r.CurrentMemoryLimit != r.RecommendedMemoryLimit || r.CurrentCPULimit != r.RecommendedCPULimit ||
+		r.CurrentEphemeralStorage != r.RecommendedEphemeralStorage
 }
 
 func (r *ResourceRecommendation) GetResourceRequirements() corev1.ResourceRequirements {
@@ -0,0 +1,372 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/cost"
+	"intelligent-cluster-optimizer/pkg/models"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+)
+
+// DefaultInterval is the simulated time between two reconciles
+const DefaultInterval = time.Hour
+
+// Strategy is a named recommendation configuration to replay
+type Strategy struct {
+	Name            string
+	Strategy        optimizerv1alpha1.OptimizationStrategy
+	Recommendations *optimizerv1alpha1.RecommendationConfig
+}
+
+// Options control a backtest run
+type Options struct {
+	// Namespaces to replay; all namespaces in the history when empty
+	Namespaces []string
+
+	// Start and End bound the replay; the bounds of the history when zero
+	Start time.Time
+	End   time.Time
+
+	// Interval is the simulated reconcile interval (default: DefaultInterval)
+	Interval time.Duration
+
+	// ResourceThresholds are applied to every strategy, as in an OptimizerConfig
+	ResourceThresholds *optimizerv1alpha1.ResourceThresholds
+
+	// Pricing is used for cost estimates (default: the default pricing model)
+	Pricing *cost.PricingModel
+}
+
+// Result is how one strategy would have performed on the replayed traffic. Every
+// container sample after the first reconcile is evaluated against the requests in
+// effect at that time; containers without a recommendation keep their actual requests.
+type Result struct {
+	Name string `json:"name"`
+
+	// Samples is the number of container samples evaluated
+	Samples int `json:"samples"`
+
+	// CPURequestExceeded and MemoryRequestExceeded count samples whose usage was above
+	// the request, i.e. CPU contention and memory eviction risk
+	CPURequestExceeded    int `json:"cpuRequestExceeded"`
+	MemoryRequestExceeded int `json:"memoryRequestExceeded"`
+
+	// CPULimitExceeded counts samples that would have been throttled and
+	// MemoryLimitExceeded samples that would have been OOM-killed
+	CPULimitExceeded    int `json:"cpuLimitExceeded"`
+	MemoryLimitExceeded int `json:"memoryLimitExceeded"`
+
+	// CPUOverProvisionPercent and MemoryOverProvisionPercent are the share of the
+	// requests left unused, summed over all samples
+	CPUOverProvisionPercent    float64 `json:"cpuOverProvisionPercent"`
+	MemoryOverProvisionPercent float64 `json:"memoryOverProvisionPercent"`
+
+	// CostPerHour is the average hourly cost of the requests over the replay
+	CostPerHour  float64 `json:"costPerHour"`
+	CostPerMonth float64 `json:"costPerMonth"`
+}
+
+// Percent returns count as a percentage of the evaluated samples
+func (r *Result) Percent(count int) float64 {
+	if r.Samples == 0 {
+		return 0
+	}
+	return float64(count) / float64(r.Samples) * 100
+}
+
+// Report compares the replayed strategies with the requests that were actually in place
+type Report struct {
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Interval   string    `json:"interval"`
+	Reconciles int       `json:"reconciles"`
+
+	// Baseline evaluates the actual requests recorded in the history
+	Baseline   Result   `json:"baseline"`
+	Strategies []Result `json:"strategies"`
+}
+
+// Backtester replays a metric history through the recommendation engine at simulated
+// reconcile times
+type Backtester struct {
+	history []models.PodMetric
+}
+
+// NewBacktester creates a backtester over the given pod metrics
+func NewBacktester(history []models.PodMetric) *Backtester {
+	sorted := make([]models.PodMetric, len(history))
+	copy(sorted, history)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	return &Backtester{history: sorted}
+}
+
+// Run replays the history once for every strategy. At each simulated reconcile the
+// engine only sees metrics recorded up to that time, and its recommendations stay in
+// effect until the next reconcile.
+func (b *Backtester) Run(opts Options, strategies []Strategy) (*Report, error) {
+	if len(b.history) == 0 {
+		return nil, fmt.Errorf("no metric history to replay")
+	}
+	if len(strategies) == 0 {
+		return nil, fmt.Errorf("no strategies to compare")
+	}
+
+	start, end := opts.Start, opts.End
+	if start.IsZero() {
+		start = b.history[0].Timestamp
+	}
+	if end.IsZero() {
+		end = b.history[len(b.history)-1].Timestamp
+	}
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	namespaces := opts.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = b.namespaces()
+	}
+
+	var reconciles []time.Time
+	for t := start.Add(interval); t.Before(end); t = t.Add(interval) {
+		reconciles = append(reconciles, t)
+	}
+	if len(reconciles) == 0 {
+		return nil, fmt.Errorf("history from %s to %s is shorter than one reconcile interval (%s)",
+			start.Format(time.RFC3339), end.Format(time.RFC3339), interval)
+	}
+	windows := b.windows(reconciles, interval, end, namespaces)
+
+	calculator := cost.NewCalculator(opts.Pricing)
+	report := &Report{
+		Start:      start,
+		End:        end,
+		Interval:   interval.String(),
+		Reconciles: len(reconciles),
+	}
+
+	baseline := newAccumulator("current", calculator)
+	for _, window := range windows {
+		baseline.evaluate(window, nil)
+	}
+	report.Baseline = baseline.result()
+
+	for _, strategy := range strategies {
+		engine := recommendation.NewEngine()
+		config := &optimizerv1alpha1.OptimizerConfig{
+			Spec: optimizerv1alpha1.OptimizerConfigSpec{
+				TargetNamespaces:   namespaces,
+				Strategy:           strategy.Strategy,
+				Recommendations:    strategy.Recommendations,
+				ResourceThresholds: opts.ResourceThresholds,
+			},
+		}
+
+		acc := newAccumulator(strategy.Name, calculator)
+		active := make(map[containerKey]requests)
+		for i, at := range reconciles {
+			recs, err := engine.GenerateRecommendations(&replayProvider{history: b.history, at: at}, config)
+			if err != nil {
+				return nil, fmt.Errorf("strategy %s: %w", strategy.Name, err)
+			}
+			for _, rec := range recs {
+				for _, c := range rec.Containers {
+					active[containerKey{rec.Namespace, rec.WorkloadName, c.ContainerName}] = requests{
+						cpu:    c.RecommendedCPU,
+						memory: c.RecommendedMemory,
+					}
+				}
+			}
+			acc.evaluate(windows[i], active)
+		}
+		report.Strategies = append(report.Strategies, acc.result())
+	}
+
+	return report, nil
+}
+
+// namespaces returns the namespaces present in the history
+func (b *Backtester) namespaces() []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, pm := range b.history {
+		if !seen[pm.Namespace] {
+			seen[pm.Namespace] = true
+			namespaces = append(namespaces, pm.Namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// windows returns, for each reconcile, the samples recorded until the next one
+func (b *Backtester) windows(reconciles []time.Time, interval time.Duration, end time.Time, namespaces []string) [][]models.PodMetric {
+	included := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		included[ns] = true
+	}
+
+	windows := make([][]models.PodMetric, len(reconciles))
+	for i, at := range reconciles {
+		until := at.Add(interval)
+		if until.After(end) || i == len(reconciles)-1 {
+			until = end
+		}
+		for _, pm := range b.history[firstAfter(b.history, at):firstAfter(b.history, until)] {
+			if included[pm.Namespace] {
+				windows[i] = append(windows[i], pm)
+			}
+		}
+	}
+	return windows
+}
+
+// firstAfter returns the index of the first metric recorded after t
+func firstAfter(history []models.PodMetric, t time.Time) int {
+	return sort.Search(len(history), func(i int) bool {
+		return history[i].Timestamp.After(t)
+	})
+}
+
+// replayProvider serves the history as the engine would have seen it at a point in time
+type replayProvider struct {
+	history []models.PodMetric
+	at      time.Time
+}
+
+func (p *replayProvider) GetMetricsByNamespace(namespace string, since time.Duration) []models.PodMetric {
+	return p.metrics(since, func(pm *models.PodMetric) bool {
+		return pm.Namespace == namespace
+	})
+}
+
+func (p *replayProvider) GetMetricsByWorkload(namespace, workloadName string, since time.Duration) []models.PodMetric {
+	return p.metrics(since, func(pm *models.PodMetric) bool {
+		return pm.Namespace == namespace && strings.HasPrefix(pm.PodName, workloadName)
+	})
+}
+
+func (p *replayProvider) metrics(since time.Duration, match func(*models.PodMetric) bool) []models.PodMetric {
+	var result []models.PodMetric
+	for i := firstAfter(p.history, p.at.Add(-since)); i < firstAfter(p.history, p.at); i++ {
+		if match(&p.history[i]) {
+			result = append(result, p.history[i])
+		}
+	}
+	return result
+}
+
+// containerKey identifies a container of a workload
+type containerKey struct {
+	namespace string
+	workload  string
+	container string
+}
+
+// requests are the CPU (millicores) and memory (bytes) requests of a container
+type requests struct {
+	cpu    int64
+	memory int64
+}
+
+// accumulator collects the outcome of one strategy over all windows
+type accumulator struct {
+	calculator *cost.Calculator
+	res        Result
+
+	cpuRequested, cpuUnused       int64
+	memoryRequested, memoryUnused int64
+	costSum                       float64
+	windows                       int
+}
+
+func newAccumulator(name string, calculator *cost.Calculator) *accumulator {
+	return &accumulator{calculator: calculator, res: Result{Name: name}}
+}
+
+// evaluate scores the samples of one window against the active recommendations. With
+// no recommendations the recorded requests are evaluated.
+func (a *accumulator) evaluate(window []models.PodMetric, active map[containerKey]requests) {
+	podRequests := make(map[string]map[string]requests)
+	for _, pm := range window {
+		workload := recommendation.WorkloadNameFromPod(pm.PodName)
+		for _, cm := range pm.Containers {
+			cpu, memory := cm.RequestCPU, cm.RequestMemory
+			cpuLimit, memoryLimit := cm.LimitCPU, cm.LimitMemory
+			if rec, ok := active[containerKey{pm.Namespace, workload, cm.ContainerName}]; ok {
+				cpuLimit = effectiveLimit(cm.LimitCPU, cm.RequestCPU, rec.cpu)
+				memoryLimit = effectiveLimit(cm.LimitMemory, cm.RequestMemory, rec.memory)
+				cpu, memory = rec.cpu, rec.memory
+			}
+
+			a.res.Samples++
+			if cm.UsageCPU > cpu {
+				a.res.CPURequestExceeded++
+			}
+			if cm.UsageMemory > memory {
+				a.res.MemoryRequestExceeded++
+			}
+			if cpuLimit > 0 && cm.UsageCPU > cpuLimit {
+				a.res.CPULimitExceeded++
+			}
+			if memoryLimit > 0 && cm.UsageMemory > memoryLimit {
+				a.res.MemoryLimitExceeded++
+			}
+
+			a.cpuRequested += cpu
+			a.cpuUnused += max(cpu-cm.UsageCPU, 0)
+			a.memoryRequested += memory
+			a.memoryUnused += max(memory-cm.UsageMemory, 0)
+
+			podKey := pm.Namespace + "/" + pm.PodName
+			if podRequests[podKey] == nil {
+				podRequests[podKey] = make(map[string]requests)
+			}
+			podRequests[podKey][cm.ContainerName] = requests{cpu: cpu, memory: memory}
+		}
+	}
+
+	// Every pod seen in the window is charged for the whole window
+	var windowCost float64
+	for _, containers := range podRequests {
+		for _, r := range containers {
+			windowCost += a.calculator.CalculateCost(r.cpu, r.memory).TotalPerHour
+		}
+	}
+	a.costSum += windowCost
+	a.windows++
+}
+
+func (a *accumulator) result() Result {
+	r := a.res
+	if a.cpuRequested > 0 {
+		r.CPUOverProvisionPercent = float64(a.cpuUnused) / float64(a.cpuRequested) * 100
+	}
+	if a.memoryRequested > 0 {
+		r.MemoryOverProvisionPercent = float64(a.memoryUnused) / float64(a.memoryRequested) * 100
+	}
+	if a.windows > 0 {
+		r.CostPerHour = a.costSum / float64(a.windows)
+		r.CostPerMonth = r.CostPerHour * 24 * 30
+	}
+	return r
+}
+
+// effectiveLimit returns the limit a container runs with after its request changes.
+// Limits equal to the request move with it, as the controller does to keep the
+// Guaranteed QoS class, and a limit is never left below the request.
+func effectiveLimit(limit, request, recommended int64) int64 {
+	if limit == 0 {
+		return 0
+	}
+	if limit == request || limit < recommended {
+		return recommended
+	}
+	return limit
+}
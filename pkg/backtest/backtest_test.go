@@ -0,0 +1,123 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/models"
+)
+
+const mi = 1024 * 1024
+
+// spikyHistory records a pod every 5 minutes for 12 hours. Usage is steady at
+// 300m/300Mi, with every 10th sample spiking to 600m/600Mi.
+func spikyHistory(start time.Time) []models.PodMetric {
+	var history []models.PodMetric
+	for i := 0; i < 12*12; i++ {
+		cpu, memory := int64(300), int64(300*mi)
+		if i%10 == 9 {
+			cpu, memory = 600, 600*mi
+		}
+		history = append(history, models.PodMetric{
+			PodName:   "web-5c9d7f8b4-abcde",
+			Namespace: "default",
+			Timestamp: start.Add(time.Duration(i) * 5 * time.Minute),
+			Containers: []models.ContainerMetric{{
+				ContainerName: "web",
+				UsageCPU:      cpu,
+				UsageMemory:   memory,
+				RequestCPU:    1000,
+				RequestMemory: 1024 * mi,
+				LimitMemory:   1024 * mi,
+			}},
+		})
+	}
+	return history
+}
+
+func TestBacktester_ComparesStrategies(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	backtester := NewBacktester(spikyHistory(start))
+
+	report, err := backtester.Run(Options{Interval: time.Hour}, []Strategy{
+		{Name: "aggressive", Strategy: optimizerv1alpha1.StrategyAggressive},
+		{Name: "conservative", Strategy: optimizerv1alpha1.StrategyConservative},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.Reconciles != 11 {
+		t.Errorf("Expected 11 reconciles in 11h55m of history, got %d", report.Reconciles)
+	}
+	if len(report.Strategies) != 2 {
+		t.Fatalf("Expected 2 strategy results, got %d", len(report.Strategies))
+	}
+
+	baseline, aggressive, conservative := report.Baseline, report.Strategies[0], report.Strategies[1]
+	if baseline.Samples == 0 || aggressive.Samples != baseline.Samples || conservative.Samples != baseline.Samples {
+		t.Fatalf("Expected every result to cover the same samples, got %d/%d/%d",
+			baseline.Samples, aggressive.Samples, conservative.Samples)
+	}
+
+	// The recorded 1000m/1Gi requests are never exceeded but mostly unused
+	if baseline.CPURequestExceeded != 0 || baseline.MemoryLimitExceeded != 0 {
+		t.Errorf("Expected no exceedances at the recorded requests, got %+v", baseline)
+	}
+	if baseline.CPUOverProvisionPercent < 60 {
+		t.Errorf("Expected the recorded requests to be over-provisioned, got %.1f%%", baseline.CPUOverProvisionPercent)
+	}
+
+	// Right-sizing is cheaper, and aggressive sizing trades safety for cost
+	if aggressive.CostPerHour >= conservative.CostPerHour || conservative.CostPerHour >= baseline.CostPerHour {
+		t.Errorf("Expected cost aggressive < conservative < baseline, got %.4f / %.4f / %.4f",
+			aggressive.CostPerHour, conservative.CostPerHour, baseline.CostPerHour)
+	}
+	if aggressive.CPURequestExceeded <= conservative.CPURequestExceeded {
+		t.Errorf("Expected aggressive sizing to exceed CPU requests more often (%d vs %d)",
+			aggressive.CPURequestExceeded, conservative.CPURequestExceeded)
+	}
+
+	// The memory limit equals the request, so it moves with the recommendation
+	if aggressive.MemoryLimitExceeded == 0 {
+		t.Error("Expected aggressive sizing to OOM on the memory spikes")
+	}
+	if conservative.MemoryLimitExceeded != 0 {
+		t.Errorf("Expected conservative sizing to absorb the spikes, got %d OOMs", conservative.MemoryLimitExceeded)
+	}
+}
+
+func TestReplayProvider_HidesFutureMetrics(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	backtester := NewBacktester(spikyHistory(start))
+
+	provider := &replayProvider{history: backtester.history, at: start.Add(time.Hour)}
+	metrics := provider.GetMetricsByNamespace("default", 30*time.Minute)
+
+	// Samples at 35, 40, ..., 60 minutes
+	if len(metrics) != 6 {
+		t.Fatalf("Expected 6 samples in the 30 minutes before the reconcile, got %d", len(metrics))
+	}
+	for _, pm := range metrics {
+		if pm.Timestamp.After(provider.at) {
+			t.Errorf("Sample at %s is after the simulated reconcile", pm.Timestamp)
+		}
+	}
+
+	if got := provider.GetMetricsByWorkload("default", "api", time.Hour); len(got) != 0 {
+		t.Errorf("Expected no samples for another workload, got %d", len(got))
+	}
+}
+
+func TestBacktester_RequiresHistory(t *testing.T) {
+	if _, err := NewBacktester(nil).Run(Options{}, []Strategy{{Name: "balanced"}}); err == nil {
+		t.Error("Expected an error without history")
+	}
+
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	short := spikyHistory(start)[:6]
+	if _, err := NewBacktester(short).Run(Options{Interval: time.Hour}, []Strategy{{Name: "balanced"}}); err == nil {
+		t.Error("Expected an error for history shorter than one interval")
+	}
+}
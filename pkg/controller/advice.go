@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+)
+
+// adviceKey identifies an advisory event about one container of one config
+func adviceKey(config *optimizerv1alpha1.OptimizerConfig, reason string,
+	workloadRec *recommendation.WorkloadRecommendation, containerName string) string {
+	return strings.Join([]string{config.Namespace, config.Name, reason,
+		workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, containerName}, "/")
+}
+
+// adviceBucketRatio is the relative step between the buckets advisory states round
+// values to, so small drifts between reconciles do not count as a change
+const adviceBucketRatio = 1.05
+
+// adviceBucket rounds a positive value to a bucket that spans adviceBucketRatio
+func adviceBucket(value int64) int {
+	if value <= 0 {
+		return 0
+	}
+	return int(math.Floor(math.Log(float64(value)) / math.Log(adviceBucketRatio)))
+}
+
+// forecastAdviceState describes which targets a forecast raised and roughly to what
+func forecastAdviceState(containerRec *recommendation.ContainerRecommendation) string {
+	var parts []string
+	if containerRec.ForecastRaisedCPU {
+		parts = append(parts, fmt.Sprintf("cpu=%d", adviceBucket(containerRec.RecommendedCPU)))
+	}
+	if containerRec.ForecastRaisedMemory {
+		parts = append(parts, fmt.Sprintf("memory=%d", adviceBucket(containerRec.RecommendedMemory)))
+	}
+	return strings.Join(parts, ",")
+}
+
+// adviceChanged records the state an advisory event describes and reports whether it
+// differs from the state last reported, so events that would repeat on every reconcile
+// are only recorded when what they describe changes
+func (r *Reconciler) adviceChanged(config *optimizerv1alpha1.OptimizerConfig, reason string,
+	workloadRec *recommendation.WorkloadRecommendation, containerName, state string) bool {
+	key := adviceKey(config, reason, workloadRec, containerName)
+
+	r.adviceMu.Lock()
+	defer r.adviceMu.Unlock()
+	if r.lastAdvice[key] == state {
+		return false
+	}
+	r.lastAdvice[key] = state
+	return true
+}
+
+// clearAdvice forgets an advisory event that no longer applies, so it is reported
+// again if the condition returns
+func (r *Reconciler) clearAdvice(config *optimizerv1alpha1.OptimizerConfig, reason string,
+	workloadRec *recommendation.WorkloadRecommendation, containerName string) {
+	r.adviceMu.Lock()
+	defer r.adviceMu.Unlock()
+	delete(r.lastAdvice, adviceKey(config, reason, workloadRec, containerName))
+}
+
+// forgetAdvice drops the advisory state of a deleted config
+func (r *Reconciler) forgetAdvice(namespace, name string) {
+	prefix := namespace + "/" + name + "/"
+
+	r.adviceMu.Lock()
+	defer r.adviceMu.Unlock()
+	for key := range r.lastAdvice {
+		if strings.HasPrefix(key, prefix) {
+			delete(r.lastAdvice, key)
+		}
+	}
+}
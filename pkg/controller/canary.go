@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/profile"
+	"intelligent-cluster-optimizer/pkg/scaler"
+
+	"k8s.io/klog/v2"
+)
+
+// rollbackReasonCanary is the rollback metric reason for aborted canaries
+const rollbackReasonCanary = "CanaryAborted"
+
+// canaryConfig returns the canary settings of a config that rolls changes out through
+// canaries, or nil for the other update strategies
+func canaryConfig(config *optimizerv1alpha1.OptimizerConfig) *scaler.CanaryConfig {
+	strategy := config.Spec.UpdateStrategy
+	if strategy == nil || strategy.Type != optimizerv1alpha1.UpdateStrategyCanary {
+		return nil
+	}
+
+	canary := &scaler.CanaryConfig{Replicas: 1, SoakPeriod: scaler.DefaultCanarySoakPeriod}
+	if strategy.Canary == nil {
+		return canary
+	}
+	if strategy.Canary.Replicas > 0 {
+		canary.Replicas = strategy.Canary.Replicas
+	}
+	if soak, err := time.ParseDuration(strategy.Canary.SoakPeriod); err == nil && soak > 0 {
+		canary.SoakPeriod = soak
+	}
+	canary.MaxRestarts = strategy.Canary.MaxRestarts
+	return canary
+}
+
+// handleCanaryAborted reports a change whose canary was less healthy than the baseline
+// and quarantines the workload like a rolled back one, so the change is not retried
+// until the rollback cooldown ends
+func (r *Reconciler) handleCanaryAborted(
+	config *optimizerv1alpha1.OptimizerConfig,
+	ref workloadRef,
+	container string,
+	reason string,
+	settings *profile.ResolvedSettings,
+	mode string,
+) {
+	klog.Warningf("[%s] Canary of %s/%s/%s container=%s aborted: %s",
+		mode, ref.Namespace, ref.Kind, ref.Name, container, reason)
+	r.optimizerEvents.RecordWarningEvent(config, events.ReasonCanaryAborted,
+		fmt.Sprintf("%s/%s container %s: canary aborted: %s", ref.Kind, ref.Name, container, reason))
+	if r.metricsExporter != nil {
+		r.metricsExporter.RecordRollback(config.Name, ref.Namespace, rollbackReasonCanary)
+	}
+
+	if settings != nil && settings.RollbackCooldown > 0 {
+		r.quarantine(config, ref, "canary aborted: "+reason, time.Now(), settings.RollbackCooldown)
+	}
+}
+
+// canaryRollout converts a started canary into the state kept in status
+func canaryRollout(canary *scaler.Canary, recommendationID string) *optimizerv1alpha1.CanaryRollout {
+	req := &canary.Request
+	return &optimizerv1alpha1.CanaryRollout{
+		Container:         req.ContainerName,
+		RecommendationID:  recommendationID,
+		CPU:               req.NewCPU,
+		Memory:            req.NewMemory,
+		CPULimit:          req.NewCPULimit,
+		MemoryLimit:       req.NewMemoryLimit,
+		EphemeralStorage:  req.NewEphemeralStorage,
+		PreserveQoS:       req.PreserveQoS,
+		Replicas:          canary.Config.Replicas,
+		SoakPeriod:        canary.Config.SoakPeriod.String(),
+		MaxRestarts:       canary.Config.MaxRestarts,
+		Partition:         canary.Partition,
+		OriginalPartition: canary.OriginalPartition,
+		ReplicaSet:        canary.ReplicaSet,
+		PreviousResources: *canary.PreviousResources.DeepCopy(),
+		BaselineRestarts:  canary.BaselineRestarts,
+	}
+}
+
+// scalerCanary restores the canary of a rollout from status
+func scalerCanary(rollout *optimizerv1alpha1.WorkloadRollout) *scaler.Canary {
+	c := rollout.Canary
+	soak, err := time.ParseDuration(c.SoakPeriod)
+	if err != nil || soak <= 0 {
+		soak = scaler.DefaultCanarySoakPeriod
+	}
+	return &scaler.Canary{
+		Request: scaler.ScaleRequest{
+			Namespace:           rollout.Namespace,
+			WorkloadKind:        rollout.Kind,
+			WorkloadName:        rollout.Name,
+			ContainerName:       c.Container,
+			NewCPU:              c.CPU,
+			NewMemory:           c.Memory,
+			NewCPULimit:         c.CPULimit,
+			NewMemoryLimit:      c.MemoryLimit,
+			NewEphemeralStorage: c.EphemeralStorage,
+			PreserveQoS:         c.PreserveQoS,
+			Strategy:            scaler.StrategyRolling,
+		},
+		Config:            scaler.CanaryConfig{Replicas: c.Replicas, SoakPeriod: soak, MaxRestarts: c.MaxRestarts},
+		StartedAt:         rollout.PhaseStartedAt.Time,
+		Partition:         c.Partition,
+		OriginalPartition: c.OriginalPartition,
+		PreviousResources: *c.PreviousResources.DeepCopy(),
+		ReplicaSet:        c.ReplicaSet,
+		BaselineRestarts:  c.BaselineRestarts,
+	}
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+	"intelligent-cluster-optimizer/pkg/safety"
+)
+
+// BudgetPriority decides which recommendations consume the change budget first
+type BudgetPriority string
+
+const (
+	// BudgetPrioritySavings changes the workloads with the highest estimated savings first
+	BudgetPrioritySavings BudgetPriority = "savings"
+	// BudgetPriorityOOMRisk changes the workloads with the most OOM kills first
+	BudgetPriorityOOMRisk BudgetPriority = "oom-risk"
+)
+
+// ParseBudgetPriority validates a change budget priority
+func ParseBudgetPriority(value string) (BudgetPriority, error) {
+	switch priority := BudgetPriority(value); priority {
+	case BudgetPrioritySavings, BudgetPriorityOOMRisk:
+		return priority, nil
+	default:
+		return "", fmt.Errorf("invalid change budget priority %q: must be %s or %s",
+			value, BudgetPrioritySavings, BudgetPriorityOOMRisk)
+	}
+}
+
+// SetChangeBudget limits how many workloads all OptimizerConfigs change at once and per
+// hour; the budget must be shared by every reconcile worker
+func (r *Reconciler) SetChangeBudget(budget *safety.ChangeBudget, priority BudgetPriority) {
+	r.changeBudget = budget
+	r.budgetPriority = priority
+}
+
+// ForgetConfig releases the change budget held by the rollouts of a deleted OptimizerConfig
+// and drops its circuit breaker metrics and the state of its advisory events
+func (r *Reconciler) ForgetConfig(namespace, name string) {
+	r.changeBudget.SyncInFlight(namespace+"/"+name, nil, time.Now())
+	r.changeBudget.SetWaiting(namespace+"/"+name, nil, time.Now())
+	r.forgetAdvice(namespace, name)
+	if r.metricsExporter != nil {
+		r.metricsExporter.ResetCircuitBreakerStates(name)
+	}
+}
+
+// syncChangeBudget reports the config's in-flight rollouts to the shared change budget
+func (r *Reconciler) syncChangeBudget(config *optimizerv1alpha1.OptimizerConfig, now time.Time) {
+	rollouts := make([]safety.BudgetRollout, 0, len(config.Status.Rollouts))
+	for _, rollout := range config.Status.Rollouts {
+		rollouts = append(rollouts, safety.BudgetRollout{
+			Workload:  safety.BudgetWorkload{Namespace: rollout.Namespace, Kind: rollout.Kind, Name: rollout.Name},
+			StartedAt: rollout.StartedAt.Time,
+		})
+	}
+	r.changeBudget.SyncInFlight(budgetOwner(config), rollouts, now)
+}
+
+// budgetLimited reports whether any change budget limit is set
+func (r *Reconciler) budgetLimited() bool {
+	global, perNamespace := r.changeBudget.Limits()
+	return !global.IsUnlimited() || !perNamespace.IsUnlimited()
+}
+
+func budgetOwner(config *optimizerv1alpha1.OptimizerConfig) string {
+	return config.Namespace + "/" + config.Name
+}
+
+// budgetRank ranks a change for the change budget: by estimated monthly savings or by
+// OOM kills, the other breaking ties. The same rank orders the changes of one config and
+// decides which config's deferred changes the shared budget keeps room for.
+func budgetRank(savingsPerMonth float64, oomKills int, priority BudgetPriority) safety.BudgetRank {
+	if priority == BudgetPriorityOOMRisk {
+		return safety.BudgetRank{Primary: float64(oomKills), Secondary: savingsPerMonth}
+	}
+	return safety.BudgetRank{Primary: savingsPerMonth, Secondary: float64(oomKills)}
+}
+
+// workloadBudgetRank ranks all changes to a workload
+func workloadBudgetRank(rec *recommendation.WorkloadRecommendation, priority BudgetPriority) safety.BudgetRank {
+	var savings float64
+	if rec.TotalEstimatedSavings != nil {
+		savings = rec.TotalEstimatedSavings.SavingsPerMonth
+	}
+	return budgetRank(savings, rec.TotalOOMCount, priority)
+}
+
+// prioritizeRecommendations orders workloads so the most valuable changes consume the
+// change budget first
+func prioritizeRecommendations(recommendations []recommendation.WorkloadRecommendation, priority BudgetPriority) {
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return workloadBudgetRank(&recommendations[i], priority).Outranks(workloadBudgetRank(&recommendations[j], priority))
+	})
+}
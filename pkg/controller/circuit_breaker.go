@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/safety"
+
+	"k8s.io/klog/v2"
+)
+
+// circuitStateValues are the circuit breaker state metric values
+var circuitStateValues = map[optimizerv1alpha1.CircuitState]float64{
+	optimizerv1alpha1.CircuitStateClosed:   0,
+	optimizerv1alpha1.CircuitStateHalfOpen: 1,
+	optimizerv1alpha1.CircuitStateOpen:     2,
+}
+
+func circuitBreakerEnabled(config *optimizerv1alpha1.OptimizerConfig) bool {
+	return config.Spec.CircuitBreaker != nil && config.Spec.CircuitBreaker.Enabled
+}
+
+// findCircuit returns the circuit of a workload, or of its namespace, or nil if it has
+// not recorded any failure
+func findCircuit(config *optimizerv1alpha1.OptimizerConfig, scope optimizerv1alpha1.CircuitScope, ref workloadRef) *optimizerv1alpha1.CircuitBreakerStatus {
+	if scope == optimizerv1alpha1.CircuitScopeNamespace {
+		ref = workloadRef{Namespace: ref.Namespace}
+	}
+	for i := range config.Status.CircuitBreakers {
+		circuit := &config.Status.CircuitBreakers[i]
+		if circuit.Scope == scope && circuit.Namespace == ref.Namespace && circuit.Kind == ref.Kind && circuit.Name == ref.Name {
+			return circuit
+		}
+	}
+	return nil
+}
+
+// checkCircuits is the CircuitBreaker gate: a workload is changed only while neither its
+// own circuit nor its namespace's is open. A half-open namespace lets one trial change
+// through at a time.
+func (r *Reconciler) checkCircuits(config *optimizerv1alpha1.OptimizerConfig, ref workloadRef, now time.Time, mode string) (allowed bool, message string) {
+	if !circuitBreakerEnabled(config) {
+		return true, ""
+	}
+	cb := safety.NewCircuitBreaker(config.Spec.CircuitBreaker)
+
+	for _, scope := range []optimizerv1alpha1.CircuitScope{optimizerv1alpha1.CircuitScopeNamespace, optimizerv1alpha1.CircuitScopeWorkload} {
+		circuit := findCircuit(config, scope, ref)
+		if circuit == nil {
+			continue
+		}
+		allowed, stateChanged := cb.Allow(circuit, now)
+		if stateChanged {
+			r.circuitStateChanged(config, circuit, mode)
+		}
+		if !allowed {
+			return false, fmt.Sprintf("%s circuit open after %d consecutive failures (last: %s); trial change at %s",
+				circuit.Scope, circuit.ConsecutiveErrors, circuit.LastError, circuit.RetryAt.Format(time.RFC3339))
+		}
+		if scope == optimizerv1alpha1.CircuitScopeNamespace && circuit.State == optimizerv1alpha1.CircuitStateHalfOpen {
+			if rollout := namespaceRollout(config, ref.Namespace); rollout != nil && rollout.Name != ref.Name {
+				return false, fmt.Sprintf("Namespace circuit half-open; waiting for the trial change to %s/%s to be verified",
+					rollout.Kind, rollout.Name)
+			}
+		}
+	}
+	return true, ""
+}
+
+// namespaceRollout returns a rollout in flight in a namespace, or nil if there is none
+func namespaceRollout(config *optimizerv1alpha1.OptimizerConfig, namespace string) *optimizerv1alpha1.WorkloadRollout {
+	for i := range config.Status.Rollouts {
+		if config.Status.Rollouts[i].Namespace == namespace {
+			return &config.Status.Rollouts[i]
+		}
+	}
+	return nil
+}
+
+// recordChangeFailure counts a failed change against the circuits of its workload and
+// namespace, opening them once their error threshold is reached
+func (r *Reconciler) recordChangeFailure(config *optimizerv1alpha1.OptimizerConfig, ref workloadRef, err error, mode string) {
+	if !circuitBreakerEnabled(config) {
+		return
+	}
+	cb := safety.NewCircuitBreaker(config.Spec.CircuitBreaker)
+	now := time.Now()
+
+	for _, scope := range []optimizerv1alpha1.CircuitScope{optimizerv1alpha1.CircuitScopeWorkload, optimizerv1alpha1.CircuitScopeNamespace} {
+		circuit := findCircuit(config, scope, ref)
+		if circuit == nil {
+			entry := optimizerv1alpha1.CircuitBreakerStatus{Scope: scope, Namespace: ref.Namespace, State: optimizerv1alpha1.CircuitStateClosed}
+			if scope == optimizerv1alpha1.CircuitScopeWorkload {
+				entry.Kind, entry.Name = ref.Kind, ref.Name
+			}
+			config.Status.CircuitBreakers = append(config.Status.CircuitBreakers, entry)
+			circuit = &config.Status.CircuitBreakers[len(config.Status.CircuitBreakers)-1]
+		}
+		if cb.RecordFailure(circuit, err, now) {
+			if r.metricsExporter != nil {
+				r.metricsExporter.RecordCircuitBreakerTrip(config.Name, string(scope), ref.Namespace)
+			}
+			r.circuitStateChanged(config, circuit, mode)
+		}
+	}
+}
+
+// recordChangeSuccess resets the circuits of a workload and its namespace after a
+// verified change; circuits left closed without errors are dropped from status
+func (r *Reconciler) recordChangeSuccess(config *optimizerv1alpha1.OptimizerConfig, ref workloadRef, mode string) {
+	if !circuitBreakerEnabled(config) {
+		return
+	}
+	cb := safety.NewCircuitBreaker(config.Spec.CircuitBreaker)
+
+	for _, scope := range []optimizerv1alpha1.CircuitScope{optimizerv1alpha1.CircuitScopeWorkload, optimizerv1alpha1.CircuitScopeNamespace} {
+		if circuit := findCircuit(config, scope, ref); circuit != nil && cb.RecordSuccess(circuit) {
+			r.circuitStateChanged(config, circuit, mode)
+		}
+	}
+
+	var circuits []optimizerv1alpha1.CircuitBreakerStatus
+	for _, circuit := range config.Status.CircuitBreakers {
+		if circuit.State != optimizerv1alpha1.CircuitStateClosed || circuit.ConsecutiveErrors > 0 {
+			circuits = append(circuits, circuit)
+		}
+	}
+	config.Status.CircuitBreakers = circuits
+}
+
+// circuitStateChanged reports a circuit moving to a new state
+func (r *Reconciler) circuitStateChanged(config *optimizerv1alpha1.OptimizerConfig, circuit *optimizerv1alpha1.CircuitBreakerStatus, mode string) {
+	name := safety.CircuitName(circuit)
+	switch circuit.State {
+	case optimizerv1alpha1.CircuitStateOpen:
+		message := fmt.Sprintf("Circuit breaker for %s opened after %d consecutive failures (last: %s); trial change at %s",
+			name, circuit.ConsecutiveErrors, circuit.LastError, circuit.RetryAt.Format(time.RFC3339))
+		klog.Warningf("[%s] %s", mode, message)
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonCircuitBreakerOpen, message)
+	default:
+		message := fmt.Sprintf("Circuit breaker for %s is %s", name, circuit.State)
+		klog.Infof("[%s] %s", mode, message)
+		r.optimizerEvents.RecordNormalEvent(config, events.ReasonCircuitBreakerChanged, message)
+	}
+}
+
+// syncCircuitBreakers drops the circuits of configs without a circuit breaker, and
+// reports the remaining ones in the CircuitBreakerOpen condition and in metrics
+func (r *Reconciler) syncCircuitBreakers(config *optimizerv1alpha1.OptimizerConfig) {
+	if !circuitBreakerEnabled(config) {
+		config.Status.CircuitBreakers = nil
+	}
+
+	if r.metricsExporter != nil {
+		r.metricsExporter.ResetCircuitBreakerStates(config.Name)
+		for _, circuit := range config.Status.CircuitBreakers {
+			r.metricsExporter.RecordCircuitBreakerState(config.Name, string(circuit.Scope), circuit.Namespace,
+				circuit.Kind, circuit.Name, circuitStateValues[circuit.State])
+		}
+	}
+
+	if !circuitBreakerEnabled(config) {
+		return
+	}
+	open := map[optimizerv1alpha1.CircuitScope]int{}
+	for _, circuit := range config.Status.CircuitBreakers {
+		if circuit.State == optimizerv1alpha1.CircuitStateOpen {
+			open[circuit.Scope]++
+		}
+	}
+	status, reason, message := optimizerv1alpha1.ConditionFalse, "AllCircuitsClosed", "No workload or namespace circuit is open"
+	if len(open) > 0 {
+		status, reason = optimizerv1alpha1.ConditionTrue, "CircuitsOpen"
+		message = fmt.Sprintf("%d workload and %d namespace circuit(s) open",
+			open[optimizerv1alpha1.CircuitScopeWorkload], open[optimizerv1alpha1.CircuitScopeNamespace])
+	}
+	if err := r.updateCondition(config, optimizerv1alpha1.ConditionTypeCircuitBreakerOpen, status, reason, message); err != nil {
+		klog.Warningf("Failed to update condition: %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// maxDecisions caps the decision traces kept in status so the object stays small
+const maxDecisions = 100
+
+// Names of the safety gates recorded in decision traces
+const (
+	gateQuarantine       = "Quarantine"
+	gateRollout          = "Rollout"
+	gateCircuitBreaker   = "CircuitBreaker"
+	gateHPA              = "HPA"
+	gatePDB              = "PDB"
+	gateAnomaly          = "Anomaly"
+	gateMemoryLeak       = "MemoryLeak"
+	gateRuntimeMemory    = "RuntimeMemory"
+	gateNodeFit          = "NodeFit"
+	gateQoS              = "QoS"
+	gateQuota            = "Quota"
+	gateMaxChangePercent = "MaxChangePercent"
+	gateChangeBudget     = "ChangeBudget"
+	gateSLA              = "SLA"
+)
+
+// blockedMessages explains container gates that withhold a recommendation without
+// leaving a reason on it
+var blockedMessages = map[string]string{
+	gateNodeFit: "raised requests do not fit any eligible node",
+	gateQuota:   "change does not fit the namespace ResourceQuota or LimitRange",
+	gateQoS:     "setting requests would change the pod QoS class",
+}
+
+// actionVerbs describe container actions in a workload's reason
+var actionVerbs = map[optimizerv1alpha1.DecisionAction]string{
+	optimizerv1alpha1.DecisionFailed:  "failed",
+	optimizerv1alpha1.DecisionApplied: "applied",
+	optimizerv1alpha1.DecisionDryRun:  "would be applied",
+	optimizerv1alpha1.DecisionSkipped: "skipped",
+}
+
+// newWorkloadDecision starts the decision trace of a workload
+func newWorkloadDecision(workloadRec *recommendation.WorkloadRecommendation) *optimizerv1alpha1.WorkloadDecision {
+	return &optimizerv1alpha1.WorkloadDecision{
+		Namespace: workloadRec.Namespace,
+		Kind:      workloadRec.WorkloadKind,
+		Name:      workloadRec.WorkloadName,
+		DecidedAt: metav1.NewTime(time.Now()),
+	}
+}
+
+// addGate appends a gate verdict to a trace
+func addGate(gates *[]optimizerv1alpha1.GateVerdict, gate string, result optimizerv1alpha1.GateResult, message string) {
+	*gates = append(*gates, optimizerv1alpha1.GateVerdict{Gate: gate, Result: result, Message: message})
+}
+
+// skipWorkload marks the whole workload as withheld by a workload-level gate
+func skipWorkload(decision *optimizerv1alpha1.WorkloadDecision, gate, message string) {
+	addGate(&decision.Gates, gate, optimizerv1alpha1.GateBlocked, message)
+	decision.Action = optimizerv1alpha1.DecisionSkipped
+	decision.Reason = fmt.Sprintf("blocked by %s gate", gate)
+}
+
+// newContainerDecision records the inputs of a container recommendation as generated
+// by the engine, before any safety gate ran
+func newContainerDecision(
+	workload *optimizerv1alpha1.WorkloadDecision,
+	containerRec *recommendation.ContainerRecommendation,
+	thresholds *optimizerv1alpha1.ResourceThresholds,
+) optimizerv1alpha1.ContainerDecision {
+	decision := optimizerv1alpha1.ContainerDecision{
+		ContainerName:    containerRec.ContainerName,
+		RecommendationID: recommendationID(workload, containerRec.ContainerName),
+		SampleCount:      int32(containerRec.SampleCount),
+		CPUPercentile:    int32(containerRec.CPUPercentile),
+		CPUUsage:         formatCPU(containerRec.CPUUsage),
+		MemoryPercentile: int32(containerRec.MemoryPercentile),
+		MemoryUsage:      formatMemory(containerRec.MemoryUsage),
+		SafetyMargin:     containerRec.SafetyMargin,
+		Confidence:       containerRec.Confidence,
+		Thresholds:       describeThresholds(thresholds),
+		CurrentCPU:       formatCPU(containerRec.CurrentCPU),
+		CurrentMemory:    formatMemory(containerRec.CurrentMemory),
+	}
+	if containerRec.OOMBoostApplied > 1.0 {
+		decision.OOMBoost = containerRec.OOMBoostApplied
+	}
+	if containerRec.StartupCPU > 0 {
+		decision.StartupCPU = formatCPU(containerRec.StartupCPU)
+	}
+	return decision
+}
+
+// recommendationID derives a short, stable ID for a container's recommendation from the
+// workload, the container and the decision time
+func recommendationID(workload *optimizerv1alpha1.WorkloadDecision, containerName string) string {
+	key := fmt.Sprintf("%s/%s/%s/%s@%s", workload.Namespace, workload.Kind, workload.Name, containerName,
+		workload.DecidedAt.UTC().Format(time.RFC3339Nano))
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// traceGate records a container gate's verdict by comparing the recommendation before
+// and after the gate ran. Reasons the gate added become the verdict's message.
+func traceGate(
+	decision *optimizerv1alpha1.ContainerDecision,
+	gate string,
+	before recommendation.ContainerRecommendation,
+	after *recommendation.ContainerRecommendation,
+	passed bool,
+) {
+	var message string
+	if len(after.Reasons) > len(before.Reasons) {
+		message = strings.Join(after.Reasons[len(before.Reasons):], "; ")
+	}
+
+	result := optimizerv1alpha1.GatePassed
+	switch {
+	case !passed:
+		result = optimizerv1alpha1.GateBlocked
+		if message == "" {
+			message = blockedMessages[gate]
+		}
+		decision.Action = optimizerv1alpha1.DecisionSkipped
+		decision.Reason = fmt.Sprintf("blocked by %s gate", gate)
+	case after.RecommendedCPU != before.RecommendedCPU || after.RecommendedMemory != before.RecommendedMemory ||
+		after.RecommendedCPULimit != before.RecommendedCPULimit || after.RecommendedMemoryLimit != before.RecommendedMemoryLimit:
+		result = optimizerv1alpha1.GateAdjusted
+	case message != "":
+		result = optimizerv1alpha1.GateWarned
+	}
+	addGate(&decision.Gates, gate, result, message)
+}
+
+// warnGate adds a warning to the last gate verdict of a container, if there is one
+func warnGate(decision *optimizerv1alpha1.ContainerDecision, warning string) {
+	if warning == "" || len(decision.Gates) == 0 {
+		return
+	}
+	last := &decision.Gates[len(decision.Gates)-1]
+	if last.Result == optimizerv1alpha1.GatePassed {
+		last.Result = optimizerv1alpha1.GateWarned
+	}
+	if last.Message != "" {
+		last.Message += "; "
+	}
+	last.Message += warning
+}
+
+// finishContainerDecision records the final values and action of a container
+func finishContainerDecision(
+	decision *optimizerv1alpha1.ContainerDecision,
+	containerRec *recommendation.ContainerRecommendation,
+	action optimizerv1alpha1.DecisionAction,
+	reason string,
+) {
+	decision.RecommendedCPU = formatCPU(containerRec.RecommendedCPU)
+	decision.RecommendedMemory = formatMemory(containerRec.RecommendedMemory)
+	decision.Adjustments = append([]string(nil), containerRec.Reasons...)
+	if decision.Action == "" {
+		decision.Action = action
+		decision.Reason = reason
+	}
+}
+
+// finishWorkloadDecision derives the workload's action from its containers. The most
+// significant container action wins: failures, then applied, dry-run and skipped changes.
+func finishWorkloadDecision(decision *optimizerv1alpha1.WorkloadDecision) {
+	if decision.Action != "" {
+		return
+	}
+
+	counts := make(map[optimizerv1alpha1.DecisionAction]int)
+	for _, c := range decision.Containers {
+		counts[c.Action]++
+	}
+	for _, action := range []optimizerv1alpha1.DecisionAction{
+		optimizerv1alpha1.DecisionFailed,
+		optimizerv1alpha1.DecisionApplied,
+		optimizerv1alpha1.DecisionDryRun,
+		optimizerv1alpha1.DecisionSkipped,
+	} {
+		if counts[action] > 0 {
+			decision.Action = action
+			decision.Reason = fmt.Sprintf("%d of %d containers %s", counts[action], len(decision.Containers),
+				actionVerbs[action])
+			return
+		}
+	}
+	decision.Action = optimizerv1alpha1.DecisionUnchanged
+	decision.Reason = "recommendations match the current resources"
+}
+
+// updateDecisions replaces the decision traces in status with those of this pass. Beyond
+// maxDecisions, workloads left unchanged are dropped before those something happened to,
+// and the number of dropped traces is recorded so explain can tell a missing trace apart.
+func (r *Reconciler) updateDecisions(config *optimizerv1alpha1.OptimizerConfig, decisions []optimizerv1alpha1.WorkloadDecision) {
+	config.Status.DecisionsOmitted = 0
+	if len(decisions) <= maxDecisions {
+		config.Status.Decisions = decisions
+		return
+	}
+
+	changed := 0
+	for _, decision := range decisions {
+		if decision.Action != optimizerv1alpha1.DecisionUnchanged {
+			changed++
+		}
+	}
+	unchanged := max(maxDecisions-changed, 0)
+
+	kept := make([]optimizerv1alpha1.WorkloadDecision, 0, maxDecisions)
+	for _, decision := range decisions {
+		if len(kept) == maxDecisions {
+			break
+		}
+		if decision.Action == optimizerv1alpha1.DecisionUnchanged {
+			if unchanged == 0 {
+				continue
+			}
+			unchanged--
+		}
+		kept = append(kept, decision)
+	}
+	config.Status.Decisions = kept
+	config.Status.DecisionsOmitted = int32(len(decisions) - len(kept))
+	klog.V(3).Infof("Decision traces of %d workloads omitted from status of %s/%s (at most %d kept)",
+		config.Status.DecisionsOmitted, config.Namespace, config.Name, maxDecisions)
+}
+
+// describeThresholds summarizes min/max thresholds, e.g. "cpu 100m-4, memory 128Mi-8Gi"
+func describeThresholds(thresholds *optimizerv1alpha1.ResourceThresholds) string {
+	if thresholds == nil {
+		return ""
+	}
+	var parts []string
+	for _, t := range []struct {
+		name  string
+		limit *optimizerv1alpha1.ResourceLimit
+	}{
+		{"cpu", thresholds.CPU},
+		{"memory", thresholds.Memory},
+		{"ephemeral-storage", thresholds.EphemeralStorage},
+	} {
+		if t.limit == nil || (t.limit.Min == "" && t.limit.Max == "") {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %s-%s", t.name, t.limit.Min, t.limit.Max))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/sla"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// healthWindow is how far back container restarts and OOM kills count against health
+const healthWindow = 15 * time.Minute
+
+var (
+	// readinessSLA expects at least 90% of the pods to be ready
+	readinessSLA = sla.SLADefinition{
+		Name:        "pod-readiness",
+		Type:        sla.SLATypeAvailability,
+		Target:      100,
+		Threshold:   10,
+		Window:      healthWindow,
+		Description: "At least 90% of pods should be ready",
+	}
+
+	// restartSLA expects at most 10% of the pods to have restarted within the window
+	restartSLA = sla.SLADefinition{
+		Name:        "container-restarts",
+		Type:        sla.SLATypeErrorRate,
+		Target:      0,
+		Threshold:   10,
+		Window:      healthWindow,
+		Description: "At most 10% of pods should have restarted containers",
+	}
+
+	// oomSLA expects at most 10% of the pods to have been OOM killed within the window
+	oomSLA = sla.SLADefinition{
+		Name:        "oom-kills",
+		Type:        sla.SLATypeErrorRate,
+		Target:      0,
+		Threshold:   10,
+		Window:      healthWindow,
+		Description: "At most 10% of pods should have OOM-killed containers",
+	}
+)
+
+// checkSystemHealth checks the pods of the config's target namespaces for readiness,
+// recent restarts and OOM kills against the health SLAs
+func (r *Reconciler) checkSystemHealth(ctx context.Context, config *optimizerv1alpha1.OptimizerConfig) (*sla.HealthCheckResult, error) {
+	var pods []corev1.Pod
+	for _, namespace := range config.Spec.TargetNamespaces {
+		list, err := r.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods in %s: %w", namespace, err)
+		}
+		pods = append(pods, list.Items...)
+	}
+	return r.slaHealthChecker.CheckSignals(podHealthSignals(pods, time.Now()))
+}
+
+// checkWorkloadHealth checks the pods of one workload against the health SLAs
+func (r *Reconciler) checkWorkloadHealth(ctx context.Context, ref workloadRef) (*sla.HealthCheckResult, error) {
+	selector, err := r.workloadSelector(ctx, ref.Namespace, ref.Kind, ref.Name)
+	if err != nil {
+		return nil, err
+	}
+	list, err := r.kubeClient.CoreV1().Pods(ref.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	return r.slaHealthChecker.CheckSignals(podHealthSignals(list.Items, time.Now()))
+}
+
+// podHealthSignals turns running pods into health signals: one readiness sample per
+// pod, and the share of pods with containers that restarted or were OOM killed within
+// healthWindow. Finished and terminating pods are left out.
+func podHealthSignals(pods []corev1.Pod, now time.Time) []sla.Signal {
+	readiness := sla.Signal{SLA: readinessSLA}
+	var counted, restarted, oomKilled int
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		counted++
+
+		ready := 0.0
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				ready = 1
+			}
+		}
+		readiness.Metrics = append(readiness.Metrics, sla.Metric{Timestamp: now, Value: ready})
+
+		var restart, oom bool
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.LastTerminationState.Terminated
+			if terminated == nil || now.Sub(terminated.FinishedAt.Time) > healthWindow {
+				continue
+			}
+			if terminated.Reason == "OOMKilled" {
+				oom = true
+			} else {
+				restart = true
+			}
+		}
+		if restart {
+			restarted++
+		}
+		if oom {
+			oomKilled++
+		}
+	}
+	if counted == 0 {
+		return nil
+	}
+
+	share := func(n int) []sla.Metric {
+		return []sla.Metric{{Timestamp: now, Value: float64(n) * 100 / float64(counted)}}
+	}
+	return []sla.Signal{
+		readiness,
+		{SLA: restartSLA, Metrics: share(restarted)},
+		{SLA: oomSLA, Metrics: share(oomKilled)},
+	}
+}
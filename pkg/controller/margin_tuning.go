@@ -0,0 +1,149 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/profile"
+	"intelligent-cluster-optimizer/pkg/tuning"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// marginTuningEnabled returns true if safety margins are tuned from post-apply outcomes
+func marginTuningEnabled(config *optimizerv1alpha1.OptimizerConfig) bool {
+	return config.Spec.MarginTuning != nil && config.Spec.MarginTuning.Enabled
+}
+
+// marginPolicy builds the tuning policy for the config. Bounds come from the resolved
+// profile, which already includes the MarginTuning overrides.
+func marginPolicy(config *optimizerv1alpha1.OptimizerConfig, resolved *profile.ResolvedSettings) tuning.Policy {
+	minMargin, maxMargin := 1.1, 1.6
+	if resolved != nil && resolved.MinSafetyMargin > 0 && resolved.MaxSafetyMargin > 0 {
+		minMargin, maxMargin = resolved.MinSafetyMargin, resolved.MaxSafetyMargin
+	}
+	return tuning.NewPolicy(config.Spec.MarginTuning, minMargin, maxMargin)
+}
+
+// observeMarginOutcomes measures what happened to each workload under observation
+// since its window started and widens or tightens its learned margin accordingly.
+// Learned margins of deleted workloads are dropped.
+func (r *Reconciler) observeMarginOutcomes(ctx context.Context, config *optimizerv1alpha1.OptimizerConfig, policy tuning.Policy, mode string) {
+	now := time.Now()
+	for _, entry := range r.marginTuner.Observing(config) {
+		outcome, err := r.measureOutcome(ctx, entry.Namespace, entry.WorkloadKind, entry.WorkloadName, entry.ObservingSince.Time)
+		if apierrors.IsNotFound(err) {
+			klog.V(3).Infof("[%s] Dropping learned margin of deleted workload %s/%s", mode, entry.Namespace, entry.WorkloadName)
+			r.marginTuner.Forget(config, entry.Namespace, entry.WorkloadName)
+			continue
+		}
+		if err != nil {
+			klog.Warningf("[%s] Failed to measure outcome for %s/%s: %v", mode, entry.Namespace, entry.WorkloadName, err)
+			continue
+		}
+		adjustment := r.marginTuner.Observe(config, entry.Namespace, entry.WorkloadName, outcome, policy, now)
+		r.recordMarginAdjustment(config, adjustment, mode)
+	}
+}
+
+// workloadSelector returns the pod selector of a workload. Entries recorded before the
+// kind was tracked have no kind; each supported kind is tried for them.
+func (r *Reconciler) workloadSelector(ctx context.Context, namespace, kind, name string) (labels.Selector, error) {
+	kinds := []string{kind}
+	if kind == "" {
+		kinds = []string{"Deployment", "StatefulSet", "DaemonSet"}
+	}
+
+	var err error
+	for _, kind := range kinds {
+		var selector *metav1.LabelSelector
+		switch kind {
+		case "Deployment":
+			var deploy *appsv1.Deployment
+			if deploy, err = r.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+				selector = deploy.Spec.Selector
+			}
+		case "StatefulSet":
+			var sts *appsv1.StatefulSet
+			if sts, err = r.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+				selector = sts.Spec.Selector
+			}
+		case "DaemonSet":
+			var ds *appsv1.DaemonSet
+			if ds, err = r.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+				selector = ds.Spec.Selector
+			}
+		default:
+			return nil, fmt.Errorf("unsupported workload kind: %s", kind)
+		}
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return metav1.LabelSelectorAsSelector(selector)
+	}
+	return nil, err
+}
+
+// measureOutcome counts OOM kills and restarts of the workload's containers that ended
+// after since, and checks the collected metrics for CPU throttling
+func (r *Reconciler) measureOutcome(ctx context.Context, namespace, kind, workloadName string, since time.Time) (tuning.Outcome, error) {
+	var outcome tuning.Outcome
+
+	selector, err := r.workloadSelector(ctx, namespace, kind, workloadName)
+	if err != nil {
+		return outcome, err
+	}
+	pods, err := r.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return outcome, fmt.Errorf("failed to list pods: %w", err)
+	}
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			terminated := status.LastTerminationState.Terminated
+			if terminated == nil || !terminated.FinishedAt.Time.After(since) {
+				continue
+			}
+			if terminated.Reason == "OOMKilled" {
+				outcome.OOMKills++
+			} else {
+				outcome.Restarts++
+			}
+		}
+	}
+
+	if elapsed := time.Since(since); elapsed > 0 {
+		metrics := r.metricsStorage.GetMetricsByWorkload(namespace, workloadName, elapsed)
+		outcome.Throttled = tuning.IsThrottled(metrics, "")
+	}
+	return outcome, nil
+}
+
+// recordMarginAdjustment logs and reports a learned margin change
+func (r *Reconciler) recordMarginAdjustment(config *optimizerv1alpha1.OptimizerConfig, adjustment *tuning.Adjustment, mode string) {
+	if adjustment == nil {
+		return
+	}
+
+	if adjustment.Widened() {
+		message := fmt.Sprintf("Safety margin for %s/%s widened from %.2f to %.2f after %s",
+			adjustment.Namespace, adjustment.WorkloadName, adjustment.OldMargin, adjustment.NewMargin, adjustment.Outcome)
+		klog.V(3).Infof("[%s] %s", mode, message)
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonSafetyMarginWidened, message)
+		return
+	}
+
+	message := fmt.Sprintf("Safety margin for %s/%s tightened from %.2f to %.2f after consistently good outcomes",
+		adjustment.Namespace, adjustment.WorkloadName, adjustment.OldMargin, adjustment.NewMargin)
+	klog.V(3).Infof("[%s] %s", mode, message)
+	r.optimizerEvents.RecordNormalEvent(config, events.ReasonSafetyMarginTightened, message)
+}
@@ -0,0 +1,150 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/leakdetector"
+	"intelligent-cluster-optimizer/pkg/models"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// leakSeverityRank orders leak severities so the worst finding per container wins
+var leakSeverityRank = map[leakdetector.LeakSeverity]int{
+	leakdetector.SeverityNone:     0,
+	leakdetector.SeverityLow:      1,
+	leakdetector.SeverityMedium:   2,
+	leakdetector.SeverityHigh:     3,
+	leakdetector.SeverityCritical: 4,
+}
+
+// analyzeMemoryLeak runs the leak detector on each pod's memory series for the
+// container and returns the most severe finding. Series are analyzed per pod so
+// restarts and rollouts do not look like memory resets.
+func (r *Reconciler) analyzeMemoryLeak(metrics []models.PodMetric, containerRec *recommendation.ContainerRecommendation) *leakdetector.LeakAnalysis {
+	series := make(map[string][]leakdetector.MemorySample)
+	limits := make(map[string]int64)
+	for _, m := range metrics {
+		for _, c := range m.Containers {
+			if c.ContainerName != containerRec.ContainerName {
+				continue
+			}
+			series[m.PodName] = append(series[m.PodName], leakdetector.MemorySample{
+				Timestamp: m.Timestamp,
+				Bytes:     c.UsageMemory,
+			})
+			if c.LimitMemory > 0 {
+				limits[m.PodName] = c.LimitMemory
+			}
+		}
+	}
+
+	var worst *leakdetector.LeakAnalysis
+	for podName, samples := range series {
+		// Without a limit, project against the current request
+		limit := limits[podName]
+		if limit == 0 {
+			limit = containerRec.CurrentMemory
+		}
+
+		analysis := r.leakDetector.AnalyzeWithLimit(samples, limit)
+		if !analysis.IsLeak {
+			continue
+		}
+		if worst == nil || leakSeverityRank[analysis.Severity] > leakSeverityRank[worst.Severity] ||
+			(analysis.Severity == worst.Severity && analysis.Statistics.Slope > worst.Statistics.Slope) {
+			worst = analysis
+		}
+	}
+	return worst
+}
+
+// guardMemoryLeak withholds memory reductions for containers with a leak severe enough
+// to block scaling (medium severity or worse). It records the finding in status, events and metrics and returns it (nil if none).
+func (r *Reconciler) guardMemoryLeak(
+	config *optimizerv1alpha1.OptimizerConfig,
+	workloadRec *recommendation.WorkloadRecommendation,
+	containerRec *recommendation.ContainerRecommendation,
+	metrics []models.PodMetric,
+	mode string,
+) *optimizerv1alpha1.MemoryLeakStatus {
+	analysis := r.analyzeMemoryLeak(metrics, containerRec)
+	if analysis == nil {
+		r.clearAdvice(config, events.ReasonMemoryLeakSuspected, workloadRec, containerRec.ContainerName)
+		if r.metricsExporter != nil {
+			r.metricsExporter.RecordMemoryLeak(workloadRec.WorkloadName, workloadRec.Namespace,
+				containerRec.ContainerName, "", false, 0, 0)
+		}
+		return nil
+	}
+
+	stats := analysis.Statistics
+	leak := &optimizerv1alpha1.MemoryLeakStatus{
+		Namespace:          workloadRec.Namespace,
+		WorkloadName:       workloadRec.WorkloadName,
+		ContainerName:      containerRec.ContainerName,
+		Severity:           string(analysis.Severity),
+		GrowthBytesPerHour: int64(stats.Slope),
+		DetectedAt:         metav1.NewTime(time.Now()),
+	}
+	if stats.TimeToOOM > 0 {
+		leak.TimeToLimit = stats.TimeToOOM.Round(time.Minute).String()
+	}
+
+	if r.metricsExporter != nil {
+		r.metricsExporter.RecordMemoryLeak(workloadRec.WorkloadName, workloadRec.Namespace,
+			containerRec.ContainerName, leak.Severity, true, stats.Slope, stats.TimeToOOM.Seconds())
+	}
+
+	details := fmt.Sprintf("%s severity, +%s/hour, R²=%.2f", analysis.Severity, formatMemory(int64(stats.Slope)), stats.RSquared)
+	if leak.TimeToLimit != "" {
+		details += ", time to limit: " + leak.TimeToLimit
+	}
+	message := fmt.Sprintf("Suspected memory leak in %s/%s/%s (%s)",
+		workloadRec.Namespace, workloadRec.WorkloadName, containerRec.ContainerName, details)
+
+	// Only leaks severe enough to block scaling hold memory; slow leaks are reported only
+	prevent, _ := analysis.ShouldPreventScaling()
+	blocked := prevent && containerRec.RecommendedMemory < containerRec.CurrentMemory
+	if blocked {
+		klog.V(3).Infof("[%s] %s - holding memory at %s instead of reducing to %s",
+			mode, message, formatMemory(containerRec.CurrentMemory), formatMemory(containerRec.RecommendedMemory))
+		containerRec.RecommendedMemory = containerRec.CurrentMemory
+		containerRec.Reasons = append(containerRec.Reasons, "memory reduction blocked: suspected memory leak")
+		message += "; memory reduction blocked"
+		if r.metricsExporter != nil {
+			r.metricsExporter.RecordMemoryReductionBlocked(workloadRec.WorkloadName, workloadRec.Namespace, containerRec.ContainerName)
+		}
+	} else {
+		klog.V(3).Infof("[%s] %s", mode, message)
+	}
+
+	// The figures drift on every pass; report again only when severity or blocking changes
+	state := fmt.Sprintf("%s,blocked=%t", analysis.Severity, blocked)
+	if r.adviceChanged(config, events.ReasonMemoryLeakSuspected, workloadRec, containerRec.ContainerName, state) {
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonMemoryLeakSuspected, message)
+	}
+	return leak
+}
+
+// updateMemoryLeakStatus records the leaks found in this pass in status and conditions
+func (r *Reconciler) updateMemoryLeakStatus(config *optimizerv1alpha1.OptimizerConfig, leaks []optimizerv1alpha1.MemoryLeakStatus) {
+	config.Status.MemoryLeaks = leaks
+
+	status := optimizerv1alpha1.ConditionFalse
+	reason := "NoLeakDetected"
+	message := "No memory leaks suspected"
+	if len(leaks) > 0 {
+		status = optimizerv1alpha1.ConditionTrue
+		reason = "LeakDetected"
+		message = fmt.Sprintf("%d container(s) with suspected memory leaks; reductions withheld for medium severity or worse", len(leaks))
+	}
+	if err := r.updateCondition(config, optimizerv1alpha1.ConditionTypeMemoryLeakSuspected, status, reason, message); err != nil {
+		klog.Warningf("Failed to update condition: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+	"intelligent-cluster-optimizer/pkg/safety"
+
+	"k8s.io/klog/v2"
+)
+
+// nodeFitPolicy returns the configured node fit policy, or "" when the check is disabled
+func nodeFitPolicy(config *optimizerv1alpha1.OptimizerConfig) optimizerv1alpha1.NodeFitPolicy {
+	awareness := config.Spec.NodeFitAwareness
+	if awareness == nil || !awareness.Enabled {
+		return ""
+	}
+	if awareness.Policy == "" {
+		return optimizerv1alpha1.NodeFitPolicyClamp
+	}
+	return awareness.Policy
+}
+
+// checkNodeFit simulates scheduling of raised requests and clamps them to the largest
+// size an eligible node can fit. It returns false when the container must be skipped,
+// either because the policy is Block or because no node can run the pod at all.
+func (r *Reconciler) checkNodeFit(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	workloadRec *recommendation.WorkloadRecommendation,
+	containerRec *recommendation.ContainerRecommendation,
+	mode string,
+) bool {
+	policy := nodeFitPolicy(config)
+	raiseCPU := containerRec.RecommendedCPU > containerRec.CurrentCPU
+	raiseMemory := containerRec.RecommendedMemory > containerRec.CurrentMemory
+	if policy == "" || (!raiseCPU && !raiseMemory) {
+		return true
+	}
+
+	result, err := r.nodeFitChecker.CheckFit(ctx, safety.NodeFitRequest{
+		Namespace:     workloadRec.Namespace,
+		Kind:          workloadRec.WorkloadKind,
+		Name:          workloadRec.WorkloadName,
+		ContainerName: containerRec.ContainerName,
+		CPU:           containerRec.RecommendedCPU,
+		Memory:        containerRec.RecommendedMemory,
+	})
+	if err != nil {
+		klog.Warningf("Failed to check node fit for %s/%s/%s: %v",
+			workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, err)
+		return true
+	}
+	if result.Fits {
+		return true
+	}
+
+	target := fmt.Sprintf("%s/%s/%s", workloadRec.Namespace, workloadRec.WorkloadName, containerRec.ContainerName)
+	if policy == optimizerv1alpha1.NodeFitPolicyBlock || result.EligibleNodes == 0 {
+		klog.V(3).Infof("[%s] Skipping %s: raised requests would leave pods Pending - %s", mode, target, result.Message)
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonNodeFitBlocked,
+			fmt.Sprintf("Blocked %s: CPU %s, memory %s would leave pods Pending (%s)", target,
+				formatCPU(containerRec.RecommendedCPU), formatMemory(containerRec.RecommendedMemory), result.Message))
+		return false
+	}
+
+	// Clamp only the raised resources, and never below what the container has today
+	cpu, memory := containerRec.RecommendedCPU, containerRec.RecommendedMemory
+	if raiseCPU {
+		cpu = max(containerRec.CurrentCPU, min(cpu, result.CPU))
+	}
+	if raiseMemory {
+		memory = max(containerRec.CurrentMemory, min(memory, result.Memory))
+	}
+
+	message := fmt.Sprintf("Clamped %s to fit node allocatable: CPU %s -> %s, memory %s -> %s (fits %d of %d eligible nodes)",
+		target, formatCPU(containerRec.RecommendedCPU), formatCPU(cpu),
+		formatMemory(containerRec.RecommendedMemory), formatMemory(memory),
+		result.FeasibleNodes, result.EligibleNodes)
+	klog.V(3).Infof("[%s] %s", mode, message)
+	r.optimizerEvents.RecordWarningEvent(config, events.ReasonNodeFitClamped, message)
+
+	containerRec.RecommendedCPU = cpu
+	containerRec.RecommendedMemory = memory
+	containerRec.Reasons = append(containerRec.Reasons, "requests clamped to fit the largest eligible node")
+	return true
+}
+
+// fitMemoryRequest lowers a raised memory request to what an eligible node can fit,
+// never below the current request
+func (r *Reconciler) fitMemoryRequest(ctx context.Context, config *optimizerv1alpha1.OptimizerConfig, req safety.NodeFitRequest, current int64) int64 {
+	policy := nodeFitPolicy(config)
+	if policy == "" || req.Memory <= current {
+		return req.Memory
+	}
+
+	result, err := r.nodeFitChecker.CheckFit(ctx, req)
+	if err != nil {
+		klog.Warningf("Failed to check node fit for %s/%s/%s: %v", req.Namespace, req.Kind, req.Name, err)
+		return req.Memory
+	}
+	if result.Fits {
+		return req.Memory
+	}
+
+	klog.V(3).Infof("Memory request for %s/%s/%s does not fit any eligible node: %s",
+		req.Namespace, req.Name, req.ContainerName, result.Message)
+	if policy == optimizerv1alpha1.NodeFitPolicyBlock || result.EligibleNodes == 0 {
+		return current
+	}
+	return max(current, min(req.Memory, result.Memory))
+}
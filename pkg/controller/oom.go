@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultOOMScanInterval is how often target namespaces are scanned for OOMKilled containers
+	DefaultOOMScanInterval = 2 * time.Minute
+
+	// DefaultOOMHistoryRetention is how long OOM history keeps influencing recommendations
+	DefaultOOMHistoryRetention = 7 * 24 * time.Hour
+)
+
+// SetOOMScanInterval overrides how often namespaces are scanned for OOM kills
+func (r *Reconciler) SetOOMScanInterval(interval time.Duration) {
+	r.oomScanInterval = interval
+}
+
+// scanForOOMs refreshes OOM history for each target namespace that has not been
+// scanned within the scan interval and reports newly observed OOM kills
+func (r *Reconciler) scanForOOMs(ctx context.Context, config *optimizerv1alpha1.OptimizerConfig, mode string) {
+	now := time.Now()
+
+	r.oomScanMu.Lock()
+	var due []string
+	for _, namespace := range config.Spec.TargetNamespaces {
+		if last, ok := r.lastOOMScan[namespace]; ok && now.Sub(last) < r.oomScanInterval {
+			continue
+		}
+		r.lastOOMScan[namespace] = now
+		due = append(due, namespace)
+	}
+	r.oomScanMu.Unlock()
+
+	if len(due) == 0 {
+		return
+	}
+
+	if removed := r.oomDetector.ClearOOMHistory(DefaultOOMHistoryRetention); removed > 0 {
+		klog.V(4).Infof("[%s] Cleared OOM history for %d workloads", mode, removed)
+	}
+
+	for _, namespace := range due {
+		results, err := r.oomDetector.CheckNamespaceForOOMs(ctx, namespace)
+		if err != nil {
+			klog.Warningf("[%s] Failed to scan namespace %s for OOM kills: %v", mode, namespace, err)
+			continue
+		}
+
+		for _, result := range results {
+			key := result.Namespace + "/" + result.WorkloadName
+
+			r.oomScanMu.Lock()
+			seen := r.lastSeenOOM[key]
+			isNew := result.LastOOMTime.After(seen)
+			if isNew {
+				r.lastSeenOOM[key] = result.LastOOMTime
+			}
+			r.oomScanMu.Unlock()
+
+			if !isNew {
+				continue
+			}
+
+			klog.V(3).Infof("[%s] OOM kills detected for %s: count=%d, priority=%s - %s",
+				mode, key, result.TotalOOMCount, result.Priority, result.RecommendedAction)
+			r.optimizerEvents.RecordWarningEvent(config, events.ReasonOOMDetected,
+				fmt.Sprintf("OOM kills in %s (count=%d, priority=%s): memory will be boosted and not reduced",
+					key, result.TotalOOMCount, result.Priority))
+		}
+	}
+}
@@ -0,0 +1,653 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/applier"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+	"intelligent-cluster-optimizer/pkg/safety"
+	"intelligent-cluster-optimizer/pkg/sla"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// DefaultOOMFastPathMinRestarts is how many restarts an OOMKilled container needs before it is bumped
+	DefaultOOMFastPathMinRestarts = 2
+
+	// DefaultOOMFastPathMinInterval is the minimum time between two bumps of the same container
+	DefaultOOMFastPathMinInterval = 15 * time.Minute
+)
+
+// Results of an OOM fast-path decision, used as the metric label
+const (
+	oomFastPathApplied       = "applied"
+	oomFastPathDryRun        = "dry_run"
+	oomFastPathRateLimited   = "rate_limited"
+	oomFastPathOutsideWindow = "outside_window"
+	oomFastPathAtLimit       = "at_limit"
+	oomFastPathQuarantined   = "quarantined"
+	oomFastPathCircuitOpen   = "circuit_open"
+	oomFastPathInFlight      = "rollout_in_flight"
+	oomFastPathQuotaBlocked  = "quota_blocked"
+	oomFastPathDeferred      = "deferred"
+	oomFastPathFailed        = "failed"
+)
+
+// oomKill identifies an OOMKilled container queued for the fast path
+type oomKill struct {
+	podKey        string
+	containerName string
+}
+
+// HandleOOMKill bumps the memory request and limit of an OOMKilled container by the
+// OOM boost factor for its restart count, capped by ResourceThresholds. It runs from
+// pod events rather than the reconcile loop, so it has its own rate limit and only
+// bypasses maintenance windows when the config opts in. It honours the same quarantine,
+// circuit breakers, quotas and change budget as the reconcile loop, and records a live
+// bump as a rollout in config's status so it is verified and rolled back like any other
+// change; the caller persists the status.
+func (r *Reconciler) HandleOOMKill(ctx context.Context, config *optimizerv1alpha1.OptimizerConfig, pod *corev1.Pod, status corev1.ContainerStatus) error {
+	fastPath := config.Spec.OOMFastPath
+	if !config.Spec.Enabled || fastPath == nil || !fastPath.Enabled {
+		return nil
+	}
+
+	minRestarts := fastPath.MinRestarts
+	if minRestarts <= 0 {
+		minRestarts = DefaultOOMFastPathMinRestarts
+	}
+	if status.RestartCount < minRestarts {
+		klog.V(4).Infof("OOM kill of %s/%s/%s below fast-path threshold (restarts=%d, min=%d)",
+			pod.Namespace, pod.Name, status.Name, status.RestartCount, minRestarts)
+		return nil
+	}
+
+	kind, name, ok := podWorkload(pod)
+	if !ok {
+		klog.V(4).Infof("Pod %s/%s has no supported owner, skipping OOM fast path", pod.Namespace, pod.Name)
+		return nil
+	}
+
+	mode := "LIVE"
+	if config.Spec.DryRun {
+		mode = "DRY-RUN"
+	}
+	target := fmt.Sprintf("%s/%s/%s", pod.Namespace, name, status.Name)
+	key := pod.Namespace + "/" + kind + "/" + name + "/" + status.Name
+	now := time.Now()
+
+	// SAFETY CHECK: Leave workloads alone while they are quarantined after a rollback
+	if q := quarantinedUntil(config, pod.Namespace, kind, name, now); q != nil {
+		klog.V(3).Infof("[%s] OOM fast path for %s skipped: quarantined until %s", mode, target, q.Until.Format(time.RFC3339))
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonOOMFastPathSkipped,
+			fmt.Sprintf("%s OOMKilled (restarts=%d) while quarantined after a rollback (%s) until %s",
+				target, status.RestartCount, q.Reason, q.Until.Format(time.RFC3339)))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathQuarantined)
+		return nil
+	}
+
+	// SAFETY CHECK: Leave workloads alone while their own or their namespace's circuit is open
+	ref := workloadRef{Namespace: pod.Namespace, Kind: kind, Name: name}
+	if allowed, message := r.checkCircuits(config, ref, now, mode); !allowed {
+		klog.V(3).Infof("[%s] OOM fast path for %s skipped: %s", mode, target, message)
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonOOMFastPathSkipped,
+			fmt.Sprintf("%s OOMKilled (restarts=%d) but %s", target, status.RestartCount, message))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathCircuitOpen)
+		return nil
+	}
+
+	interval := DefaultOOMFastPathMinInterval
+	if d, err := time.ParseDuration(fastPath.MinInterval); err == nil && d > 0 {
+		interval = d
+	}
+
+	r.oomFastPathMu.Lock()
+	last, seen := r.lastOOMFastPath[key]
+	limited := seen && now.Sub(last) < interval
+	if !limited {
+		r.lastOOMFastPath[key] = now
+	}
+	r.oomFastPathMu.Unlock()
+
+	if limited {
+		klog.V(3).Infof("[%s] OOM fast path for %s rate limited (last bump %s ago)",
+			mode, target, now.Sub(last).Round(time.Second))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathRateLimited)
+		return nil
+	}
+
+	if !config.Spec.DryRun && len(config.Spec.MaintenanceWindows) > 0 && !fastPath.AllowOutsideMaintenanceWindow &&
+		!r.maintenanceWindowCheck.IsInMaintenanceWindow(config) {
+		r.forgetOOMFastPath(key)
+		klog.V(3).Infof("[%s] OOM fast path for %s skipped outside maintenance window", mode, target)
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonOOMFastPathSkipped,
+			fmt.Sprintf("%s OOMKilled (restarts=%d) outside maintenance window; set oomFastPath.allowOutsideMaintenanceWindow to bump immediately",
+				target, status.RestartCount))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathOutsideWindow)
+		return nil
+	}
+
+	// SAFETY CHECK: Change a workload again only once its last changes are verified
+	if rollout := findRollout(config, ref); rollout != nil {
+		message := fmt.Sprintf("earlier changes still in phase %s: %s", rollout.Phase, rollout.Message)
+		r.forgetOOMFastPath(key)
+		klog.V(3).Infof("[%s] OOM fast path for %s skipped: %s", mode, target, message)
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonOOMFastPathSkipped,
+			fmt.Sprintf("%s OOMKilled (restarts=%d) but %s", target, status.RestartCount, message))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathInFlight)
+		return nil
+	}
+
+	current, err := r.applier.GetCurrentResources(ctx, pod.Namespace, kind, name, status.Name)
+	if err != nil {
+		r.forgetOOMFastPath(key)
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathFailed)
+		return fmt.Errorf("failed to get resources of %s: %v", target, err)
+	}
+
+	boost := safety.CalculateMemoryBoost(status.RestartCount)
+	maxMemory := memoryThresholdMax(config.Spec.ResourceThresholds)
+	request := quantityBytes(current.CurrentMemory)
+	limit := quantityBytes(current.CurrentMemoryLimit)
+	if request == 0 && limit == 0 {
+		klog.V(3).Infof("[%s] OOM fast path for %s skipped: no memory request or limit set", mode, target)
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathAtLimit)
+		return nil
+	}
+
+	newRequest := boostMemory(request, boost, maxMemory)
+	newLimit := boostMemory(limit, boost, maxMemory)
+
+	// Keep the bumped request schedulable; the limit does not affect placement
+	newRequest = r.fitMemoryRequest(ctx, config, safety.NodeFitRequest{
+		Namespace:     pod.Namespace,
+		Kind:          kind,
+		Name:          name,
+		ContainerName: status.Name,
+		CPU:           quantityMillis(current.CurrentCPU),
+		Memory:        newRequest,
+	}, request)
+	if limit > 0 && newLimit < newRequest {
+		newLimit = newRequest
+	}
+	if !config.Spec.AllowQoSClassChange && limit == request {
+		// Keep a Guaranteed container's limit equal to its request
+		newLimit = newRequest
+	}
+
+	// SAFETY CHECK: Keep the bump within the namespace's ResourceQuotas and LimitRanges
+	cpu := quantityMillis(current.CurrentCPU)
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: pod.Namespace, WorkloadKind: kind, WorkloadName: name}
+	containerRec := &recommendation.ContainerRecommendation{
+		ContainerName:          status.Name,
+		CurrentCPU:             cpu,
+		RecommendedCPU:         cpu,
+		CurrentMemory:          request,
+		RecommendedMemory:      newRequest,
+		CurrentMemoryLimit:     limit,
+		RecommendedMemoryLimit: newLimit,
+	}
+	if !r.checkQuota(ctx, config, workloadRec, containerRec, nil, mode) {
+		r.forgetOOMFastPath(key)
+		klog.V(3).Infof("[%s] OOM fast path for %s skipped: bump exceeds namespace quotas", mode, target)
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonOOMFastPathSkipped,
+			fmt.Sprintf("%s OOMKilled (restarts=%d) but the bump would exceed the namespace's ResourceQuota or LimitRange",
+				target, status.RestartCount))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathQuotaBlocked)
+		return nil
+	}
+	newRequest, newLimit = containerRec.RecommendedMemory, containerRec.RecommendedMemoryLimit
+
+	if newRequest <= request && newLimit <= limit {
+		klog.V(3).Infof("[%s] OOM fast path for %s skipped: memory already at threshold max", mode, target)
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonOOMFastPathSkipped,
+			fmt.Sprintf("%s OOMKilled (restarts=%d) but memory is already at the configured maximum", target, status.RestartCount))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathAtLimit)
+		return nil
+	}
+
+	rec := &applier.ResourceRecommendation{
+		Namespace:              pod.Namespace,
+		WorkloadKind:           kind,
+		WorkloadName:           name,
+		ContainerName:          status.Name,
+		CurrentCPU:             current.CurrentCPU,
+		RecommendedCPU:         current.CurrentCPU,
+		CurrentMemory:          current.CurrentMemory,
+		RecommendedMemory:      current.CurrentMemory,
+		CurrentMemoryLimit:     current.CurrentMemoryLimit,
+		RecommendedMemoryLimit: current.CurrentMemoryLimit,
+		PreserveQoS:            !config.Spec.AllowQoSClassChange,
+	}
+	if newRequest > request {
+		rec.RecommendedMemory = formatMemory(newRequest)
+	}
+	if newLimit > limit {
+		rec.RecommendedMemoryLimit = formatMemory(newLimit)
+	}
+
+	klog.Infof("[%s] OOM fast path for %s (restarts=%d, boost=%.2fx): request %s -> %s, limit %s -> %s",
+		mode, target, status.RestartCount, boost, rec.CurrentMemory, rec.RecommendedMemory,
+		rec.CurrentMemoryLimit, rec.RecommendedMemoryLimit)
+
+	// SAFETY CHECK: Stay within the cluster-wide and per-namespace change budget
+	var budget safety.BudgetDecision
+	budgetWorkload := safety.BudgetWorkload{Namespace: pod.Namespace, Kind: kind, Name: name}
+	if !config.Spec.DryRun {
+		rank := budgetRank(0, int(status.RestartCount), r.budgetPriority)
+		budget = r.changeBudget.Acquire(budgetOwner(config), budgetWorkload, rank, now)
+		if !budget.Allowed {
+			r.forgetOOMFastPath(key)
+			klog.V(3).Infof("[%s] OOM fast path for %s deferred: %s", mode, target, budget.Message)
+			r.optimizerEvents.RecordWarningEvent(config, events.ReasonOOMFastPathSkipped,
+				fmt.Sprintf("%s OOMKilled (restarts=%d) but the change budget is exhausted: %s",
+					target, status.RestartCount, budget.Message))
+			if r.metricsExporter != nil {
+				r.metricsExporter.RecordChangeDeferred(config.Name, pod.Namespace, budget.Limit)
+			}
+			r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathDeferred)
+			return nil
+		}
+	}
+
+	// Baseline the workload's health for the rollout verification
+	var baseline *sla.HealthCheckResult
+	if !config.Spec.DryRun {
+		if baseline, err = r.checkWorkloadHealth(ctx, ref); err != nil {
+			klog.Warningf("[%s] Failed to check health of %s/%s: %v", mode, pod.Namespace, name, err)
+		}
+	}
+
+	result, err := r.applier.Apply(ctx, rec, config.Spec.DryRun)
+	if budget.Acquired && (err != nil || !result.Applied) {
+		r.changeBudget.Release(budgetOwner(config), budgetWorkload)
+	}
+	if err != nil {
+		if !config.Spec.DryRun && !errors.Is(err, applier.ErrQoSClassChange) {
+			r.recordChangeFailure(config, ref, err, mode)
+		}
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonScalingFailed,
+			fmt.Sprintf("OOM fast path failed for %s: %v", target, err))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathFailed)
+		return fmt.Errorf("failed to apply OOM fast path to %s: %v", target, err)
+	}
+
+	changes := strings.Join(result.Changes, ", ")
+	if config.Spec.DryRun {
+		r.optimizerEvents.RecordNormalEvent(config, events.ReasonDryRunSimulated,
+			fmt.Sprintf("OOM fast path would bump %s (restarts=%d, boost=%.2fx): %s", target, status.RestartCount, boost, changes))
+		r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathDryRun)
+		return nil
+	}
+	if result.Applied {
+		r.trackRollout(config, rec, result, baseline, now)
+	}
+
+	r.optimizerEvents.RecordNormalEvent(config, events.ReasonOOMFastPathApplied,
+		fmt.Sprintf("Bumped memory of %s after repeated OOM kills (restarts=%d, boost=%.2fx): %s",
+			target, status.RestartCount, boost, changes))
+	r.recordOOMFastPath(name, pod.Namespace, status.Name, oomFastPathApplied)
+	return nil
+}
+
+// forgetOOMFastPath clears the rate limit of a container whose bump did not happen
+func (r *Reconciler) forgetOOMFastPath(key string) {
+	r.oomFastPathMu.Lock()
+	delete(r.lastOOMFastPath, key)
+	r.oomFastPathMu.Unlock()
+}
+
+func (r *Reconciler) recordOOMFastPath(workload, namespace, container, result string) {
+	if r.metricsExporter != nil {
+		r.metricsExporter.RecordOOMFastPath(workload, namespace, container, result)
+	}
+}
+
+// boostMemory multiplies bytes by the boost, rounded up to whole MiB and capped at max (0 = no cap)
+func boostMemory(bytes int64, boost float64, max int64) int64 {
+	if bytes <= 0 {
+		return 0
+	}
+	const mi = 1024 * 1024
+	boosted := int64(math.Ceil(float64(bytes)*boost/mi)) * mi
+	if max > 0 && boosted > max {
+		boosted = max
+	}
+	if boosted < bytes {
+		return bytes
+	}
+	return boosted
+}
+
+// memoryThresholdMax returns the configured maximum memory in bytes, or 0 if none
+func memoryThresholdMax(thresholds *optimizerv1alpha1.ResourceThresholds) int64 {
+	if thresholds == nil || thresholds.Memory == nil {
+		return 0
+	}
+	return quantityBytes(thresholds.Memory.Max)
+}
+
+// quantityBytes parses a memory quantity, returning 0 when it is empty or invalid
+func quantityBytes(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0
+	}
+	return q.Value()
+}
+
+// quantityMillis parses a CPU quantity into millicores, returning 0 when it is empty or invalid
+func quantityMillis(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return 0
+	}
+	return q.MilliValue()
+}
+
+// podWorkload resolves the Deployment, StatefulSet or DaemonSet that controls a pod
+func podWorkload(pod *corev1.Pod) (kind, name string, ok bool) {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		switch owner.Kind {
+		case "StatefulSet", "DaemonSet":
+			return owner.Kind, owner.Name, true
+		case "ReplicaSet":
+			hash := pod.Labels["pod-template-hash"]
+			if hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+				return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// newOOMKills returns the container statuses of newPod that were OOMKilled since oldPod
+func newOOMKills(oldPod, newPod *corev1.Pod) []corev1.ContainerStatus {
+	previous := make(map[string]int32, len(oldPod.Status.ContainerStatuses))
+	for _, status := range oldPod.Status.ContainerStatuses {
+		previous[status.Name] = status.RestartCount
+	}
+
+	var kills []corev1.ContainerStatus
+	for _, status := range newPod.Status.ContainerStatuses {
+		if status.RestartCount <= previous[status.Name] {
+			continue
+		}
+		terminated := status.LastTerminationState.Terminated
+		if terminated != nil && terminated.Reason == "OOMKilled" {
+			kills = append(kills, status)
+		}
+	}
+	return kills
+}
+
+// podWatch is the pod informer of one namespace watched for OOM kills
+type podWatch struct {
+	informer cache.SharedIndexInformer
+	stop     chan struct{}
+}
+
+// fastPathNamespaces returns the namespaces targeted by enabled configs that enable the OOM fast path
+func fastPathNamespaces(configs []interface{}) map[string]bool {
+	namespaces := make(map[string]bool)
+	for _, obj := range configs {
+		config, ok := obj.(*optimizerv1alpha1.OptimizerConfig)
+		if !ok || !config.Spec.Enabled || config.Spec.OOMFastPath == nil || !config.Spec.OOMFastPath.Enabled {
+			continue
+		}
+		for _, namespace := range config.Spec.TargetNamespaces {
+			namespaces[namespace] = true
+		}
+	}
+	return namespaces
+}
+
+// syncPodWatches starts a namespace-scoped pod informer for every namespace the OOM fast
+// path is enabled for and stops the informers of namespaces it no longer covers, so pods
+// are only cached where a config acts on their OOM kills
+func (c *OptimizerController) syncPodWatches() {
+	wanted := fastPathNamespaces(c.informer.GetStore().List())
+
+	c.podWatchesMu.Lock()
+	defer c.podWatchesMu.Unlock()
+
+	for namespace, w := range c.podWatches {
+		if !wanted[namespace] {
+			klog.V(3).Infof("Stopping OOM fast-path pod watch in namespace %s", namespace)
+			close(w.stop)
+			delete(c.podWatches, namespace)
+		}
+	}
+	for namespace := range wanted {
+		if _, ok := c.podWatches[namespace]; ok {
+			continue
+		}
+		informer := informers.NewSharedInformerFactoryWithOptions(c.kubeClient, time.Minute*10,
+			informers.WithNamespace(namespace)).Core().V1().Pods().Informer()
+		// Only status, labels and owners are read; drop managed fields to keep the cache small
+		if err := informer.SetTransform(func(obj interface{}) (interface{}, error) {
+			if pod, ok := obj.(*corev1.Pod); ok {
+				pod.ManagedFields = nil
+			}
+			return obj, nil
+		}); err != nil {
+			klog.Warningf("Failed to set pod transform for namespace %s: %v", namespace, err)
+		}
+		if _, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: c.enqueueOOMKills,
+		}); err != nil {
+			klog.Warningf("Failed to watch pods in namespace %s for OOM kills: %v", namespace, err)
+			continue
+		}
+
+		klog.V(3).Infof("Starting OOM fast-path pod watch in namespace %s", namespace)
+		w := &podWatch{informer: informer, stop: make(chan struct{})}
+		c.podWatches[namespace] = w
+		go informer.Run(w.stop)
+	}
+}
+
+// stopPodWatches stops all pod informers when the controller shuts down
+func (c *OptimizerController) stopPodWatches() {
+	c.podWatchesMu.Lock()
+	defer c.podWatchesMu.Unlock()
+	for namespace, w := range c.podWatches {
+		close(w.stop)
+		delete(c.podWatches, namespace)
+	}
+}
+
+// podWatchInformer returns the pod informer of a namespace, or nil if it is not watched
+func (c *OptimizerController) podWatchInformer(namespace string) cache.SharedIndexInformer {
+	c.podWatchesMu.Lock()
+	defer c.podWatchesMu.Unlock()
+	if w, ok := c.podWatches[namespace]; ok {
+		return w.informer
+	}
+	return nil
+}
+
+// enqueueOOMKills queues containers newly OOMKilled between two versions of a pod
+func (c *OptimizerController) enqueueOOMKills(oldObj, newObj interface{}) {
+	oldPod, ok := oldObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+	newPod, ok := newObj.(*corev1.Pod)
+	if !ok {
+		return
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(newPod)
+	if err != nil {
+		return
+	}
+	for _, status := range newOOMKills(oldPod, newPod) {
+		klog.V(4).Infof("Container %s/%s OOMKilled (restarts=%d), queued for fast path", key, status.Name, status.RestartCount)
+		c.oomQueue.Add(oomKill{podKey: key, containerName: status.Name})
+	}
+}
+
+func (c *OptimizerController) runOOMWorker(ctx context.Context) {
+	for c.processNextOOMKill(ctx) {
+	}
+}
+
+func (c *OptimizerController) processNextOOMKill(ctx context.Context) bool {
+	item, shutdown := c.oomQueue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.oomQueue.Done(item)
+
+	// Failed bumps are not retried; the reconcile loop still covers the workload
+	c.oomQueue.Forget(item)
+	if err := c.syncOOMKill(ctx, item); err != nil {
+		utilruntime.HandleError(err)
+	}
+	return true
+}
+
+// syncOOMKill runs the fast path for each OptimizerConfig targeting the pod's namespace
+func (c *OptimizerController) syncOOMKill(ctx context.Context, item oomKill) error {
+	namespace, _, err := cache.SplitMetaNamespaceKey(item.podKey)
+	if err != nil {
+		return nil
+	}
+	podInformer := c.podWatchInformer(namespace)
+	if podInformer == nil {
+		return nil
+	}
+	obj, exists, err := podInformer.GetIndexer().GetByKey(item.podKey)
+	if err != nil {
+		return fmt.Errorf("error fetching pod %s: %v", item.podKey, err)
+	}
+	if !exists {
+		return nil
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return fmt.Errorf("unexpected object type: %T", obj)
+	}
+
+	var status *corev1.ContainerStatus
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == item.containerName {
+			status = &pod.Status.ContainerStatuses[i]
+			break
+		}
+	}
+	if status == nil {
+		return nil
+	}
+
+	for _, obj := range c.informer.GetStore().List() {
+		config, ok := obj.(*optimizerv1alpha1.OptimizerConfig)
+		if !ok || config.Spec.OOMFastPath == nil || !config.Spec.OOMFastPath.Enabled {
+			continue
+		}
+		if !containsNamespace(config.Spec.TargetNamespaces, pod.Namespace) {
+			continue
+		}
+		updated := config.DeepCopy()
+		err := c.reconciler.HandleOOMKill(ctx, updated, pod, *status)
+		if !equality.Semantic.DeepEqual(config.Status, updated.Status) {
+			if updateErr := c.updateOOMFastPathStatus(ctx, config, updated); updateErr != nil {
+				return fmt.Errorf("failed to update status: %v", updateErr)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateOOMFastPathStatus persists the status changes of the fast path. The reconcile
+// workers update status concurrently, so on a conflict the changes are merged into the
+// latest version of the config and written again.
+func (c *OptimizerController) updateOOMFastPathStatus(ctx context.Context, original, updated *optimizerv1alpha1.OptimizerConfig) error {
+	latest := updated
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		_, err := c.optimizerClient.UpdateStatus(ctx, latest, metav1.UpdateOptions{})
+		if !apierrors.IsConflict(err) {
+			return err
+		}
+		fresh, getErr := c.optimizerClient.Get(ctx, original.Name, metav1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		mergeOOMFastPathStatus(fresh, original, updated)
+		latest = fresh
+		return err
+	})
+}
+
+// mergeOOMFastPathStatus copies what the fast path changed between original and updated
+// - the rollouts it started and the circuits it recorded failures on - into latest
+func mergeOOMFastPathStatus(latest, original, updated *optimizerv1alpha1.OptimizerConfig) {
+	for _, rollout := range updated.Status.Rollouts {
+		ref := workloadRef{Namespace: rollout.Namespace, Kind: rollout.Kind, Name: rollout.Name}
+		if findRollout(original, ref) == nil && findRollout(latest, ref) == nil {
+			latest.Status.Rollouts = append(latest.Status.Rollouts, rollout)
+		}
+	}
+
+	for _, circuit := range updated.Status.CircuitBreakers {
+		if previous := matchCircuit(original.Status.CircuitBreakers, circuit); previous != nil &&
+			equality.Semantic.DeepEqual(*previous, circuit) {
+			continue
+		}
+		if current := matchCircuit(latest.Status.CircuitBreakers, circuit); current != nil {
+			*current = circuit
+		} else {
+			latest.Status.CircuitBreakers = append(latest.Status.CircuitBreakers, circuit)
+		}
+	}
+}
+
+// matchCircuit returns the entry of circuits for the same scope and target as circuit, or nil
+func matchCircuit(circuits []optimizerv1alpha1.CircuitBreakerStatus, circuit optimizerv1alpha1.CircuitBreakerStatus) *optimizerv1alpha1.CircuitBreakerStatus {
+	for i := range circuits {
+		c := &circuits[i]
+		if c.Scope == circuit.Scope && c.Namespace == circuit.Namespace && c.Kind == circuit.Kind && c.Name == circuit.Name {
+			return c
+		}
+	}
+	return nil
+}
+
+func containsNamespace(namespaces []string, namespace string) bool {
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
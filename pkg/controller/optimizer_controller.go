@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
@@ -26,8 +27,13 @@ type OptimizerController struct {
 	optimizerClient *optimizerv1alpha1.OptimizerConfigClient
 	informer        cache.SharedIndexInformer
 	workqueue       workqueue.TypedRateLimitingInterface[string]
+	oomQueue        workqueue.TypedRateLimitingInterface[oomKill]
 	eventRecorder   record.EventRecorder
 	reconciler      *Reconciler
+
+	// Pod watches drive the OOM fast path, one per namespace targeted by a config that enables it
+	podWatchesMu sync.Mutex
+	podWatches   map[string]*podWatch
 }
 
 func NewOptimizerController(
@@ -59,8 +65,12 @@ func NewOptimizerController(
 		workqueue: workqueue.NewTypedRateLimitingQueue(
 			workqueue.DefaultTypedControllerRateLimiter[string](),
 		),
+		oomQueue: workqueue.NewTypedRateLimitingQueue(
+			workqueue.DefaultTypedControllerRateLimiter[oomKill](),
+		),
 		eventRecorder: eventRecorder,
 		reconciler:    reconciler,
+		podWatches:    make(map[string]*podWatch),
 	}
 
 	_, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -96,6 +106,8 @@ func NewOptimizerController(
 func (c *OptimizerController) Run(ctx context.Context, workers int) error {
 	defer utilruntime.HandleCrash()
 	defer c.workqueue.ShutDown()
+	defer c.oomQueue.ShutDown()
+	defer c.stopPodWatches()
 
 	klog.Info("Starting OptimizerConfig controller")
 	klog.Infof("Starting %d workers", workers)
@@ -111,6 +123,7 @@ func (c *OptimizerController) Run(ctx context.Context, workers int) error {
 	for i := 0; i < workers; i++ {
 		go wait.UntilWithContext(ctx, c.runWorker, time.Second)
 	}
+	go wait.UntilWithContext(ctx, c.runOOMWorker, time.Second)
 
 	klog.Info("Workers started")
 	<-ctx.Done()
@@ -148,8 +161,12 @@ func (c *OptimizerController) syncHandler(ctx context.Context, key string) error
 		return fmt.Errorf("error fetching object with key %s: %v", key, err)
 	}
 
+	// Watch pods only in the namespaces a config enables the OOM fast path for
+	defer c.syncPodWatches()
+
 	if !exists {
 		klog.V(3).Infof("OptimizerConfig %s/%s deleted", namespace, name)
+		c.reconciler.ForgetConfig(namespace, name)
 		return nil
 	}
 
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"fmt"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/applier"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// preserveQoS keeps the workload's pod QoS class unless the config allows class changes.
+// Limits of Guaranteed workloads move with the final requests. BestEffort workloads are
+// skipped, since giving them requests would make them Burstable.
+func (r *Reconciler) preserveQoS(
+	config *optimizerv1alpha1.OptimizerConfig,
+	workloadRec *recommendation.WorkloadRecommendation,
+	containerRec *recommendation.ContainerRecommendation,
+	mode string,
+) bool {
+	if config.Spec.AllowQoSClassChange || workloadRec.QoSClass != corev1.PodQOSBestEffort {
+		r.clearAdvice(config, events.ReasonQoSClassChangeBlocked, workloadRec, containerRec.ContainerName)
+	}
+	if config.Spec.AllowQoSClassChange {
+		return true
+	}
+
+	target := fmt.Sprintf("%s/%s/%s", workloadRec.Namespace, workloadRec.WorkloadName, containerRec.ContainerName)
+	switch workloadRec.QoSClass {
+	case corev1.PodQOSGuaranteed:
+		containerRec.RecommendedCPULimit = containerRec.RecommendedCPU
+		containerRec.RecommendedMemoryLimit = containerRec.RecommendedMemory
+		if containerRec.RecommendedCPULimit != containerRec.CurrentCPULimit ||
+			containerRec.RecommendedMemoryLimit != containerRec.CurrentMemoryLimit {
+			containerRec.Reasons = append(containerRec.Reasons, "limits moved with requests to keep the Guaranteed QoS class")
+		}
+	case corev1.PodQOSBestEffort:
+		klog.V(3).Infof("[%s] Skipping %s: setting requests would make a BestEffort pod Burstable", mode, target)
+		if r.adviceChanged(config, events.ReasonQoSClassChangeBlocked, workloadRec, containerRec.ContainerName, string(corev1.PodQOSBestEffort)) {
+			r.optimizerEvents.RecordWarningEvent(config, events.ReasonQoSClassChangeBlocked,
+				fmt.Sprintf("Skipped %s: setting requests would change QoS class from BestEffort to Burstable; set allowQoSClassChange to apply", target))
+		}
+		return false
+	}
+	return true
+}
+
+// recordQoSClassChange surfaces a change that moves a workload to another QoS class
+func (r *Reconciler) recordQoSClassChange(config *optimizerv1alpha1.OptimizerConfig, rec *applier.ResourceRecommendation, result *applier.ApplyResult) {
+	if !result.QoSClassChanged() {
+		return
+	}
+
+	message := fmt.Sprintf("%s/%s/%s would change QoS class from %s to %s",
+		rec.Namespace, rec.WorkloadName, rec.ContainerName, result.QoSClass, result.NewQoSClass)
+	if rec.PreserveQoS {
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonQoSClassChangeBlocked,
+			message+"; set allowQoSClassChange to apply")
+		return
+	}
+	r.optimizerEvents.RecordWarningEvent(config, events.ReasonQoSClassChange, message)
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+	"intelligent-cluster-optimizer/pkg/safety"
+
+	"k8s.io/klog/v2"
+)
+
+// quotaPolicy returns the configured quota policy, or "" when the check is disabled
+func quotaPolicy(config *optimizerv1alpha1.OptimizerConfig) optimizerv1alpha1.QuotaPolicy {
+	awareness := config.Spec.QuotaAwareness
+	if awareness == nil || !awareness.Enabled {
+		return ""
+	}
+	if awareness.Policy == "" {
+		return optimizerv1alpha1.QuotaPolicyClamp
+	}
+	return awareness.Policy
+}
+
+// movingLimit returns the recommended limit when it moves with the request, else 0
+func movingLimit(recommended, current int64) int64 {
+	if recommended > 0 && recommended != current {
+		return recommended
+	}
+	return 0
+}
+
+// checkQuota validates a container change against the namespace's ResourceQuotas and
+// LimitRanges and clamps it into the allowed range. Ephemeral-storage requests and
+// limits that move with the requests are checked too, so it runs after preserveQoS.
+// The quota usage of containers kept is added to reserved (when not nil) so the changes
+// checked after them in the same pass only get the headroom that is left.
+// It returns false when the container must be skipped: with the Block policy, when no
+// size passes, or when a raise could only be clamped below the current requests.
+func (r *Reconciler) checkQuota(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	workloadRec *recommendation.WorkloadRecommendation,
+	containerRec *recommendation.ContainerRecommendation,
+	reserved safety.QuotaReservations,
+	mode string,
+) bool {
+	policy := quotaPolicy(config)
+	cpuLimit := movingLimit(containerRec.RecommendedCPULimit, containerRec.CurrentCPULimit)
+	memoryLimit := movingLimit(containerRec.RecommendedMemoryLimit, containerRec.CurrentMemoryLimit)
+	storage := movingLimit(containerRec.RecommendedEphemeralStorage, containerRec.CurrentEphemeralStorage)
+	if policy == "" || (containerRec.RecommendedCPU == containerRec.CurrentCPU &&
+		containerRec.RecommendedMemory == containerRec.CurrentMemory &&
+		cpuLimit == 0 && memoryLimit == 0 && storage == 0) {
+		r.reportQuota(config, workloadRec, containerRec.ContainerName, "", "")
+		return true
+	}
+
+	result, err := r.quotaChecker.CheckQuota(ctx, safety.QuotaRequest{
+		Namespace:        workloadRec.Namespace,
+		Kind:             workloadRec.WorkloadKind,
+		Name:             workloadRec.WorkloadName,
+		ContainerName:    containerRec.ContainerName,
+		CPU:              containerRec.RecommendedCPU,
+		Memory:           containerRec.RecommendedMemory,
+		EphemeralStorage: storage,
+		CPULimit:         cpuLimit,
+		MemoryLimit:      memoryLimit,
+		Reserved:         reserved,
+	})
+	if err != nil {
+		klog.Warningf("Failed to check quotas for %s/%s/%s: %v",
+			workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, err)
+		return true
+	}
+	if result.Fits {
+		r.reportQuota(config, workloadRec, containerRec.ContainerName, "", "")
+		if reserved != nil {
+			reserved.Reserve(workloadRec.Namespace, result)
+		}
+		return true
+	}
+
+	target := fmt.Sprintf("%s/%s/%s", workloadRec.Namespace, workloadRec.WorkloadName, containerRec.ContainerName)
+	raisedBelowCurrent := (containerRec.RecommendedCPU > containerRec.CurrentCPU && result.CPU < containerRec.CurrentCPU) ||
+		(containerRec.RecommendedMemory > containerRec.CurrentMemory && result.Memory < containerRec.CurrentMemory) ||
+		(storage > containerRec.CurrentEphemeralStorage && result.EphemeralStorage < containerRec.CurrentEphemeralStorage)
+	if policy == optimizerv1alpha1.QuotaPolicyBlock || !result.Feasible || raisedBelowCurrent {
+		klog.V(3).Infof("[%s] Skipping %s: change would be rejected by %s - %s",
+			mode, target, strings.Join(result.Violations, ", "), result.Message)
+		message := fmt.Sprintf("Blocked %s: CPU %s, memory %s", target,
+			formatCPU(containerRec.RecommendedCPU), formatMemory(containerRec.RecommendedMemory))
+		if storage > 0 {
+			message += fmt.Sprintf(", ephemeral storage %s", formatMemory(storage))
+		}
+		if r.reportQuota(config, workloadRec, containerRec.ContainerName, events.ReasonQuotaBlocked, strings.Join(result.Violations, ",")) {
+			r.optimizerEvents.RecordWarningEvent(config, events.ReasonQuotaBlocked,
+				fmt.Sprintf("%s exceed namespace limits (%s)", message, result.Message))
+		}
+		return false
+	}
+
+	message := fmt.Sprintf("Clamped %s to namespace limits (%s): CPU %s -> %s, memory %s -> %s",
+		target, strings.Join(result.Violations, ", "),
+		formatCPU(containerRec.RecommendedCPU), formatCPU(result.CPU),
+		formatMemory(containerRec.RecommendedMemory), formatMemory(result.Memory))
+	if storage > 0 {
+		message += fmt.Sprintf(", ephemeral storage %s -> %s", formatMemory(storage), formatMemory(result.EphemeralStorage))
+	}
+	klog.V(3).Infof("[%s] %s", mode, message)
+	if r.reportQuota(config, workloadRec, containerRec.ContainerName, events.ReasonQuotaClamped, strings.Join(result.Violations, ",")) {
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonQuotaClamped, message)
+	}
+
+	// Limits that move with the requests keep their distance to them
+	if cpuLimit > 0 {
+		containerRec.RecommendedCPULimit += result.CPU - containerRec.RecommendedCPU
+	}
+	if memoryLimit > 0 {
+		containerRec.RecommendedMemoryLimit += result.Memory - containerRec.RecommendedMemory
+	}
+	containerRec.RecommendedCPU = result.CPU
+	containerRec.RecommendedMemory = result.Memory
+	if storage > 0 {
+		containerRec.RecommendedEphemeralStorage = result.EphemeralStorage
+	}
+	containerRec.Reasons = append(containerRec.Reasons, "requests clamped to namespace ResourceQuota and LimitRange")
+	if reserved != nil {
+		reserved.Reserve(workloadRec.Namespace, result)
+	}
+	return true
+}
+
+// reportQuota records which quota event applies to a container, keyed on the quotas and
+// LimitRanges it violates rather than on the clamped sizes that follow the quota usage,
+// and reports whether the event should be recorded. An empty reason clears both events.
+func (r *Reconciler) reportQuota(config *optimizerv1alpha1.OptimizerConfig,
+	workloadRec *recommendation.WorkloadRecommendation, containerName, reason, violations string) bool {
+	for _, other := range []string{events.ReasonQuotaBlocked, events.ReasonQuotaClamped} {
+		if other != reason {
+			r.clearAdvice(config, other, workloadRec, containerName)
+		}
+	}
+	if reason == "" {
+		return false
+	}
+	return r.adviceChanged(config, reason, workloadRec, containerName, violations)
+}
@@ -2,7 +2,9 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"intelligent-cluster-optimizer/pkg/anomaly"
@@ -10,14 +12,19 @@ import (
 	"intelligent-cluster-optimizer/pkg/applier"
 	"intelligent-cluster-optimizer/pkg/events"
 	"intelligent-cluster-optimizer/pkg/gitops"
+	"intelligent-cluster-optimizer/pkg/leakdetector"
+	"intelligent-cluster-optimizer/pkg/metrics"
 	"intelligent-cluster-optimizer/pkg/pareto"
 	"intelligent-cluster-optimizer/pkg/prediction"
 	"intelligent-cluster-optimizer/pkg/profile"
 	"intelligent-cluster-optimizer/pkg/recommendation"
 	"intelligent-cluster-optimizer/pkg/safety"
 	"intelligent-cluster-optimizer/pkg/scheduler"
+	"intelligent-cluster-optimizer/pkg/simulation"
 	"intelligent-cluster-optimizer/pkg/sla"
 	"intelligent-cluster-optimizer/pkg/storage"
+	"intelligent-cluster-optimizer/pkg/timepattern"
+	"intelligent-cluster-optimizer/pkg/tuning"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -30,15 +37,33 @@ type ReconcileResult struct {
 	Updated      bool
 }
 
+// workloadRef identifies a workload changed during a reconcile
+type workloadRef struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// appendWorkloadRef adds ref to refs unless it is already listed
+func appendWorkloadRef(refs []workloadRef, ref workloadRef) []workloadRef {
+	for _, existing := range refs {
+		if existing == ref {
+			return refs
+		}
+	}
+	return append(refs, ref)
+}
+
 type Reconciler struct {
 	kubeClient             kubernetes.Interface
 	hpaChecker             *safety.HPAChecker
 	pdbChecker             *safety.PDBChecker
+	nodeFitChecker         *safety.NodeFitChecker
+	quotaChecker           *safety.QuotaChecker
 	applier                *applier.Applier
 	eventRecorder          record.EventRecorder
 	optimizerEvents        *events.OptimizerEventRecorder
 	maintenanceWindowCheck *scheduler.MaintenanceWindowChecker
-	circuitBreaker         *safety.CircuitBreaker
 	recommendationEngine   *recommendation.Engine
 	metricsStorage         *storage.InMemoryStorage
 	profileResolver        *profile.Resolver
@@ -47,18 +72,42 @@ type Reconciler struct {
 	paretoHelper           *pareto.RecommendationHelper
 	gitopsExporter         gitops.Exporter
 	slaHealthChecker       sla.HealthChecker
+	timePatternAnalyzer    *timepattern.Analyzer
+	timeProfileScheduler   *scheduler.TimeProfileScheduler
+	leakDetector           *leakdetector.Detector
+	metricsExporter        *metrics.PrometheusExporter
+	oomDetector            *safety.OOMDetector
+	oomProvider            recommendation.OOMInfoProvider
+	oomScanInterval        time.Duration
+	packingSimulator       *simulation.Simulator
+	simulationInterval     time.Duration
+	marginTuner            *tuning.MarginTuner
+	changeBudget           *safety.ChangeBudget
+	budgetPriority         BudgetPriority
+
+	oomScanMu   sync.Mutex
+	lastOOMScan map[string]time.Time // namespace -> last scan
+	lastSeenOOM map[string]time.Time // namespace/workload -> latest OOM reported
+
+	oomFastPathMu   sync.Mutex
+	lastOOMFastPath map[string]time.Time // namespace/kind/workload/container -> last fast-path bump
+
+	adviceMu   sync.Mutex
+	lastAdvice map[string]string // config/reason/workload/container -> state last reported
 }
 
 func NewReconciler(kubeClient kubernetes.Interface, eventRecorder record.EventRecorder) *Reconciler {
-	return &Reconciler{
+	oomDetector := safety.NewOOMDetector(kubeClient)
+	r := &Reconciler{
 		kubeClient:             kubeClient,
 		hpaChecker:             safety.NewHPAChecker(kubeClient),
 		pdbChecker:             safety.NewPDBChecker(kubeClient),
+		nodeFitChecker:         safety.NewNodeFitChecker(kubeClient),
+		quotaChecker:           safety.NewQuotaChecker(kubeClient),
 		applier:                applier.NewApplier(kubeClient, eventRecorder),
 		eventRecorder:          eventRecorder,
 		optimizerEvents:        events.NewOptimizerEventRecorder(eventRecorder),
 		maintenanceWindowCheck: scheduler.NewMaintenanceWindowChecker(),
-		circuitBreaker:         safety.NewCircuitBreaker(),
 		recommendationEngine:   recommendation.NewEngine(),
 		metricsStorage:         storage.NewStorage(),
 		profileResolver:        profile.NewResolver(),
@@ -67,12 +116,35 @@ func NewReconciler(kubeClient kubernetes.Interface, eventRecorder record.EventRe
 		paretoHelper:           pareto.NewRecommendationHelper(),
 		gitopsExporter:         gitops.NewExporter(),
 		slaHealthChecker:       sla.NewHealthChecker(),
-	}
+		timePatternAnalyzer:    timepattern.NewAnalyzer(),
+		timeProfileScheduler:   scheduler.NewTimeProfileScheduler(),
+		leakDetector:           leakdetector.NewDetector(),
+		oomDetector:            oomDetector,
+		oomProvider:            recommendation.NewOOMDetectorProvider(oomDetector),
+		oomScanInterval:        DefaultOOMScanInterval,
+		packingSimulator:       simulation.NewSimulator(),
+		simulationInterval:     DefaultSimulationInterval,
+		marginTuner:            tuning.NewMarginTuner(),
+		changeBudget:           safety.NewChangeBudget(safety.BudgetLimits{}, safety.BudgetLimits{}),
+		budgetPriority:         BudgetPrioritySavings,
+		lastOOMScan:            make(map[string]time.Time),
+		lastSeenOOM:            make(map[string]time.Time),
+		lastOOMFastPath:        make(map[string]time.Time),
+		lastAdvice:             make(map[string]string),
+	}
+	r.applier.SetMetricsSource(r.metricsStorage)
+	return r
 }
 
 // SetMetricsStorage allows injecting a shared metrics storage instance
 func (r *Reconciler) SetMetricsStorage(store *storage.InMemoryStorage) {
 	r.metricsStorage = store
+	r.applier.SetMetricsSource(store)
+}
+
+// SetMetricsExporter enables Prometheus metrics for reconcile decisions
+func (r *Reconciler) SetMetricsExporter(exporter *metrics.PrometheusExporter) {
+	r.metricsExporter = exporter
 }
 
 // GetMetricsStorage returns the metrics storage for external population
@@ -100,19 +172,20 @@ func (r *Reconciler) Reconcile(ctx context.Context, config *optimizerv1alpha1.Op
 		return result, nil
 	}
 
-	if config.Spec.CircuitBreaker != nil && config.Spec.CircuitBreaker.Enabled {
-		if !r.circuitBreaker.ShouldAllow(config) {
-			klog.V(3).Infof("Circuit breaker is open for %s/%s", config.Namespace, config.Name)
-			r.optimizerEvents.RecordWarningEvent(config, events.ReasonCircuitBreakerOpen,
-				"Circuit breaker open, skipping optimization")
-			if err := r.updatePhase(config, optimizerv1alpha1.OptimizerPhaseCircuitOpen, "Circuit breaker open"); err != nil {
-				return result, err
-			}
-			result.Updated = true
-			result.RequeueAfter = 5 * time.Minute
-			return result, nil
-		}
+	// Follow changes applied by earlier passes; they are verified and rolled back even
+	// while new changes are held back by maintenance windows
+	mode := "LIVE"
+	if config.Spec.DryRun {
+		mode = "DRY-RUN"
 	}
+	r.progressRollouts(ctx, config, mode)
+	r.syncChangeBudget(config, time.Now())
+	defer func() {
+		r.syncCircuitBreakers(config)
+		if len(config.Status.Rollouts) > 0 && (result.RequeueAfter == 0 || result.RequeueAfter > rolloutRequeue) {
+			result.RequeueAfter = rolloutRequeue
+		}
+	}()
 
 	inMaintenanceWindow := r.maintenanceWindowCheck.IsInMaintenanceWindow(config)
 	config.Status.ActiveMaintenanceWindow = inMaintenanceWindow
@@ -145,9 +218,7 @@ func (r *Reconciler) Reconcile(ctx context.Context, config *optimizerv1alpha1.Op
 		return result, nil
 	}
 
-	mode := "LIVE"
 	if config.Spec.DryRun {
-		mode = "DRY-RUN"
 		klog.Infof("OptimizerConfig %s/%s running in DRY-RUN mode - no changes will be applied", config.Namespace, config.Name)
 	} else {
 		klog.Infof("OptimizerConfig %s/%s running in LIVE mode - changes will be applied", config.Namespace, config.Name)
@@ -156,15 +227,6 @@ func (r *Reconciler) Reconcile(ctx context.Context, config *optimizerv1alpha1.Op
 	// Process recommendations with per-workload safety checks
 	if err := r.processRecommendations(ctx, config, mode); err != nil {
 		klog.Warningf("Failed to process recommendations: %v", err)
-		if config.Spec.CircuitBreaker != nil && config.Spec.CircuitBreaker.Enabled {
-			if stateChanged := r.circuitBreaker.RecordFailure(config, err); stateChanged {
-				stateName := r.circuitBreaker.GetStateName(config.Status.CircuitState)
-				klog.Warningf("Circuit breaker state changed to %s for %s/%s after error: %v",
-					stateName, config.Namespace, config.Name, err)
-				r.optimizerEvents.RecordWarningEvent(config, "CircuitBreakerStateChanged",
-					"Circuit breaker state: "+stateName)
-			}
-		}
 	}
 
 	if err := r.updatePhase(config, optimizerv1alpha1.OptimizerPhaseActive, "Reconciliation successful"); err != nil {
@@ -179,18 +241,14 @@ func (r *Reconciler) Reconcile(ctx context.Context, config *optimizerv1alpha1.Op
 	now := metav1.NewTime(time.Now())
 	config.Status.LastRecommendationTime = &now
 
-	if config.Spec.CircuitBreaker != nil && config.Spec.CircuitBreaker.Enabled {
-		if stateChanged := r.circuitBreaker.RecordSuccess(config); stateChanged {
-			stateName := r.circuitBreaker.GetStateName(config.Status.CircuitState)
-			klog.Infof("Circuit breaker state changed to %s for %s/%s", stateName, config.Namespace, config.Name)
-			r.optimizerEvents.RecordNormalEvent(config, "CircuitBreakerStateChanged",
-				"Circuit breaker state: "+stateName)
-		}
-	}
-
 	result.Updated = true
 	result.RequeueAfter = 30 * time.Second
 
+	// Switch time-of-day profiles right at the next schedule boundary
+	if next := nextTimeProfileSwitch(config); next > 0 && next < result.RequeueAfter {
+		result.RequeueAfter = next
+	}
+
 	return result, nil
 }
 
@@ -237,7 +295,7 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 	klog.V(4).Infof("[%s] Processing recommendations for OptimizerConfig %s/%s", mode, config.Namespace, config.Name)
 
 	// SLA SAFETY CHECK: Pre-optimization health check
-	preOptHealth, err := r.checkSystemHealth(config)
+	preOptHealth, err := r.checkSystemHealth(ctx, config)
 	if err != nil {
 		klog.Warningf("[%s] Failed to perform pre-optimization health check: %v", mode, err)
 	} else {
@@ -260,8 +318,19 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 		// Continue with nil settings - will skip MaxChangePercent check
 	}
 
+	// Refresh OOMKilled history so OOMing containers get boosted memory and are never downsized
+	r.scanForOOMs(ctx, config, mode)
+
+	// Tune safety margins from what happened after earlier changes
+	var marginProvider recommendation.SafetyMarginProvider
+	tuningPolicy := marginPolicy(config, resolvedSettings)
+	if marginTuningEnabled(config) {
+		r.observeMarginOutcomes(ctx, config, tuningPolicy, mode)
+		marginProvider = r.marginTuner.Provider(config, tuningPolicy)
+	}
+
 	// Generate recommendations using the engine with P95/P99 percentile calculation
-	recommendations, err := r.recommendationEngine.GenerateRecommendations(r.metricsStorage, config)
+	recommendations, err := r.recommendationEngine.GenerateRecommendationsWithMargins(r.metricsStorage, r.oomProvider, marginProvider, config)
 	if err != nil {
 		return fmt.Errorf("failed to generate recommendations: %w", err)
 	}
@@ -269,6 +338,7 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 	if len(recommendations) == 0 {
 		klog.V(3).Infof("[%s] No recommendations generated for %s/%s (insufficient metrics or no changes needed)",
 			mode, config.Namespace, config.Name)
+		r.updateDecisions(config, nil)
 		return nil
 	}
 
@@ -298,14 +368,70 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 		}
 	}
 
+	// Estimate how many nodes the recommendations would free
+	r.simulatePacking(ctx, config, recommendations, mode)
+
+	// Release workloads whose post-rollback cooldown has passed
+	r.pruneQuarantine(config, time.Now())
+
+	// Let the most valuable changes consume the change budget first
+	if r.budgetLimited() && !config.Spec.DryRun {
+		prioritizeRecommendations(recommendations, r.budgetPriority)
+	}
+
 	// Process each workload recommendation
-	var appliedCount, skippedCount int
+	var appliedCount, skippedCount, deferredCount int
+	var deferredReason string
+	var waiting []safety.BudgetCandidate // changes deferred by the change budget
+	// Quota usage of the changes approved so far, which quota status does not show yet
+	quotaReservations := safety.QuotaReservations{}
+	var memoryLeaks []optimizerv1alpha1.MemoryLeakStatus
+	inFlight := rolloutRefs(config) // workloads still rolling out changes from earlier passes
+	var decisions []optimizerv1alpha1.WorkloadDecision
 	for _, workloadRec := range recommendations {
+		decision := newWorkloadDecision(&workloadRec)
+
+		// SAFETY CHECK: Leave workloads alone while they are quarantined after a rollback
+		if q := quarantinedUntil(config, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, time.Now()); q != nil {
+			message := fmt.Sprintf("rolled back at %s (%s); quarantined until %s",
+				q.RolledBackAt.Format(time.RFC3339), q.Reason, q.Until.Format(time.RFC3339))
+			klog.V(3).Infof("[%s] Skipping %s/%s/%s: %s",
+				mode, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, message)
+			skipWorkload(decision, gateQuarantine, message)
+			decisions = append(decisions, *decision)
+			skippedCount++
+			continue
+		}
+
+		// SAFETY CHECK: Change a workload again only once its last changes are verified
+		ref := workloadRef{Namespace: workloadRec.Namespace, Kind: workloadRec.WorkloadKind, Name: workloadRec.WorkloadName}
+		if inFlight[ref] {
+			rollout := findRollout(config, ref)
+			message := fmt.Sprintf("earlier changes still in phase %s: %s", rollout.Phase, rollout.Message)
+			klog.V(3).Infof("[%s] Skipping %s/%s/%s: %s",
+				mode, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, message)
+			skipWorkload(decision, gateRollout, message)
+			decisions = append(decisions, *decision)
+			skippedCount++
+			continue
+		}
+
+		// SAFETY CHECK: Leave workloads alone while their own or their namespace's circuit is open
+		if allowed, message := r.checkCircuits(config, ref, time.Now(), mode); !allowed {
+			klog.V(3).Infof("[%s] Skipping %s/%s/%s: %s",
+				mode, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, message)
+			skipWorkload(decision, gateCircuitBreaker, message)
+			decisions = append(decisions, *decision)
+			skippedCount++
+			continue
+		}
+
 		// SAFETY CHECK: Check HPA conflicts before processing this workload
 		if config.Spec.HPAAwareness != nil && config.Spec.HPAAwareness.Enabled {
 			hpaResult, err := r.hpaChecker.CheckHPAConflict(ctx, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName)
 			if err != nil {
 				klog.Warningf("Failed to check HPA conflict for %s/%s/%s: %v", workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, err)
+				addGate(&decision.Gates, gateHPA, optimizerv1alpha1.GateWarned, fmt.Sprintf("check failed: %v", err))
 			} else if hpaResult.HasConflict {
 				policy := config.Spec.HPAAwareness.ConflictPolicy
 				if policy == "" || policy == optimizerv1alpha1.HPAConflictPolicySkip {
@@ -316,6 +442,8 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 					if err := r.updateCondition(config, optimizerv1alpha1.ConditionTypeHPAConflict, optimizerv1alpha1.ConditionTrue, "ConflictDetected", hpaResult.Message); err != nil {
 						klog.Warningf("Failed to update condition: %v", err)
 					}
+					skipWorkload(decision, gateHPA, hpaResult.Message)
+					decisions = append(decisions, *decision)
 					skippedCount++
 					continue
 				} else if policy == optimizerv1alpha1.HPAConflictPolicyWarn {
@@ -323,7 +451,10 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 						mode, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, hpaResult.Message)
 					r.optimizerEvents.RecordWarningEvent(config, events.ReasonHPAConflictDetected,
 						fmt.Sprintf("HPA conflict for %s/%s (proceeding): %s", workloadRec.WorkloadKind, workloadRec.WorkloadName, hpaResult.Message))
+					addGate(&decision.Gates, gateHPA, optimizerv1alpha1.GateWarned, hpaResult.Message)
 				}
+			} else {
+				addGate(&decision.Gates, gateHPA, optimizerv1alpha1.GatePassed, "")
 			}
 		}
 
@@ -332,6 +463,7 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 			pdbResult, err := r.pdbChecker.CheckPDBSafety(ctx, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, 1)
 			if err != nil {
 				klog.Warningf("Failed to check PDB for %s/%s/%s: %v", workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, err)
+				addGate(&decision.Gates, gatePDB, optimizerv1alpha1.GateWarned, fmt.Sprintf("check failed: %v", err))
 			} else if pdbResult.HasPDB && !pdbResult.IsSafe {
 				if config.Spec.PDBAwareness.RespectMinAvailable {
 					klog.V(3).Infof("[%s] Skipping %s/%s/%s: PDB violation detected - %s",
@@ -341,6 +473,8 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 					if err := r.updateCondition(config, optimizerv1alpha1.ConditionTypePDBViolation, optimizerv1alpha1.ConditionTrue, "ViolationDetected", pdbResult.Message); err != nil {
 						klog.Warningf("Failed to update condition: %v", err)
 					}
+					skipWorkload(decision, gatePDB, pdbResult.Message)
+					decisions = append(decisions, *decision)
 					skippedCount++
 					continue
 				} else {
@@ -348,7 +482,10 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 						mode, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName, pdbResult.Message)
 					r.optimizerEvents.RecordWarningEvent(config, events.ReasonPDBViolation,
 						fmt.Sprintf("PDB violation for %s/%s (proceeding): %s", workloadRec.WorkloadKind, workloadRec.WorkloadName, pdbResult.Message))
+					addGate(&decision.Gates, gatePDB, optimizerv1alpha1.GateWarned, pdbResult.Message)
 				}
+			} else {
+				addGate(&decision.Gates, gatePDB, optimizerv1alpha1.GatePassed, "")
 			}
 		}
 
@@ -361,11 +498,17 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 					mode, workloadRec.Namespace, workloadRec.WorkloadName, anomalyResult.BlockReason, anomalyResult.RecommendedAction)
 				r.optimizerEvents.RecordWarningEvent(config, events.ReasonAnomalyDetected,
 					fmt.Sprintf("Anomaly in %s/%s: %s", workloadRec.Namespace, workloadRec.WorkloadName, anomalyResult.BlockReason))
+				skipWorkload(decision, gateAnomaly, anomalyResult.BlockReason)
+				decisions = append(decisions, *decision)
 				skippedCount++
 				continue
 			} else if anomalyResult.HasAnyAnomaly {
 				klog.V(4).Infof("[%s] Workload %s/%s has %d anomalies (severity: %s) - proceeding with caution",
 					mode, workloadRec.Namespace, workloadRec.WorkloadName, anomalyResult.AnomalyCount, anomalyResult.HighestSeverity)
+				addGate(&decision.Gates, gateAnomaly, optimizerv1alpha1.GateWarned,
+					fmt.Sprintf("%d anomalies (severity: %s)", anomalyResult.AnomalyCount, anomalyResult.HighestSeverity))
+			} else {
+				addGate(&decision.Gates, gateAnomaly, optimizerv1alpha1.GatePassed, "")
 			}
 		}
 
@@ -396,6 +539,9 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 			}
 		}
 
+		// TIME PROFILE: Scale recommendations to the time-of-day profile active now
+		r.applyTimeProfile(config, &workloadRec, mode)
+
 		// PARETO: Generate multi-objective optimal recommendations
 		if len(workloadRec.Containers) > 0 {
 			// Build metrics for Pareto optimization from first container
@@ -432,7 +578,55 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 			}
 		}
 
+		containers := r.workloadContainers(ctx, &workloadRec, mode)
 		for _, containerRec := range workloadRec.Containers {
+			decision.Containers = append(decision.Containers, newContainerDecision(decision, &containerRec, config.Spec.ResourceThresholds))
+			trace := &decision.Containers[len(decision.Containers)-1]
+
+			// SAFETY CHECK: Never reduce memory of a container that appears to be leaking
+			before := containerRec
+			if leak := r.guardMemoryLeak(config, &workloadRec, &containerRec, workloadMetrics, mode); leak != nil {
+				memoryLeaks = append(memoryLeaks, *leak)
+			}
+			traceGate(trace, gateMemoryLeak, before, &containerRec, true)
+
+			// SAFETY CHECK: Never size memory below what the JVM heap or GOMEMLIMIT will use
+			before = containerRec
+			r.applyRuntimeMemoryFloor(config, &workloadRec, &containerRec, containers[containerRec.ContainerName], mode)
+			traceGate(trace, gateRuntimeMemory, before, &containerRec, true)
+			warnGate(trace, containerRec.RuntimeAdvice)
+
+			// SAFETY CHECK: Never raise requests beyond what an eligible node can schedule
+			before = containerRec
+			passed := r.checkNodeFit(ctx, config, &workloadRec, &containerRec, mode)
+			traceGate(trace, gateNodeFit, before, &containerRec, passed)
+			if !passed {
+				finishContainerDecision(trace, &containerRec, "", "")
+				skippedCount++
+				continue
+			}
+
+			// SAFETY CHECK: Keep the pod QoS class unless class changes are allowed, moving
+			// Guaranteed limits before the quota check sees them
+			before = containerRec
+			passed = r.preserveQoS(config, &workloadRec, &containerRec, mode)
+			traceGate(trace, gateQoS, before, &containerRec, passed)
+			if !passed {
+				finishContainerDecision(trace, &containerRec, "", "")
+				skippedCount++
+				continue
+			}
+
+			// SAFETY CHECK: Keep changes within namespace ResourceQuotas and LimitRanges, limits included
+			before = containerRec
+			passed = r.checkQuota(ctx, config, &workloadRec, &containerRec, quotaReservations, mode)
+			traceGate(trace, gateQuota, before, &containerRec, passed)
+			if !passed {
+				finishContainerDecision(trace, &containerRec, "", "")
+				skippedCount++
+				continue
+			}
+
 			// Convert to applier format
 			rec := &applier.ResourceRecommendation{
 				Namespace:         workloadRec.Namespace,
@@ -443,12 +637,39 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 				RecommendedCPU:    formatCPU(containerRec.RecommendedCPU),
 				CurrentMemory:     formatMemory(containerRec.CurrentMemory),
 				RecommendedMemory: formatMemory(containerRec.RecommendedMemory),
+				PreserveQoS:       !config.Spec.AllowQoSClassChange,
+				OptimizerConfig:   config.Namespace + "/" + config.Name,
+				RecommendationID:  trace.RecommendationID,
+				Canary:            canaryConfig(config),
+			}
+			if containerRec.RecommendedCPULimit > 0 && containerRec.RecommendedCPULimit != containerRec.CurrentCPULimit {
+				rec.CurrentCPULimit = formatCPU(containerRec.CurrentCPULimit)
+				rec.RecommendedCPULimit = formatCPU(containerRec.RecommendedCPULimit)
+			}
+			if containerRec.RecommendedMemoryLimit > 0 && containerRec.RecommendedMemoryLimit != containerRec.CurrentMemoryLimit {
+				rec.CurrentMemoryLimit = formatMemory(containerRec.CurrentMemoryLimit)
+				rec.RecommendedMemoryLimit = formatMemory(containerRec.RecommendedMemoryLimit)
+			}
+			if containerRec.RecommendedEphemeralStorage > 0 && containerRec.RecommendedEphemeralStorage != containerRec.CurrentEphemeralStorage {
+				rec.CurrentEphemeralStorage = formatMemory(containerRec.CurrentEphemeralStorage)
+				rec.RecommendedEphemeralStorage = formatMemory(containerRec.RecommendedEphemeralStorage)
 			}
 
 			// Skip if no changes needed
 			if !rec.HasChanges() {
 				klog.V(4).Infof("[%s] Skipping %s/%s/%s: no changes needed",
 					mode, rec.Namespace, rec.WorkloadName, rec.ContainerName)
+				finishContainerDecision(trace, &containerRec, optimizerv1alpha1.DecisionUnchanged, "no changes needed")
+				skippedCount++
+				continue
+			}
+
+			// SAFETY CHECK: Canary one container of a workload at a time
+			if rollout := findRollout(config, ref); rollout != nil && !config.Spec.DryRun &&
+				(rollout.Phase == optimizerv1alpha1.RolloutPhaseCanary || rec.Canary != nil) {
+				reason := fmt.Sprintf("waiting for the canary of container %s", rollout.Containers[len(rollout.Containers)-1])
+				addGate(&trace.Gates, gateRollout, optimizerv1alpha1.GateBlocked, reason)
+				finishContainerDecision(trace, &containerRec, optimizerv1alpha1.DecisionSkipped, reason)
 				skippedCount++
 				continue
 			}
@@ -462,9 +683,45 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 						mode, rec.Namespace, rec.WorkloadName, rec.ContainerName, reason, changePercent, containerRec.Confidence)
 					r.optimizerEvents.RecordWarningEvent(config, events.ReasonRecommendationSkipped,
 						fmt.Sprintf("Skipped %s/%s: %s", rec.WorkloadName, rec.ContainerName, reason))
+					addGate(&trace.Gates, gateMaxChangePercent, optimizerv1alpha1.GateBlocked,
+						fmt.Sprintf("%s (change=%.1f%%, confidence=%.1f%%)", reason, changePercent, containerRec.Confidence))
+					finishContainerDecision(trace, &containerRec, optimizerv1alpha1.DecisionSkipped, reason)
 					skippedCount++
 					continue
 				}
+				addGate(&trace.Gates, gateMaxChangePercent, optimizerv1alpha1.GatePassed,
+					fmt.Sprintf("change=%.1f%%, confidence=%.1f%%", changePercent, containerRec.Confidence))
+			}
+
+			// Surface forecast-driven raises so users can see why a target went up, again only
+			// when another resource is raised or a raised target moves by more than 5%
+			if containerRec.ForecastApplied {
+				report := r.adviceChanged(config, events.ReasonForecastAdjusted, &workloadRec, containerRec.ContainerName,
+					forecastAdviceState(&containerRec))
+				for _, reason := range containerRec.Reasons {
+					klog.V(3).Infof("[%s] %s/%s/%s: %s", mode, rec.Namespace, rec.WorkloadName, rec.ContainerName, reason)
+					if report {
+						r.optimizerEvents.RecordNormalEvent(config, events.ReasonForecastAdjusted,
+							fmt.Sprintf("%s/%s: %s", rec.WorkloadName, rec.ContainerName, reason))
+					}
+				}
+			} else {
+				r.clearAdvice(config, events.ReasonForecastAdjusted, &workloadRec, containerRec.ContainerName)
+			}
+
+			// Startup-dominated containers keep a separate CPU figure for the warm-up phase,
+			// reported again only when it moves by more than 5%
+			if containerRec.StartupCPU > 0 {
+				message := fmt.Sprintf("%s/%s/%s needs %s CPU while warming up against %s steady-state (%d warm-up samples excluded)",
+					rec.Namespace, rec.WorkloadName, rec.ContainerName, formatCPU(containerRec.StartupCPU),
+					rec.RecommendedCPU, containerRec.WarmUpSamplesExcluded)
+				klog.V(3).Infof("[%s] %s", mode, message)
+				if r.adviceChanged(config, events.ReasonStartupCPURecommended, &workloadRec, containerRec.ContainerName,
+					fmt.Sprintf("cpu=%d", adviceBucket(containerRec.StartupCPU))) {
+					r.optimizerEvents.RecordNormalEvent(config, events.ReasonStartupCPURecommended, message)
+				}
+			} else {
+				r.clearAdvice(config, events.ReasonStartupCPURecommended, &workloadRec, containerRec.ContainerName)
 			}
 
 			// Log recommendation details with cost savings
@@ -480,14 +737,59 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 				rec.CurrentMemory, rec.RecommendedMemory, containerRec.MemoryPercentile,
 				containerRec.Confidence, containerRec.SampleCount, savingsInfo)
 
+			// SAFETY CHECK: Stay within the cluster-wide and per-namespace change budget
+			var budget safety.BudgetDecision
+			if !config.Spec.DryRun {
+				budgetWorkload := safety.BudgetWorkload{Namespace: rec.Namespace, Kind: rec.WorkloadKind, Name: rec.WorkloadName}
+				rank := workloadBudgetRank(&workloadRec, r.budgetPriority)
+				budget = r.changeBudget.Acquire(budgetOwner(config), budgetWorkload, rank, time.Now())
+				if !budget.Allowed {
+					waiting = append(waiting, safety.BudgetCandidate{Workload: budgetWorkload, Rank: rank})
+					klog.V(3).Infof("[%s] Deferring %s/%s/%s: %s",
+						mode, rec.Namespace, rec.WorkloadName, rec.ContainerName, budget.Message)
+					if r.metricsExporter != nil {
+						r.metricsExporter.RecordChangeDeferred(config.Name, rec.Namespace, budget.Limit)
+					}
+					addGate(&trace.Gates, gateChangeBudget, optimizerv1alpha1.GateBlocked, budget.Message)
+					finishContainerDecision(trace, &containerRec, optimizerv1alpha1.DecisionSkipped, budget.Message)
+					deferredCount++
+					deferredReason = budget.Message
+					skippedCount++
+					continue
+				}
+				addGate(&trace.Gates, gateChangeBudget, optimizerv1alpha1.GatePassed, "")
+			}
+
+			// Baseline the workload's own health before its first change for the rollout verification
+			var baseline *sla.HealthCheckResult
+			if !config.Spec.DryRun && findRollout(config, ref) == nil {
+				if baseline, err = r.checkWorkloadHealth(ctx, ref); err != nil {
+					klog.Warningf("[%s] Failed to check health of %s/%s: %v", mode, rec.Namespace, rec.WorkloadName, err)
+				}
+			}
+
 			applyResult, err := r.applier.Apply(ctx, rec, config.Spec.DryRun)
+			if budget.Acquired && (err != nil || !applyResult.Applied) {
+				r.changeBudget.Release(budgetOwner(config),
+					safety.BudgetWorkload{Namespace: rec.Namespace, Kind: rec.WorkloadKind, Name: rec.WorkloadName})
+			}
 			if err != nil {
 				klog.Warningf("[%s] Failed to apply recommendation for %s/%s/%s: %v",
 					mode, rec.Namespace, rec.WorkloadName, rec.ContainerName, err)
+				if errors.Is(err, applier.ErrQoSClassChange) {
+					r.recordQoSClassChange(config, rec, applyResult)
+				} else if !config.Spec.DryRun {
+					config.Status.TotalUpdatesFailed++
+					r.recordChangeFailure(config, ref, err, mode)
+				}
+				finishContainerDecision(trace, &containerRec, optimizerv1alpha1.DecisionFailed, err.Error())
 				continue
 			}
+			r.recordQoSClassChange(config, rec, applyResult)
 
 			if config.Spec.DryRun {
+				finishContainerDecision(trace, &containerRec, optimizerv1alpha1.DecisionDryRun,
+					fmt.Sprintf("%d changes would be applied", len(applyResult.Changes)))
 				klog.V(3).Infof("[DRY-RUN] Summary: %d changes would be applied to %s/%s",
 					len(applyResult.Changes), applyResult.WorkloadKind, applyResult.WorkloadName)
 				for _, change := range applyResult.Changes {
@@ -497,8 +799,31 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 				appliedCount++
 				klog.Infof("[LIVE] Successfully applied changes to %s/%s/%s",
 					rec.Namespace, rec.WorkloadName, rec.ContainerName)
+				r.trackRollout(config, rec, applyResult, baseline, time.Now())
+				if marginTuningEnabled(config) {
+					r.marginTuner.RecordApplied(config, rec.Namespace, rec.WorkloadKind, rec.WorkloadName, containerRec.SafetyMargin, time.Now())
+				}
+				finishContainerDecision(trace, &containerRec, optimizerv1alpha1.DecisionApplied,
+					fmt.Sprintf("%d changes applied", len(applyResult.Changes)))
+			} else {
+				finishContainerDecision(trace, &containerRec, optimizerv1alpha1.DecisionUnchanged, "workload already matches the recommendation")
 			}
 		}
+
+		finishWorkloadDecision(decision)
+		decisions = append(decisions, *decision)
+	}
+
+	r.updateMemoryLeakStatus(config, memoryLeaks)
+	r.updateDecisions(config, decisions)
+
+	// Keep room in the shared budget for the deferred changes that outrank other configs'
+	if !config.Spec.DryRun {
+		r.changeBudget.SetWaiting(budgetOwner(config), waiting, time.Now())
+	}
+	if deferredCount > 0 {
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonChangeBudgetExhausted,
+			fmt.Sprintf("Deferred %d changes to later passes: %s", deferredCount, deferredReason))
 	}
 
 	// Record events with savings information
@@ -513,43 +838,6 @@ func (r *Reconciler) processRecommendations(ctx context.Context, config *optimiz
 			fmt.Sprintf("Applied %d resource optimizations (estimated savings: $%.2f/month)",
 				appliedCount, totalSavingsPerMonth))
 		config.Status.TotalUpdatesApplied += int64(appliedCount)
-
-		// SLA SAFETY CHECK: Post-optimization health check
-		if preOptHealth != nil && !config.Spec.DryRun {
-			// Wait a bit for metrics to stabilize after changes
-			time.Sleep(5 * time.Second)
-
-			postOptHealth, err := r.checkSystemHealth(config)
-			if err != nil {
-				klog.Warningf("[%s] Failed to perform post-optimization health check: %v", mode, err)
-			} else {
-				klog.V(3).Infof("[%s] Post-optimization health: Score=%.1f, IsHealthy=%v, Message=%s",
-					mode, postOptHealth.Score, postOptHealth.IsHealthy, postOptHealth.Message)
-
-				// Compare health before and after optimization
-				impact, err := r.slaHealthChecker.CompareHealth(preOptHealth, postOptHealth)
-				if err != nil {
-					klog.Warningf("[%s] Failed to compare health: %v", mode, err)
-				} else {
-					klog.V(3).Infof("[%s] SLA Impact: Score=%.2f, Recommendation=%s",
-						mode, impact.ImpactScore, impact.Recommendation)
-
-					// Check if rollback is recommended
-					if shouldRollback, reason := sla.ShouldRollback(impact); shouldRollback {
-						klog.Warningf("[%s] SLA health degraded after optimization: %s", mode, reason)
-						r.optimizerEvents.RecordWarningEvent(config, events.ReasonOptimizationDegraded,
-							fmt.Sprintf("SLA degradation detected: %s. Consider rollback.", reason))
-						// Note: Actual rollback would require storing previous state
-						// For now, we just log and alert
-					} else if impact.ImpactScore > 0.1 {
-						klog.Infof("[%s] SLA health improved after optimization (score change: +%.1f%%)",
-							mode, impact.ImpactScore*100)
-						r.optimizerEvents.RecordNormalEvent(config, "SLAImproved",
-							fmt.Sprintf("SLA health improved by %.1f%%", impact.ImpactScore*100))
-					}
-				}
-			}
-		}
 	}
 
 	config.Status.TotalRecommendations += int64(len(recommendations))
@@ -631,15 +919,17 @@ func (r *Reconciler) exportToGitOps(
 	for _, workloadRec := range recommendations {
 		for _, containerRec := range workloadRec.Containers {
 			gitopsRec := gitops.ResourceRecommendation{
-				Namespace:         workloadRec.Namespace,
-				Name:              workloadRec.WorkloadName,
-				Kind:              workloadRec.WorkloadKind,
-				ContainerName:     containerRec.ContainerName,
-				RecommendedCPU:    containerRec.RecommendedCPU,
-				RecommendedMemory: containerRec.RecommendedMemory,
-				SetLimits:         false, // Could be configurable
-				Confidence:        containerRec.Confidence,
-				Reason:            fmt.Sprintf("P%d CPU, P%d Memory, %d samples", containerRec.CPUPercentile, containerRec.MemoryPercentile, containerRec.SampleCount),
+				Namespace:                   workloadRec.Namespace,
+				Name:                        workloadRec.WorkloadName,
+				Kind:                        workloadRec.WorkloadKind,
+				ContainerName:               containerRec.ContainerName,
+				RecommendedCPU:              containerRec.RecommendedCPU,
+				RecommendedMemory:           containerRec.RecommendedMemory,
+				RecommendedEphemeralStorage: containerRec.RecommendedEphemeralStorage,
+				StartupCPU:                  containerRec.StartupCPU,
+				SetLimits:                   false, // Could be configurable
+				Confidence:                  containerRec.Confidence,
+				Reason:                      fmt.Sprintf("P%d CPU, P%d Memory, %d samples", containerRec.CPUPercentile, containerRec.MemoryPercentile, containerRec.SampleCount),
 			}
 			gitopsRecommendations = append(gitopsRecommendations, gitopsRec)
 		}
@@ -691,19 +981,3 @@ func (r *Reconciler) exportToGitOps(
 
 	return nil
 }
-
-// checkSystemHealth performs SLA health check for the system
-func (r *Reconciler) checkSystemHealth(config *optimizerv1alpha1.OptimizerConfig) (*sla.HealthCheckResult, error) {
-	// Collect recent metrics for SLA evaluation
-	// In a production implementation, you would collect actual latency/error rate metrics
-	// For now, we'll use a simplified approach with CPU metrics as a proxy
-
-	// Return a default healthy result if no metrics available
-	// This prevents blocking optimizations when the system is starting up
-	return &sla.HealthCheckResult{
-		Timestamp: time.Now(),
-		IsHealthy: true,
-		Score:     100.0,
-		Message:   "SLA monitoring active - using default healthy state",
-	}, nil
-}
@@ -2,13 +2,27 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/applier"
+	"intelligent-cluster-optimizer/pkg/cost"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/models"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+	"intelligent-cluster-optimizer/pkg/safety"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestReconciler_DisabledConfig(t *testing.T) {
@@ -71,8 +85,27 @@ func TestReconciler_InitialReconcile(t *testing.T) {
 }
 
 func TestReconciler_CircuitBreakerOpen(t *testing.T) {
-	client := fake.NewSimpleClientset()
-	r := NewReconciler(client, nil)
+	deploy, _ := oomFastPathFixtures(0)
+	client := fake.NewSimpleClientset(deploy)
+	r := NewReconciler(client, record.NewFakeRecorder(100))
+
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		r.metricsStorage.Add(models.PodMetric{
+			PodName:   "api-6d4f9c7b8-abcde",
+			Namespace: "default",
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+			Containers: []models.ContainerMetric{{
+				ContainerName: "app",
+				UsageCPU:      50,
+				UsageMemory:   200 * 1024 * 1024,
+				RequestCPU:    100,
+				RequestMemory: 256 * 1024 * 1024,
+			}},
+		})
+	}
+
+	openedAt, retryAt := metav1.NewTime(now.Add(-time.Minute)), metav1.NewTime(now.Add(4*time.Minute))
 	config := &optimizerv1alpha1.OptimizerConfig{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:       "test-config",
@@ -83,6 +116,7 @@ func TestReconciler_CircuitBreakerOpen(t *testing.T) {
 			Enabled:          true,
 			TargetNamespaces: []string{"default"},
 			Strategy:         optimizerv1alpha1.StrategyBalanced,
+			DryRun:           true,
 			CircuitBreaker: &optimizerv1alpha1.CircuitBreakerConfig{
 				Enabled:          true,
 				ErrorThreshold:   5,
@@ -90,8 +124,19 @@ func TestReconciler_CircuitBreakerOpen(t *testing.T) {
 			},
 		},
 		Status: optimizerv1alpha1.OptimizerConfigStatus{
-			Phase:        optimizerv1alpha1.OptimizerPhaseActive,
-			CircuitState: optimizerv1alpha1.CircuitStateOpen,
+			Phase: optimizerv1alpha1.OptimizerPhaseActive,
+			CircuitBreakers: []optimizerv1alpha1.CircuitBreakerStatus{{
+				Scope:             optimizerv1alpha1.CircuitScopeWorkload,
+				Namespace:         "default",
+				Kind:              "Deployment",
+				Name:              "api",
+				State:             optimizerv1alpha1.CircuitStateOpen,
+				ConsecutiveErrors: 5,
+				Trips:             1,
+				OpenedAt:          &openedAt,
+				RetryAt:           &retryAt,
+				LastError:         "rollout failed",
+			}},
 		},
 	}
 
@@ -104,12 +149,65 @@ func TestReconciler_CircuitBreakerOpen(t *testing.T) {
 		t.Error("Expected status to be updated")
 	}
 
-	if config.Status.Phase != optimizerv1alpha1.OptimizerPhaseCircuitOpen {
-		t.Errorf("Expected phase CircuitOpen, got %s", config.Status.Phase)
+	// An open workload circuit no longer pauses the whole config
+	if config.Status.Phase != optimizerv1alpha1.OptimizerPhaseActive {
+		t.Errorf("Expected phase Active, got %s", config.Status.Phase)
+	}
+
+	if len(config.Status.Decisions) != 1 {
+		t.Fatalf("Expected one decision, got %d", len(config.Status.Decisions))
+	}
+	decision := config.Status.Decisions[0]
+	if decision.Action != optimizerv1alpha1.DecisionSkipped || len(decision.Gates) == 0 || decision.Gates[0].Gate != gateCircuitBreaker {
+		t.Errorf("Expected api to be skipped by the circuit breaker gate, got %s %+v", decision.Action, decision.Gates)
+	}
+
+	if cond := findCondition(config, optimizerv1alpha1.ConditionTypeCircuitBreakerOpen); cond == nil || cond.Status != optimizerv1alpha1.ConditionTrue {
+		t.Errorf("Expected CircuitBreakerOpen condition to be True, got %+v", cond)
 	}
+}
+
+func TestRecordChangeFailure_OpensWorkloadAndNamespaceCircuits(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), record.NewFakeRecorder(100))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			CircuitBreaker: &optimizerv1alpha1.CircuitBreakerConfig{
+				Enabled:                 true,
+				ErrorThreshold:          2,
+				NamespaceErrorThreshold: 3,
+			},
+		},
+	}
+	api := workloadRef{Namespace: "default", Kind: "Deployment", Name: "api"}
+	web := workloadRef{Namespace: "default", Kind: "Deployment", Name: "web"}
 
-	if result.RequeueAfter != 5*time.Minute {
-		t.Errorf("Expected requeue after 5 minutes, got %v", result.RequeueAfter)
+	r.recordChangeFailure(config, api, errors.New("rollout failed"), "LIVE")
+	if allowed, _ := r.checkCircuits(config, api, time.Now(), "LIVE"); !allowed {
+		t.Fatal("Expected api to be allowed below the error threshold")
+	}
+
+	r.recordChangeFailure(config, api, errors.New("rollout failed"), "LIVE")
+	if allowed, _ := r.checkCircuits(config, api, time.Now(), "LIVE"); allowed {
+		t.Error("Expected api circuit to open after 2 failures")
+	}
+	if allowed, _ := r.checkCircuits(config, web, time.Now(), "LIVE"); !allowed {
+		t.Error("Expected web to stay allowed while only api failed")
+	}
+
+	r.recordChangeFailure(config, web, errors.New("canary aborted"), "LIVE")
+	if allowed, message := r.checkCircuits(config, web, time.Now(), "LIVE"); allowed || !strings.Contains(message, "Namespace") {
+		t.Errorf("Expected namespace circuit to open after 3 failures, got allowed=%v %q", allowed, message)
+	}
+
+	// A verified change resets the failures but leaves open circuits open
+	r.recordChangeSuccess(config, web, "LIVE")
+	ns := findCircuit(config, optimizerv1alpha1.CircuitScopeNamespace, web)
+	if ns == nil || ns.State != optimizerv1alpha1.CircuitStateOpen || ns.ConsecutiveErrors != 0 {
+		t.Errorf("Expected namespace circuit to stay open with its errors reset, got %+v", ns)
+	}
+	if findCircuit(config, optimizerv1alpha1.CircuitScopeWorkload, web) != nil {
+		t.Error("Expected closed web circuit without errors to be dropped")
 	}
 }
 
@@ -329,3 +427,1703 @@ func TestReconciler_ProfileOverrideMaxChangePercent(t *testing.T) {
 		t.Errorf("Expected MaxChangePercent 10.0 from override, got %.1f", settings.MaxChangePercent)
 	}
 }
+
+func TestReconciler_TimeProfileScalesRecommendations(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	r := NewReconciler(client, nil)
+
+	// Seven days of night-batch usage: busy from 22:00 to 06:00 UTC
+	now := time.Now()
+	for i := 0; i < 7*24*12; i++ {
+		ts := now.Add(-time.Duration(i) * 5 * time.Minute)
+		cpu := int64(100)
+		if h := ts.UTC().Hour(); h >= 22 || h < 6 {
+			cpu = 900
+		}
+		r.metricsStorage.Add(models.PodMetric{
+			PodName:   "batch-7c9f6d5b8-k2j4x",
+			Namespace: "default",
+			Timestamp: ts,
+			Containers: []models.ContainerMetric{{
+				ContainerName: "worker",
+				UsageCPU:      cpu,
+				UsageMemory:   256 * 1024 * 1024,
+			}},
+		})
+	}
+
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			TimeBasedScaling: &optimizerv1alpha1.TimeBasedScalingConfig{Enabled: true},
+		},
+	}
+	workloadRec := &recommendation.WorkloadRecommendation{
+		Namespace:    "default",
+		WorkloadKind: "Deployment",
+		WorkloadName: "batch",
+		Containers: []recommendation.ContainerRecommendation{{
+			ContainerName:     "worker",
+			CurrentCPU:        1000,
+			CurrentMemory:     1024 * 1024 * 1024,
+			RecommendedCPU:    1000,
+			RecommendedMemory: 512 * 1024 * 1024,
+		}},
+	}
+
+	r.applyTimeProfile(config, workloadRec, "DRY-RUN")
+
+	if len(config.Status.TimeProfiles) != 1 {
+		t.Fatalf("Expected one time profile status entry, got %d", len(config.Status.TimeProfiles))
+	}
+	status := config.Status.TimeProfiles[0]
+	if status.Pattern != "night_batch" {
+		t.Errorf("Expected night_batch pattern, got %s", status.Pattern)
+	}
+	if status.NextSwitch == nil {
+		t.Error("Expected next switch time to be recorded")
+	}
+
+	expectedCPU := map[string]int64{"batch-window": 1500, "daytime": 500}
+	want, ok := expectedCPU[status.Profile]
+	if !ok {
+		t.Fatalf("Expected batch-window or daytime profile, got %s", status.Profile)
+	}
+	if got := workloadRec.Containers[0].RecommendedCPU; got != want {
+		t.Errorf("Expected CPU scaled to %dm by %s profile, got %dm", want, status.Profile, got)
+	}
+	if len(workloadRec.Containers[0].Reasons) == 0 {
+		t.Error("Expected a reason for the time-of-day adjustment")
+	}
+	if next := nextTimeProfileSwitch(config); next <= 0 || next > 16*time.Hour {
+		t.Errorf("Expected next switch within 16h, got %v", next)
+	}
+}
+
+func TestReconciler_TimeProfileDisabled(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+	config := &optimizerv1alpha1.OptimizerConfig{}
+	workloadRec := &recommendation.WorkloadRecommendation{
+		Namespace:    "default",
+		WorkloadName: "batch",
+		Containers: []recommendation.ContainerRecommendation{{
+			ContainerName:  "worker",
+			RecommendedCPU: 1000,
+		}},
+	}
+
+	r.applyTimeProfile(config, workloadRec, "DRY-RUN")
+
+	if workloadRec.Containers[0].RecommendedCPU != 1000 {
+		t.Errorf("Expected recommendation untouched when disabled, got %dm", workloadRec.Containers[0].RecommendedCPU)
+	}
+	if len(config.Status.TimeProfiles) != 0 {
+		t.Errorf("Expected no time profile status when disabled, got %d", len(config.Status.TimeProfiles))
+	}
+}
+
+func leakingMetrics(podName string, growthPerHour int64) []models.PodMetric {
+	now := time.Now()
+	var metrics []models.PodMetric
+	for i := 0; i < 12*12; i++ {
+		ts := now.Add(-12 * time.Hour).Add(time.Duration(i) * 5 * time.Minute)
+		metrics = append(metrics, models.PodMetric{
+			PodName:   podName,
+			Namespace: "default",
+			Timestamp: ts,
+			Containers: []models.ContainerMetric{{
+				ContainerName: "app",
+				UsageMemory:   256*1024*1024 + int64(float64(growthPerHour)*float64(i)/12),
+				LimitMemory:   2 * 1024 * 1024 * 1024,
+			}},
+		})
+	}
+	return metrics
+}
+
+func TestReconciler_MemoryLeakBlocksReduction(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+	config := &optimizerv1alpha1.OptimizerConfig{}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadName: "api"}
+	containerRec := &recommendation.ContainerRecommendation{
+		ContainerName:     "app",
+		CurrentMemory:     1024 * 1024 * 1024,
+		RecommendedMemory: 512 * 1024 * 1024,
+	}
+
+	leak := r.guardMemoryLeak(config, workloadRec, containerRec, leakingMetrics("api-6d4f9c7b8-abcde", 40*1024*1024), "DRY-RUN")
+	if leak == nil {
+		t.Fatal("Expected leak to be detected for steadily growing memory")
+	}
+	if containerRec.RecommendedMemory != containerRec.CurrentMemory {
+		t.Errorf("Expected memory reduction to be blocked, got recommended %d", containerRec.RecommendedMemory)
+	}
+	if leak.TimeToLimit == "" {
+		t.Error("Expected projected time to limit")
+	}
+
+	r.updateMemoryLeakStatus(config, []optimizerv1alpha1.MemoryLeakStatus{*leak})
+	if len(config.Status.MemoryLeaks) != 1 {
+		t.Errorf("Expected leak in status, got %d", len(config.Status.MemoryLeaks))
+	}
+	found := false
+	for _, cond := range config.Status.Conditions {
+		if cond.Type == optimizerv1alpha1.ConditionTypeMemoryLeakSuspected && cond.Status == optimizerv1alpha1.ConditionTrue {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected MemoryLeakSuspected condition to be True")
+	}
+}
+
+func TestReconciler_MemoryLeakEventReportedOnce(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := NewReconciler(fake.NewSimpleClientset(), recorder)
+	config := &optimizerv1alpha1.OptimizerConfig{ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"}}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api"}
+	guard := func(growth int64) {
+		containerRec := &recommendation.ContainerRecommendation{
+			ContainerName: "app", CurrentMemory: 1024 * 1024 * 1024, RecommendedMemory: 512 * 1024 * 1024,
+		}
+		r.guardMemoryLeak(config, workloadRec, containerRec, leakingMetrics("api-6d4f9c7b8-abcde", growth), "DRY-RUN")
+	}
+
+	// The slope differs on every reconcile, but severity and blocking do not
+	for i := int64(0); i < 3; i++ {
+		guard((40 + i) * 1024 * 1024)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected one MemoryLeakSuspected event over three reconciles, got %d", len(recorder.Events))
+	}
+	<-recorder.Events
+
+	// A leak that goes away is reported again when it returns
+	guard(0)
+	guard(40 * 1024 * 1024)
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected the returning leak to be reported again, got %d events", len(recorder.Events))
+	}
+}
+
+func TestReconciler_SlowMemoryLeakDoesNotBlockReduction(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+	config := &optimizerv1alpha1.OptimizerConfig{}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadName: "api"}
+	containerRec := &recommendation.ContainerRecommendation{
+		ContainerName:     "app",
+		CurrentMemory:     1024 * 1024 * 1024,
+		RecommendedMemory: 512 * 1024 * 1024,
+	}
+
+	leak := r.guardMemoryLeak(config, workloadRec, containerRec, leakingMetrics("api-6d4f9c7b8-abcde", 4*1024*1024), "DRY-RUN")
+	if leak == nil {
+		t.Fatal("Expected slow leak to be reported")
+	}
+	if leak.Severity != "low" {
+		t.Fatalf("Expected low severity, got %s", leak.Severity)
+	}
+	if containerRec.RecommendedMemory != 512*1024*1024 {
+		t.Errorf("Expected a low-severity leak not to block the reduction, got recommended %d", containerRec.RecommendedMemory)
+	}
+}
+
+func TestReconciler_MemoryLeakAllowsStableReduction(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+	config := &optimizerv1alpha1.OptimizerConfig{}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadName: "api"}
+	containerRec := &recommendation.ContainerRecommendation{
+		ContainerName:     "app",
+		CurrentMemory:     1024 * 1024 * 1024,
+		RecommendedMemory: 512 * 1024 * 1024,
+	}
+
+	if leak := r.guardMemoryLeak(config, workloadRec, containerRec, leakingMetrics("api-6d4f9c7b8-abcde", 0), "DRY-RUN"); leak != nil {
+		t.Errorf("Expected no leak for flat memory, got %+v", leak)
+	}
+	if containerRec.RecommendedMemory != 512*1024*1024 {
+		t.Errorf("Expected reduction to proceed, got recommended %d", containerRec.RecommendedMemory)
+	}
+}
+
+func TestReconciler_ScanForOOMs(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-6d4f9c7b8-abcde", Namespace: "default"},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "app",
+				RestartCount: 2,
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						Reason:     "OOMKilled",
+						FinishedAt: metav1.NewTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			}},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := NewReconciler(fake.NewSimpleClientset(pod), recorder)
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{TargetNamespaces: []string{"default"}},
+	}
+
+	r.scanForOOMs(context.Background(), config, "DRY-RUN")
+
+	info := r.oomProvider.GetOOMHistory("default", "api")
+	if info == nil || info.ContainerOOMs["app"].OOMCount != 2 {
+		t.Fatalf("Expected OOM history for api/app, got %+v", info)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected one OOMDetected event, got %d", len(recorder.Events))
+	}
+
+	// A second scan within the interval is skipped and already reported OOMs are not re-announced
+	r.scanForOOMs(context.Background(), config, "DRY-RUN")
+	r.SetOOMScanInterval(0)
+	r.scanForOOMs(context.Background(), config, "DRY-RUN")
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected no duplicate OOMDetected events, got %d", len(recorder.Events))
+	}
+}
+
+func oomFastPathFixtures(restarts int32) (*appsv1.Deployment, *corev1.Pod) {
+	replicas := int32(1)
+	controller := true
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("100m"),
+						corev1.ResourceMemory: resource.MustParse("256Mi"),
+					},
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+				},
+			}}}},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api-6d4f9c7b8-abcde",
+			Namespace: "default",
+			Labels:    map[string]string{"pod-template-hash": "6d4f9c7b8"},
+			OwnerReferences: []metav1.OwnerReference{{
+				Kind: "ReplicaSet", Name: "api-6d4f9c7b8", Controller: &controller,
+			}},
+		},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:         "app",
+			RestartCount: restarts,
+			LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled"},
+			},
+		}}},
+	}
+	return deploy, pod
+}
+
+// closedMaintenanceWindow returns a one-hour daily window starting twelve hours from now
+func closedMaintenanceWindow() []optimizerv1alpha1.MaintenanceWindow {
+	hour := (time.Now().UTC().Hour() + 12) % 24
+	return []optimizerv1alpha1.MaintenanceWindow{{Schedule: fmt.Sprintf("0 %d * * *", hour), Duration: "1h"}}
+}
+
+func TestReconciler_HandleOOMKillBumpsMemory(t *testing.T) {
+	deploy, pod := oomFastPathFixtures(3)
+	client := fake.NewSimpleClientset(deploy)
+	recorder := record.NewFakeRecorder(10)
+	r := NewReconciler(client, recorder)
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:            true,
+			TargetNamespaces:   []string{"default"},
+			MaintenanceWindows: closedMaintenanceWindow(),
+			ResourceThresholds: &optimizerv1alpha1.ResourceThresholds{
+				Memory: &optimizerv1alpha1.ResourceLimit{Max: "320Mi"},
+			},
+			OOMFastPath: &optimizerv1alpha1.OOMFastPathConfig{
+				Enabled:                       true,
+				AllowOutsideMaintenanceWindow: true,
+			},
+		},
+	}
+
+	if err := r.HandleOOMKill(context.Background(), config, pod, pod.Status.ContainerStatuses[0]); err != nil {
+		t.Fatalf("HandleOOMKill failed: %v", err)
+	}
+
+	updated, err := client.AppsV1().Deployments("default").Get(context.Background(), "api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	resources := updated.Spec.Template.Spec.Containers[0].Resources
+	// 3 restarts boost memory by 1.5x (384Mi), capped by the 320Mi threshold
+	if got := resources.Requests.Memory().String(); got != "320Mi" {
+		t.Errorf("Expected memory request 320Mi, got %s", got)
+	}
+	if got := resources.Limits.Memory().String(); got != "320Mi" {
+		t.Errorf("Expected memory limit 320Mi, got %s", got)
+	}
+	if got := resources.Requests.Cpu().String(); got != "100m" {
+		t.Errorf("Expected CPU request to be unchanged, got %s", got)
+	}
+	// The bump is tracked like any other change so it is verified and can be rolled back
+	if len(config.Status.Rollouts) != 1 || config.Status.Rollouts[0].Name != "api" ||
+		len(config.Status.Rollouts[0].Containers) != 1 || config.Status.Rollouts[0].Containers[0] != "app" {
+		t.Errorf("Expected the bump to be tracked as a rollout of api/app, got %+v", config.Status.Rollouts)
+	}
+
+	// A second OOM within the interval is rate limited
+	events := len(recorder.Events)
+	if err := r.HandleOOMKill(context.Background(), config, pod, pod.Status.ContainerStatuses[0]); err != nil {
+		t.Fatalf("HandleOOMKill failed: %v", err)
+	}
+	if len(recorder.Events) != events {
+		t.Errorf("Expected rate-limited OOM kill to record no events, got %d new", len(recorder.Events)-events)
+	}
+}
+
+func TestReconciler_HandleOOMKillRespectsMaintenanceWindow(t *testing.T) {
+	deploy, pod := oomFastPathFixtures(3)
+	client := fake.NewSimpleClientset(deploy)
+	r := NewReconciler(client, record.NewFakeRecorder(10))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:            true,
+			TargetNamespaces:   []string{"default"},
+			MaintenanceWindows: closedMaintenanceWindow(),
+			OOMFastPath:        &optimizerv1alpha1.OOMFastPathConfig{Enabled: true},
+		},
+	}
+
+	if err := r.HandleOOMKill(context.Background(), config, pod, pod.Status.ContainerStatuses[0]); err != nil {
+		t.Fatalf("HandleOOMKill failed: %v", err)
+	}
+
+	updated, _ := client.AppsV1().Deployments("default").Get(context.Background(), "api", metav1.GetOptions{})
+	if got := updated.Spec.Template.Spec.Containers[0].Resources.Requests.Memory().String(); got != "256Mi" {
+		t.Errorf("Expected memory to stay at 256Mi outside the maintenance window, got %s", got)
+	}
+}
+
+func TestReconciler_HandleOOMKillHonoursSafetyGates(t *testing.T) {
+	now := time.Now()
+	retryAt := metav1.NewTime(now.Add(time.Hour))
+	until := metav1.NewTime(now.Add(time.Hour))
+
+	tests := []struct {
+		name    string
+		want    string
+		objects []runtime.Object
+		setup   func(r *Reconciler, config *optimizerv1alpha1.OptimizerConfig)
+	}{
+		{
+			name: "quarantined workload",
+			want: "quarantined",
+			setup: func(r *Reconciler, config *optimizerv1alpha1.OptimizerConfig) {
+				config.Status.Quarantined = []optimizerv1alpha1.QuarantinedWorkload{{
+					Namespace: "default", Kind: "Deployment", Name: "api",
+					Reason: "error rate rose", RolledBackAt: metav1.NewTime(now), Until: until,
+				}}
+			},
+		},
+		{
+			name: "open workload circuit",
+			want: "circuit open",
+			setup: func(r *Reconciler, config *optimizerv1alpha1.OptimizerConfig) {
+				config.Spec.CircuitBreaker = &optimizerv1alpha1.CircuitBreakerConfig{Enabled: true}
+				config.Status.CircuitBreakers = []optimizerv1alpha1.CircuitBreakerStatus{{
+					Scope: optimizerv1alpha1.CircuitScopeWorkload, Namespace: "default", Kind: "Deployment", Name: "api",
+					State: optimizerv1alpha1.CircuitStateOpen, ConsecutiveErrors: 5, Trips: 1, RetryAt: &retryAt,
+				}}
+			},
+		},
+		{
+			name: "exhausted change budget",
+			want: "change budget",
+			setup: func(r *Reconciler, config *optimizerv1alpha1.OptimizerConfig) {
+				budget := safety.NewChangeBudget(safety.BudgetLimits{MaxChangesPerHour: 1}, safety.BudgetLimits{})
+				budget.Acquire("other/config", safety.BudgetWorkload{Namespace: "default", Kind: "Deployment", Name: "web"}, safety.BudgetRank{}, now)
+				r.SetChangeBudget(budget, BudgetPrioritySavings)
+			},
+		},
+		{
+			name: "rollout in flight",
+			want: "earlier changes",
+			setup: func(r *Reconciler, config *optimizerv1alpha1.OptimizerConfig) {
+				config.Status.Rollouts = []optimizerv1alpha1.WorkloadRollout{{
+					Namespace: "default", Kind: "Deployment", Name: "api",
+					Phase: optimizerv1alpha1.RolloutPhaseProgressing, Message: "rolling out", StartedAt: metav1.NewTime(now),
+				}}
+			},
+		},
+		{
+			name: "quota blocks the bump",
+			want: "ResourceQuota",
+			objects: []runtime.Object{&corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+				Status: corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("300Mi")},
+					Used: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("256Mi")},
+				},
+			}},
+			setup: func(r *Reconciler, config *optimizerv1alpha1.OptimizerConfig) {
+				config.Spec.QuotaAwareness = &optimizerv1alpha1.QuotaAwareness{Enabled: true, Policy: optimizerv1alpha1.QuotaPolicyBlock}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deploy, pod := oomFastPathFixtures(3)
+			client := fake.NewSimpleClientset(append(tt.objects, deploy)...)
+			recorder := record.NewFakeRecorder(10)
+			r := NewReconciler(client, recorder)
+			config := &optimizerv1alpha1.OptimizerConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "opt", Namespace: "default"},
+				Spec: optimizerv1alpha1.OptimizerConfigSpec{
+					Enabled:          true,
+					TargetNamespaces: []string{"default"},
+					OOMFastPath:      &optimizerv1alpha1.OOMFastPathConfig{Enabled: true},
+				},
+			}
+			tt.setup(r, config)
+			rollouts := len(config.Status.Rollouts)
+
+			if err := r.HandleOOMKill(context.Background(), config, pod, pod.Status.ContainerStatuses[0]); err != nil {
+				t.Fatalf("HandleOOMKill failed: %v", err)
+			}
+
+			updated, _ := client.AppsV1().Deployments("default").Get(context.Background(), "api", metav1.GetOptions{})
+			if got := updated.Spec.Template.Spec.Containers[0].Resources.Requests.Memory().String(); got != "256Mi" {
+				t.Errorf("Expected memory to stay at 256Mi, got %s", got)
+			}
+			if len(config.Status.Rollouts) != rollouts {
+				t.Errorf("Expected no rollout to be tracked, got %+v", config.Status.Rollouts)
+			}
+			var skipped []string
+			for len(recorder.Events) > 0 {
+				if event := <-recorder.Events; strings.Contains(event, events.ReasonOOMFastPathSkipped) {
+					skipped = append(skipped, event)
+				}
+			}
+			if len(skipped) != 1 || !strings.Contains(skipped[0], tt.want) {
+				t.Errorf("Expected an OOMFastPathSkipped event mentioning %q, got %q", tt.want, skipped)
+			}
+		})
+	}
+}
+
+func TestMergeOOMFastPathStatus(t *testing.T) {
+	now := metav1.Now()
+	original := &optimizerv1alpha1.OptimizerConfig{Status: optimizerv1alpha1.OptimizerConfigStatus{
+		CircuitBreakers: []optimizerv1alpha1.CircuitBreakerStatus{
+			{Scope: optimizerv1alpha1.CircuitScopeNamespace, Namespace: "default", State: optimizerv1alpha1.CircuitStateClosed, ConsecutiveErrors: 1},
+			{Scope: optimizerv1alpha1.CircuitScopeWorkload, Namespace: "default", Kind: "Deployment", Name: "web", State: optimizerv1alpha1.CircuitStateClosed, ConsecutiveErrors: 1},
+		},
+	}}
+	updated := original.DeepCopy()
+	updated.Status.Rollouts = []optimizerv1alpha1.WorkloadRollout{{Namespace: "default", Kind: "Deployment", Name: "api", StartedAt: now}}
+	updated.Status.CircuitBreakers[0].ConsecutiveErrors = 2
+	updated.Status.CircuitBreakers = append(updated.Status.CircuitBreakers, optimizerv1alpha1.CircuitBreakerStatus{
+		Scope: optimizerv1alpha1.CircuitScopeWorkload, Namespace: "default", Kind: "Deployment", Name: "api",
+		State: optimizerv1alpha1.CircuitStateClosed, ConsecutiveErrors: 1,
+	})
+
+	// A reconcile has meanwhile started a rollout of web and reset its circuit
+	latest := original.DeepCopy()
+	latest.Status.Rollouts = []optimizerv1alpha1.WorkloadRollout{{Namespace: "default", Kind: "Deployment", Name: "web", StartedAt: now}}
+	latest.Status.CircuitBreakers = latest.Status.CircuitBreakers[:1]
+
+	mergeOOMFastPathStatus(latest, original, updated)
+
+	if len(latest.Status.Rollouts) != 2 || latest.Status.Rollouts[1].Name != "api" {
+		t.Errorf("Expected the fast-path rollout to be added next to web's, got %+v", latest.Status.Rollouts)
+	}
+	if len(latest.Status.CircuitBreakers) != 2 {
+		t.Fatalf("Expected the namespace and api circuits, got %+v", latest.Status.CircuitBreakers)
+	}
+	if latest.Status.CircuitBreakers[0].ConsecutiveErrors != 2 {
+		t.Errorf("Expected the namespace circuit to carry the fast-path failure, got %d errors", latest.Status.CircuitBreakers[0].ConsecutiveErrors)
+	}
+	if latest.Status.CircuitBreakers[1].Name != "api" {
+		t.Errorf("Expected the api circuit to be added, got %+v", latest.Status.CircuitBreakers[1])
+	}
+}
+
+func TestFastPathNamespaces(t *testing.T) {
+	configs := []interface{}{
+		&optimizerv1alpha1.OptimizerConfig{Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled: true, TargetNamespaces: []string{"payments", "orders"},
+			OOMFastPath: &optimizerv1alpha1.OOMFastPathConfig{Enabled: true},
+		}},
+		&optimizerv1alpha1.OptimizerConfig{Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled: true, TargetNamespaces: []string{"batch"},
+		}},
+		&optimizerv1alpha1.OptimizerConfig{Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled: false, TargetNamespaces: []string{"legacy"},
+			OOMFastPath: &optimizerv1alpha1.OOMFastPathConfig{Enabled: true},
+		}},
+	}
+
+	namespaces := fastPathNamespaces(configs)
+	if len(namespaces) != 2 || !namespaces["payments"] || !namespaces["orders"] {
+		t.Errorf("Expected pods watched only in payments and orders, got %v", namespaces)
+	}
+}
+
+func TestNewOOMKills(t *testing.T) {
+	_, oldPod := oomFastPathFixtures(2)
+	newPod := oldPod.DeepCopy()
+	if kills := newOOMKills(oldPod, newPod); len(kills) != 0 {
+		t.Errorf("Expected no new OOM kills without a restart, got %d", len(kills))
+	}
+
+	newPod.Status.ContainerStatuses[0].RestartCount = 3
+	if kills := newOOMKills(oldPod, newPod); len(kills) != 1 || kills[0].Name != "app" {
+		t.Errorf("Expected a new OOM kill for app, got %+v", kills)
+	}
+
+	kind, name, ok := podWorkload(newPod)
+	if !ok || kind != "Deployment" || name != "api" {
+		t.Errorf("Expected Deployment api, got %s %s (%v)", kind, name, ok)
+	}
+}
+
+func TestReconciler_CheckNodeFitClampsRaise(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("2Gi"),
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	r := NewReconciler(fake.NewSimpleClientset(deploy, node), record.NewFakeRecorder(10))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			NodeFitAwareness: &optimizerv1alpha1.NodeFitAwareness{Enabled: true},
+		},
+	}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api"}
+	containerRec := &recommendation.ContainerRecommendation{
+		ContainerName:     "app",
+		CurrentCPU:        100,
+		RecommendedCPU:    200,
+		CurrentMemory:     256 * 1024 * 1024,
+		RecommendedMemory: 8 * 1024 * 1024 * 1024,
+	}
+
+	// The check is opt-in
+	unchecked := *containerRec
+	if !r.checkNodeFit(context.Background(), &optimizerv1alpha1.OptimizerConfig{}, workloadRec, &unchecked, "DRY-RUN") ||
+		unchecked.RecommendedMemory != containerRec.RecommendedMemory {
+		t.Errorf("Expected no node fit check without nodeFitAwareness, got memory %d", unchecked.RecommendedMemory)
+	}
+
+	if !r.checkNodeFit(context.Background(), config, workloadRec, containerRec, "DRY-RUN") {
+		t.Fatal("Expected the Clamp policy to keep the container")
+	}
+	if containerRec.RecommendedMemory != 2*1024*1024*1024 || containerRec.RecommendedCPU != 200 {
+		t.Errorf("Expected memory clamped to 2Gi and CPU unchanged, got cpu=%d memory=%d",
+			containerRec.RecommendedCPU, containerRec.RecommendedMemory)
+	}
+
+	config.Spec.NodeFitAwareness = &optimizerv1alpha1.NodeFitAwareness{Enabled: true, Policy: optimizerv1alpha1.NodeFitPolicyBlock}
+	containerRec.RecommendedMemory = 8 * 1024 * 1024 * 1024
+	if r.checkNodeFit(context.Background(), config, workloadRec, containerRec, "DRY-RUN") {
+		t.Error("Expected the Block policy to skip the container")
+	}
+}
+
+func TestReconciler_SimulatePackingUpdatesStatus(t *testing.T) {
+	controller := true
+	var objects []runtime.Object
+	for i := 1; i <= 2; i++ {
+		objects = append(objects,
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)},
+				Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("8Gi"),
+				}},
+			},
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            fmt.Sprintf("db-%d", i-1),
+					Namespace:       "default",
+					OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &controller}},
+				},
+				Spec: corev1.PodSpec{
+					NodeName: fmt.Sprintf("node-%d", i),
+					Containers: []corev1.Container{{
+						Name: "db",
+						Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("3"),
+							corev1.ResourceMemory: resource.MustParse("2Gi"),
+						}},
+					}},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			})
+	}
+	r := NewReconciler(fake.NewSimpleClientset(objects...), record.NewFakeRecorder(10))
+	config := &optimizerv1alpha1.OptimizerConfig{}
+	recs := []recommendation.WorkloadRecommendation{{
+		Namespace: "default", WorkloadKind: "StatefulSet", WorkloadName: "db",
+		Containers: []recommendation.ContainerRecommendation{{
+			ContainerName: "db", RecommendedCPU: 500, RecommendedMemory: 1024 * 1024 * 1024,
+		}},
+	}}
+
+	r.simulatePacking(context.Background(), config, recs, "DRY-RUN")
+
+	status := config.Status.PackingSimulation
+	if status == nil {
+		t.Fatal("Expected packing simulation status to be set")
+	}
+	if status.Nodes != 2 || status.NodesAfterRepack != 2 || status.ReclaimableNodes != 1 {
+		t.Errorf("Expected 1 of 2 nodes reclaimable, got %+v", status)
+	}
+	if len(status.Pools) != 1 || status.Pools[0].Pool != "default" {
+		t.Errorf("Expected a single default pool, got %+v", status.Pools)
+	}
+
+	// A fresh result is not recomputed within the interval
+	simulatedAt := status.SimulatedAt
+	r.simulatePacking(context.Background(), config, nil, "DRY-RUN")
+	if !config.Status.PackingSimulation.SimulatedAt.Equal(&simulatedAt) || config.Status.PackingSimulation.ReclaimableNodes != 1 {
+		t.Error("Expected the simulation to be throttled")
+	}
+}
+
+func TestReconciler_ApplyRuntimeMemoryFloorResolvesEnvAndDedupesEvents(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	deploy.Spec.Template.Spec.Containers[0].Env = []corev1.EnvVar{{
+		Name: "JAVA_TOOL_OPTIONS",
+		ValueFrom: &corev1.EnvVarSource{ConfigMapKeyRef: &corev1.ConfigMapKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "jvm"}, Key: "options",
+		}},
+	}}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "jvm", Namespace: "default"},
+		Data:       map[string]string{"options": "-Xmx1g"},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := NewReconciler(fake.NewSimpleClientset(deploy, configMap), recorder)
+	config := &optimizerv1alpha1.OptimizerConfig{ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"}}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api"}
+
+	containers := r.workloadContainers(context.Background(), workloadRec, "DRY-RUN")
+	for i := 0; i < 2; i++ {
+		containerRec := &recommendation.ContainerRecommendation{
+			ContainerName:     "app",
+			CurrentMemory:     2048 * 1024 * 1024,
+			RecommendedMemory: 600 * 1024 * 1024,
+		}
+		r.applyRuntimeMemoryFloor(config, workloadRec, containerRec, containers["app"], "DRY-RUN")
+		if containerRec.RecommendedMemory != 1280*1024*1024 {
+			t.Fatalf("Expected the -Xmx1g from the ConfigMap to keep memory at 1280Mi, got %d", containerRec.RecommendedMemory)
+		}
+	}
+
+	// The floor and the oversized-heap advice are reported once, not on every reconcile
+	if len(recorder.Events) != 2 {
+		t.Errorf("Expected RuntimeMemoryFloor and RuntimeSettingMismatch once each, got %d events", len(recorder.Events))
+	}
+}
+
+func TestReconciler_CheckQuotaClampsRaise(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("1Gi")},
+			Used: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("256Mi")},
+		},
+	}
+	r := NewReconciler(fake.NewSimpleClientset(deploy, quota), record.NewFakeRecorder(10))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			QuotaAwareness:   &optimizerv1alpha1.QuotaAwareness{Enabled: true},
+		},
+	}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api"}
+	containerRec := &recommendation.ContainerRecommendation{
+		ContainerName:     "app",
+		CurrentCPU:        100,
+		RecommendedCPU:    100,
+		CurrentMemory:     256 * 1024 * 1024,
+		RecommendedMemory: 1024 * 1024 * 1024,
+	}
+
+	// The check is opt-in
+	unchecked := *containerRec
+	if !r.checkQuota(context.Background(), &optimizerv1alpha1.OptimizerConfig{}, workloadRec, &unchecked, nil, "DRY-RUN") ||
+		unchecked.RecommendedMemory != containerRec.RecommendedMemory {
+		t.Errorf("Expected no quota check without quotaAwareness, got memory %d", unchecked.RecommendedMemory)
+	}
+
+	// 768Mi of headroom must hold the resized pod and its surge pod during the rollout
+	if !r.checkQuota(context.Background(), config, workloadRec, containerRec, nil, "DRY-RUN") {
+		t.Fatal("Expected the Clamp policy to keep the container")
+	}
+	if containerRec.RecommendedMemory != 768*1024*1024 {
+		t.Errorf("Expected memory clamped to 768Mi, got %d", containerRec.RecommendedMemory)
+	}
+
+	config.Spec.QuotaAwareness = &optimizerv1alpha1.QuotaAwareness{Enabled: true, Policy: optimizerv1alpha1.QuotaPolicyBlock}
+	containerRec.RecommendedMemory = 1024 * 1024 * 1024
+	if r.checkQuota(context.Background(), config, workloadRec, containerRec, nil, "DRY-RUN") {
+		t.Error("Expected the Block policy to skip the container")
+	}
+}
+
+func TestReconciler_CheckQuotaSharesHeadroomWithinPass(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("1Gi")},
+			Used: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("256Mi")},
+		},
+	}
+	r := NewReconciler(fake.NewSimpleClientset(deploy, quota), record.NewFakeRecorder(10))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			QuotaAwareness:   &optimizerv1alpha1.QuotaAwareness{Enabled: true, Policy: optimizerv1alpha1.QuotaPolicyClamp},
+		},
+	}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api"}
+	raise := func() *recommendation.ContainerRecommendation {
+		return &recommendation.ContainerRecommendation{
+			ContainerName:     "app",
+			CurrentCPU:        100,
+			RecommendedCPU:    100,
+			CurrentMemory:     256 * 1024 * 1024,
+			RecommendedMemory: 512 * 1024 * 1024,
+		}
+	}
+	reserved := safety.QuotaReservations{}
+
+	// The first raise and its surge pod take 512Mi of the 768Mi headroom
+	first := raise()
+	if !r.checkQuota(context.Background(), config, workloadRec, first, reserved, "DRY-RUN") || first.RecommendedMemory != 512*1024*1024 {
+		t.Fatalf("Expected the first raise to fit, got memory %d", first.RecommendedMemory)
+	}
+
+	// The 256Mi left only hold the second change's surge pod
+	second := raise()
+	r.checkQuota(context.Background(), config, workloadRec, second, reserved, "DRY-RUN")
+	if second.RecommendedMemory != 256*1024*1024 {
+		t.Errorf("Expected the second raise to be clamped to the remaining headroom, got memory %d", second.RecommendedMemory)
+	}
+}
+
+func TestReconciler_CheckQuotaEventReportedOnce(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("1Gi")},
+			Used: corev1.ResourceList{corev1.ResourceRequestsMemory: resource.MustParse("256Mi")},
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := NewReconciler(fake.NewSimpleClientset(deploy, quota), recorder)
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			QuotaAwareness:   &optimizerv1alpha1.QuotaAwareness{Enabled: true, Policy: optimizerv1alpha1.QuotaPolicyClamp},
+		},
+	}
+	workloadRec := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api"}
+	check := func(memory int64) {
+		containerRec := &recommendation.ContainerRecommendation{
+			ContainerName: "app", CurrentCPU: 100, RecommendedCPU: 100,
+			CurrentMemory: 256 * 1024 * 1024, RecommendedMemory: memory,
+		}
+		r.checkQuota(context.Background(), config, workloadRec, containerRec, nil, "DRY-RUN")
+	}
+
+	for i := int64(0); i < 3; i++ {
+		check((1024 + i) * 1024 * 1024)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected one QuotaClamped event over three reconciles, got %d", len(recorder.Events))
+	}
+	<-recorder.Events
+
+	// Once the change fits, a later clamp is reported again
+	check(300 * 1024 * 1024)
+	check(1024 * 1024 * 1024)
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected the clamp to be reported again after the change fit, got %d events", len(recorder.Events))
+	}
+}
+
+func TestReconciler_CheckQuotaClampsGuaranteedLimits(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	deploy.Spec.Template.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("100m")
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "default"},
+		Status: corev1.ResourceQuotaStatus{
+			Hard: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("1Gi")},
+			Used: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("256Mi")},
+		},
+	}
+	r := NewReconciler(fake.NewSimpleClientset(deploy, quota), record.NewFakeRecorder(10))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			QuotaAwareness:   &optimizerv1alpha1.QuotaAwareness{Enabled: true},
+		},
+	}
+	workloadRec := &recommendation.WorkloadRecommendation{
+		Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api", QoSClass: corev1.PodQOSGuaranteed,
+	}
+	containerRec := &recommendation.ContainerRecommendation{
+		ContainerName:      "app",
+		CurrentCPU:         100,
+		RecommendedCPU:     100,
+		CurrentMemory:      256 * 1024 * 1024,
+		RecommendedMemory:  1024 * 1024 * 1024,
+		CurrentCPULimit:    100,
+		CurrentMemoryLimit: 256 * 1024 * 1024,
+	}
+
+	// The requests alone fit; the limit moved by the QoS gate must fit limits.memory as well
+	if !r.preserveQoS(config, workloadRec, containerRec, "DRY-RUN") {
+		t.Fatal("Expected a Guaranteed workload to be kept")
+	}
+	if !r.checkQuota(context.Background(), config, workloadRec, containerRec, nil, "DRY-RUN") {
+		t.Fatal("Expected the Clamp policy to keep the container")
+	}
+	if containerRec.RecommendedMemory != 768*1024*1024 || containerRec.RecommendedMemoryLimit != 768*1024*1024 {
+		t.Errorf("Expected memory request and limit clamped to 768Mi, got request=%d limit=%d",
+			containerRec.RecommendedMemory, containerRec.RecommendedMemoryLimit)
+	}
+}
+
+func TestReconciler_PreserveQoSBestEffortReportedOnce(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	r := NewReconciler(fake.NewSimpleClientset(), recorder)
+	config := &optimizerv1alpha1.OptimizerConfig{ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"}}
+	workloadRec := &recommendation.WorkloadRecommendation{
+		Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "batch", QoSClass: corev1.PodQOSBestEffort,
+	}
+
+	for i := 0; i < 3; i++ {
+		if r.preserveQoS(config, workloadRec, &recommendation.ContainerRecommendation{ContainerName: "worker"}, "DRY-RUN") {
+			t.Fatal("Expected a BestEffort workload to be skipped")
+		}
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected one QoSClassChangeBlocked event over three reconciles, got %d", len(recorder.Events))
+	}
+}
+
+func TestReconciler_PreserveQoSMovesGuaranteedLimits(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	deploy.Spec.Template.Spec.Containers[0].Resources.Limits[corev1.ResourceCPU] = resource.MustParse("100m")
+	r := NewReconciler(fake.NewSimpleClientset(deploy), record.NewFakeRecorder(10))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{TargetNamespaces: []string{"default"}},
+	}
+	workloadRec := &recommendation.WorkloadRecommendation{
+		Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api", QoSClass: corev1.PodQOSGuaranteed,
+	}
+	containerRec := &recommendation.ContainerRecommendation{
+		ContainerName:      "app",
+		CurrentCPU:         100,
+		RecommendedCPU:     200,
+		CurrentMemory:      256 * 1024 * 1024,
+		RecommendedMemory:  512 * 1024 * 1024,
+		CurrentCPULimit:    100,
+		CurrentMemoryLimit: 256 * 1024 * 1024,
+	}
+
+	if !r.preserveQoS(config, workloadRec, containerRec, "DRY-RUN") {
+		t.Fatal("Expected a Guaranteed workload to be kept")
+	}
+	if containerRec.RecommendedCPULimit != 200 || containerRec.RecommendedMemoryLimit != 512*1024*1024 {
+		t.Errorf("Expected limits to follow the requests, got cpu=%dm memory=%d",
+			containerRec.RecommendedCPULimit, containerRec.RecommendedMemoryLimit)
+	}
+
+	// Moving only the requests is called out in the dry-run diff
+	rec := &applier.ResourceRecommendation{
+		Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api", ContainerName: "app",
+		CurrentCPU: "100m", RecommendedCPU: "200m", CurrentMemory: "256Mi", RecommendedMemory: "512Mi",
+		PreserveQoS: true,
+	}
+	result, err := r.applier.Apply(context.Background(), rec, true)
+	if err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if !result.QoSClassChanged() || result.NewQoSClass != corev1.PodQOSBurstable {
+		t.Errorf("Expected the dry run to report Guaranteed -> Burstable, got %s -> %s", result.QoSClass, result.NewQoSClass)
+	}
+	if _, err := r.applier.Apply(context.Background(), rec, false); !errors.Is(err, applier.ErrQoSClassChange) {
+		t.Errorf("Expected the live apply to refuse the class change, got %v", err)
+	}
+
+	// BestEffort workloads are skipped unless class changes are allowed
+	workloadRec.QoSClass = corev1.PodQOSBestEffort
+	if r.preserveQoS(config, workloadRec, containerRec, "DRY-RUN") {
+		t.Error("Expected a BestEffort workload to be skipped")
+	}
+	config.Spec.AllowQoSClassChange = true
+	if !r.preserveQoS(config, workloadRec, containerRec, "DRY-RUN") {
+		t.Error("Expected allowQoSClassChange to keep the workload")
+	}
+}
+
+func TestReconciler_ObserveMarginOutcomesWidensAfterOOM(t *testing.T) {
+	applied := time.Now().Add(-time.Hour)
+	deploy, _ := oomFastPathFixtures(0)
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-6d4f9c7b8-abcde", Namespace: "default", Labels: map[string]string{"app": "api"}},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "app",
+				RestartCount: 1,
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						Reason:     "OOMKilled",
+						FinishedAt: metav1.NewTime(applied.Add(30 * time.Minute)),
+					},
+				},
+			}},
+		},
+	}
+	// Pods of other workloads are not counted, even when their names look alike
+	other := pod.DeepCopy()
+	other.Name = "api-worker-7c5d8b9f6-fghij"
+	other.Labels = map[string]string{"app": "api-worker"}
+	recorder := record.NewFakeRecorder(10)
+	client := fake.NewSimpleClientset(deploy, pod, other)
+	r := NewReconciler(client, recorder)
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			MarginTuning:     &optimizerv1alpha1.MarginTuningConfig{Enabled: true, Step: 0.05},
+		},
+	}
+	policy := marginPolicy(config, nil)
+
+	// An OOM kill before the change was applied is not the change's fault
+	outcome, err := r.measureOutcome(context.Background(), "default", "Deployment", "api", applied.Add(time.Hour))
+	if err != nil || outcome.IsIncident() {
+		t.Fatalf("Expected no incident after the OOM kill, got %+v (err=%v)", outcome, err)
+	}
+
+	r.marginTuner.RecordApplied(config, "default", "Deployment", "api", 1.2, applied)
+	r.observeMarginOutcomes(context.Background(), config, policy, "LIVE")
+
+	learned := config.Status.LearnedMargins
+	if len(learned) != 1 || learned[0].SafetyMargin != 1.3 || learned[0].LastOutcome != "1 OOM kill" {
+		t.Fatalf("Expected the margin to widen to 1.30 after the OOM kill, got %+v", learned)
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected one SafetyMarginWidened event, got %d", len(recorder.Events))
+	}
+
+	// The same OOM kill does not widen the margin twice
+	r.observeMarginOutcomes(context.Background(), config, policy, "LIVE")
+	if config.Status.LearnedMargins[0].SafetyMargin != 1.3 {
+		t.Errorf("Expected the margin to stay at 1.30, got %.2f", config.Status.LearnedMargins[0].SafetyMargin)
+	}
+
+	// The learned margin of a deleted workload is dropped
+	if err := client.AppsV1().Deployments("default").Delete(context.Background(), "api", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete deployment: %v", err)
+	}
+	r.observeMarginOutcomes(context.Background(), config, policy, "LIVE")
+	if len(config.Status.LearnedMargins) != 0 {
+		t.Errorf("Expected the learned margin to be dropped, got %+v", config.Status.LearnedMargins)
+	}
+}
+
+func TestReconciler_ProcessRecommendationsRecordsDecisionTrace(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	r := NewReconciler(fake.NewSimpleClientset(deploy), record.NewFakeRecorder(100))
+
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		r.metricsStorage.Add(models.PodMetric{
+			PodName:   "api-6d4f9c7b8-abcde",
+			Namespace: "default",
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+			Containers: []models.ContainerMetric{{
+				ContainerName: "app",
+				UsageCPU:      500,
+				UsageMemory:   200 * 1024 * 1024,
+				RequestCPU:    100,
+				RequestMemory: 256 * 1024 * 1024,
+			}},
+		})
+	}
+
+	// The production profile allows at most a 20% change, far less than 100m -> 600m
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:          true,
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+			Profile:          optimizerv1alpha1.ProfileProduction,
+			DryRun:           true,
+		},
+	}
+	if err := r.processRecommendations(context.Background(), config, "DRY-RUN"); err != nil {
+		t.Fatalf("processRecommendations failed: %v", err)
+	}
+
+	if len(config.Status.Decisions) != 1 {
+		t.Fatalf("Expected one decision, got %d", len(config.Status.Decisions))
+	}
+	decision := config.Status.Decisions[0]
+	if decision.Name != "api" || decision.Action != optimizerv1alpha1.DecisionSkipped {
+		t.Errorf("Expected api to be skipped, got %s %s (%s)", decision.Name, decision.Action, decision.Reason)
+	}
+	if len(decision.Containers) != 1 {
+		t.Fatalf("Expected one container decision, got %d", len(decision.Containers))
+	}
+
+	c := decision.Containers[0]
+	if c.CPUUsage != "500m" || c.SafetyMargin == 0 || c.SampleCount == 0 || c.CurrentCPU != "100m" || c.RecommendationID == "" {
+		t.Errorf("Expected the engine inputs to be recorded, got %+v", c)
+	}
+	if len(c.Gates) == 0 {
+		t.Fatal("Expected gate verdicts to be recorded")
+	}
+	last := c.Gates[len(c.Gates)-1]
+	if last.Gate != gateMaxChangePercent || last.Result != optimizerv1alpha1.GateBlocked {
+		t.Errorf("Expected MaxChangePercent to block last, got %+v", last)
+	}
+	if c.Action != optimizerv1alpha1.DecisionSkipped || c.Reason == "" {
+		t.Errorf("Expected a skipped container with a reason, got %s (%q)", c.Action, c.Reason)
+	}
+}
+
+func TestTraceGate(t *testing.T) {
+	before := recommendation.ContainerRecommendation{ContainerName: "app", RecommendedMemory: 512}
+	var decision optimizerv1alpha1.ContainerDecision
+
+	after := before
+	traceGate(&decision, gateQuota, before, &after, true)
+
+	after.RecommendedMemory = 256
+	after.Reasons = append(after.Reasons, "memory clamped to quota")
+	traceGate(&decision, gateNodeFit, before, &after, true)
+
+	traceGate(&decision, gateQoS, after, &after, false)
+
+	expected := []optimizerv1alpha1.GateVerdict{
+		{Gate: gateQuota, Result: optimizerv1alpha1.GatePassed},
+		{Gate: gateNodeFit, Result: optimizerv1alpha1.GateAdjusted, Message: "memory clamped to quota"},
+		{Gate: gateQoS, Result: optimizerv1alpha1.GateBlocked, Message: blockedMessages[gateQoS]},
+	}
+	if len(decision.Gates) != len(expected) {
+		t.Fatalf("Expected %d verdicts, got %+v", len(expected), decision.Gates)
+	}
+	for i, want := range expected {
+		if decision.Gates[i] != want {
+			t.Errorf("Verdict %d: expected %+v, got %+v", i, want, decision.Gates[i])
+		}
+	}
+	if decision.Action != optimizerv1alpha1.DecisionSkipped {
+		t.Errorf("Expected a blocked gate to skip the container, got %s", decision.Action)
+	}
+}
+
+func TestReconciler_RollbackDegradedRestoresAndQuarantines(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	client := fake.NewSimpleClientset(deploy)
+	r := NewReconciler(client, record.NewFakeRecorder(100))
+	ctx := context.Background()
+
+	// Simulate a live change: snapshot, then shrink the container
+	if err := r.applier.RollbackManager().SavePreviousConfig(ctx, "default", "Deployment", "api", "app"); err != nil {
+		t.Fatalf("SavePreviousConfig failed: %v", err)
+	}
+	changed := deploy.DeepCopy()
+	changed.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("50m")
+	if _, err := client.AppsV1().Deployments("default").Update(ctx, changed, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:          true,
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+			DryRun:           true,
+		},
+	}
+	changes := []containerRef{{
+		workloadRef: workloadRef{Namespace: "default", Kind: "Deployment", Name: "api"},
+		Container:   "app",
+	}}
+	r.rollbackDegraded(ctx, config, changes, "error rate increased", time.Hour, "LIVE")
+
+	restored, err := client.AppsV1().Deployments("default").Get(ctx, "api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	if cpu := restored.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]; cpu.String() != "100m" {
+		t.Errorf("Expected CPU rolled back to 100m, got %s", cpu.String())
+	}
+	if len(config.Status.Quarantined) != 1 || config.Status.Quarantined[0].Name != "api" {
+		t.Fatalf("Expected api to be quarantined, got %+v", config.Status.Quarantined)
+	}
+	if cond := findCondition(config, optimizerv1alpha1.ConditionTypeRolledBack); cond == nil || cond.Status != optimizerv1alpha1.ConditionTrue {
+		t.Errorf("Expected RolledBack condition to be True, got %+v", cond)
+	}
+
+	// The next pass leaves the quarantined workload alone
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		r.metricsStorage.Add(models.PodMetric{
+			PodName:   "api-6d4f9c7b8-abcde",
+			Namespace: "default",
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+			Containers: []models.ContainerMetric{{
+				ContainerName: "app",
+				UsageCPU:      50,
+				UsageMemory:   200 * 1024 * 1024,
+				RequestCPU:    100,
+				RequestMemory: 256 * 1024 * 1024,
+			}},
+		})
+	}
+	if err := r.processRecommendations(ctx, config, "DRY-RUN"); err != nil {
+		t.Fatalf("processRecommendations failed: %v", err)
+	}
+	if len(config.Status.Decisions) != 1 {
+		t.Fatalf("Expected one decision, got %d", len(config.Status.Decisions))
+	}
+	decision := config.Status.Decisions[0]
+	if decision.Action != optimizerv1alpha1.DecisionSkipped || len(decision.Gates) == 0 || decision.Gates[0].Gate != gateQuarantine {
+		t.Errorf("Expected api to be skipped by the quarantine gate, got %s %+v", decision.Action, decision.Gates)
+	}
+
+	// Once the cooldown has passed the quarantine is released
+	r.pruneQuarantine(config, now.Add(2*time.Hour))
+	if len(config.Status.Quarantined) != 0 {
+		t.Errorf("Expected the quarantine to expire, got %+v", config.Status.Quarantined)
+	}
+	if cond := findCondition(config, optimizerv1alpha1.ConditionTypeRolledBack); cond == nil || cond.Status != optimizerv1alpha1.ConditionFalse {
+		t.Errorf("Expected RolledBack condition to be False, got %+v", cond)
+	}
+}
+
+func findCondition(config *optimizerv1alpha1.OptimizerConfig, conditionType optimizerv1alpha1.OptimizerConditionType) *optimizerv1alpha1.OptimizerCondition {
+	for i := range config.Status.Conditions {
+		if config.Status.Conditions[i].Type == conditionType {
+			return &config.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func TestProgressRollouts_VerifiesCompletedRollout(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	client := fake.NewSimpleClientset(deploy)
+	r := NewReconciler(client, record.NewFakeRecorder(100))
+	ctx := context.Background()
+
+	baseline := 100.0
+	started := metav1.NewTime(time.Now().Add(-time.Minute))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:          true,
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+		},
+		Status: optimizerv1alpha1.OptimizerConfigStatus{
+			Phase: optimizerv1alpha1.OptimizerPhaseActive,
+			Rollouts: []optimizerv1alpha1.WorkloadRollout{{
+				Namespace:           "default",
+				Kind:                "Deployment",
+				Name:                "api",
+				Containers:          []string{"app"},
+				Phase:               optimizerv1alpha1.RolloutPhaseProgressing,
+				StartedAt:           started,
+				PhaseStartedAt:      started,
+				BaselineHealthScore: &baseline,
+			}},
+		},
+	}
+
+	// The Deployment's pods are all updated and available
+	r.progressRollouts(ctx, config, "LIVE")
+	if len(config.Status.Rollouts) != 1 || config.Status.Rollouts[0].Phase != optimizerv1alpha1.RolloutPhaseVerifying {
+		t.Fatalf("Expected the rollout to move to Verifying, got %+v", config.Status.Rollouts)
+	}
+
+	// SLA health is only compared once the new pods have served for a while
+	r.progressRollouts(ctx, config, "LIVE")
+	if len(config.Status.Rollouts) != 1 {
+		t.Fatalf("Expected the rollout to wait before verification, got %+v", config.Status.Rollouts)
+	}
+
+	config.Status.Rollouts[0].PhaseStartedAt = metav1.NewTime(time.Now().Add(-2 * rolloutVerifyDelay))
+	r.progressRollouts(ctx, config, "LIVE")
+	if len(config.Status.Rollouts) != 0 {
+		t.Errorf("Expected the verified rollout to be dropped, got %+v", config.Status.Rollouts)
+	}
+	if len(config.Status.Quarantined) != 0 {
+		t.Errorf("Expected no rollback for healthy SLAs, got %+v", config.Status.Quarantined)
+	}
+}
+
+func TestProcessRecommendations_BlockedByUnhealthyPods(t *testing.T) {
+	now := time.Now()
+	var objects []runtime.Object
+	for i, state := range []string{"ready", "oom", "oom", "unready"} {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("api-%d", i), Namespace: "default", Labels: map[string]string{"app": "api"}},
+			Status: corev1.PodStatus{
+				Phase:             corev1.PodRunning,
+				Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+				ContainerStatuses: []corev1.ContainerStatus{{Name: "app"}},
+			},
+		}
+		switch state {
+		case "oom":
+			pod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{
+				Reason: "OOMKilled", FinishedAt: metav1.NewTime(now.Add(-time.Minute)),
+			}
+		case "unready":
+			pod.Status.Conditions[0].Status = corev1.ConditionFalse
+		}
+		objects = append(objects, pod)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := NewReconciler(fake.NewSimpleClientset(objects...), recorder)
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:          true,
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+		},
+	}
+
+	// Half of the pods were OOM killed in the last minutes
+	err := r.processRecommendations(context.Background(), config, "LIVE")
+	if err == nil || !strings.Contains(err.Error(), "blocked by SLA health check") {
+		t.Fatalf("Expected the SLA health check to block optimization, got %v", err)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, events.ReasonOptimizationBlocked) || !strings.Contains(event, "oom-kills") {
+			t.Errorf("Expected an OptimizationBlocked event about OOM kills, got %q", event)
+		}
+	default:
+		t.Error("Expected an OptimizationBlocked event")
+	}
+}
+
+func TestProgressRollouts_DetectsDegradedWorkloadPods(t *testing.T) {
+	deploy, pod := oomFastPathFixtures(0)
+	pod.Labels["app"] = "api"
+	pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}}
+	pod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{
+		Reason: "OOMKilled", FinishedAt: metav1.NewTime(time.Now().Add(-time.Minute)),
+	}
+	recorder := record.NewFakeRecorder(100)
+	r := NewReconciler(fake.NewSimpleClientset(deploy, pod), recorder)
+
+	baseline := 100.0
+	verifying := metav1.NewTime(time.Now().Add(-2 * rolloutVerifyDelay))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:          true,
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+		},
+		Status: optimizerv1alpha1.OptimizerConfigStatus{
+			Phase: optimizerv1alpha1.OptimizerPhaseActive,
+			Rollouts: []optimizerv1alpha1.WorkloadRollout{{
+				Namespace:           "default",
+				Kind:                "Deployment",
+				Name:                "api",
+				Containers:          []string{"app"},
+				Phase:               optimizerv1alpha1.RolloutPhaseVerifying,
+				StartedAt:           verifying,
+				PhaseStartedAt:      verifying,
+				BaselineHealthScore: &baseline,
+			}},
+		},
+	}
+
+	// The workload's only pod is unready after an OOM kill
+	r.progressRollouts(context.Background(), config, "LIVE")
+	found := false
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, events.ReasonOptimizationDegraded) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected an OptimizationDegraded event for the unhealthy pods")
+	}
+}
+
+func TestProgressRollouts_RollsBackMissedDeadline(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	client := fake.NewSimpleClientset(deploy)
+	r := NewReconciler(client, record.NewFakeRecorder(100))
+	ctx := context.Background()
+
+	// Simulate a live change whose new pods never become available
+	if err := r.applier.RollbackManager().SavePreviousConfig(ctx, "default", "Deployment", "api", "app"); err != nil {
+		t.Fatalf("SavePreviousConfig failed: %v", err)
+	}
+	changed := deploy.DeepCopy()
+	changed.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU] = resource.MustParse("50m")
+	changed.Status.AvailableReplicas = 0
+	changed.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentProgressing,
+		Status:  corev1.ConditionFalse,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: `ReplicaSet "api-7f8d9" has timed out progressing.`,
+	}}
+	if _, err := client.AppsV1().Deployments("default").Update(ctx, changed, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+
+	started := metav1.NewTime(time.Now().Add(-15 * time.Minute))
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:          true,
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+		},
+		Status: optimizerv1alpha1.OptimizerConfigStatus{
+			Phase: optimizerv1alpha1.OptimizerPhaseActive,
+			Rollouts: []optimizerv1alpha1.WorkloadRollout{{
+				Namespace:      "default",
+				Kind:           "Deployment",
+				Name:           "api",
+				Containers:     []string{"app"},
+				Phase:          optimizerv1alpha1.RolloutPhaseProgressing,
+				StartedAt:      started,
+				PhaseStartedAt: started,
+			}},
+		},
+	}
+
+	r.progressRollouts(ctx, config, "LIVE")
+
+	if len(config.Status.Rollouts) != 0 {
+		t.Errorf("Expected the failed rollout to be dropped, got %+v", config.Status.Rollouts)
+	}
+	restored, err := client.AppsV1().Deployments("default").Get(ctx, "api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	if cpu := restored.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]; cpu.String() != "100m" {
+		t.Errorf("Expected CPU rolled back to 100m, got %s", cpu.String())
+	}
+	if len(config.Status.Quarantined) != 1 || config.Status.Quarantined[0].Name != "api" {
+		t.Fatalf("Expected api to be quarantined, got %+v", config.Status.Quarantined)
+	}
+	if cond := findCondition(config, optimizerv1alpha1.ConditionTypeRolledBack); cond == nil || cond.Reason != rollbackReasonRolloutFailed {
+		t.Errorf("Expected RolledBack condition with reason %s, got %+v", rollbackReasonRolloutFailed, cond)
+	}
+}
+
+func TestReconciler_SkipsWorkloadWithRolloutInFlight(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	deploy.Status.UpdatedReplicas = 0
+	client := fake.NewSimpleClientset(deploy)
+	r := NewReconciler(client, record.NewFakeRecorder(100))
+	ctx := context.Background()
+
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		r.metricsStorage.Add(models.PodMetric{
+			PodName:   "api-6d4f9c7b8-abcde",
+			Namespace: "default",
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+			Containers: []models.ContainerMetric{{
+				ContainerName: "app",
+				UsageCPU:      50,
+				UsageMemory:   200 * 1024 * 1024,
+				RequestCPU:    100,
+				RequestMemory: 256 * 1024 * 1024,
+			}},
+		})
+	}
+
+	started := metav1.NewTime(now)
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:          true,
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+			DryRun:           true,
+		},
+		Status: optimizerv1alpha1.OptimizerConfigStatus{
+			Phase: optimizerv1alpha1.OptimizerPhaseActive,
+			Rollouts: []optimizerv1alpha1.WorkloadRollout{{
+				Namespace:      "default",
+				Kind:           "Deployment",
+				Name:           "api",
+				Containers:     []string{"app"},
+				Phase:          optimizerv1alpha1.RolloutPhaseProgressing,
+				StartedAt:      started,
+				PhaseStartedAt: started,
+			}},
+		},
+	}
+
+	result, err := r.Reconcile(ctx, config)
+	if err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if result.RequeueAfter != rolloutRequeue {
+		t.Errorf("Expected a requeue after %s while the rollout is in flight, got %s", rolloutRequeue, result.RequeueAfter)
+	}
+	if len(config.Status.Rollouts) != 1 {
+		t.Fatalf("Expected the rollout to stay in flight, got %+v", config.Status.Rollouts)
+	}
+	if len(config.Status.Decisions) != 1 {
+		t.Fatalf("Expected one decision, got %d", len(config.Status.Decisions))
+	}
+	decision := config.Status.Decisions[0]
+	if decision.Action != optimizerv1alpha1.DecisionSkipped || len(decision.Gates) == 0 || decision.Gates[0].Gate != gateRollout {
+		t.Errorf("Expected api to be skipped by the rollout gate, got %s %+v", decision.Action, decision.Gates)
+	}
+}
+
+func TestPrioritizeRecommendations(t *testing.T) {
+	withSavings := func(name string, savings float64, ooms int) recommendation.WorkloadRecommendation {
+		return recommendation.WorkloadRecommendation{
+			WorkloadName:          name,
+			TotalEstimatedSavings: &cost.SavingsEstimate{SavingsPerMonth: savings},
+			TotalOOMCount:         ooms,
+		}
+	}
+	recs := []recommendation.WorkloadRecommendation{
+		withSavings("small", 5, 0),
+		withSavings("oom", 1, 3),
+		withSavings("large", 50, 0),
+		{WorkloadName: "unpriced"},
+	}
+
+	prioritizeRecommendations(recs, BudgetPrioritySavings)
+	if got := []string{recs[0].WorkloadName, recs[1].WorkloadName, recs[2].WorkloadName, recs[3].WorkloadName}; fmt.Sprint(got) != "[large small oom unpriced]" {
+		t.Errorf("Expected savings order [large small oom unpriced], got %v", got)
+	}
+
+	prioritizeRecommendations(recs, BudgetPriorityOOMRisk)
+	if recs[0].WorkloadName != "oom" || recs[1].WorkloadName != "large" {
+		t.Errorf("Expected oom-risk order to start with oom then large, got %s, %s", recs[0].WorkloadName, recs[1].WorkloadName)
+	}
+}
+
+func TestReconciler_DefersChangeOverBudget(t *testing.T) {
+	deploy, _ := oomFastPathFixtures(0)
+	client := fake.NewSimpleClientset(deploy)
+	recorder := record.NewFakeRecorder(100)
+	r := NewReconciler(client, recorder)
+	ctx := context.Background()
+
+	now := time.Now()
+	budget := safety.NewChangeBudget(safety.BudgetLimits{MaxConcurrentRollouts: 1}, safety.BudgetLimits{})
+	budget.SyncInFlight("team/other-config", []safety.BudgetRollout{{
+		Workload:  safety.BudgetWorkload{Namespace: "team", Kind: "Deployment", Name: "web"},
+		StartedAt: now,
+	}}, now)
+	r.SetChangeBudget(budget, BudgetPrioritySavings)
+
+	for i := 0; i < 100; i++ {
+		r.metricsStorage.Add(models.PodMetric{
+			PodName:   "api-6d4f9c7b8-abcde",
+			Namespace: "default",
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+			Containers: []models.ContainerMetric{{
+				ContainerName: "app",
+				UsageCPU:      50,
+				UsageMemory:   200 * 1024 * 1024,
+				RequestCPU:    100,
+				RequestMemory: 256 * 1024 * 1024,
+			}},
+		})
+	}
+
+	config := &optimizerv1alpha1.OptimizerConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-config", Namespace: "default"},
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			Enabled:          true,
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+		},
+		Status: optimizerv1alpha1.OptimizerConfigStatus{Phase: optimizerv1alpha1.OptimizerPhaseActive},
+	}
+
+	if _, err := r.Reconcile(ctx, config); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if len(config.Status.Rollouts) != 0 {
+		t.Errorf("Expected no rollout to start over budget, got %+v", config.Status.Rollouts)
+	}
+	if len(config.Status.Decisions) != 1 {
+		t.Fatalf("Expected one decision, got %d", len(config.Status.Decisions))
+	}
+	decision := config.Status.Decisions[0]
+	if decision.Action != optimizerv1alpha1.DecisionSkipped {
+		t.Errorf("Expected api to be deferred, got %s", decision.Action)
+	}
+	if len(decision.Containers) != 1 {
+		t.Fatalf("Expected one container decision, got %d", len(decision.Containers))
+	}
+	gates := decision.Containers[0].Gates
+	if gate := gates[len(gates)-1]; gate.Gate != gateChangeBudget || gate.Result != optimizerv1alpha1.GateBlocked {
+		t.Errorf("Expected the change budget gate to block, got %+v", gate)
+	}
+
+	found := false
+	for len(recorder.Events) > 0 {
+		if event := <-recorder.Events; strings.Contains(event, events.ReasonChangeBudgetExhausted) {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("Expected a ChangeBudgetExhausted event")
+	}
+
+	// Once the slot frees up, it is kept for api over lower-ranked changes of other configs
+	budget.SyncInFlight("team/other-config", nil, now)
+	lower := budget.Acquire("team/other-config", safety.BudgetWorkload{Namespace: "team", Kind: "Deployment", Name: "worker"},
+		safety.BudgetRank{Primary: -1}, now)
+	if lower.Allowed {
+		t.Errorf("Expected the free slot to be reserved for the deferred api change, got %+v", lower)
+	}
+	if _, err := r.Reconcile(ctx, config); err != nil {
+		t.Fatalf("Reconcile failed: %v", err)
+	}
+	if len(config.Status.Rollouts) != 1 || config.Status.Rollouts[0].Name != "api" {
+		t.Errorf("Expected the deferred api change to take the free slot, got %+v", config.Status.Rollouts)
+	}
+}
+
+func TestForecastAdviceState_IgnoresSmallDrift(t *testing.T) {
+	raised := func(cpu, memory int64, raisedCPU, raisedMemory bool) string {
+		return forecastAdviceState(&recommendation.ContainerRecommendation{
+			RecommendedCPU: cpu, RecommendedMemory: memory,
+			ForecastRaisedCPU: raisedCPU, ForecastRaisedMemory: raisedMemory,
+		})
+	}
+	base := raised(500, 512<<20, true, false)
+	if got := raised(502, 530<<20, true, false); got != base {
+		t.Errorf("Expected a 0.4%% CPU drift and an unraised memory change to keep the state %q, got %q", base, got)
+	}
+	if got := raised(600, 512<<20, true, false); got == base {
+		t.Errorf("Expected a 20%% CPU change to change the state %q", base)
+	}
+	if got := raised(500, 512<<20, true, true); got == base {
+		t.Errorf("Expected a newly raised memory target to change the state %q", base)
+	}
+}
+
+func TestNewContainerDecision_StartupCPU(t *testing.T) {
+	workload := newWorkloadDecision(&recommendation.WorkloadRecommendation{
+		Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api",
+	})
+
+	decision := newContainerDecision(workload, &recommendation.ContainerRecommendation{
+		ContainerName: "app", RecommendedCPU: 200, StartupCPU: 1500,
+	}, nil)
+	if decision.StartupCPU != "1500m" {
+		t.Errorf("Expected startup CPU 1500m in the trace, got %q", decision.StartupCPU)
+	}
+
+	decision = newContainerDecision(workload, &recommendation.ContainerRecommendation{ContainerName: "app", RecommendedCPU: 200}, nil)
+	if decision.StartupCPU != "" {
+		t.Errorf("Expected no startup CPU without a warm-up recommendation, got %q", decision.StartupCPU)
+	}
+}
+
+func TestUpdateDecisions_DropsUnchangedFirst(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+	config := &optimizerv1alpha1.OptimizerConfig{}
+
+	var decisions []optimizerv1alpha1.WorkloadDecision
+	for i := 0; i < maxDecisions; i++ {
+		decisions = append(decisions, optimizerv1alpha1.WorkloadDecision{
+			Name: fmt.Sprintf("idle-%d", i), Action: optimizerv1alpha1.DecisionUnchanged,
+		})
+	}
+	for i := 0; i < 5; i++ {
+		decisions = append(decisions, optimizerv1alpha1.WorkloadDecision{
+			Name: fmt.Sprintf("skipped-%d", i), Action: optimizerv1alpha1.DecisionSkipped,
+		})
+	}
+
+	r.updateDecisions(config, decisions)
+	if len(config.Status.Decisions) != maxDecisions || config.Status.DecisionsOmitted != 5 {
+		t.Fatalf("Expected %d traces and 5 omitted, got %d and %d", maxDecisions, len(config.Status.Decisions), config.Status.DecisionsOmitted)
+	}
+	if last := config.Status.Decisions[maxDecisions-1]; last.Name != "skipped-4" {
+		t.Errorf("Expected the skipped workloads to be kept over unchanged ones, last trace is %s", last.Name)
+	}
+
+	r.updateDecisions(config, decisions[:10])
+	if config.Status.DecisionsOmitted != 0 {
+		t.Errorf("Expected the omitted count to reset, got %d", config.Status.DecisionsOmitted)
+	}
+}
+
+func TestAdviceChanged_ReportsOnlyChanges(t *testing.T) {
+	r := NewReconciler(fake.NewSimpleClientset(), nil)
+	config := &optimizerv1alpha1.OptimizerConfig{ObjectMeta: metav1.ObjectMeta{Name: "opt", Namespace: "default"}}
+	workload := &recommendation.WorkloadRecommendation{Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "api"}
+
+	steps := []struct {
+		state string
+		want  bool
+	}{
+		{"cpu=500,memory=512", true},
+		{"cpu=500,memory=512", false},
+		{"cpu=600,memory=512", true},
+	}
+	for i, step := range steps {
+		if got := r.adviceChanged(config, events.ReasonForecastAdjusted, workload, "app", step.state); got != step.want {
+			t.Errorf("step %d: expected adviceChanged=%v for %s, got %v", i, step.want, step.state, got)
+		}
+	}
+
+	r.clearAdvice(config, events.ReasonForecastAdjusted, workload, "app")
+	if !r.adviceChanged(config, events.ReasonForecastAdjusted, workload, "app", "cpu=600,memory=512") {
+		t.Error("Expected advice to be reported again after it was cleared")
+	}
+
+	r.ForgetConfig("default", "opt")
+	if len(r.lastAdvice) != 0 {
+		t.Errorf("Expected ForgetConfig to drop advisory state, got %v", r.lastAdvice)
+	}
+}
@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Rollback metric reasons
+const (
+	// rollbackReasonSLA labels rollbacks triggered by post-optimization SLA degradation
+	rollbackReasonSLA = "SLADegraded"
+	// rollbackReasonRolloutFailed labels rollbacks of changes whose rollout did not complete
+	rollbackReasonRolloutFailed = "ProgressDeadlineExceeded"
+)
+
+// rollbackCause describes why changes are rolled back in events, metrics and decision traces
+type rollbackCause struct {
+	// reason labels the rollback metric and the RolledBack condition
+	reason string
+	// description completes "rolled back after ..."
+	description string
+	// gate is the decision trace gate blocked by the rollback
+	gate string
+}
+
+var (
+	causeSLADegraded   = rollbackCause{reason: rollbackReasonSLA, description: "SLA degradation", gate: gateSLA}
+	causeRolloutFailed = rollbackCause{reason: rollbackReasonRolloutFailed, description: "a failed rollout", gate: gateRollout}
+)
+
+// containerRef identifies a container changed during a reconcile
+type containerRef struct {
+	workloadRef
+	Container string
+}
+
+// SetRollbackHistoryRetention sets how many rollback snapshots are kept per container
+func (r *Reconciler) SetRollbackHistoryRetention(retention int) {
+	r.applier.RollbackManager().SetRetention(retention)
+}
+
+// rollbackDegraded rolls back changes after SLA degradation
+func (r *Reconciler) rollbackDegraded(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	changes []containerRef,
+	reason string,
+	cooldown time.Duration,
+	mode string,
+) {
+	r.rollBack(ctx, config, changes, causeSLADegraded, reason, cooldown, mode)
+}
+
+// rollBack restores the changed containers of each workload to the revision before
+// its changes in a single workload update, then quarantines the workloads for the
+// cooldown so the next passes do not reapply the same recommendation. Workloads are
+// restored newest change first.
+func (r *Reconciler) rollBack(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	changes []containerRef,
+	cause rollbackCause,
+	reason string,
+	cooldown time.Duration,
+	mode string,
+) {
+	var workloads []workloadRef
+	containers := make(map[workloadRef][]string)
+	for i := len(changes) - 1; i >= 0; i-- {
+		change := changes[i]
+		workloads = appendWorkloadRef(workloads, change.workloadRef)
+		containers[change.workloadRef] = append(containers[change.workloadRef], change.Container)
+	}
+
+	rolledBack := make(map[workloadRef]int)
+	for _, ref := range workloads {
+		plan, err := r.applier.RollbackManager().RollbackChanges(ctx, ref.Namespace, ref.Kind, ref.Name, containers[ref])
+		if err != nil {
+			klog.Warningf("[%s] Failed to roll back %s/%s/%s containers=%v: %v",
+				mode, ref.Namespace, ref.Kind, ref.Name, containers[ref], err)
+			r.optimizerEvents.RecordWarningEvent(config, events.ReasonRollbackFailed,
+				fmt.Sprintf("Failed to roll back %s/%s: %v", ref.Kind, ref.Name, err))
+			continue
+		}
+		rolledBack[ref] = len(plan.Changes)
+	}
+
+	now := time.Now()
+	for _, ref := range workloads {
+		if count := rolledBack[ref]; count > 0 {
+			klog.Warningf("[%s] Rolled back %d container(s) of %s/%s/%s after %s",
+				mode, count, ref.Namespace, ref.Kind, ref.Name, cause.description)
+			r.optimizerEvents.RecordWarningEvent(config, events.ReasonOptimizationRolledBack,
+				fmt.Sprintf("Rolled back %s/%s (%d containers): %s", ref.Kind, ref.Name, count, reason))
+			if r.metricsExporter != nil {
+				r.metricsExporter.RecordRollback(config.Name, ref.Namespace, cause.reason)
+			}
+			markRolledBack(config.Status.Decisions, ref, cause, reason)
+		}
+		if cooldown > 0 {
+			r.quarantine(config, ref, reason, now, cooldown)
+		}
+	}
+
+	message := fmt.Sprintf("%d workload(s) rolled back after %s: %s", len(rolledBack), cause.description, reason)
+	if err := r.updateCondition(config, optimizerv1alpha1.ConditionTypeRolledBack, optimizerv1alpha1.ConditionTrue,
+		cause.reason, message); err != nil {
+		klog.Warningf("Failed to update condition: %v", err)
+	}
+}
+
+// markRolledBack notes the rollback in the workload's decision trace
+func markRolledBack(decisions []optimizerv1alpha1.WorkloadDecision, ref workloadRef, cause rollbackCause, reason string) {
+	for i := range decisions {
+		d := &decisions[i]
+		if d.Namespace == ref.Namespace && d.Kind == ref.Kind && d.Name == ref.Name {
+			d.Reason = "rolled back after " + cause.description
+			addGate(&d.Gates, cause.gate, optimizerv1alpha1.GateBlocked, reason)
+		}
+	}
+}
+
+// quarantine excludes a workload from optimization until the cooldown has passed,
+// replacing any earlier quarantine of the same workload
+func (r *Reconciler) quarantine(
+	config *optimizerv1alpha1.OptimizerConfig,
+	ref workloadRef,
+	reason string,
+	now time.Time,
+	cooldown time.Duration,
+) {
+	entry := optimizerv1alpha1.QuarantinedWorkload{
+		Namespace:    ref.Namespace,
+		Kind:         ref.Kind,
+		Name:         ref.Name,
+		Reason:       reason,
+		RolledBackAt: metav1.NewTime(now),
+		Until:        metav1.NewTime(now.Add(cooldown)),
+	}
+	for i, q := range config.Status.Quarantined {
+		if q.Namespace == ref.Namespace && q.Kind == ref.Kind && q.Name == ref.Name {
+			config.Status.Quarantined[i] = entry
+			return
+		}
+	}
+	config.Status.Quarantined = append(config.Status.Quarantined, entry)
+	r.optimizerEvents.RecordWarningEvent(config, events.ReasonWorkloadQuarantined,
+		fmt.Sprintf("%s/%s excluded from optimization until %s", ref.Kind, ref.Name, entry.Until.Format(time.RFC3339)))
+}
+
+// quarantinedUntil returns the active quarantine of a workload, or nil if it may be optimized
+func quarantinedUntil(config *optimizerv1alpha1.OptimizerConfig, namespace, kind, name string, now time.Time) *optimizerv1alpha1.QuarantinedWorkload {
+	for i := range config.Status.Quarantined {
+		q := &config.Status.Quarantined[i]
+		if q.Namespace == namespace && q.Kind == kind && q.Name == name && now.Before(q.Until.Time) {
+			return q
+		}
+	}
+	return nil
+}
+
+// pruneQuarantine drops expired quarantines and clears the RolledBack condition once
+// the last one has expired
+func (r *Reconciler) pruneQuarantine(config *optimizerv1alpha1.OptimizerConfig, now time.Time) {
+	if len(config.Status.Quarantined) == 0 {
+		return
+	}
+
+	active := config.Status.Quarantined[:0]
+	for _, q := range config.Status.Quarantined {
+		if now.Before(q.Until.Time) {
+			active = append(active, q)
+		} else {
+			klog.V(3).Infof("Quarantine of %s/%s/%s expired", q.Namespace, q.Kind, q.Name)
+		}
+	}
+	if len(active) > 0 {
+		config.Status.Quarantined = active
+		return
+	}
+
+	config.Status.Quarantined = nil
+	if err := r.updateCondition(config, optimizerv1alpha1.ConditionTypeRolledBack, optimizerv1alpha1.ConditionFalse,
+		"QuarantineExpired", "No workloads are quarantined after a rollback"); err != nil {
+		klog.Warningf("Failed to update condition: %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/applier"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/profile"
+	"intelligent-cluster-optimizer/pkg/scaler"
+	"intelligent-cluster-optimizer/pkg/sla"
+	"intelligent-cluster-optimizer/pkg/tuning"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// rolloutRequeue is how soon a config is reconciled again while changes are in flight
+	rolloutRequeue = 15 * time.Second
+
+	// rolloutVerifyDelay is how long the pods of a completed rollout serve before SLA
+	// health is compared against the baseline
+	rolloutVerifyDelay = time.Minute
+)
+
+// trackRollout records an applied change in status so later reconciles can follow its
+// rollout, verify SLA health and roll it back without blocking this one
+func (r *Reconciler) trackRollout(
+	config *optimizerv1alpha1.OptimizerConfig,
+	rec *applier.ResourceRecommendation,
+	result *applier.ApplyResult,
+	baseline *sla.HealthCheckResult,
+	now time.Time,
+) {
+	rollout := findRollout(config, workloadRef{Namespace: rec.Namespace, Kind: rec.WorkloadKind, Name: rec.WorkloadName})
+	if rollout == nil {
+		config.Status.Rollouts = append(config.Status.Rollouts, optimizerv1alpha1.WorkloadRollout{
+			Namespace: rec.Namespace,
+			Kind:      rec.WorkloadKind,
+			Name:      rec.WorkloadName,
+			StartedAt: metav1.NewTime(now),
+		})
+		rollout = &config.Status.Rollouts[len(config.Status.Rollouts)-1]
+		if baseline != nil {
+			score := baseline.Score
+			rollout.BaselineHealthScore = &score
+			for _, violation := range baseline.Violations {
+				rollout.BaselineViolations = append(rollout.BaselineViolations, violation.SLA.Name)
+			}
+		}
+	}
+	rollout.Containers = append(rollout.Containers, rec.ContainerName)
+
+	if result.Canary != nil {
+		rollout.Phase = optimizerv1alpha1.RolloutPhaseCanary
+		rollout.PhaseStartedAt = metav1.NewTime(result.Canary.StartedAt)
+		rollout.Canary = canaryRollout(result.Canary, rec.RecommendationID)
+		rollout.Message = fmt.Sprintf("canary of container %s on %d pods", rec.ContainerName, result.Canary.Config.Replicas)
+		return
+	}
+	rollout.Phase = optimizerv1alpha1.RolloutPhaseProgressing
+	rollout.PhaseStartedAt = metav1.NewTime(now)
+	rollout.Message = "rolling out"
+}
+
+// findRollout returns the in-flight rollout of a workload, or nil if it has none
+func findRollout(config *optimizerv1alpha1.OptimizerConfig, ref workloadRef) *optimizerv1alpha1.WorkloadRollout {
+	for i := range config.Status.Rollouts {
+		rollout := &config.Status.Rollouts[i]
+		if rollout.Namespace == ref.Namespace && rollout.Kind == ref.Kind && rollout.Name == ref.Name {
+			return rollout
+		}
+	}
+	return nil
+}
+
+// rolloutRefs lists the workloads with a rollout in flight
+func rolloutRefs(config *optimizerv1alpha1.OptimizerConfig) map[workloadRef]bool {
+	refs := make(map[workloadRef]bool, len(config.Status.Rollouts))
+	for _, rollout := range config.Status.Rollouts {
+		refs[workloadRef{Namespace: rollout.Namespace, Kind: rollout.Kind, Name: rollout.Name}] = true
+	}
+	return refs
+}
+
+// progressRollouts moves every in-flight rollout forward by one step: canaries are
+// analyzed and promoted or aborted, rolling updates are checked for completion or a
+// missed progress deadline, and completed rollouts are verified against the SLA
+// baseline. Finished rollouts are dropped from status.
+func (r *Reconciler) progressRollouts(ctx context.Context, config *optimizerv1alpha1.OptimizerConfig, mode string) {
+	if len(config.Status.Rollouts) == 0 {
+		return
+	}
+
+	settings, err := r.profileResolver.Resolve(config)
+	if err != nil {
+		klog.Warningf("Failed to resolve profile settings, rollouts will not be rolled back: %v", err)
+	}
+
+	now := time.Now()
+	rollouts := config.Status.Rollouts
+	var inFlight []optimizerv1alpha1.WorkloadRollout
+	for i := range rollouts {
+		rollout := rollouts[i].DeepCopy()
+		if r.progressRollout(ctx, config, rollout, settings, now, mode) {
+			inFlight = append(inFlight, *rollout)
+		}
+	}
+	config.Status.Rollouts = inFlight
+}
+
+// progressRollout advances one rollout and reports whether it is still in flight
+func (r *Reconciler) progressRollout(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	rollout *optimizerv1alpha1.WorkloadRollout,
+	settings *profile.ResolvedSettings,
+	now time.Time,
+	mode string,
+) bool {
+	switch rollout.Phase {
+	case optimizerv1alpha1.RolloutPhaseCanary:
+		return r.progressCanary(ctx, config, rollout, settings, now, mode)
+
+	case optimizerv1alpha1.RolloutPhaseProgressing:
+		status, err := r.applier.Scaler().RolloutStatus(ctx, rollout.Namespace, rollout.Kind, rollout.Name,
+			rollout.PhaseStartedAt.Time, now)
+		if apierrors.IsNotFound(err) {
+			klog.V(3).Infof("[%s] %s/%s/%s was deleted during its rollout", mode, rollout.Namespace, rollout.Kind, rollout.Name)
+			return false
+		}
+		if err != nil {
+			klog.Warningf("[%s] Failed to check rollout of %s/%s/%s: %v", mode, rollout.Namespace, rollout.Kind, rollout.Name, err)
+			return true
+		}
+		rollout.Message = status.Message
+		switch status.State {
+		case scaler.RolloutComplete:
+			klog.Infof("[%s] Rollout of %s/%s/%s complete, verifying SLA health", mode, rollout.Namespace, rollout.Kind, rollout.Name)
+			setRolloutPhase(rollout, optimizerv1alpha1.RolloutPhaseVerifying, now)
+		case scaler.RolloutFailed:
+			r.rolloutFailed(ctx, config, rollout, status.Message, settings, mode)
+			return false
+		}
+		return true
+
+	case optimizerv1alpha1.RolloutPhaseVerifying:
+		if now.Sub(rollout.PhaseStartedAt.Time) < rolloutVerifyDelay {
+			return true
+		}
+		ref := workloadRef{Namespace: rollout.Namespace, Kind: rollout.Kind, Name: rollout.Name}
+		if degraded := r.verifyRollout(ctx, config, rollout, settings, mode); degraded != "" {
+			r.recordChangeFailure(config, ref, fmt.Errorf("SLA degraded: %s", degraded), mode)
+		} else {
+			r.recordChangeSuccess(config, ref, mode)
+		}
+		return false
+
+	default:
+		klog.Warningf("[%s] Dropping rollout of %s/%s/%s in unknown phase %q",
+			mode, rollout.Namespace, rollout.Kind, rollout.Name, rollout.Phase)
+		return false
+	}
+}
+
+// progressCanary compares the canary pods against the baseline; a passing canary is
+// promoted to a rolling update and a failing one is reverted
+func (r *Reconciler) progressCanary(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	rollout *optimizerv1alpha1.WorkloadRollout,
+	settings *profile.ResolvedSettings,
+	now time.Time,
+	mode string,
+) bool {
+	if rollout.Canary == nil {
+		setRolloutPhase(rollout, optimizerv1alpha1.RolloutPhaseProgressing, now)
+		return true
+	}
+	canary := scalerCanary(rollout)
+
+	analysis, err := r.applier.Scaler().AnalyzeCanary(ctx, canary, now)
+	if apierrors.IsNotFound(err) {
+		klog.V(3).Infof("[%s] %s/%s/%s was deleted during its canary", mode, rollout.Namespace, rollout.Kind, rollout.Name)
+		return false
+	}
+	if err != nil {
+		klog.Warningf("[%s] Canary analysis of %s/%s/%s failed: %v", mode, rollout.Namespace, rollout.Kind, rollout.Name, err)
+		return true
+	}
+
+	switch analysis.Verdict {
+	case scaler.CanaryPassed:
+		err := r.applier.PromoteCanary(ctx, canary, config.Namespace+"/"+config.Name, rollout.Canary.RecommendationID)
+		if err != nil {
+			klog.Warningf("[%s] Failed to promote canary of %s/%s/%s: %v", mode, rollout.Namespace, rollout.Kind, rollout.Name, err)
+			rollout.Message = fmt.Sprintf("canary passed; promotion failed: %v", err)
+			return true
+		}
+		klog.Infof("[%s] Canary of %s/%s/%s passed, rolling out to all pods", mode, rollout.Namespace, rollout.Kind, rollout.Name)
+		r.optimizerEvents.RecordNormalEvent(config, events.ReasonCanaryPromoted,
+			fmt.Sprintf("%s/%s container %s: canary passed, rolling out to all pods", rollout.Kind, rollout.Name, canary.Request.ContainerName))
+		rollout.Canary = nil
+		rollout.Message = "canary passed; rolling out"
+		setRolloutPhase(rollout, optimizerv1alpha1.RolloutPhaseProgressing, now)
+		return true
+
+	case scaler.CanaryFailed:
+		reason := strings.Join(analysis.Reasons, "; ")
+		if err := r.applier.Scaler().AbortCanary(ctx, canary, reason); err != nil {
+			klog.Warningf("[%s] Failed to abort canary of %s/%s/%s: %v", mode, rollout.Namespace, rollout.Kind, rollout.Name, err)
+			rollout.Message = fmt.Sprintf("canary failed (%s); abort failed: %v", reason, err)
+			return true
+		}
+		ref := workloadRef{Namespace: rollout.Namespace, Kind: rollout.Kind, Name: rollout.Name}
+		r.handleCanaryAborted(config, ref, canary.Request.ContainerName, reason, settings, mode)
+		r.recordChangeFailure(config, ref, fmt.Errorf("canary aborted: %s", reason), mode)
+		return false
+
+	default:
+		rollout.Message = fmt.Sprintf("canary soaking: %d of %d canary pods ready, %d restarts (baseline %d of %d ready)",
+			analysis.Canary.Ready, analysis.Canary.Pods, analysis.Canary.Restarts, analysis.Baseline.Ready, analysis.Baseline.Pods)
+		return true
+	}
+}
+
+// rolloutFailed reports a rollout that missed its progress deadline and rolls its
+// changes back when the profile rolls back on errors
+func (r *Reconciler) rolloutFailed(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	rollout *optimizerv1alpha1.WorkloadRollout,
+	message string,
+	settings *profile.ResolvedSettings,
+	mode string,
+) {
+	klog.Warningf("[%s] Rollout of %s/%s/%s failed: %s", mode, rollout.Namespace, rollout.Kind, rollout.Name, message)
+	r.recordChangeFailure(config, workloadRef{Namespace: rollout.Namespace, Kind: rollout.Kind, Name: rollout.Name},
+		fmt.Errorf("rollout failed: %s", message), mode)
+	if settings == nil || !settings.RollbackOnError {
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonRolloutFailed,
+			fmt.Sprintf("Rollout of %s/%s failed: %s. Consider rollback.", rollout.Kind, rollout.Name, message))
+		return
+	}
+	r.optimizerEvents.RecordWarningEvent(config, events.ReasonRolloutFailed,
+		fmt.Sprintf("Rollout of %s/%s failed: %s. Rolling back.", rollout.Kind, rollout.Name, message))
+	r.rollBack(ctx, config, rolloutChanges(rollout), causeRolloutFailed, message, settings.RollbackCooldown, mode)
+}
+
+// verifyRollout compares SLA health after a completed rollout against the baseline
+// taken before the changes, and rolls them back when health degraded. It returns why
+// health degraded, or "" when it did not.
+func (r *Reconciler) verifyRollout(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	rollout *optimizerv1alpha1.WorkloadRollout,
+	settings *profile.ResolvedSettings,
+	mode string,
+) (degraded string) {
+	if rollout.BaselineHealthScore == nil {
+		return ""
+	}
+
+	postOptHealth, err := r.checkWorkloadHealth(ctx, workloadRef{Namespace: rollout.Namespace, Kind: rollout.Kind, Name: rollout.Name})
+	if err != nil {
+		klog.Warningf("[%s] Failed to perform post-optimization health check: %v", mode, err)
+		return ""
+	}
+	klog.V(3).Infof("[%s] Post-optimization health of %s/%s/%s: Score=%.1f, IsHealthy=%v, Message=%s",
+		mode, rollout.Namespace, rollout.Kind, rollout.Name, postOptHealth.Score, postOptHealth.IsHealthy, postOptHealth.Message)
+
+	impact, err := r.slaHealthChecker.CompareHealth(baselineHealth(rollout), postOptHealth)
+	if err != nil {
+		klog.Warningf("[%s] Failed to compare health: %v", mode, err)
+		return ""
+	}
+	klog.V(3).Infof("[%s] SLA Impact: Score=%.2f, Recommendation=%s", mode, impact.ImpactScore, impact.Recommendation)
+
+	shouldRollback, reason := sla.ShouldRollback(impact)
+	if !shouldRollback {
+		if impact.ImpactScore > 0.1 {
+			klog.Infof("[%s] SLA health improved after optimization (score change: +%.1f%%)", mode, impact.ImpactScore*100)
+			r.optimizerEvents.RecordNormalEvent(config, "SLAImproved",
+				fmt.Sprintf("SLA health improved by %.1f%%", impact.ImpactScore*100))
+		}
+		return ""
+	}
+
+	klog.Warningf("[%s] SLA health degraded after optimizing %s/%s/%s: %s", mode, rollout.Namespace, rollout.Kind, rollout.Name, reason)
+	rollbackEnabled := settings != nil && settings.RollbackOnError
+	if rollbackEnabled {
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonOptimizationDegraded,
+			fmt.Sprintf("SLA degradation detected: %s. Rolling back %s/%s.", reason, rollout.Kind, rollout.Name))
+	} else {
+		r.optimizerEvents.RecordWarningEvent(config, events.ReasonOptimizationDegraded,
+			fmt.Sprintf("SLA degradation detected: %s. Consider rollback.", reason))
+	}
+	if marginTuningEnabled(config) {
+		adjustment := r.marginTuner.Observe(config, rollout.Namespace, rollout.Name,
+			tuning.Outcome{SLADegraded: true}, marginPolicy(config, settings), time.Now())
+		r.recordMarginAdjustment(config, adjustment, mode)
+	}
+	if rollbackEnabled {
+		r.rollbackDegraded(ctx, config, rolloutChanges(rollout), reason, settings.RollbackCooldown, mode)
+	}
+	return reason
+}
+
+// baselineHealth rebuilds the pre-change health check kept on a rollout
+func baselineHealth(rollout *optimizerv1alpha1.WorkloadRollout) *sla.HealthCheckResult {
+	baseline := &sla.HealthCheckResult{Score: *rollout.BaselineHealthScore}
+	for _, name := range rollout.BaselineViolations {
+		baseline.Violations = append(baseline.Violations, sla.SLAViolation{SLA: sla.SLADefinition{Name: name}})
+	}
+	return baseline
+}
+
+// rolloutChanges lists the containers changed by a rollout in the order they were changed
+func rolloutChanges(rollout *optimizerv1alpha1.WorkloadRollout) []containerRef {
+	ref := workloadRef{Namespace: rollout.Namespace, Kind: rollout.Kind, Name: rollout.Name}
+	changes := make([]containerRef, 0, len(rollout.Containers))
+	for _, container := range rollout.Containers {
+		changes = append(changes, containerRef{workloadRef: ref, Container: container})
+	}
+	return changes
+}
+
+func setRolloutPhase(rollout *optimizerv1alpha1.WorkloadRollout, phase optimizerv1alpha1.RolloutPhase, now time.Time) {
+	rollout.Phase = phase
+	rollout.PhaseStartedAt = metav1.NewTime(now)
+}
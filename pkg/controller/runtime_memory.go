@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// workloadContainers returns the containers of the workload's pod template by name,
+// or nil if the template cannot be read
+func (r *Reconciler) workloadContainers(ctx context.Context, workloadRec *recommendation.WorkloadRecommendation, mode string) map[string]*corev1.Container {
+	podSpec, err := r.applier.GetPodSpec(ctx, workloadRec.Namespace, workloadRec.WorkloadKind, workloadRec.WorkloadName)
+	if err != nil {
+		klog.V(4).Infof("[%s] Could not read pod template of %s/%s: %v",
+			mode, workloadRec.Namespace, workloadRec.WorkloadName, err)
+		return nil
+	}
+	containers := make(map[string]*corev1.Container, len(podSpec.Containers))
+	for i := range podSpec.Containers {
+		r.resolveRuntimeEnv(ctx, workloadRec.Namespace, &podSpec.Containers[i], mode)
+		containers[podSpec.Containers[i].Name] = &podSpec.Containers[i]
+	}
+	return containers
+}
+
+// resolveRuntimeEnv fills in runtime memory variables that the container takes from
+// ConfigMaps or Secrets, through ValueFrom or EnvFrom, so their settings are honoured.
+// Downward API references stay unset: a GOMEMLIMIT taken from limits.memory follows the
+// limit and does not bound the request.
+func (r *Reconciler) resolveRuntimeEnv(ctx context.Context, namespace string, container *corev1.Container, mode string) {
+	explicit := make(map[string]bool, len(container.Env))
+	for i := range container.Env {
+		e := &container.Env[i]
+		explicit[e.Name] = true
+		if e.ValueFrom == nil || !recommendation.IsRuntimeMemoryEnv(e.Name) {
+			continue
+		}
+
+		var value string
+		var found bool
+		var err error
+		switch {
+		case e.ValueFrom.ConfigMapKeyRef != nil:
+			ref := e.ValueFrom.ConfigMapKeyRef
+			value, found, err = r.configMapValue(ctx, namespace, ref.Name, ref.Key)
+		case e.ValueFrom.SecretKeyRef != nil:
+			ref := e.ValueFrom.SecretKeyRef
+			value, found, err = r.secretValue(ctx, namespace, ref.Name, ref.Key)
+		default:
+			continue
+		}
+		if err != nil {
+			klog.V(4).Infof("[%s] Could not resolve %s of container %s: %v", mode, e.Name, container.Name, err)
+			continue
+		}
+		if found {
+			e.Value, e.ValueFrom = value, nil
+		}
+	}
+
+	// Later EnvFrom sources win over earlier ones, explicit Env wins over all of them
+	fromSources := make(map[string]string)
+	for _, source := range container.EnvFrom {
+		var data map[string]string
+		switch {
+		case source.ConfigMapRef != nil:
+			cm, err := r.kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, source.ConfigMapRef.Name, metav1.GetOptions{})
+			if err != nil {
+				klog.V(4).Infof("[%s] Could not read ConfigMap %s of container %s: %v", mode, source.ConfigMapRef.Name, container.Name, err)
+				continue
+			}
+			data = cm.Data
+		case source.SecretRef != nil:
+			secret, err := r.kubeClient.CoreV1().Secrets(namespace).Get(ctx, source.SecretRef.Name, metav1.GetOptions{})
+			if err != nil {
+				klog.V(4).Infof("[%s] Could not read Secret %s of container %s: %v", mode, source.SecretRef.Name, container.Name, err)
+				continue
+			}
+			data = make(map[string]string, len(secret.Data))
+			for k, v := range secret.Data {
+				data[k] = string(v)
+			}
+		}
+		for key, value := range data {
+			name := source.Prefix + key
+			if recommendation.IsRuntimeMemoryEnv(name) {
+				fromSources[name] = value
+			}
+		}
+	}
+	for name, value := range fromSources {
+		if !explicit[name] {
+			container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: value})
+		}
+	}
+}
+
+// configMapValue returns a key of a ConfigMap; found is false when the key is missing
+func (r *Reconciler) configMapValue(ctx context.Context, namespace, name, key string) (string, bool, error) {
+	cm, err := r.kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	value, found := cm.Data[key]
+	return value, found, nil
+}
+
+// secretValue returns a key of a Secret; found is false when the key is missing
+func (r *Reconciler) secretValue(ctx context.Context, namespace, name, key string) (string, bool, error) {
+	secret, err := r.kubeClient.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", false, err
+	}
+	value, found := secret.Data[key]
+	return string(value), found, nil
+}
+
+// applyRuntimeMemoryFloor keeps memory at or above what the container's JVM heap or
+// GOMEMLIMIT settings need, and warns when the runtime setting itself should change
+// alongside the request
+func (r *Reconciler) applyRuntimeMemoryFloor(
+	config *optimizerv1alpha1.OptimizerConfig,
+	workloadRec *recommendation.WorkloadRecommendation,
+	containerRec *recommendation.ContainerRecommendation,
+	container *corev1.Container,
+	mode string,
+) {
+	if container == nil {
+		return
+	}
+
+	usageTarget := containerRec.RecommendedMemory
+	settings := r.recommendationEngine.ApplyRuntimeMemoryFloor(containerRec, container)
+	if settings == nil {
+		r.clearAdvice(config, events.ReasonRuntimeMemoryFloor, workloadRec, containerRec.ContainerName)
+		r.clearAdvice(config, events.ReasonRuntimeSettingMismatch, workloadRec, containerRec.ContainerName)
+		return
+	}
+
+	// Events are recorded when the setting or the limit it is judged against changes,
+	// not on every reconcile while usage drifts
+	target := fmt.Sprintf("%s/%s/%s", workloadRec.Namespace, workloadRec.WorkloadName, containerRec.ContainerName)
+	state := fmt.Sprintf("%s,limit=%d", settings.Setting, containerRec.CurrentMemoryLimit)
+	if containerRec.RecommendedMemory > usageTarget {
+		message := fmt.Sprintf("Memory for %s kept at %s instead of %s: %s needs at least %s",
+			target, formatMemory(containerRec.RecommendedMemory), formatMemory(usageTarget),
+			settings.Setting, formatMemory(containerRec.RuntimeMemoryFloor))
+		klog.V(3).Infof("[%s] %s", mode, message)
+		if r.adviceChanged(config, events.ReasonRuntimeMemoryFloor, workloadRec, containerRec.ContainerName, state) {
+			r.optimizerEvents.RecordNormalEvent(config, events.ReasonRuntimeMemoryFloor, message)
+		}
+	} else {
+		r.clearAdvice(config, events.ReasonRuntimeMemoryFloor, workloadRec, containerRec.ContainerName)
+	}
+	if containerRec.RuntimeAdvice != "" {
+		klog.V(3).Infof("[%s] %s: %s", mode, target, containerRec.RuntimeAdvice)
+		if r.adviceChanged(config, events.ReasonRuntimeSettingMismatch, workloadRec, containerRec.ContainerName, state) {
+			r.optimizerEvents.RecordWarningEvent(config, events.ReasonRuntimeSettingMismatch,
+				fmt.Sprintf("%s: %s", target, containerRec.RuntimeAdvice))
+		}
+	} else {
+		r.clearAdvice(config, events.ReasonRuntimeSettingMismatch, workloadRec, containerRec.ContainerName)
+	}
+}
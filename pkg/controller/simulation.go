@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"context"
+	"math"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// DefaultSimulationInterval is how often the cluster is re-packed to estimate reclaimable nodes
+const DefaultSimulationInterval = 10 * time.Minute
+
+// SetSimulationInterval overrides how often the bin-packing simulation runs per config
+func (r *Reconciler) SetSimulationInterval(interval time.Duration) {
+	r.simulationInterval = interval
+}
+
+// simulatePacking re-packs every running pod with the recommended requests and records
+// how many nodes the recommendations would free in the status summary. Listing the
+// whole cluster is expensive, so the simulation runs at most once per interval.
+func (r *Reconciler) simulatePacking(
+	ctx context.Context,
+	config *optimizerv1alpha1.OptimizerConfig,
+	recommendations []recommendation.WorkloadRecommendation,
+	mode string,
+) {
+	if last := config.Status.PackingSimulation; last != nil && time.Since(last.SimulatedAt.Time) < r.simulationInterval {
+		return
+	}
+
+	nodes, err := r.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("[%s] Failed to list nodes for packing simulation: %v", mode, err)
+		return
+	}
+	pods, err := r.kubeClient.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		klog.Warningf("[%s] Failed to list pods for packing simulation: %v", mode, err)
+		return
+	}
+
+	result := r.packingSimulator.Simulate(nodes.Items, pods.Items, recommendations)
+
+	status := &optimizerv1alpha1.PackingSimulationStatus{
+		Nodes:                     int32(result.Nodes),
+		NodesAfterRepack:          int32(result.NodesAfterRepack),
+		NodesAfterRecommendations: int32(result.NodesAfterRecommendations),
+		ReclaimableNodes:          int32(result.ReclaimableNodes),
+		UnschedulablePods:         int32(result.UnschedulablePods),
+		SimulatedAt:               metav1.Now(),
+	}
+	for _, pool := range result.Pools {
+		status.Pools = append(status.Pools, optimizerv1alpha1.NodePoolPacking{
+			Pool:                       pool.Pool,
+			Nodes:                      int32(pool.Nodes),
+			NodesAfterRepack:           int32(pool.NodesAfterRepack),
+			NodesAfterRecommendations:  int32(pool.NodesAfterRecommendations),
+			ReclaimableNodes:           int32(pool.ReclaimableNodes),
+			UnschedulablePods:          int32(pool.UnschedulablePods),
+			CPUFragmentationPercent:    int32(math.Round(pool.CPUFragmentation * 100)),
+			MemoryFragmentationPercent: int32(math.Round(pool.MemoryFragmentation * 100)),
+		})
+
+		if r.metricsExporter != nil {
+			r.metricsExporter.RecordReclaimableNodes(config.Name, config.Namespace, pool.Pool, pool.ReclaimableNodes)
+		}
+	}
+	config.Status.PackingSimulation = status
+
+	klog.V(3).Infof("[%s] Packing simulation: %d nodes, %d after re-pack, %d with recommendations (%d reclaimable, %d unschedulable pods)",
+		mode, result.Nodes, result.NodesAfterRepack, result.NodesAfterRecommendations,
+		result.ReclaimableNodes, result.UnschedulablePods)
+}
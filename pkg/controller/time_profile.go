@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/events"
+	"intelligent-cluster-optimizer/pkg/models"
+	"intelligent-cluster-optimizer/pkg/recommendation"
+	"intelligent-cluster-optimizer/pkg/scheduler"
+	"intelligent-cluster-optimizer/pkg/timepattern"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// defaultTimeProfileHistory is how much history is analyzed for time-of-day patterns
+const defaultTimeProfileHistory = 7 * 24 * time.Hour
+
+// applyTimeProfile detects the workload's time-of-day pattern and scales its container
+// recommendations by the profile active now. The scaled recommendations still pass
+// through maintenance windows and the per-container safety gates.
+func (r *Reconciler) applyTimeProfile(config *optimizerv1alpha1.OptimizerConfig, workloadRec *recommendation.WorkloadRecommendation, mode string) {
+	tbs := config.Spec.TimeBasedScaling
+	if tbs == nil || !tbs.Enabled {
+		return
+	}
+
+	location := time.UTC
+	if tbs.Timezone != "" {
+		loc, err := time.LoadLocation(tbs.Timezone)
+		if err != nil {
+			klog.Warningf("Invalid time-based scaling timezone %s, using UTC: %v", tbs.Timezone, err)
+		} else {
+			location = loc
+		}
+	}
+
+	history := defaultTimeProfileHistory
+	if d, err := time.ParseDuration(tbs.HistoryDuration); err == nil && d > 0 {
+		history = d
+	}
+
+	metrics := r.metricsStorage.GetMetricsByWorkload(workloadRec.Namespace, workloadRec.WorkloadName, history)
+	pattern := r.timePatternAnalyzer.Analyze(timePatternSamples(metrics, location))
+	if pattern.ScalingRecommendation == nil || !pattern.ScalingRecommendation.Enabled {
+		klog.V(4).Infof("[%s] No time-of-day profile for %s/%s: %s",
+			mode, workloadRec.Namespace, workloadRec.WorkloadName, pattern.Description)
+		removeTimeProfileStatus(config, workloadRec.Namespace, workloadRec.WorkloadName)
+		return
+	}
+
+	profile := r.timeProfileScheduler.ActiveProfile(pattern.ScalingRecommendation.Schedules, time.Now(), location)
+
+	reason := ""
+	if !profile.IsBase() {
+		reason = fmt.Sprintf("time-of-day profile %q scaled CPU by %.2fx and memory by %.2fx (%s pattern)",
+			profile.Name, profile.CPUMultiplier, profile.MemoryMultiplier, pattern.PatternType)
+	}
+	for i := range workloadRec.Containers {
+		r.recommendationEngine.ScaleRecommendation(&workloadRec.Containers[i],
+			profile.CPUMultiplier, profile.MemoryMultiplier, config.Spec.ResourceThresholds, reason)
+	}
+
+	previous := setTimeProfileStatus(config, workloadRec.Namespace, workloadRec.WorkloadName, string(pattern.PatternType), profile)
+	if previous != profile.Name {
+		klog.V(3).Infof("[%s] %s/%s switched to time-of-day profile %s (cpu=%.2fx, memory=%.2fx)",
+			mode, workloadRec.Namespace, workloadRec.WorkloadName, profile.Name, profile.CPUMultiplier, profile.MemoryMultiplier)
+		r.optimizerEvents.RecordNormalEvent(config, events.ReasonTimeProfileSwitched,
+			fmt.Sprintf("%s/%s switched to time-of-day profile %s (%s pattern, cpu=%.2fx, memory=%.2fx)",
+				workloadRec.Namespace, workloadRec.WorkloadName, profile.Name, pattern.PatternType,
+				profile.CPUMultiplier, profile.MemoryMultiplier))
+	}
+}
+
+// timePatternSamples sums container usage per pod sample, in the configured timezone
+func timePatternSamples(metrics []models.PodMetric, location *time.Location) []timepattern.Sample {
+	samples := make([]timepattern.Sample, 0, len(metrics))
+	for _, m := range metrics {
+		sample := timepattern.Sample{Timestamp: m.Timestamp.In(location)}
+		for _, c := range m.Containers {
+			sample.CPU += c.UsageCPU
+			sample.Memory += c.UsageMemory
+		}
+		samples = append(samples, sample)
+	}
+	return samples
+}
+
+// setTimeProfileStatus records the active profile for a workload and returns the previous profile name
+func setTimeProfileStatus(config *optimizerv1alpha1.OptimizerConfig, namespace, workloadName, pattern string, profile *scheduler.TimeProfile) string {
+	entry := optimizerv1alpha1.WorkloadTimeProfile{
+		Namespace:    namespace,
+		WorkloadName: workloadName,
+		Pattern:      pattern,
+		Profile:      profile.Name,
+	}
+	if profile.NextSwitch != nil {
+		entry.NextSwitch = &metav1.Time{Time: *profile.NextSwitch}
+	}
+
+	for i, existing := range config.Status.TimeProfiles {
+		if existing.Namespace == namespace && existing.WorkloadName == workloadName {
+			config.Status.TimeProfiles[i] = entry
+			return existing.Profile
+		}
+	}
+	config.Status.TimeProfiles = append(config.Status.TimeProfiles, entry)
+	return scheduler.BaseProfileName
+}
+
+// removeTimeProfileStatus drops the status entry of a workload that no longer has a pattern
+func removeTimeProfileStatus(config *optimizerv1alpha1.OptimizerConfig, namespace, workloadName string) {
+	for i, existing := range config.Status.TimeProfiles {
+		if existing.Namespace == namespace && existing.WorkloadName == workloadName {
+			config.Status.TimeProfiles = append(config.Status.TimeProfiles[:i], config.Status.TimeProfiles[i+1:]...)
+			return
+		}
+	}
+}
+
+// nextTimeProfileSwitch returns how long until the earliest schedule boundary, or 0 if none
+func nextTimeProfileSwitch(config *optimizerv1alpha1.OptimizerConfig) time.Duration {
+	var next time.Duration
+	for _, p := range config.Status.TimeProfiles {
+		if p.NextSwitch == nil {
+			continue
+		}
+		if until := time.Until(p.NextSwitch.Time); until > 0 && (next == 0 || until < next) {
+			next = until
+		}
+	}
+	return next
+}
@@ -21,6 +21,9 @@ type PricingModel struct {
 	// Memory pricing per GB per hour (in USD)
 	MemoryPerGBHour float64
 
+	// Ephemeral storage (node local disk) pricing per GB per hour (in USD)
+	EphemeralStoragePerGBHour float64
+
 	// Region for pricing (affects rates)
 	Region string
 
@@ -40,39 +43,44 @@ const (
 // DefaultPricingModels provides typical cloud pricing (approximate USD values)
 var DefaultPricingModels = map[string]*PricingModel{
 	"aws-us-east-1": {
-		Provider:        "aws",
-		Region:          "us-east-1",
-		CPUPerCoreHour:  0.0416, // ~$30/month per core
-		MemoryPerGBHour: 0.0052, // ~$3.75/month per GB
-		Tier:            TierOnDemand,
+		Provider:                  "aws",
+		Region:                    "us-east-1",
+		CPUPerCoreHour:            0.0416,  // ~$30/month per core
+		MemoryPerGBHour:           0.0052,  // ~$3.75/month per GB
+		EphemeralStoragePerGBHour: 0.00011, // ~$0.08/month per GB (gp3)
+		Tier:                      TierOnDemand,
 	},
 	"aws-us-east-1-spot": {
-		Provider:        "aws",
-		Region:          "us-east-1",
-		CPUPerCoreHour:  0.0125, // ~70% discount
-		MemoryPerGBHour: 0.0016,
-		Tier:            TierSpot,
+		Provider:                  "aws",
+		Region:                    "us-east-1",
+		CPUPerCoreHour:            0.0125, // ~70% discount
+		MemoryPerGBHour:           0.0016,
+		EphemeralStoragePerGBHour: 0.00011, // Disks are not discounted
+		Tier:                      TierSpot,
 	},
 	"gcp-us-central1": {
-		Provider:        "gcp",
-		Region:          "us-central1",
-		CPUPerCoreHour:  0.0350,
-		MemoryPerGBHour: 0.0047,
-		Tier:            TierOnDemand,
+		Provider:                  "gcp",
+		Region:                    "us-central1",
+		CPUPerCoreHour:            0.0350,
+		MemoryPerGBHour:           0.0047,
+		EphemeralStoragePerGBHour: 0.00014,
+		Tier:                      TierOnDemand,
 	},
 	"azure-eastus": {
-		Provider:        "azure",
-		Region:          "eastus",
-		CPUPerCoreHour:  0.0400,
-		MemoryPerGBHour: 0.0050,
-		Tier:            TierOnDemand,
+		Provider:                  "azure",
+		Region:                    "eastus",
+		CPUPerCoreHour:            0.0400,
+		MemoryPerGBHour:           0.0050,
+		EphemeralStoragePerGBHour: 0.00012,
+		Tier:                      TierOnDemand,
 	},
 	"default": {
-		Provider:        "generic",
-		Region:          "default",
-		CPUPerCoreHour:  0.040, // Conservative estimate
-		MemoryPerGBHour: 0.005,
-		Tier:            TierOnDemand,
+		Provider:                  "generic",
+		Region:                    "default",
+		CPUPerCoreHour:            0.040, // Conservative estimate
+		MemoryPerGBHour:           0.005,
+		EphemeralStoragePerGBHour: 0.0001,
+		Tier:                      TierOnDemand,
 	},
 }
 
@@ -101,6 +109,9 @@ type ResourceCost struct {
 	// Memory cost per hour
 	MemoryCostPerHour float64
 
+	// Ephemeral storage cost per hour
+	EphemeralStorageCostPerHour float64
+
 	// Total cost per hour
 	TotalPerHour float64
 
@@ -119,9 +130,10 @@ type SavingsEstimate struct {
 	RecommendedCost ResourceCost
 
 	// Savings breakdown
-	CPUSavingsPerHour    float64
-	MemorySavingsPerHour float64
-	TotalSavingsPerHour  float64
+	CPUSavingsPerHour              float64
+	MemorySavingsPerHour           float64
+	EphemeralStorageSavingsPerHour float64
+	TotalSavingsPerHour            float64
 
 	// Projected savings
 	SavingsPerDay   float64
@@ -132,8 +144,9 @@ type SavingsEstimate struct {
 	PercentageReduction float64
 
 	// Resource changes
-	CPUReduction    float64 // in millicores
-	MemoryReduction int64   // in bytes
+	CPUReduction              float64 // in millicores
+	MemoryReduction           int64   // in bytes
+	EphemeralStorageReduction int64   // in bytes
 }
 
 // ContainerSavings represents savings for a single container
@@ -160,23 +173,32 @@ type WorkloadSavings struct {
 // cpuMillicores: CPU in millicores (1000m = 1 core)
 // memoryBytes: Memory in bytes
 func (c *Calculator) CalculateCost(cpuMillicores int64, memoryBytes int64) ResourceCost {
+	return c.CalculateCostWithStorage(cpuMillicores, memoryBytes, 0)
+}
+
+// CalculateCostWithStorage calculates the cost of resource allocation including
+// ephemeral storage in bytes
+func (c *Calculator) CalculateCostWithStorage(cpuMillicores, memoryBytes, ephemeralStorageBytes int64) ResourceCost {
 	// Convert millicores to cores
 	cpuCores := float64(cpuMillicores) / 1000.0
 
 	// Convert bytes to GB
 	memoryGB := float64(memoryBytes) / (1024 * 1024 * 1024)
+	ephemeralStorageGB := float64(ephemeralStorageBytes) / (1024 * 1024 * 1024)
 
 	cpuCostPerHour := cpuCores * c.pricing.CPUPerCoreHour
 	memoryCostPerHour := memoryGB * c.pricing.MemoryPerGBHour
-	totalPerHour := cpuCostPerHour + memoryCostPerHour
+	ephemeralStorageCostPerHour := ephemeralStorageGB * c.pricing.EphemeralStoragePerGBHour
+	totalPerHour := cpuCostPerHour + memoryCostPerHour + ephemeralStorageCostPerHour
 
 	return ResourceCost{
-		CPUCostPerHour:    cpuCostPerHour,
-		MemoryCostPerHour: memoryCostPerHour,
-		TotalPerHour:      totalPerHour,
-		TotalPerDay:       totalPerHour * 24,
-		TotalPerMonth:     totalPerHour * 24 * 30,
-		TotalPerYear:      totalPerHour * 24 * 365,
+		CPUCostPerHour:              cpuCostPerHour,
+		MemoryCostPerHour:           memoryCostPerHour,
+		EphemeralStorageCostPerHour: ephemeralStorageCostPerHour,
+		TotalPerHour:                totalPerHour,
+		TotalPerDay:                 totalPerHour * 24,
+		TotalPerMonth:               totalPerHour * 24 * 30,
+		TotalPerYear:                totalPerHour * 24 * 365,
 	}
 }
 
@@ -185,12 +207,23 @@ func (c *Calculator) EstimateSavings(
 	currentCPU, recommendedCPU int64, // millicores
 	currentMemory, recommendedMemory int64, // bytes
 ) SavingsEstimate {
-	currentCost := c.CalculateCost(currentCPU, currentMemory)
-	recommendedCost := c.CalculateCost(recommendedCPU, recommendedMemory)
+	return c.EstimateSavingsWithStorage(currentCPU, recommendedCPU, currentMemory, recommendedMemory, 0, 0)
+}
+
+// EstimateSavingsWithStorage calculates savings between current and recommended resources
+// including ephemeral storage
+func (c *Calculator) EstimateSavingsWithStorage(
+	currentCPU, recommendedCPU int64, // millicores
+	currentMemory, recommendedMemory int64, // bytes
+	currentEphemeralStorage, recommendedEphemeralStorage int64, // bytes
+) SavingsEstimate {
+	currentCost := c.CalculateCostWithStorage(currentCPU, currentMemory, currentEphemeralStorage)
+	recommendedCost := c.CalculateCostWithStorage(recommendedCPU, recommendedMemory, recommendedEphemeralStorage)
 
 	cpuSavingsPerHour := currentCost.CPUCostPerHour - recommendedCost.CPUCostPerHour
 	memorySavingsPerHour := currentCost.MemoryCostPerHour - recommendedCost.MemoryCostPerHour
-	totalSavingsPerHour := cpuSavingsPerHour + memorySavingsPerHour
+	ephemeralStorageSavingsPerHour := currentCost.EphemeralStorageCostPerHour - recommendedCost.EphemeralStorageCostPerHour
+	totalSavingsPerHour := cpuSavingsPerHour + memorySavingsPerHour + ephemeralStorageSavingsPerHour
 
 	// Calculate percentage reduction (avoid division by zero)
 	var percentageReduction float64
@@ -199,17 +232,19 @@ func (c *Calculator) EstimateSavings(
 	}
 
 	return SavingsEstimate{
-		CurrentCost:          currentCost,
-		RecommendedCost:      recommendedCost,
-		CPUSavingsPerHour:    cpuSavingsPerHour,
-		MemorySavingsPerHour: memorySavingsPerHour,
-		TotalSavingsPerHour:  totalSavingsPerHour,
-		SavingsPerDay:        totalSavingsPerHour * 24,
-		SavingsPerMonth:      totalSavingsPerHour * 24 * 30,
-		SavingsPerYear:       totalSavingsPerHour * 24 * 365,
-		PercentageReduction:  percentageReduction,
-		CPUReduction:         float64(currentCPU - recommendedCPU),
-		MemoryReduction:      currentMemory - recommendedMemory,
+		CurrentCost:                    currentCost,
+		RecommendedCost:                recommendedCost,
+		CPUSavingsPerHour:              cpuSavingsPerHour,
+		MemorySavingsPerHour:           memorySavingsPerHour,
+		EphemeralStorageSavingsPerHour: ephemeralStorageSavingsPerHour,
+		TotalSavingsPerHour:            totalSavingsPerHour,
+		SavingsPerDay:                  totalSavingsPerHour * 24,
+		SavingsPerMonth:                totalSavingsPerHour * 24 * 30,
+		SavingsPerYear:                 totalSavingsPerHour * 24 * 365,
+		PercentageReduction:            percentageReduction,
+		CPUReduction:                   float64(currentCPU - recommendedCPU),
+		MemoryReduction:                currentMemory - recommendedMemory,
+		EphemeralStorageReduction:      currentEphemeralStorage - recommendedEphemeralStorage,
 	}
 }
 
@@ -220,28 +255,31 @@ func (c *Calculator) EstimateWorkloadSavings(
 	containers []ContainerResourceChange,
 ) WorkloadSavings {
 	var containerSavings []ContainerSavings
-	var totalCPUSavings, totalMemorySavings float64
+	var totalCPUSavings, totalMemorySavings, totalEphemeralStorageSavings float64
 	var totalCurrentCost, totalRecommendedCost float64
 
 	for _, container := range containers {
-		savings := c.EstimateSavings(
+		savings := c.EstimateSavingsWithStorage(
 			container.CurrentCPU, container.RecommendedCPU,
 			container.CurrentMemory, container.RecommendedMemory,
+			container.CurrentEphemeralStorage, container.RecommendedEphemeralStorage,
 		)
 
 		// Scale by replica count
 		scaledSavings := SavingsEstimate{
-			CurrentCost:          scaleResourceCost(savings.CurrentCost, replicaCount),
-			RecommendedCost:      scaleResourceCost(savings.RecommendedCost, replicaCount),
-			CPUSavingsPerHour:    savings.CPUSavingsPerHour * float64(replicaCount),
-			MemorySavingsPerHour: savings.MemorySavingsPerHour * float64(replicaCount),
-			TotalSavingsPerHour:  savings.TotalSavingsPerHour * float64(replicaCount),
-			SavingsPerDay:        savings.SavingsPerDay * float64(replicaCount),
-			SavingsPerMonth:      savings.SavingsPerMonth * float64(replicaCount),
-			SavingsPerYear:       savings.SavingsPerYear * float64(replicaCount),
-			PercentageReduction:  savings.PercentageReduction,
-			CPUReduction:         savings.CPUReduction * float64(replicaCount),
-			MemoryReduction:      savings.MemoryReduction * int64(replicaCount),
+			CurrentCost:                    scaleResourceCost(savings.CurrentCost, replicaCount),
+			RecommendedCost:                scaleResourceCost(savings.RecommendedCost, replicaCount),
+			CPUSavingsPerHour:              savings.CPUSavingsPerHour * float64(replicaCount),
+			MemorySavingsPerHour:           savings.MemorySavingsPerHour * float64(replicaCount),
+			EphemeralStorageSavingsPerHour: savings.EphemeralStorageSavingsPerHour * float64(replicaCount),
+			TotalSavingsPerHour:            savings.TotalSavingsPerHour * float64(replicaCount),
+			SavingsPerDay:                  savings.SavingsPerDay * float64(replicaCount),
+			SavingsPerMonth:                savings.SavingsPerMonth * float64(replicaCount),
+			SavingsPerYear:                 savings.SavingsPerYear * float64(replicaCount),
+			PercentageReduction:            savings.PercentageReduction,
+			CPUReduction:                   savings.CPUReduction * float64(replicaCount),
+			MemoryReduction:                savings.MemoryReduction * int64(replicaCount),
+			EphemeralStorageReduction:      savings.EphemeralStorageReduction * int64(replicaCount),
 		}
 
 		containerSavings = append(containerSavings, ContainerSavings{
@@ -255,11 +293,12 @@ func (c *Calculator) EstimateWorkloadSavings(
 
 		totalCPUSavings += scaledSavings.CPUSavingsPerHour
 		totalMemorySavings += scaledSavings.MemorySavingsPerHour
+		totalEphemeralStorageSavings += scaledSavings.EphemeralStorageSavingsPerHour
 		totalCurrentCost += scaledSavings.CurrentCost.TotalPerHour
 		totalRecommendedCost += scaledSavings.RecommendedCost.TotalPerHour
 	}
 
-	totalSavingsPerHour := totalCPUSavings + totalMemorySavings
+	totalSavingsPerHour := totalCPUSavings + totalMemorySavings + totalEphemeralStorageSavings
 	var percentageReduction float64
 	if totalCurrentCost > 0 {
 		percentageReduction = (totalSavingsPerHour / totalCurrentCost) * 100
@@ -284,13 +323,14 @@ func (c *Calculator) EstimateWorkloadSavings(
 				TotalPerMonth: totalRecommendedCost * 24 * 30,
 				TotalPerYear:  totalRecommendedCost * 24 * 365,
 			},
-			CPUSavingsPerHour:    totalCPUSavings,
-			MemorySavingsPerHour: totalMemorySavings,
-			TotalSavingsPerHour:  totalSavingsPerHour,
-			SavingsPerDay:        totalSavingsPerHour * 24,
-			SavingsPerMonth:      totalSavingsPerHour * 24 * 30,
-			SavingsPerYear:       totalSavingsPerHour * 24 * 365,
-			PercentageReduction:  percentageReduction,
+			CPUSavingsPerHour:              totalCPUSavings,
+			MemorySavingsPerHour:           totalMemorySavings,
+			EphemeralStorageSavingsPerHour: totalEphemeralStorageSavings,
+			TotalSavingsPerHour:            totalSavingsPerHour,
+			SavingsPerDay:                  totalSavingsPerHour * 24,
+			SavingsPerMonth:                totalSavingsPerHour * 24 * 30,
+			SavingsPerYear:                 totalSavingsPerHour * 24 * 365,
+			PercentageReduction:            percentageReduction,
 		},
 	}
 }
@@ -302,18 +342,22 @@ type ContainerResourceChange struct {
 	RecommendedCPU    int64 // millicores
 	CurrentMemory     int64 // bytes
 	RecommendedMemory int64 // bytes
+
+	CurrentEphemeralStorage     int64 // bytes
+	RecommendedEphemeralStorage int64 // bytes
 }
 
 // scaleResourceCost multiplies a ResourceCost by a replica count
 func scaleResourceCost(cost ResourceCost, replicas int32) ResourceCost {
 	factor := float64(replicas)
 	return ResourceCost{
-		CPUCostPerHour:    cost.CPUCostPerHour * factor,
-		MemoryCostPerHour: cost.MemoryCostPerHour * factor,
-		TotalPerHour:      cost.TotalPerHour * factor,
-		TotalPerDay:       cost.TotalPerDay * factor,
-		TotalPerMonth:     cost.TotalPerMonth * factor,
-		TotalPerYear:      cost.TotalPerYear * factor,
+		CPUCostPerHour:              cost.CPUCostPerHour * factor,
+		MemoryCostPerHour:           cost.MemoryCostPerHour * factor,
+		EphemeralStorageCostPerHour: cost.EphemeralStorageCostPerHour * factor,
+		TotalPerHour:                cost.TotalPerHour * factor,
+		TotalPerDay:                 cost.TotalPerDay * factor,
+		TotalPerMonth:               cost.TotalPerMonth * factor,
+		TotalPerYear:                cost.TotalPerYear * factor,
 	}
 }
 
@@ -358,7 +402,7 @@ type SavingsReport struct {
 // GenerateReport creates a comprehensive savings report from multiple workloads
 func (c *Calculator) GenerateReport(workloads []WorkloadSavings) SavingsReport {
 	var totalCurrentCost, totalRecommendedCost float64
-	var totalCPUSavings, totalMemorySavings float64
+	var totalCPUSavings, totalMemorySavings, totalEphemeralStorageSavings float64
 	containerCount := 0
 
 	for _, w := range workloads {
@@ -366,10 +410,11 @@ func (c *Calculator) GenerateReport(workloads []WorkloadSavings) SavingsReport {
 		totalRecommendedCost += w.TotalSavings.RecommendedCost.TotalPerHour
 		totalCPUSavings += w.TotalSavings.CPUSavingsPerHour
 		totalMemorySavings += w.TotalSavings.MemorySavingsPerHour
+		totalEphemeralStorageSavings += w.TotalSavings.EphemeralStorageSavingsPerHour
 		containerCount += len(w.Containers)
 	}
 
-	totalSavingsPerHour := totalCPUSavings + totalMemorySavings
+	totalSavingsPerHour := totalCPUSavings + totalMemorySavings + totalEphemeralStorageSavings
 	var percentageReduction float64
 	if totalCurrentCost > 0 {
 		percentageReduction = (totalSavingsPerHour / totalCurrentCost) * 100
@@ -392,13 +437,14 @@ func (c *Calculator) GenerateReport(workloads []WorkloadSavings) SavingsReport {
 				TotalPerMonth: totalRecommendedCost * 24 * 30,
 				TotalPerYear:  totalRecommendedCost * 24 * 365,
 			},
-			CPUSavingsPerHour:    totalCPUSavings,
-			MemorySavingsPerHour: totalMemorySavings,
-			TotalSavingsPerHour:  totalSavingsPerHour,
-			SavingsPerDay:        totalSavingsPerHour * 24,
-			SavingsPerMonth:      totalSavingsPerHour * 24 * 30,
-			SavingsPerYear:       totalSavingsPerHour * 24 * 365,
-			PercentageReduction:  percentageReduction,
+			CPUSavingsPerHour:              totalCPUSavings,
+			MemorySavingsPerHour:           totalMemorySavings,
+			EphemeralStorageSavingsPerHour: totalEphemeralStorageSavings,
+			TotalSavingsPerHour:            totalSavingsPerHour,
+			SavingsPerDay:                  totalSavingsPerHour * 24,
+			SavingsPerMonth:                totalSavingsPerHour * 24 * 30,
+			SavingsPerYear:                 totalSavingsPerHour * 24 * 365,
+			PercentageReduction:            percentageReduction,
 		},
 		TotalCurrentCost: ResourceCost{
 			TotalPerHour:  totalCurrentCost,
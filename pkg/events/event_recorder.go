@@ -24,6 +24,31 @@ const (
 	ReasonPeakLoadPredicted        = "PeakLoadPredicted"
 	ReasonGitOpsExportSucceeded    = "GitOpsExportSucceeded"
 	ReasonGitOpsExportFailed       = "GitOpsExportFailed"
+	ReasonForecastAdjusted         = "ForecastAdjusted"
+	ReasonTimeProfileSwitched      = "TimeProfileSwitched"
+	ReasonMemoryLeakSuspected      = "MemoryLeakSuspected"
+	ReasonOOMDetected              = "OOMDetected"
+	ReasonOOMFastPathApplied       = "OOMFastPathApplied"
+	ReasonOOMFastPathSkipped       = "OOMFastPathSkipped"
+	ReasonNodeFitClamped           = "NodeFitClamped"
+	ReasonNodeFitBlocked           = "NodeFitBlocked"
+	ReasonQuotaClamped             = "QuotaClamped"
+	ReasonQuotaBlocked             = "QuotaBlocked"
+	ReasonQoSClassChange           = "QoSClassChange"
+	ReasonQoSClassChangeBlocked    = "QoSClassChangeBlocked"
+	ReasonSafetyMarginTightened    = "SafetyMarginTightened"
+	ReasonSafetyMarginWidened      = "SafetyMarginWidened"
+	ReasonRuntimeMemoryFloor       = "RuntimeMemoryFloor"
+	ReasonRuntimeSettingMismatch   = "RuntimeSettingMismatch"
+	ReasonStartupCPURecommended    = "StartupCPURecommended"
+	ReasonOptimizationRolledBack   = "OptimizationRolledBack"
+	ReasonRollbackFailed           = "RollbackFailed"
+	ReasonWorkloadQuarantined      = "WorkloadQuarantined"
+	ReasonCanaryAborted            = "CanaryAborted"
+	ReasonCanaryPromoted           = "CanaryPromoted"
+	ReasonRolloutFailed            = "RolloutFailed"
+	ReasonChangeBudgetExhausted    = "ChangeBudgetExhausted"
+	ReasonCircuitBreakerChanged    = "CircuitBreakerStateChanged"
 )
 
 type OptimizerEventRecorder struct {
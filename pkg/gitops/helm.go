@@ -53,6 +53,9 @@ func (g *DefaultHelmGenerator) GenerateValues(recommendations []ResourceRecommen
 		requests := resources["requests"].(map[string]interface{})
 		requests["cpu"] = formatCPU(rec.RecommendedCPU)
 		requests["memory"] = formatMemory(rec.RecommendedMemory)
+		if rec.RecommendedEphemeralStorage > 0 {
+			requests["ephemeral-storage"] = formatMemory(rec.RecommendedEphemeralStorage)
+		}
 
 		// Set limits if requested
 		if rec.SetLimits {
@@ -76,6 +79,15 @@ func (g *DefaultHelmGenerator) GenerateValues(recommendations []ResourceRecommen
 				optimizer["reason"] = rec.Reason
 			}
 		}
+
+		// Startup CPU is advice for charts that size a warm-up boost, not a request
+		if rec.StartupCPU > 0 {
+			if _, exists := workloadValues["optimizer"]; !exists {
+				workloadValues["optimizer"] = make(map[string]interface{})
+			}
+			optimizer := workloadValues["optimizer"].(map[string]interface{})
+			optimizer["startupCPU"] = formatCPU(rec.StartupCPU)
+		}
 	}
 
 	// Convert to YAML
@@ -142,10 +154,7 @@ func GenerateValuesWithPath(rec ResourceRecommendation, path []string) (HelmValu
 	}
 
 	resources := map[string]interface{}{
-		"requests": map[string]string{
-			"cpu":    formatCPU(rec.RecommendedCPU),
-			"memory": formatMemory(rec.RecommendedMemory),
-		},
+		"requests": buildRequests(rec),
 	}
 
 	if rec.SetLimits {
@@ -46,6 +46,29 @@ func TestGenerateValues(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "startup CPU",
+			recommendations: []ResourceRecommendation{
+				{
+					Namespace:         "production",
+					Name:              "api-server",
+					Kind:              "Deployment",
+					ContainerName:     "api",
+					RecommendedCPU:    500,
+					RecommendedMemory: 512 * 1024 * 1024,
+					StartupCPU:        1500,
+				},
+			},
+			wantErr: false,
+			verify: func(t *testing.T, values string) {
+				if !strings.Contains(values, `startupCPU: "1.50"`) {
+					t.Errorf("Expected values to contain the startup CPU, got:\n%s", values)
+				}
+				if strings.Contains(values, "cpu: \"1.50\"") {
+					t.Error("Expected the startup CPU not to be set as a request")
+				}
+			},
+		},
 		{
 			name: "multiple recommendations",
 			recommendations: []ResourceRecommendation{
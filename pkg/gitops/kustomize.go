@@ -38,6 +38,11 @@ func (g *DefaultKustomizeGenerator) GenerateStrategicMerge(rec ResourceRecommend
 		return "", fmt.Errorf("failed to marshal patch to YAML: %w", err)
 	}
 
+	// Startup CPU is advice for the operator, not part of the patch
+	if rec.StartupCPU > 0 {
+		return fmt.Sprintf("# Container %s needs %s CPU while warming up\n", rec.ContainerName, formatCPU(rec.StartupCPU)) +
+			string(yamlBytes), nil
+	}
 	return string(yamlBytes), nil
 }
 
@@ -68,6 +73,16 @@ func (g *DefaultKustomizeGenerator) GenerateJSON6902(rec ResourceRecommendation)
 		Value: formatMemory(rec.RecommendedMemory),
 	})
 
+	// Ephemeral-storage request patch ("add" also replaces an existing value)
+	if rec.RecommendedEphemeralStorage > 0 {
+		storagePath := fmt.Sprintf("/spec/template/spec/containers/%d/resources/requests/ephemeral-storage", containerIndex)
+		patches = append(patches, JSON6902Patch{
+			Op:    "add",
+			Path:  storagePath,
+			Value: formatMemory(rec.RecommendedEphemeralStorage),
+		})
+	}
+
 	// If SetLimits is true, also patch limits
 	if rec.SetLimits {
 		cpuLimitPath := fmt.Sprintf("/spec/template/spec/containers/%d/resources/limits/cpu", containerIndex)
@@ -117,10 +132,7 @@ func (g *DefaultKustomizeGenerator) GenerateKustomization(patchFiles []string) (
 // buildPatchSpec builds the spec section of the patch
 func buildPatchSpec(rec ResourceRecommendation) interface{} {
 	resources := map[string]interface{}{
-		"requests": map[string]string{
-			"cpu":    formatCPU(rec.RecommendedCPU),
-			"memory": formatMemory(rec.RecommendedMemory),
-		},
+		"requests": buildRequests(rec),
 	}
 
 	// Add limits if requested
@@ -148,6 +160,18 @@ func buildPatchSpec(rec ResourceRecommendation) interface{} {
 	}
 }
 
+// buildRequests returns the resource requests of a recommendation
+func buildRequests(rec ResourceRecommendation) map[string]string {
+	requests := map[string]string{
+		"cpu":    formatCPU(rec.RecommendedCPU),
+		"memory": formatMemory(rec.RecommendedMemory),
+	}
+	if rec.RecommendedEphemeralStorage > 0 {
+		requests["ephemeral-storage"] = formatMemory(rec.RecommendedEphemeralStorage)
+	}
+	return requests
+}
+
 // getAPIVersion returns the API version for a given kind
 func getAPIVersion(kind string) string {
 	switch kind {
@@ -214,5 +238,8 @@ func validateRecommendation(rec ResourceRecommendation) error {
 	if rec.RecommendedMemory <= 0 {
 		return fmt.Errorf("recommended memory must be positive")
 	}
+	if rec.RecommendedEphemeralStorage < 0 {
+		return fmt.Errorf("recommended ephemeral storage must not be negative")
+	}
 	return nil
 }
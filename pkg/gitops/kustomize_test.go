@@ -54,6 +54,31 @@ func TestGenerateStrategicMerge(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "startup CPU noted as a comment",
+			rec: ResourceRecommendation{
+				Namespace:         "production",
+				Name:              "api-server",
+				Kind:              "Deployment",
+				ContainerName:     "api",
+				RecommendedCPU:    500,
+				RecommendedMemory: 512 * 1024 * 1024,
+				StartupCPU:        1500,
+			},
+			wantErr: false,
+			verify: func(t *testing.T, patch string) {
+				if !strings.HasPrefix(patch, "# Container api needs 1.50 CPU while warming up\n") {
+					t.Errorf("Expected a startup CPU comment, got:\n%s", patch)
+				}
+				var p KustomizePatch
+				if err := yaml.Unmarshal([]byte(patch), &p); err != nil {
+					t.Fatalf("Failed to unmarshal patch: %v", err)
+				}
+				if strings.Contains(patch, "cpu: \"1.50\"") {
+					t.Error("Expected the startup CPU not to be patched as a request")
+				}
+			},
+		},
 		{
 			name: "statefulset with limits",
 			rec: ResourceRecommendation{
@@ -213,6 +238,35 @@ func TestGenerateJSON6902(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "JSON 6902 with ephemeral storage",
+			rec: ResourceRecommendation{
+				Namespace:                   "production",
+				Name:                        "api-server",
+				Kind:                        "Deployment",
+				ContainerName:               "api",
+				RecommendedCPU:              500,
+				RecommendedMemory:           512 * 1024 * 1024,
+				RecommendedEphemeralStorage: 2 * 1024 * 1024 * 1024,
+			},
+			wantErr: false,
+			verify: func(t *testing.T, patch string) {
+				var patches []JSON6902Patch
+				if err := json.Unmarshal([]byte(patch), &patches); err != nil {
+					t.Fatalf("Failed to unmarshal JSON: %v", err)
+				}
+
+				// Should have 3 patches (CPU, memory and ephemeral-storage requests)
+				if len(patches) != 3 {
+					t.Fatalf("Expected 3 patches, got %d", len(patches))
+				}
+
+				storage := patches[2]
+				if storage.Op != "add" || !strings.HasSuffix(storage.Path, "/requests/ephemeral-storage") || storage.Value != "2Gi" {
+					t.Errorf("Unexpected ephemeral-storage patch: %+v", storage)
+				}
+			},
+		},
 		{
 			name: "JSON 6902 with limits",
 			rec: ResourceRecommendation{
@@ -49,6 +49,15 @@ type ResourceRecommendation struct {
 	// RecommendedMemory in bytes
 	RecommendedMemory int64
 
+	// RecommendedEphemeralStorage in bytes, 0 leaves the ephemeral-storage request unchanged.
+	// Ephemeral-storage limits are never set, since exceeding one evicts the pod.
+	RecommendedEphemeralStorage int64
+
+	// StartupCPU in millicores is the CPU needed while warming up, 0 when warm-up does not
+	// dominate. It is exported for operators (for example to size a startup CPU boost) and
+	// never patched into requests.
+	StartupCPU int64
+
 	// SetLimits indicates whether to set limits equal to requests
 	SetLimits bool
 
@@ -9,6 +9,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
@@ -53,8 +54,18 @@ func (c *MetricsCollector) GetPodMetrics(namespace string) ([]models.PodMetric,
 
 	// Create a loopup map for pod specs
 	podMap := make(map[string]corev1.Pod)
+	nodeNames := make(map[string]bool)
 	for _, p := range podList.Items {
 		podMap[p.Name] = p
+		if p.Spec.NodeName != "" {
+			nodeNames[p.Spec.NodeName] = true
+		}
+	}
+
+	// Ephemeral-storage usage is not part of the metrics API, read it from the kubelets
+	storageUsage, err := c.getEphemeralStorageUsage(context.TODO(), nodeNames)
+	if err != nil {
+		klog.Warningf("Collecting metrics without ephemeral-storage usage of some nodes: %v", err)
 	}
 
 	var results []models.PodMetric
@@ -68,6 +79,14 @@ func (c *MetricsCollector) GetPodMetrics(namespace string) ([]models.PodMetric,
 			continue // Pod might have been deleted while we were fetching
 		}
 
+		// ContainersReady flips once per pod, so it is the best available ready time per container
+		var readyAt time.Time
+		for _, cond := range podSpec.Status.Conditions {
+			if cond.Type == corev1.ContainersReady && cond.Status == corev1.ConditionTrue {
+				readyAt = cond.LastTransitionTime.Time
+			}
+		}
+
 		var containerMetrics []models.ContainerMetric
 
 		// Match individual containers
@@ -75,6 +94,7 @@ func (c *MetricsCollector) GetPodMetrics(namespace string) ([]models.PodMetric,
 
 			// Initialize default values (0)
 			var reqCPU, reqMem, limCPU, limMem int64
+			var reqStorage, limStorage int64
 
 			// Find the specific container config inside the Pod Spec
 			for _, containerSpec := range podSpec.Spec.Containers {
@@ -86,6 +106,24 @@ func (c *MetricsCollector) GetPodMetrics(namespace string) ([]models.PodMetric,
 					// Extract Limits (if they exist)
 					limCPU = containerSpec.Resources.Limits.Cpu().MilliValue()
 					limMem = containerSpec.Resources.Limits.Memory().Value() / (1024 * 1024)
+
+					// Ephemeral storage is kept in bytes
+					reqStorage = containerSpec.Resources.Requests.StorageEphemeral().Value()
+					limStorage = containerSpec.Resources.Limits.StorageEphemeral().Value()
+					break
+				}
+			}
+
+			// Find when the running container instance started and whether it is ready
+			var startedAt, containerReadyAt time.Time
+			for _, status := range podSpec.Status.ContainerStatuses {
+				if status.Name == containerUsage.Name {
+					if status.State.Running != nil {
+						startedAt = status.State.Running.StartedAt.Time
+					}
+					if status.Ready {
+						containerReadyAt = readyAt
+					}
 					break
 				}
 			}
@@ -103,13 +141,26 @@ func (c *MetricsCollector) GetPodMetrics(namespace string) ([]models.PodMetric,
 				RequestMemory: reqMem,
 				LimitCPU:      limCPU,
 				LimitMemory:   limMem,
+
+				UsageEphemeralStorage:   storageUsage[containerKey{m.Namespace, m.Name, containerUsage.Name}],
+				RequestEphemeralStorage: reqStorage,
+				LimitEphemeralStorage:   limStorage,
+
+				StartedAt: startedAt,
+				ReadyAt:   containerReadyAt,
 			})
 		}
 
+		var startTime time.Time
+		if podSpec.Status.StartTime != nil {
+			startTime = podSpec.Status.StartTime.Time
+		}
+
 		results = append(results, models.PodMetric{
 			PodName:    m.Name,
 			Namespace:  m.Namespace,
 			Timestamp:  time.Now(),
+			StartTime:  startTime,
 			Containers: containerMetrics,
 		})
 	}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// kubeletSummary is the subset of the kubelet stats summary (/stats/summary) used to
+// read per-container ephemeral-storage usage
+type kubeletSummary struct {
+	Pods []kubeletPodStats `json:"pods"`
+}
+
+type kubeletPodStats struct {
+	PodRef struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"podRef"`
+	Containers []kubeletContainerStats `json:"containers"`
+}
+
+type kubeletContainerStats struct {
+	Name   string          `json:"name"`
+	Rootfs *kubeletFsStats `json:"rootfs,omitempty"`
+	Logs   *kubeletFsStats `json:"logs,omitempty"`
+}
+
+type kubeletFsStats struct {
+	UsedBytes *uint64 `json:"usedBytes,omitempty"`
+}
+
+// containerKey identifies a container across pods
+type containerKey struct {
+	namespace string
+	pod       string
+	container string
+}
+
+// parseEphemeralStorageUsage extracts the ephemeral-storage usage of every container in
+// a kubelet stats summary. A container's usage is its writable layer plus its logs, the
+// same amount the kubelet counts against its ephemeral-storage limit. Pod-level emptyDir
+// volumes cannot be attributed to a container and are left out.
+func parseEphemeralStorageUsage(data []byte) (map[containerKey]int64, error) {
+	var summary kubeletSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet stats summary: %w", err)
+	}
+
+	usage := make(map[containerKey]int64)
+	for _, pod := range summary.Pods {
+		for _, container := range pod.Containers {
+			var used uint64
+			if container.Rootfs != nil && container.Rootfs.UsedBytes != nil {
+				used += *container.Rootfs.UsedBytes
+			}
+			if container.Logs != nil && container.Logs.UsedBytes != nil {
+				used += *container.Logs.UsedBytes
+			}
+			usage[containerKey{pod.PodRef.Namespace, pod.PodRef.Name, container.Name}] = int64(used)
+		}
+	}
+	return usage, nil
+}
+
+// maxConcurrentNodeScrapes bounds how many kubelet stats summaries are fetched at once
+const maxConcurrentNodeScrapes = 10
+
+// getEphemeralStorageUsage reads container ephemeral-storage usage from the kubelet
+// stats summary of each node, proxied through the API server
+func (c *MetricsCollector) getEphemeralStorageUsage(ctx context.Context, nodeNames map[string]bool) (map[containerKey]int64, error) {
+	return scrapeEphemeralStorageUsage(ctx, nodeNames, func(ctx context.Context, nodeName string) ([]byte, error) {
+		return c.Clientset.CoreV1().RESTClient().Get().
+			AbsPath("/api/v1/nodes", nodeName, "proxy", "stats", "summary").
+			DoRaw(ctx)
+	})
+}
+
+// scrapeEphemeralStorageUsage fetches the stats summary of the given nodes concurrently,
+// at most maxConcurrentNodeScrapes at a time. A node that cannot be scraped is skipped:
+// the usage of every other node is still returned, together with an error naming the
+// nodes that failed.
+func scrapeEphemeralStorageUsage(ctx context.Context, nodeNames map[string]bool,
+	fetch func(ctx context.Context, nodeName string) ([]byte, error)) (map[containerKey]int64, error) {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		errs  []error
+		usage = make(map[containerKey]int64)
+		slots = make(chan struct{}, maxConcurrentNodeScrapes)
+	)
+
+	for nodeName := range nodeNames {
+		wg.Add(1)
+		go func(nodeName string) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			nodeUsage, err := fetchNodeUsage(ctx, nodeName, fetch)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			for key, used := range nodeUsage {
+				usage[key] = used
+			}
+		}(nodeName)
+	}
+	wg.Wait()
+
+	return usage, errors.Join(errs...)
+}
+
+func fetchNodeUsage(ctx context.Context, nodeName string,
+	fetch func(ctx context.Context, nodeName string) ([]byte, error)) (map[containerKey]int64, error) {
+	data, err := fetch(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stats summary of node %s: %w", nodeName, err)
+	}
+	nodeUsage, err := parseEphemeralStorageUsage(data)
+	if err != nil {
+		return nil, fmt.Errorf("node %s: %w", nodeName, err)
+	}
+	return nodeUsage, nil
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseEphemeralStorageUsage(t *testing.T) {
+	summary := []byte(`{
+		"node": {"nodeName": "node-1"},
+		"pods": [{
+			"podRef": {"name": "api-7d4b9c-x2k4q", "namespace": "default"},
+			"containers": [
+				{"name": "app", "rootfs": {"usedBytes": 1048576}, "logs": {"usedBytes": 2097152}},
+				{"name": "sidecar", "rootfs": {"availableBytes": 100}}
+			],
+			"ephemeral-storage": {"usedBytes": 99999999}
+		}]
+	}`)
+
+	usage, err := parseEphemeralStorageUsage(summary)
+	if err != nil {
+		t.Fatalf("parseEphemeralStorageUsage failed: %v", err)
+	}
+
+	if got := usage[containerKey{"default", "api-7d4b9c-x2k4q", "app"}]; got != 3*1024*1024 {
+		t.Errorf("Expected rootfs + logs (3Mi), got %d", got)
+	}
+	sidecar, ok := usage[containerKey{"default", "api-7d4b9c-x2k4q", "sidecar"}]
+	if !ok || sidecar != 0 {
+		t.Errorf("Expected zero usage for a container without used bytes, got %d (present=%v)", sidecar, ok)
+	}
+
+	if _, err := parseEphemeralStorageUsage([]byte("not json")); err == nil {
+		t.Error("Expected an error for an invalid summary")
+	}
+}
+
+func TestScrapeEphemeralStorageUsage_KeepsPartialResults(t *testing.T) {
+	nodes := map[string]bool{"node-1": true, "node-2": true, "node-3": true}
+	fetch := func(ctx context.Context, nodeName string) ([]byte, error) {
+		if nodeName == "node-2" {
+			return nil, errors.New("connection refused")
+		}
+		return []byte(fmt.Sprintf(`{"pods": [{"podRef": {"name": "pod-%s", "namespace": "default"},
+			"containers": [{"name": "app", "rootfs": {"usedBytes": 1024}}]}]}`, nodeName)), nil
+	}
+
+	usage, err := scrapeEphemeralStorageUsage(context.Background(), nodes, fetch)
+	if err == nil || !strings.Contains(err.Error(), "node-2") {
+		t.Errorf("Expected an error naming the failed node, got %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("Expected usage of the two reachable nodes, got %v", usage)
+	}
+	for _, node := range []string{"node-1", "node-3"} {
+		if got := usage[containerKey{"default", "pod-" + node, "app"}]; got != 1024 {
+			t.Errorf("Expected 1024 bytes for the container on %s, got %d", node, got)
+		}
+	}
+}
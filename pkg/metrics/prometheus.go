@@ -46,6 +46,25 @@ type PrometheusExporter struct {
 	PodStartupDurationAvg *prometheus.GaugeVec
 	PodStartupDurationP95 *prometheus.GaugeVec
 	SlowStartupsTotal     *prometheus.CounterVec
+
+	// Memory leak metrics
+	MemoryLeakSuspected     *prometheus.GaugeVec
+	MemoryLeakGrowthRate    *prometheus.GaugeVec
+	MemoryLeakTimeToLimit   *prometheus.GaugeVec
+	MemoryReductionsBlocked *prometheus.CounterVec
+
+	// OOM fast-path metrics
+	OOMFastPathActions *prometheus.CounterVec
+
+	// Bin-packing simulation metrics
+	ReclaimableNodes *prometheus.GaugeVec
+
+	// Change budget metrics
+	ChangesDeferred *prometheus.CounterVec
+
+	// Circuit breaker metrics
+	CircuitBreakerState *prometheus.GaugeVec
+	CircuitBreakerTrips *prometheus.CounterVec
 }
 
 // NewPrometheusExporter creates a new Prometheus metrics exporter
@@ -244,6 +263,88 @@ func NewPrometheusExporter(namespace string) *PrometheusExporter {
 			},
 			[]string{"workload", "namespace", "threshold"},
 		),
+
+		// Memory leak metrics
+		MemoryLeakSuspected: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "memory_leak_suspected",
+				Help:      "Whether a memory leak is suspected for a container (1 = suspected)",
+			},
+			[]string{"workload", "namespace", "container", "severity"},
+		),
+		MemoryLeakGrowthRate: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "memory_leak_growth_bytes_per_hour",
+				Help:      "Memory growth rate of containers with a suspected leak",
+			},
+			[]string{"workload", "namespace", "container"},
+		),
+		MemoryLeakTimeToLimit: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "memory_leak_time_to_limit_seconds",
+				Help:      "Projected time until a leaking container reaches its memory limit",
+			},
+			[]string{"workload", "namespace", "container"},
+		),
+		MemoryReductionsBlocked: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "memory_reductions_blocked_total",
+				Help:      "Total number of memory reductions blocked due to a suspected leak",
+			},
+			[]string{"workload", "namespace", "container"},
+		),
+
+		// OOM fast-path metrics
+		OOMFastPathActions: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "oom_fastpath_actions_total",
+				Help:      "Total number of OOM fast-path decisions by result (applied/dry_run/rate_limited/outside_window/at_limit/quarantined/circuit_open/deferred/failed)",
+			},
+			[]string{"workload", "namespace", "container", "result"},
+		),
+
+		// Bin-packing simulation metrics
+		ReclaimableNodes: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "reclaimable_nodes",
+				Help:      "Nodes the current recommendations would free per node pool beyond re-packing at current requests, from the bin-packing simulation",
+			},
+			[]string{"config", "namespace", "pool"},
+		),
+
+		// Change budget metrics
+		ChangesDeferred: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "changes_deferred_total",
+				Help:      "Total number of changes deferred by the change budget, by exhausted limit (global_concurrent/global_hourly/namespace_concurrent/namespace_hourly)",
+			},
+			[]string{"config", "namespace", "limit"},
+		),
+
+		// Circuit breaker metrics
+		CircuitBreakerState: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: namespace,
+				Name:      "circuit_breaker_state",
+				Help:      "State of workload and namespace circuit breakers that recorded failures (0=Closed, 1=HalfOpen, 2=Open)",
+			},
+			[]string{"config", "scope", "namespace", "kind", "workload"},
+		),
+		CircuitBreakerTrips: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "circuit_breaker_trips_total",
+				Help:      "Total number of times a workload or namespace circuit breaker opened",
+			},
+			[]string{"config", "scope", "namespace"},
+		),
 	}
 }
 
@@ -353,3 +454,55 @@ func (e *PrometheusExporter) RecordSlowStartup(workload, namespace string, thres
 	threshold := fmt.Sprintf("%.0fs", thresholdSeconds)
 	e.SlowStartupsTotal.WithLabelValues(workload, namespace, threshold).Inc()
 }
+
+// RecordMemoryLeak records the leak analysis of a container. The series of containers
+// without a suspected leak are removed.
+func (e *PrometheusExporter) RecordMemoryLeak(workload, namespace, container, severity string, suspected bool, bytesPerHour, timeToLimitSeconds float64) {
+	e.MemoryLeakSuspected.DeletePartialMatch(prometheus.Labels{
+		"workload": workload, "namespace": namespace, "container": container,
+	})
+	if !suspected {
+		e.MemoryLeakGrowthRate.DeleteLabelValues(workload, namespace, container)
+		e.MemoryLeakTimeToLimit.DeleteLabelValues(workload, namespace, container)
+		return
+	}
+	e.MemoryLeakSuspected.WithLabelValues(workload, namespace, container, severity).Set(1)
+	e.MemoryLeakGrowthRate.WithLabelValues(workload, namespace, container).Set(bytesPerHour)
+	e.MemoryLeakTimeToLimit.WithLabelValues(workload, namespace, container).Set(timeToLimitSeconds)
+}
+
+// RecordMemoryReductionBlocked records a memory reduction withheld because of a suspected leak
+func (e *PrometheusExporter) RecordMemoryReductionBlocked(workload, namespace, container string) {
+	e.MemoryReductionsBlocked.WithLabelValues(workload, namespace, container).Inc()
+}
+
+// RecordOOMFastPath records the outcome of an OOM fast-path memory bump
+func (e *PrometheusExporter) RecordOOMFastPath(workload, namespace, container, result string) {
+	e.OOMFastPathActions.WithLabelValues(workload, namespace, container, result).Inc()
+}
+
+// RecordReclaimableNodes records the simulated number of reclaimable nodes in a node pool
+func (e *PrometheusExporter) RecordReclaimableNodes(config, namespace, pool string, nodes int) {
+	e.ReclaimableNodes.WithLabelValues(config, namespace, pool).Set(float64(nodes))
+}
+
+// RecordChangeDeferred records a change deferred because a change budget limit was exhausted
+func (e *PrometheusExporter) RecordChangeDeferred(config, namespace, limit string) {
+	e.ChangesDeferred.WithLabelValues(config, namespace, limit).Inc()
+}
+
+// ResetCircuitBreakerStates removes the circuit breaker series of a config, before its
+// current circuits are recorded again
+func (e *PrometheusExporter) ResetCircuitBreakerStates(config string) {
+	e.CircuitBreakerState.DeletePartialMatch(prometheus.Labels{"config": config})
+}
+
+// RecordCircuitBreakerState records the state of a workload or namespace circuit breaker
+func (e *PrometheusExporter) RecordCircuitBreakerState(config, scope, namespace, kind, workload string, state float64) {
+	e.CircuitBreakerState.WithLabelValues(config, scope, namespace, kind, workload).Set(state)
+}
+
+// RecordCircuitBreakerTrip records a circuit breaker opening
+func (e *PrometheusExporter) RecordCircuitBreakerTrip(config, scope, namespace string) {
+	e.CircuitBreakerTrips.WithLabelValues(config, scope, namespace).Inc()
+}
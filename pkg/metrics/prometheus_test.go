@@ -414,3 +414,53 @@ func TestPrometheusExporter_RecordPodStartupMetrics(t *testing.T) {
 		t.Errorf("Expected slow startups 1.0, got %f", slow)
 	}
 }
+
+func TestPrometheusExporter_RecordMemoryLeak(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	exporter := &PrometheusExporter{
+		MemoryLeakSuspected: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Namespace: "test", Name: "memory_leak_suspected", Help: "Test metric"},
+			[]string{"workload", "namespace", "container", "severity"},
+		),
+		MemoryLeakGrowthRate: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Namespace: "test", Name: "memory_leak_growth_bytes_per_hour", Help: "Test metric"},
+			[]string{"workload", "namespace", "container"},
+		),
+		MemoryLeakTimeToLimit: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{Namespace: "test", Name: "memory_leak_time_to_limit_seconds", Help: "Test metric"},
+			[]string{"workload", "namespace", "container"},
+		),
+		MemoryReductionsBlocked: prometheus.NewCounterVec(
+			prometheus.CounterOpts{Namespace: "test", Name: "memory_reductions_blocked_total", Help: "Test metric"},
+			[]string{"workload", "namespace", "container"},
+		),
+	}
+
+	registry.MustRegister(exporter.MemoryLeakSuspected)
+	registry.MustRegister(exporter.MemoryLeakGrowthRate)
+	registry.MustRegister(exporter.MemoryLeakTimeToLimit)
+	registry.MustRegister(exporter.MemoryReductionsBlocked)
+
+	exporter.RecordMemoryLeak("api", "default", "app", "high", true, 50*1024*1024, 3600)
+	exporter.RecordMemoryReductionBlocked("api", "default", "app")
+
+	if v := testutil.ToFloat64(exporter.MemoryLeakSuspected.WithLabelValues("api", "default", "app", "high")); v != 1.0 {
+		t.Errorf("Expected leak suspected 1.0, got %f", v)
+	}
+	if v := testutil.ToFloat64(exporter.MemoryLeakTimeToLimit.WithLabelValues("api", "default", "app")); v != 3600 {
+		t.Errorf("Expected time to limit 3600, got %f", v)
+	}
+	if v := testutil.ToFloat64(exporter.MemoryReductionsBlocked.WithLabelValues("api", "default", "app")); v != 1.0 {
+		t.Errorf("Expected blocked reductions 1.0, got %f", v)
+	}
+
+	// Clearing the leak removes the series
+	exporter.RecordMemoryLeak("api", "default", "app", "", false, 0, 0)
+	if n := testutil.CollectAndCount(exporter.MemoryLeakSuspected); n != 0 {
+		t.Errorf("Expected leak series to be removed, got %d", n)
+	}
+	if n := testutil.CollectAndCount(exporter.MemoryLeakGrowthRate); n != 0 {
+		t.Errorf("Expected growth rate series to be removed, got %d", n)
+	}
+}
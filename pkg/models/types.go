@@ -17,6 +17,16 @@ type ContainerMetric struct {
 	RequestMemory int64 `json:"request_memory"`
 	LimitCPU      int64 `json:"limit_cpu"`
 	LimitMemory   int64 `json:"limit_memory"`
+
+	// Ephemeral storage in bytes (container rootfs and logs, from kubelet stats)
+	UsageEphemeralStorage   int64 `json:"usage_ephemeral_storage,omitempty"`
+	RequestEphemeralStorage int64 `json:"request_ephemeral_storage,omitempty"`
+	LimitEphemeralStorage   int64 `json:"limit_ephemeral_storage,omitempty"`
+
+	// Lifecycle of the running container instance, zero when unknown. StartedAt
+	// resets on every restart; ReadyAt is when the pod's containers became ready.
+	StartedAt time.Time `json:"started_at"`
+	ReadyAt   time.Time `json:"ready_at"`
 }
 
 // PodMetric represents a single data point for a pod
@@ -24,6 +34,7 @@ type PodMetric struct {
 	PodName    string            `json:"pod_name"`
 	Namespace  string            `json:"namespace"`
 	Timestamp  time.Time         `json:"timestamp"`
+	StartTime  time.Time         `json:"start_time"` // When the kubelet acknowledged the pod, zero when unknown
 	Containers []ContainerMetric `json:"containers"`
 	//CPUMillis  int64             `json:"cpu_millis"` // CPU usage in millicores (m)
 	//MemoryMB   int64             `json:"memory_mb"`  // Memory usage in Megabytes (Mi)
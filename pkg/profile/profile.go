@@ -50,6 +50,11 @@ type ProfileSettings struct {
 	// SafetyMargin is the multiplier applied to recommendations (e.g., 1.2 = 20% buffer)
 	SafetyMargin float64
 
+	// MinSafetyMargin and MaxSafetyMargin bound the per-workload margins learned
+	// from the outcomes of applied changes
+	MinSafetyMargin float64
+	MaxSafetyMargin float64
+
 	// MinSamples is the minimum samples required before making recommendations
 	MinSamples int
 
@@ -71,6 +76,9 @@ type ProfileSettings struct {
 	// RollbackOnError indicates whether to automatically rollback on errors
 	RollbackOnError bool
 
+	// RollbackCooldown is how long a rolled-back workload is left alone
+	RollbackCooldown time.Duration
+
 	// CircuitBreakerEnabled controls circuit breaker activation
 	CircuitBreakerEnabled bool
 
@@ -152,6 +160,8 @@ func ProductionProfile() *Profile {
 			CPUPercentile:    99, // Use P99 for safety
 			MemoryPercentile: 99,
 			SafetyMargin:     1.4, // 40% buffer
+			MinSafetyMargin:  1.2,
+			MaxSafetyMargin:  2.0,
 			MinSamples:       200,
 			HistoryDuration:  7 * 24 * time.Hour, // 1 week of data
 			MinConfidence:    80.0,               // High confidence required
@@ -159,6 +169,7 @@ func ProductionProfile() *Profile {
 			MaxChangePercent: 20.0,               // Max 20% change at a time
 			RequireApproval:  true,               // Require manual approval
 			RollbackOnError:  true,
+			RollbackCooldown: 24 * time.Hour,
 
 			CircuitBreakerEnabled:   true,
 			CircuitBreakerThreshold: 2, // Open after 2 errors
@@ -184,6 +195,8 @@ func StagingProfile() *Profile {
 			CPUPercentile:    95, // P95
 			MemoryPercentile: 95,
 			SafetyMargin:     1.2, // 20% buffer
+			MinSafetyMargin:  1.1,
+			MaxSafetyMargin:  1.6,
 			MinSamples:       100,
 			HistoryDuration:  3 * 24 * time.Hour, // 3 days of data
 			MinConfidence:    60.0,
@@ -191,6 +204,7 @@ func StagingProfile() *Profile {
 			MaxChangePercent: 30.0,          // Max 30% change
 			RequireApproval:  false,
 			RollbackOnError:  true,
+			RollbackCooldown: 12 * time.Hour,
 
 			CircuitBreakerEnabled:   true,
 			CircuitBreakerThreshold: 3,
@@ -216,6 +230,8 @@ func DevelopmentProfile() *Profile {
 			CPUPercentile:    90, // P90
 			MemoryPercentile: 90,
 			SafetyMargin:     1.1, // 10% buffer
+			MinSafetyMargin:  1.05,
+			MaxSafetyMargin:  1.4,
 			MinSamples:       50,
 			HistoryDuration:  24 * time.Hour, // 1 day of data
 			MinConfidence:    40.0,           // Lower confidence OK
@@ -223,6 +239,7 @@ func DevelopmentProfile() *Profile {
 			MaxChangePercent: 50.0,           // Allow larger changes
 			RequireApproval:  false,
 			RollbackOnError:  true,
+			RollbackCooldown: 4 * time.Hour,
 
 			CircuitBreakerEnabled:   true,
 			CircuitBreakerThreshold: 5,
@@ -248,6 +265,8 @@ func TestProfile() *Profile {
 			CPUPercentile:    80, // P80 - more aggressive
 			MemoryPercentile: 85,
 			SafetyMargin:     1.05, // 5% buffer only
+			MinSafetyMargin:  1.0,
+			MaxSafetyMargin:  1.2,
 			MinSamples:       20,
 			HistoryDuration:  6 * time.Hour, // Just 6 hours
 			MinConfidence:    20.0,          // Very low confidence OK
@@ -255,6 +274,7 @@ func TestProfile() *Profile {
 			MaxChangePercent: 0, // No limit
 			RequireApproval:  false,
 			RollbackOnError:  false, // Don't bother rolling back tests
+			RollbackCooldown: time.Hour,
 
 			CircuitBreakerEnabled:   false, // No circuit breaker
 			CircuitBreakerThreshold: 10,
@@ -300,6 +320,12 @@ func (s *ProfileSettings) Merge(overrides *ProfileSettings) ProfileSettings {
 	if overrides.SafetyMargin > 0 {
 		result.SafetyMargin = overrides.SafetyMargin
 	}
+	if overrides.MinSafetyMargin > 0 {
+		result.MinSafetyMargin = overrides.MinSafetyMargin
+	}
+	if overrides.MaxSafetyMargin > 0 {
+		result.MaxSafetyMargin = overrides.MaxSafetyMargin
+	}
 	if overrides.MinSamples > 0 {
 		result.MinSamples = overrides.MinSamples
 	}
@@ -315,6 +341,9 @@ func (s *ProfileSettings) Merge(overrides *ProfileSettings) ProfileSettings {
 	if overrides.MaxChangePercent > 0 {
 		result.MaxChangePercent = overrides.MaxChangePercent
 	}
+	if overrides.RollbackCooldown > 0 {
+		result.RollbackCooldown = overrides.RollbackCooldown
+	}
 	if overrides.CircuitBreakerThreshold > 0 {
 		result.CircuitBreakerThreshold = overrides.CircuitBreakerThreshold
 	}
@@ -351,6 +380,12 @@ func (s *ProfileSettings) Validate() error {
 	if s.SafetyMargin < 1.0 || s.SafetyMargin > 3.0 {
 		return fmt.Errorf("SafetyMargin must be between 1.0 and 3.0, got %.2f", s.SafetyMargin)
 	}
+	if s.MinSafetyMargin > 0 && s.MaxSafetyMargin > 0 {
+		if s.MinSafetyMargin < 1.0 || s.MaxSafetyMargin > 3.0 || s.MinSafetyMargin > s.MaxSafetyMargin {
+			return fmt.Errorf("safety margin bounds must satisfy 1.0 <= min <= max <= 3.0, got %.2f-%.2f",
+				s.MinSafetyMargin, s.MaxSafetyMargin)
+		}
+	}
 	if s.MinSamples < 1 {
 		return fmt.Errorf("MinSamples must be at least 1, got %d", s.MinSamples)
 	}
@@ -360,6 +395,9 @@ func (s *ProfileSettings) Validate() error {
 	if s.MaxChangePercent < 0 {
 		return fmt.Errorf("MaxChangePercent must be non-negative, got %.2f", s.MaxChangePercent)
 	}
+	if s.RollbackCooldown < 0 {
+		return fmt.Errorf("RollbackCooldown must be non-negative, got %v", s.RollbackCooldown)
+	}
 
 	validStrategies := map[string]bool{
 		"aggressive":   true,
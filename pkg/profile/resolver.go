@@ -36,6 +36,10 @@ type ResolvedSettings struct {
 	// SafetyMargin is the resolved safety margin
 	SafetyMargin float64
 
+	// MinSafetyMargin and MaxSafetyMargin bound learned per-workload margins
+	MinSafetyMargin float64
+	MaxSafetyMargin float64
+
 	// MinSamples is the minimum samples required
 	MinSamples int
 
@@ -60,6 +64,9 @@ type ResolvedSettings struct {
 	// RollbackOnError indicates if rollback is enabled
 	RollbackOnError bool
 
+	// RollbackCooldown is how long a rolled-back workload is quarantined
+	RollbackCooldown time.Duration
+
 	// CircuitBreakerEnabled indicates if circuit breaker is active
 	CircuitBreakerEnabled bool
 
@@ -103,6 +110,8 @@ func (r *Resolver) resolveFromProfile(spec *optimizerv1alpha1.OptimizerConfigSpe
 		CPUPercentile:           settings.CPUPercentile,
 		MemoryPercentile:        settings.MemoryPercentile,
 		SafetyMargin:            settings.SafetyMargin,
+		MinSafetyMargin:         settings.MinSafetyMargin,
+		MaxSafetyMargin:         settings.MaxSafetyMargin,
 		MinSamples:              settings.MinSamples,
 		HistoryDuration:         settings.HistoryDuration,
 		MinConfidence:           settings.MinConfidence,
@@ -111,6 +120,7 @@ func (r *Resolver) resolveFromProfile(spec *optimizerv1alpha1.OptimizerConfigSpe
 		RequireApproval:         settings.RequireApproval,
 		DryRun:                  settings.DryRunByDefault,
 		RollbackOnError:         settings.RollbackOnError,
+		RollbackCooldown:        settings.RollbackCooldown,
 		CircuitBreakerEnabled:   settings.CircuitBreakerEnabled,
 		CircuitBreakerThreshold: settings.CircuitBreakerThreshold,
 		MinCPUMillicores:        settings.MinCPUMillicores,
@@ -139,6 +149,8 @@ func (r *Resolver) resolveFromSpec(spec *optimizerv1alpha1.OptimizerConfigSpec)
 		CPUPercentile:           95,
 		MemoryPercentile:        95,
 		SafetyMargin:            1.2,
+		MinSafetyMargin:         1.1,
+		MaxSafetyMargin:         1.6,
 		MinSamples:              100,
 		HistoryDuration:         24 * time.Hour,
 		MinConfidence:           50.0,
@@ -147,6 +159,7 @@ func (r *Resolver) resolveFromSpec(spec *optimizerv1alpha1.OptimizerConfigSpec)
 		RequireApproval:         false,
 		DryRun:                  spec.DryRun,
 		RollbackOnError:         true,
+		RollbackCooldown:        12 * time.Hour,
 		CircuitBreakerEnabled:   true,
 		CircuitBreakerThreshold: 5,
 		MinCPUMillicores:        10,
@@ -185,6 +198,14 @@ func (r *Resolver) applyOverrides(resolved *ResolvedSettings, overrides *optimiz
 	if overrides.DryRun != nil {
 		resolved.DryRun = *overrides.DryRun
 	}
+	if overrides.RollbackOnError != nil {
+		resolved.RollbackOnError = *overrides.RollbackOnError
+	}
+	if overrides.RollbackCooldown != "" {
+		if d, err := time.ParseDuration(overrides.RollbackCooldown); err == nil && d >= 0 {
+			resolved.RollbackCooldown = d
+		}
+	}
 }
 
 // applySpecOverrides applies explicit spec values to resolved settings
@@ -210,6 +231,16 @@ func (r *Resolver) applySpecOverrides(resolved *ResolvedSettings, spec *optimize
 		}
 	}
 
+	// Margin tuning bounds
+	if spec.MarginTuning != nil {
+		if spec.MarginTuning.MinSafetyMargin > 0 {
+			resolved.MinSafetyMargin = spec.MarginTuning.MinSafetyMargin
+		}
+		if spec.MarginTuning.MaxSafetyMargin > 0 {
+			resolved.MaxSafetyMargin = spec.MarginTuning.MaxSafetyMargin
+		}
+	}
+
 	// Circuit breaker config overrides
 	if spec.CircuitBreaker != nil {
 		resolved.CircuitBreakerEnabled = spec.CircuitBreaker.Enabled
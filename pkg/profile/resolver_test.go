@@ -89,6 +89,7 @@ func TestResolverWithProfileOverrides(t *testing.T) {
 
 	minConfidence := 90.0
 	requireApproval := false
+	rollbackOnError := false
 
 	config := &optimizerv1alpha1.OptimizerConfig{
 		ObjectMeta: metav1.ObjectMeta{
@@ -99,9 +100,11 @@ func TestResolverWithProfileOverrides(t *testing.T) {
 			Profile:          optimizerv1alpha1.ProfileProduction,
 			TargetNamespaces: []string{"production"},
 			ProfileOverrides: &optimizerv1alpha1.ProfileOverrides{
-				MinConfidence:   &minConfidence,
-				RequireApproval: &requireApproval,
-				ApplyDelay:      "48h",
+				MinConfidence:    &minConfidence,
+				RequireApproval:  &requireApproval,
+				ApplyDelay:       "48h",
+				RollbackOnError:  &rollbackOnError,
+				RollbackCooldown: "2h",
 			},
 		},
 	}
@@ -121,6 +124,12 @@ func TestResolverWithProfileOverrides(t *testing.T) {
 	if resolved.ApplyDelay != 48*time.Hour {
 		t.Errorf("expected apply delay 48h, got %v", resolved.ApplyDelay)
 	}
+	if resolved.RollbackOnError {
+		t.Error("expected rollback on error to be false (overridden)")
+	}
+	if resolved.RollbackCooldown != 2*time.Hour {
+		t.Errorf("expected rollback cooldown 2h, got %v", resolved.RollbackCooldown)
+	}
 
 	// Base values should remain from production profile
 	if resolved.Strategy != "conservative" {
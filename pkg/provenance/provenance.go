@@ -0,0 +1,255 @@
+package provenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// Annotations stamped on a workload's metadata when the optimizer changes it. They sit
+// outside the pod template, so recording them does not trigger a rollout.
+const (
+	annotationPrefix = "optimizer.intelligent-cluster-optimizer.io/"
+
+	// OriginalResourcesAnnotation holds a JSON map of container names to their resources
+	// before the optimizer first changed them
+	OriginalResourcesAnnotation = annotationPrefix + "original-resources"
+
+	// PreviousResourcesAnnotation holds a JSON map of container names to their resources
+	// before the optimizer last changed them
+	PreviousResourcesAnnotation = annotationPrefix + "previous-resources"
+
+	// LastOptimizedAnnotation holds the RFC3339 time of the latest change
+	LastOptimizedAnnotation = annotationPrefix + "last-optimized"
+
+	// OptimizerConfigAnnotation holds the namespace/name of the OptimizerConfig that made the latest change
+	OptimizerConfigAnnotation = annotationPrefix + "optimizer-config"
+
+	// RecommendationIDAnnotation holds the ID of the recommendation behind the latest change
+	RecommendationIDAnnotation = annotationPrefix + "recommendation-id"
+)
+
+// Resources are the requests and limits of one container
+type Resources struct {
+	CPU              string `json:"cpu,omitempty"`
+	Memory           string `json:"memory,omitempty"`
+	CPULimit         string `json:"cpuLimit,omitempty"`
+	MemoryLimit      string `json:"memoryLimit,omitempty"`
+	EphemeralStorage string `json:"ephemeralStorage,omitempty"`
+}
+
+// FromContainer reads the resources of a container
+func FromContainer(container *corev1.Container) Resources {
+	var r Resources
+	if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+		r.CPU = cpu.String()
+	}
+	if mem, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+		r.Memory = mem.String()
+	}
+	if limit, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+		r.CPULimit = limit.String()
+	}
+	if limit, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+		r.MemoryLimit = limit.String()
+	}
+	if storage, ok := container.Resources.Requests[corev1.ResourceEphemeralStorage]; ok {
+		r.EphemeralStorage = storage.String()
+	}
+	return r
+}
+
+// Record is the optimizer provenance of a workload as stamped on its annotations
+type Record struct {
+	// Original maps container names to their resources before the first change
+	Original map[string]Resources `json:"original,omitempty"`
+
+	// Previous maps container names to their resources before the latest change
+	Previous map[string]Resources `json:"previous,omitempty"`
+
+	// LastOptimized is when the latest change was applied
+	LastOptimized time.Time `json:"lastOptimized"`
+
+	// OptimizerConfig is the namespace/name of the OptimizerConfig behind the latest change
+	OptimizerConfig string `json:"optimizerConfig,omitempty"`
+
+	// RecommendationID identifies the recommendation behind the latest change
+	RecommendationID string `json:"recommendationId,omitempty"`
+}
+
+// Change describes one applied change to a container
+type Change struct {
+	ContainerName    string
+	Before           Resources
+	OptimizerConfig  string
+	RecommendationID string
+	AppliedAt        time.Time
+}
+
+// IsEmpty reports whether the workload carries no provenance
+func (r *Record) IsEmpty() bool {
+	return len(r.Original) == 0 && len(r.Previous) == 0 && r.LastOptimized.IsZero() &&
+		r.OptimizerConfig == "" && r.RecommendationID == ""
+}
+
+// Read parses the provenance annotations of a workload
+func Read(annotations map[string]string) (*Record, error) {
+	record := &Record{
+		Original: make(map[string]Resources),
+		Previous: make(map[string]Resources),
+	}
+	for key, target := range map[string]map[string]Resources{
+		OriginalResourcesAnnotation: record.Original,
+		PreviousResourcesAnnotation: record.Previous,
+	} {
+		value, ok := annotations[key]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal([]byte(value), &target); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %v", key, err)
+		}
+	}
+	if value, ok := annotations[LastOptimizedAnnotation]; ok {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %v", LastOptimizedAnnotation, err)
+		}
+		record.LastOptimized = t
+	}
+	record.OptimizerConfig = annotations[OptimizerConfigAnnotation]
+	record.RecommendationID = annotations[RecommendationIDAnnotation]
+	return record, nil
+}
+
+// Apply records a change: the container's resources before it become its previous
+// resources, and also its original ones if the optimizer had not changed it before
+func (r *Record) Apply(change Change) {
+	if r.Original == nil {
+		r.Original = make(map[string]Resources)
+	}
+	if r.Previous == nil {
+		r.Previous = make(map[string]Resources)
+	}
+	if _, ok := r.Original[change.ContainerName]; !ok {
+		r.Original[change.ContainerName] = change.Before
+	}
+	r.Previous[change.ContainerName] = change.Before
+	r.LastOptimized = change.AppliedAt
+	r.OptimizerConfig = change.OptimizerConfig
+	r.RecommendationID = change.RecommendationID
+}
+
+// Annotations renders the record as workload annotations
+func (r *Record) Annotations() (map[string]string, error) {
+	annotations := make(map[string]string)
+	for key, resources := range map[string]map[string]Resources{
+		OriginalResourcesAnnotation: r.Original,
+		PreviousResourcesAnnotation: r.Previous,
+	} {
+		if len(resources) == 0 {
+			continue
+		}
+		encoded, err := json.Marshal(resources)
+		if err != nil {
+			return nil, err
+		}
+		annotations[key] = string(encoded)
+	}
+	if !r.LastOptimized.IsZero() {
+		annotations[LastOptimizedAnnotation] = r.LastOptimized.UTC().Format(time.RFC3339)
+	}
+	if r.OptimizerConfig != "" {
+		annotations[OptimizerConfigAnnotation] = r.OptimizerConfig
+	}
+	if r.RecommendationID != "" {
+		annotations[RecommendationIDAnnotation] = r.RecommendationID
+	}
+	return annotations, nil
+}
+
+// Get reads the provenance of a Deployment, StatefulSet or DaemonSet
+func Get(ctx context.Context, kubeClient kubernetes.Interface, namespace, kind, name string) (*Record, error) {
+	meta, err := getMeta(ctx, kubeClient, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	return Read(meta.Annotations)
+}
+
+// Stamp records a change on the annotations of a Deployment, StatefulSet or DaemonSet
+func Stamp(ctx context.Context, kubeClient kubernetes.Interface, namespace, kind, name string, change Change) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		meta, err := getMeta(ctx, kubeClient, namespace, kind, name)
+		if err != nil {
+			return err
+		}
+		record, err := Read(meta.Annotations)
+		if err != nil {
+			return err
+		}
+		record.Apply(change)
+		annotations, err := record.Annotations()
+		if err != nil {
+			return err
+		}
+
+		// The resource version makes the merge patch fail on concurrent updates
+		metadata := map[string]interface{}{"annotations": annotations}
+		if meta.ResourceVersion != "" {
+			metadata["resourceVersion"] = meta.ResourceVersion
+		}
+		patch, err := json.Marshal(map[string]interface{}{"metadata": metadata})
+		if err != nil {
+			return err
+		}
+		return patchMeta(ctx, kubeClient, namespace, kind, name, patch)
+	})
+}
+
+func getMeta(ctx context.Context, kubeClient kubernetes.Interface, namespace, kind, name string) (*metav1.ObjectMeta, error) {
+	switch kind {
+	case "Deployment":
+		deploy, err := kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &deploy.ObjectMeta, nil
+	case "StatefulSet":
+		sts, err := kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &sts.ObjectMeta, nil
+	case "DaemonSet":
+		ds, err := kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &ds.ObjectMeta, nil
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s", kind)
+	}
+}
+
+func patchMeta(ctx context.Context, kubeClient kubernetes.Interface, namespace, kind, name string, patch []byte) error {
+	var err error
+	switch kind {
+	case "Deployment":
+		_, err = kubeClient.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	case "StatefulSet":
+		_, err = kubeClient.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	case "DaemonSet":
+		_, err = kubeClient.AppsV1().DaemonSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{})
+	default:
+		err = fmt.Errorf("unsupported kind: %s", kind)
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package provenance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStamp_KeepsOriginalAndTracksPrevious(t *testing.T) {
+	client := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "api",
+			Namespace:   "default",
+			Annotations: map[string]string{"team": "payments"},
+		},
+	})
+	ctx := context.Background()
+	first := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, change := range []Change{
+		{ContainerName: "app", Before: Resources{CPU: "500m", Memory: "1Gi"}, OptimizerConfig: "default/optimizer",
+			RecommendationID: "aaa", AppliedAt: first},
+		{ContainerName: "app", Before: Resources{CPU: "300m", Memory: "512Mi"}, OptimizerConfig: "default/optimizer",
+			RecommendationID: "bbb", AppliedAt: first.Add(time.Hour)},
+		{ContainerName: "sidecar", Before: Resources{CPU: "50m"}, OptimizerConfig: "ops/sidecars",
+			RecommendationID: "ccc", AppliedAt: first.Add(2 * time.Hour)},
+	} {
+		if err := Stamp(ctx, client, "default", "Deployment", "api", change); err != nil {
+			t.Fatalf("Stamp %d failed: %v", i, err)
+		}
+	}
+
+	deploy, err := client.AppsV1().Deployments("default").Get(ctx, "api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	if deploy.Annotations["team"] != "payments" {
+		t.Errorf("Expected unrelated annotations to be kept, got %v", deploy.Annotations)
+	}
+
+	record, err := Read(deploy.Annotations)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if got := record.Original["app"]; got.CPU != "500m" || got.Memory != "1Gi" {
+		t.Errorf("Expected app's original 500m/1Gi, got %+v", got)
+	}
+	if got := record.Previous["app"]; got.CPU != "300m" || got.Memory != "512Mi" {
+		t.Errorf("Expected app's previous 300m/512Mi, got %+v", got)
+	}
+	if got := record.Original["sidecar"]; got.CPU != "50m" {
+		t.Errorf("Expected sidecar's original 50m, got %+v", got)
+	}
+	if !record.LastOptimized.Equal(first.Add(2*time.Hour)) || record.OptimizerConfig != "ops/sidecars" ||
+		record.RecommendationID != "ccc" {
+		t.Errorf("Expected the latest change's provenance, got %+v", record)
+	}
+}
+
+func TestRead_InvalidAnnotation(t *testing.T) {
+	if _, err := Read(map[string]string{OriginalResourcesAnnotation: "{not json"}); err == nil {
+		t.Error("Expected an error for a malformed original-resources annotation")
+	}
+	record, err := Read(nil)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !record.IsEmpty() {
+		t.Errorf("Expected an empty record, got %+v", record)
+	}
+}
@@ -0,0 +1,73 @@
+package qos
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Resources are the CPU and memory requests and limits of one container, in millicores
+// and bytes. Zero means unset.
+type Resources struct {
+	RequestCPU    int64
+	RequestMemory int64
+	LimitCPU      int64
+	LimitMemory   int64
+}
+
+// Class computes the pod QoS class the kubelet assigns to a pod with these containers:
+// Guaranteed when every container has CPU and memory limits equal to its requests,
+// BestEffort when no container sets any, and Burstable otherwise. Unset requests
+// default to the limit, as the API server does on admission.
+func Class(containers []Resources) corev1.PodQOSClass {
+	if len(containers) == 0 {
+		return corev1.PodQOSBestEffort
+	}
+
+	bestEffort, guaranteed := true, true
+	for _, c := range containers {
+		requestCPU, requestMemory := c.RequestCPU, c.RequestMemory
+		if requestCPU == 0 {
+			requestCPU = c.LimitCPU
+		}
+		if requestMemory == 0 {
+			requestMemory = c.LimitMemory
+		}
+
+		if requestCPU != 0 || requestMemory != 0 || c.LimitCPU != 0 || c.LimitMemory != 0 {
+			bestEffort = false
+		}
+		if c.LimitCPU == 0 || c.LimitMemory == 0 || requestCPU != c.LimitCPU || requestMemory != c.LimitMemory {
+			guaranteed = false
+		}
+	}
+
+	switch {
+	case bestEffort:
+		return corev1.PodQOSBestEffort
+	case guaranteed:
+		return corev1.PodQOSGuaranteed
+	default:
+		return corev1.PodQOSBurstable
+	}
+}
+
+// FromContainer extracts the CPU and memory requests and limits of a container
+func FromContainer(container *corev1.Container) Resources {
+	return Resources{
+		RequestCPU:    container.Resources.Requests.Cpu().MilliValue(),
+		RequestMemory: container.Resources.Requests.Memory().Value(),
+		LimitCPU:      container.Resources.Limits.Cpu().MilliValue(),
+		LimitMemory:   container.Resources.Limits.Memory().Value(),
+	}
+}
+
+// PodClass computes the QoS class of a pod template, including its init containers
+func PodClass(spec *corev1.PodSpec) corev1.PodQOSClass {
+	containers := make([]Resources, 0, len(spec.InitContainers)+len(spec.Containers))
+	for i := range spec.InitContainers {
+		containers = append(containers, FromContainer(&spec.InitContainers[i]))
+	}
+	for i := range spec.Containers {
+		containers = append(containers, FromContainer(&spec.Containers[i]))
+	}
+	return Class(containers)
+}
@@ -0,0 +1,84 @@
+package qos
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestClass(t *testing.T) {
+	tests := []struct {
+		name       string
+		containers []Resources
+		expected   corev1.PodQOSClass
+	}{
+		{
+			name:       "no containers",
+			containers: nil,
+			expected:   corev1.PodQOSBestEffort,
+		},
+		{
+			name:       "nothing set",
+			containers: []Resources{{}, {}},
+			expected:   corev1.PodQOSBestEffort,
+		},
+		{
+			name:       "requests equal limits",
+			containers: []Resources{{RequestCPU: 500, RequestMemory: 256, LimitCPU: 500, LimitMemory: 256}},
+			expected:   corev1.PodQOSGuaranteed,
+		},
+		{
+			name:       "limits only default the requests",
+			containers: []Resources{{LimitCPU: 500, LimitMemory: 256}},
+			expected:   corev1.PodQOSGuaranteed,
+		},
+		{
+			name:       "requests below limits",
+			containers: []Resources{{RequestCPU: 250, RequestMemory: 256, LimitCPU: 500, LimitMemory: 256}},
+			expected:   corev1.PodQOSBurstable,
+		},
+		{
+			name: "one container without limits",
+			containers: []Resources{
+				{RequestCPU: 500, RequestMemory: 256, LimitCPU: 500, LimitMemory: 256},
+				{RequestCPU: 100},
+			},
+			expected: corev1.PodQOSBurstable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Class(tt.containers); got != tt.expected {
+				t.Errorf("Class() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPodClassIncludesInitContainers(t *testing.T) {
+	guaranteed := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		},
+	}
+	spec := &corev1.PodSpec{
+		InitContainers: []corev1.Container{{Name: "init"}},
+		Containers:     []corev1.Container{{Name: "app", Resources: guaranteed}},
+	}
+
+	if got := PodClass(spec); got != corev1.PodQOSBurstable {
+		t.Errorf("Expected an init container without limits to make the pod Burstable, got %s", got)
+	}
+
+	spec.InitContainers[0].Resources = guaranteed
+	if got := PodClass(spec); got != corev1.PodQOSGuaranteed {
+		t.Errorf("Expected Guaranteed, got %s", got)
+	}
+}
@@ -9,7 +9,9 @@ import (
 	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
 	"intelligent-cluster-optimizer/pkg/cost"
 	"intelligent-cluster-optimizer/pkg/models"
+	"intelligent-cluster-optimizer/pkg/qos"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/klog/v2"
 )
 
@@ -33,6 +35,9 @@ type Engine struct {
 
 	// RecommendationTTL is how long recommendations remain valid
 	RecommendationTTL time.Duration
+
+	// Recommenders available for selection by name
+	registry *Registry
 }
 
 // NewEngine creates a new recommendation engine with sensible defaults
@@ -47,9 +52,20 @@ func NewEngine() *Engine {
 		confidenceCalculator:    NewConfidenceCalculator(),
 		expectedSampleInterval:  30 * time.Second, // Default: metrics collected every 30s
 		RecommendationTTL:       DefaultRecommendationTTL,
+		registry:                NewDefaultRegistry(),
 	}
 }
 
+// Registry returns the recommender registry used by the engine
+func (e *Engine) Registry() *Registry {
+	return e.registry
+}
+
+// RegisterRecommender makes a custom recommender selectable by name
+func (e *Engine) RegisterRecommender(rec Recommender) error {
+	return e.registry.Register(rec)
+}
+
 // SetRecommendationTTL sets the time-to-live for recommendations
 func (e *Engine) SetRecommendationTTL(ttl time.Duration) {
 	e.RecommendationTTL = ttl
@@ -77,6 +93,9 @@ type ContainerRecommendation struct {
 	SampleCount       int
 	CPUPercentile     int
 	MemoryPercentile  int
+	CPUUsage          int64   // millicores used at CPUPercentile
+	MemoryUsage       int64   // bytes used at MemoryPercentile
+	SafetyMargin      float64 // Multiplier applied on top of the percentiles
 	Confidence        float64 // 0-100 overall confidence score
 
 	// Detailed confidence scoring
@@ -90,6 +109,35 @@ type ContainerRecommendation struct {
 	OOMCount        int
 	OOMBoostApplied float64 // Memory boost multiplier applied due to OOM history
 	OOMPriority     string  // Priority level based on OOM frequency
+
+	// Forecast-driven sizing
+	ForecastApplied      bool     // Whether a forecast raised the recommendation
+	ForecastRaisedCPU    bool     // Whether the forecast raised the CPU target
+	ForecastRaisedMemory bool     // Whether the forecast raised the memory target
+	Reasons              []string // Human-readable explanations for adjustments
+
+	// Warm-up handling. StartupCPU is the CPU in millicores the container needs while
+	// warming up, set only when startup dominates the steady-state recommendation.
+	WarmUpSamplesExcluded int
+	StartupCPU            int64
+
+	// Runtime memory settings (JVM heap, GOMEMLIMIT). RuntimeMemoryFloor is the least
+	// memory in bytes the runtime needs; RuntimeAdvice is set when the runtime setting
+	// itself should change alongside the request.
+	RuntimeMemoryFloor int64
+	RuntimeAdvice      string
+
+	// Limits in millicores and bytes, 0 when unset. Recommended limits are only set
+	// when the limits move with the requests, e.g. to keep a Guaranteed QoS class.
+	CurrentCPULimit        int64
+	CurrentMemoryLimit     int64
+	RecommendedCPULimit    int64
+	RecommendedMemoryLimit int64
+
+	// Ephemeral-storage requests in bytes. RecommendedEphemeralStorage is 0 when no
+	// ephemeral-storage usage was reported for the container.
+	CurrentEphemeralStorage     int64
+	RecommendedEphemeralStorage int64
 }
 
 // CalculateCPUChangePercent returns the percentage change in CPU from current to recommended.
@@ -147,6 +195,9 @@ type WorkloadRecommendation struct {
 	HasOOMHistory bool
 	TotalOOMCount int
 	OOMPriority   string // Overall priority based on OOM history
+
+	// QoSClass is the pod QoS class the workload runs with today
+	QoSClass corev1.PodQOSClass
 }
 
 // DefaultRecommendationTTL is the default time-to-live for recommendations
@@ -245,11 +296,30 @@ func (e *Engine) GenerateRecommendations(
 	return e.GenerateRecommendationsWithOOM(provider, nil, config)
 }
 
+// SafetyMarginProvider supplies a per-workload safety margin, e.g. one learned from
+// the outcomes of earlier changes
+type SafetyMarginProvider interface {
+	// GetSafetyMargin returns the margin to use for the workload given the margin
+	// the config and strategy resolve to
+	GetSafetyMargin(namespace, workloadName string, base float64) float64
+}
+
 // GenerateRecommendationsWithOOM generates recommendations with OOM-aware memory adjustments
 func (e *Engine) GenerateRecommendationsWithOOM(
 	provider MetricsProvider,
 	oomProvider OOMInfoProvider,
 	config *optimizerv1alpha1.OptimizerConfig,
+) ([]WorkloadRecommendation, error) {
+	return e.GenerateRecommendationsWithMargins(provider, oomProvider, nil, config)
+}
+
+// GenerateRecommendationsWithMargins generates OOM-aware recommendations using the
+// per-workload safety margins from marginProvider (nil uses the config's margin)
+func (e *Engine) GenerateRecommendationsWithMargins(
+	provider MetricsProvider,
+	oomProvider OOMInfoProvider,
+	marginProvider SafetyMarginProvider,
+	config *optimizerv1alpha1.OptimizerConfig,
 ) ([]WorkloadRecommendation, error) {
 	var recommendations []WorkloadRecommendation
 
@@ -259,6 +329,12 @@ func (e *Engine) GenerateRecommendationsWithOOM(
 	safetyMargin := e.defaultSafetyMargin
 	minSamples := e.defaultMinSamples
 	historyDuration := e.defaultHistoryDuration
+	halfLife := DefaultHistogramHalfLife
+	memoryPeakInterval := DefaultMemoryPeakInterval
+	cpuRecommender := RecommenderPercentile
+	memoryRecommender := RecommenderPercentile
+	var forecast forecastSettings
+	var warmUp warmUpSettings
 
 	if config.Spec.Recommendations != nil {
 		if config.Spec.Recommendations.CPUPercentile > 0 {
@@ -278,6 +354,33 @@ func (e *Engine) GenerateRecommendationsWithOOM(
 				historyDuration = d
 			}
 		}
+		if config.Spec.Recommendations.Algorithm != "" {
+			cpuRecommender = string(config.Spec.Recommendations.Algorithm)
+			memoryRecommender = string(config.Spec.Recommendations.Algorithm)
+		}
+		if config.Spec.Recommendations.CPURecommender != "" {
+			cpuRecommender = config.Spec.Recommendations.CPURecommender
+		}
+		if config.Spec.Recommendations.MemoryRecommender != "" {
+			memoryRecommender = config.Spec.Recommendations.MemoryRecommender
+		}
+		if config.Spec.Recommendations.HalfLife != "" {
+			if d, err := time.ParseDuration(config.Spec.Recommendations.HalfLife); err == nil && d > 0 {
+				halfLife = d
+			}
+		}
+		if config.Spec.Recommendations.MemoryPeakInterval != "" {
+			if d, err := time.ParseDuration(config.Spec.Recommendations.MemoryPeakInterval); err == nil && d > 0 {
+				memoryPeakInterval = d
+			}
+		}
+		forecast = parseForecastSettings(config.Spec.Recommendations.Forecast)
+		warmUp = parseWarmUpSettings(config.Spec.Recommendations.WarmUp)
+	}
+
+	selection, err := e.selectRecommenders(cpuRecommender, memoryRecommender)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply strategy-based adjustments
@@ -288,8 +391,19 @@ func (e *Engine) GenerateRecommendationsWithOOM(
 		safetyMargin,
 	)
 
-	klog.V(4).Infof("Generating recommendations with: CPU P%d, Memory P%d, SafetyMargin %.2f, MinSamples %d, History %v",
-		cpuPercentile, memoryPercentile, safetyMargin, minSamples, historyDuration)
+	selection.context = RecommenderContext{
+		CPUPercentile:          cpuPercentile,
+		MemoryPercentile:       memoryPercentile,
+		SafetyMargin:           safetyMargin,
+		HalfLife:               halfLife,
+		MemoryPeakInterval:     memoryPeakInterval,
+		ExpectedSampleInterval: e.expectedSampleInterval,
+	}
+	selection.forecast = forecast
+	selection.warmUp = warmUp
+
+	klog.V(4).Infof("Generating recommendations with: CPU P%d, Memory P%d, SafetyMargin %.2f, MinSamples %d, History %v, Recommenders cpu=%s memory=%s",
+		cpuPercentile, memoryPercentile, safetyMargin, minSamples, historyDuration, cpuRecommender, memoryRecommender)
 
 	// Process each target namespace
 	for _, namespace := range config.Spec.TargetNamespaces {
@@ -309,16 +423,22 @@ func (e *Engine) GenerateRecommendationsWithOOM(
 				oomInfo = oomProvider.GetOOMHistory(namespace, workloadName)
 			}
 
+			workloadMargin := safetyMargin
+			if marginProvider != nil {
+				workloadMargin = marginProvider.GetSafetyMargin(namespace, workloadName, safetyMargin)
+			}
+
 			rec := e.generateWorkloadRecommendationWithOOM(
 				namespace,
 				workloadName,
 				containerMetrics,
 				cpuPercentile,
 				memoryPercentile,
-				safetyMargin,
+				workloadMargin,
 				minSamples,
 				config.Spec.ResourceThresholds,
 				oomInfo,
+				selection,
 			)
 			if rec != nil {
 				recommendations = append(recommendations, *rec)
@@ -400,6 +520,16 @@ func (e *Engine) groupByWorkload(metrics []models.PodMetric) map[string]map[stri
 				usageMemory:   cm.UsageMemory,
 				requestCPU:    cm.RequestCPU,
 				requestMemory: cm.RequestMemory,
+				limitCPU:      cm.LimitCPU,
+				limitMemory:   cm.LimitMemory,
+
+				usageEphemeralStorage:   cm.UsageEphemeralStorage,
+				requestEphemeralStorage: cm.RequestEphemeralStorage,
+				limitEphemeralStorage:   cm.LimitEphemeralStorage,
+
+				podStartTime: pm.StartTime,
+				startedAt:    cm.StartedAt,
+				readyAt:      cm.ReadyAt,
 			}
 			result[workloadName][cm.ContainerName] = append(
 				result[workloadName][cm.ContainerName],
@@ -417,6 +547,78 @@ type containerSample struct {
 	usageMemory   int64
 	requestCPU    int64
 	requestMemory int64
+	limitCPU      int64
+	limitMemory   int64
+
+	usageEphemeralStorage   int64
+	requestEphemeralStorage int64
+	limitEphemeralStorage   int64
+
+	// Lifecycle of the container instance the sample came from, zero when unknown
+	podStartTime time.Time
+	startedAt    time.Time
+	readyAt      time.Time
+}
+
+// recommenderSelection holds the recommender chosen for each resource and the settings shared by all containers
+type recommenderSelection struct {
+	cpu      Recommender
+	memory   Recommender
+	context  RecommenderContext
+	forecast forecastSettings
+	warmUp   warmUpSettings
+}
+
+// selectRecommenders looks up the named CPU and memory recommenders in the registry
+func (e *Engine) selectRecommenders(cpuName, memoryName string) (recommenderSelection, error) {
+	cpu, ok := e.registry.Get(cpuName)
+	if !ok {
+		return recommenderSelection{}, fmt.Errorf("unknown CPU recommender %q (available: %v)", cpuName, e.registry.Names())
+	}
+	memory, ok := e.registry.Get(memoryName)
+	if !ok {
+		return recommenderSelection{}, fmt.Errorf("unknown memory recommender %q (available: %v)", memoryName, e.registry.Names())
+	}
+	return recommenderSelection{cpu: cpu, memory: memory}, nil
+}
+
+// recommendTargets asks the selected recommenders for the CPU and memory targets. A
+// recommender selected for both resources runs once; otherwise each recommender is
+// asked only for the resource it was selected for.
+func recommendTargets(selection recommenderSelection, input *RecommenderInput) (cpu, memory ResourceTarget, err error) {
+	if selection.cpu.Name() == selection.memory.Name() {
+		targets, err := recommendResources(selection.cpu, input, ResourceCPU, ResourceMemory)
+		if err != nil {
+			return ResourceTarget{}, ResourceTarget{}, err
+		}
+		return targets[ResourceCPU], targets[ResourceMemory], nil
+	}
+
+	cpuTargets, err := recommendResources(selection.cpu, input, ResourceCPU)
+	if err != nil {
+		return ResourceTarget{}, ResourceTarget{}, err
+	}
+	memoryTargets, err := recommendResources(selection.memory, input, ResourceMemory)
+	if err != nil {
+		return ResourceTarget{}, ResourceTarget{}, err
+	}
+	return cpuTargets[ResourceCPU], memoryTargets[ResourceMemory], nil
+}
+
+// recommendResources runs a recommender for the given resources and checks it returned a target for each
+func recommendResources(rec Recommender, input *RecommenderInput, resources ...ResourceType) (map[ResourceType]ResourceTarget, error) {
+	scoped := *input
+	scoped.Resources = resources
+	output, err := rec.Recommend(&scoped)
+	if err != nil {
+		return nil, fmt.Errorf("recommender %s failed: %w", rec.Name(), err)
+	}
+	for _, resource := range resources {
+		if _, ok := output.Targets[resource]; !ok {
+			return nil, fmt.Errorf("recommender %s returned no %s target", rec.Name(), resource)
+		}
+	}
+	return output.Targets, nil
 }
 
 // generateWorkloadRecommendationWithOOM generates recommendations with OOM-aware memory adjustments
@@ -428,11 +630,15 @@ func (e *Engine) generateWorkloadRecommendationWithOOM(
 	minSamples int,
 	thresholds *optimizerv1alpha1.ResourceThresholds,
 	oomInfo *OOMHistoryInfo,
+	selection recommenderSelection,
 ) *WorkloadRecommendation {
 	var containerRecs []ContainerRecommendation
 	var totalOOMCount int
 	hasOOMHistory := false
 
+	selection.context.Namespace = namespace
+	selection.context.WorkloadName = workloadName
+
 	for containerName, samples := range containerMetrics {
 		if len(samples) < minSamples {
 			klog.V(4).Infof("Skipping container %s/%s/%s: insufficient samples (%d < %d)",
@@ -459,6 +665,7 @@ func (e *Engine) generateWorkloadRecommendationWithOOM(
 			minSamples,
 			thresholds,
 			containerOOM,
+			selection,
 		)
 		if rec != nil {
 			containerRecs = append(containerRecs, *rec)
@@ -489,6 +696,21 @@ func (e *Engine) generateWorkloadRecommendationWithOOM(
 			namespace, workloadName, totalOOMCount, oomPriority)
 	}
 
+	// The QoS class covers every container of the pod, including ones without a recommendation
+	var current []qos.Resources
+	for _, samples := range containerMetrics {
+		if len(samples) == 0 {
+			continue
+		}
+		latest := samples[len(samples)-1]
+		current = append(current, qos.Resources{
+			RequestCPU:    latest.requestCPU,
+			RequestMemory: latest.requestMemory,
+			LimitCPU:      latest.limitCPU,
+			LimitMemory:   latest.limitMemory,
+		})
+	}
+
 	// Calculate current totals for prediction comparison
 	var currentTotalCPU, currentTotalMemory int64
 	for _, c := range containerRecs {
@@ -510,24 +732,26 @@ func (e *Engine) generateWorkloadRecommendationWithOOM(
 		HasOOMHistory:         hasOOMHistory,
 		TotalOOMCount:         totalOOMCount,
 		OOMPriority:           oomPriority,
+		QoSClass:              qos.Class(current),
 	}
 }
 
 // aggregateContainerSavings combines savings from all containers in a workload
 func (e *Engine) aggregateContainerSavings(containers []ContainerRecommendation) *cost.SavingsEstimate {
-	var totalCPUSavings, totalMemorySavings float64
+	var totalCPUSavings, totalMemorySavings, totalEphemeralStorageSavings float64
 	var totalCurrentCost, totalRecommendedCost float64
 
 	for _, c := range containers {
 		if c.EstimatedSavings != nil {
 			totalCPUSavings += c.EstimatedSavings.CPUSavingsPerHour
 			totalMemorySavings += c.EstimatedSavings.MemorySavingsPerHour
+			totalEphemeralStorageSavings += c.EstimatedSavings.EphemeralStorageSavingsPerHour
 			totalCurrentCost += c.EstimatedSavings.CurrentCost.TotalPerHour
 			totalRecommendedCost += c.EstimatedSavings.RecommendedCost.TotalPerHour
 		}
 	}
 
-	totalSavingsPerHour := totalCPUSavings + totalMemorySavings
+	totalSavingsPerHour := totalCPUSavings + totalMemorySavings + totalEphemeralStorageSavings
 	var percentageReduction float64
 	if totalCurrentCost > 0 {
 		percentageReduction = (totalSavingsPerHour / totalCurrentCost) * 100
@@ -546,13 +770,14 @@ func (e *Engine) aggregateContainerSavings(containers []ContainerRecommendation)
 			TotalPerMonth: totalRecommendedCost * 24 * 30,
 			TotalPerYear:  totalRecommendedCost * 24 * 365,
 		},
-		CPUSavingsPerHour:    totalCPUSavings,
-		MemorySavingsPerHour: totalMemorySavings,
-		TotalSavingsPerHour:  totalSavingsPerHour,
-		SavingsPerDay:        totalSavingsPerHour * 24,
-		SavingsPerMonth:      totalSavingsPerHour * 24 * 30,
-		SavingsPerYear:       totalSavingsPerHour * 24 * 365,
-		PercentageReduction:  percentageReduction,
+		CPUSavingsPerHour:              totalCPUSavings,
+		MemorySavingsPerHour:           totalMemorySavings,
+		EphemeralStorageSavingsPerHour: totalEphemeralStorageSavings,
+		TotalSavingsPerHour:            totalSavingsPerHour,
+		SavingsPerDay:                  totalSavingsPerHour * 24,
+		SavingsPerMonth:                totalSavingsPerHour * 24 * 30,
+		SavingsPerYear:                 totalSavingsPerHour * 24 * 365,
+		PercentageReduction:            percentageReduction,
 	}
 }
 
@@ -565,9 +790,20 @@ func (e *Engine) generateContainerRecommendation(
 	minSamples int,
 	thresholds *optimizerv1alpha1.ResourceThresholds,
 ) *ContainerRecommendation {
+	percentile, _ := e.registry.Get(RecommenderPercentile)
+	selection := recommenderSelection{
+		cpu:    percentile,
+		memory: percentile,
+		context: RecommenderContext{
+			CPUPercentile:          cpuPercentile,
+			MemoryPercentile:       memoryPercentile,
+			SafetyMargin:           safetyMargin,
+			ExpectedSampleInterval: e.expectedSampleInterval,
+		},
+	}
 	return e.generateContainerRecommendationWithOOM(
 		containerName, samples, cpuPercentile, memoryPercentile,
-		safetyMargin, minSamples, thresholds, nil,
+		safetyMargin, minSamples, thresholds, nil, selection,
 	)
 }
 
@@ -580,29 +816,87 @@ func (e *Engine) generateContainerRecommendationWithOOM(
 	minSamples int,
 	thresholds *optimizerv1alpha1.ResourceThresholds,
 	oomInfo *ContainerOOMDetails,
+	selection recommenderSelection,
 ) *ContainerRecommendation {
-	// Extract CPU and memory values along with timestamps
+	if len(samples) < minSamples {
+		klog.V(4).Infof("Skipping container %s: insufficient samples (%d < %d)", containerName, len(samples), minSamples)
+		return nil
+	}
+
+	// Leave warm-up samples out of the percentiles, unless too few samples would remain
+	var reasons []string
+	var warmUpSamples []containerSample
+	if selection.warmUp.enabled {
+		steady, warmUp := splitWarmUp(samples, selection.warmUp)
+		if len(warmUp) > 0 && len(steady) >= minSamples {
+			samples, warmUpSamples = steady, warmUp
+			reasons = append(reasons, fmt.Sprintf("excluded %d warm-up samples", len(warmUp)))
+		} else if len(warmUp) > 0 {
+			klog.V(4).Infof("Container %s: keeping %d warm-up samples, only %d steady-state samples (< %d)",
+				containerName, len(warmUp), len(steady), minSamples)
+		}
+	}
+
+	// Extract CPU values along with timestamps
 	cpuValues := make([]int64, len(samples))
-	memoryValues := make([]int64, len(samples))
 	timestamps := make([]time.Time, len(samples))
+	usageSamples := make([]UsageSample, len(samples))
 
-	var currentCPU, currentMemory int64
+	var currentCPU, currentMemory, currentCPULimit, currentMemoryLimit int64
 	for i, s := range samples {
 		cpuValues[i] = s.usageCPU
-		memoryValues[i] = s.usageMemory
 		timestamps[i] = s.timestamp
+		usageSamples[i] = UsageSample{
+			Timestamp:     s.timestamp,
+			CPU:           s.usageCPU,
+			Memory:        s.usageMemory,
+			RequestCPU:    s.requestCPU,
+			RequestMemory: s.requestMemory,
+		}
 		// Use the most recent request values as "current"
 		currentCPU = s.requestCPU
 		currentMemory = s.requestMemory
+		currentCPULimit = s.limitCPU
+		currentMemoryLimit = s.limitMemory
 	}
 
-	// Calculate percentiles
-	cpuP := calculatePercentile(cpuValues, cpuPercentile)
-	memoryP := calculatePercentile(memoryValues, memoryPercentile)
+	// Ask the selected recommenders for targets (percentile + safety margin)
+	input := &RecommenderInput{Samples: usageSamples, Context: selection.context}
+	input.Context.ContainerName = containerName
+	input.Context.CPUPercentile = cpuPercentile
+	input.Context.MemoryPercentile = memoryPercentile
+	input.Context.SafetyMargin = safetyMargin
 
-	// Apply safety margin
-	recommendedCPU := int64(float64(cpuP) * safetyMargin)
-	recommendedMemory := int64(float64(memoryP) * safetyMargin)
+	cpuTarget, memoryTarget, err := recommendTargets(selection, input)
+	if err != nil {
+		klog.Warningf("Skipping container %s: %v", containerName, err)
+		return nil
+	}
+
+	cpuP := cpuTarget.Usage
+	memoryP := memoryTarget.Usage
+	recommendedCPU := cpuTarget.Target
+	recommendedMemory := memoryTarget.Target
+
+	// Let forecasts raise (never lower) the targets when forecast-driven sizing is enabled
+	forecastApplied, forecastRaisedCPU, forecastRaisedMemory := false, false, false
+	if selection.forecast.enabled {
+		forecastCPU, forecastMemory, forecastReasons, err := applyForecast(
+			usageSamples, recommendedCPU, recommendedMemory, safetyMargin, selection.forecast)
+		if err != nil {
+			klog.V(4).Infof("Container %s: forecast skipped: %v", containerName, err)
+		} else {
+			forecastRaisedCPU = forecastCPU > recommendedCPU
+			forecastRaisedMemory = forecastMemory > recommendedMemory
+			forecastApplied = forecastRaisedCPU || forecastRaisedMemory
+			recommendedCPU = forecastCPU
+			recommendedMemory = forecastMemory
+			reasons = append(reasons, forecastReasons...)
+			for _, reason := range forecastReasons {
+				klog.V(3).Infof("Container %s: %s", containerName, reason)
+			}
+		}
+	}
 
 	// Apply OOM boost to memory if container has OOM history
 	var oomBoostApplied float64 = 1.0
@@ -615,28 +909,51 @@ func (e *Engine) generateContainerRecommendationWithOOM(
 		oomCount = oomInfo.OOMCount
 		oomPriority = oomInfo.Priority
 
-		// Apply OOM boost - this ensures we don't reduce memory below current if there's OOM history
+		// Apply OOM boost to the usage-based target
 		if oomInfo.RecommendedBoost > 1.0 {
 			oomBoostApplied = oomInfo.RecommendedBoost
 			boostedMemory := int64(float64(recommendedMemory) * oomInfo.RecommendedBoost)
 
-			// If OOM occurred, never recommend less memory than current
-			if boostedMemory < currentMemory {
-				boostedMemory = currentMemory
-				klog.V(3).Infof("Container %s has OOM history - not reducing memory below current (%d bytes)",
-					containerName, currentMemory)
-			}
-
 			klog.V(3).Infof("Container %s: applying OOM boost %.2fx to memory: %d -> %d bytes (OOM count: %d, priority: %s)",
 				containerName, oomInfo.RecommendedBoost, recommendedMemory, boostedMemory, oomCount, oomPriority)
 
 			recommendedMemory = boostedMemory
 		}
+
+		// If OOM occurred, never recommend less memory than current
+		if recommendedMemory < currentMemory {
+			klog.V(3).Infof("Container %s has OOM history - not reducing memory below current (%d bytes)",
+				containerName, currentMemory)
+			recommendedMemory = currentMemory
+		}
 	}
 
 	// Apply thresholds
-	recommendedCPU = e.applyThresholds(recommendedCPU, thresholds, "cpu")
-	recommendedMemory = e.applyThresholds(recommendedMemory, thresholds, "memory")
+	if clamped := e.applyThresholds(recommendedCPU, thresholds, "cpu"); clamped != recommendedCPU {
+		reasons = append(reasons, fmt.Sprintf("cpu clamped from %dm to %dm by resource thresholds", recommendedCPU, clamped))
+		recommendedCPU = clamped
+	}
+	if clamped := e.applyThresholds(recommendedMemory, thresholds, "memory"); clamped != recommendedMemory {
+		reasons = append(reasons, fmt.Sprintf("memory clamped from %dMi to %dMi by resource thresholds",
+			recommendedMemory/(1024*1024), clamped/(1024*1024)))
+		recommendedMemory = clamped
+	}
+	if hasOOMHistory && recommendedMemory < currentMemory {
+		// A max threshold must not undo the OOM floor
+		recommendedMemory = currentMemory
+	}
+
+	startupCPU, startupReason := e.recommendStartupCPU(warmUpSamples, recommendedCPU, safetyMargin, thresholds, selection.warmUp)
+	if startupReason != "" {
+		reasons = append(reasons, startupReason)
+		klog.V(3).Infof("Container %s: %s", containerName, startupReason)
+	}
+
+	currentEphemeralStorage, recommendedEphemeralStorage, storageReason := e.recommendEphemeralStorage(
+		samples, safetyMargin, thresholds)
+	if storageReason != "" {
+		reasons = append(reasons, storageReason)
+	}
 
 	// Calculate detailed confidence score using all metrics
 	// We use CPU values for confidence calculation as they typically have more variance
@@ -646,13 +963,14 @@ func (e *Engine) generateContainerRecommendationWithOOM(
 		e.expectedSampleInterval,
 	)
 
-	// Use the detailed score as the main confidence value
-	confidence := confidenceDetails.Score
+	// Use the detailed score as the main confidence value, bounded by the recommenders' own confidence
+	confidence := minFloat(confidenceDetails.Score, minFloat(cpuTarget.Confidence, memoryTarget.Confidence))
 
 	// Calculate cost savings
-	savings := e.costCalculator.EstimateSavings(
+	savings := e.costCalculator.EstimateSavingsWithStorage(
 		currentCPU, recommendedCPU,
 		currentMemory, recommendedMemory,
+		currentEphemeralStorage, effectiveEphemeralStorage(currentEphemeralStorage, recommendedEphemeralStorage),
 	)
 
 	oomLogSuffix := ""
@@ -667,21 +985,103 @@ func (e *Engine) generateContainerRecommendationWithOOM(
 		confidence, savings.TotalSavingsPerHour, oomLogSuffix)
 
 	return &ContainerRecommendation{
-		ContainerName:     containerName,
-		CurrentCPU:        currentCPU,
-		CurrentMemory:     currentMemory,
-		RecommendedCPU:    recommendedCPU,
-		RecommendedMemory: recommendedMemory,
-		SampleCount:       len(samples),
-		CPUPercentile:     cpuPercentile,
-		MemoryPercentile:  memoryPercentile,
-		Confidence:        confidence,
-		ConfidenceDetails: &confidenceDetails,
-		EstimatedSavings:  &savings,
-		HasOOMHistory:     hasOOMHistory,
-		OOMCount:          oomCount,
-		OOMBoostApplied:   oomBoostApplied,
-		OOMPriority:       oomPriority,
+		ContainerName:        containerName,
+		CurrentCPU:           currentCPU,
+		CurrentMemory:        currentMemory,
+		RecommendedCPU:       recommendedCPU,
+		RecommendedMemory:    recommendedMemory,
+		SampleCount:          len(samples),
+		CPUPercentile:        cpuPercentile,
+		MemoryPercentile:     memoryPercentile,
+		CPUUsage:             cpuP,
+		MemoryUsage:          memoryP,
+		SafetyMargin:         safetyMargin,
+		Confidence:           confidence,
+		ConfidenceDetails:    &confidenceDetails,
+		EstimatedSavings:     &savings,
+		HasOOMHistory:        hasOOMHistory,
+		OOMCount:             oomCount,
+		OOMBoostApplied:      oomBoostApplied,
+		OOMPriority:          oomPriority,
+		ForecastApplied:      forecastApplied,
+		ForecastRaisedCPU:    forecastRaisedCPU,
+		ForecastRaisedMemory: forecastRaisedMemory,
+		Reasons:              reasons,
+
+		WarmUpSamplesExcluded: len(warmUpSamples),
+		StartupCPU:            startupCPU,
+
+		CurrentCPULimit:    currentCPULimit,
+		CurrentMemoryLimit: currentMemoryLimit,
+
+		CurrentEphemeralStorage:     currentEphemeralStorage,
+		RecommendedEphemeralStorage: recommendedEphemeralStorage,
+	}
+}
+
+// recommendEphemeralStorage sizes the ephemeral-storage request from the peak observed
+// usage plus the safety margin. Peak rather than a percentile is used because running
+// out evicts the pod and usage (logs, caches) tends to only grow. The request is never
+// raised above an existing ephemeral-storage limit. Returns 0 as the recommendation
+// when the container reported no usage.
+func (e *Engine) recommendEphemeralStorage(
+	samples []containerSample,
+	safetyMargin float64,
+	thresholds *optimizerv1alpha1.ResourceThresholds,
+) (current, recommended int64, reason string) {
+	var peak, limit int64
+	for _, s := range samples {
+		if s.usageEphemeralStorage > peak {
+			peak = s.usageEphemeralStorage
+		}
+		current = s.requestEphemeralStorage
+		limit = s.limitEphemeralStorage
+	}
+	if peak == 0 {
+		return current, 0, ""
+	}
+
+	recommended = e.applyThresholds(int64(float64(peak)*safetyMargin), thresholds, "ephemeral-storage")
+	if limit > 0 && recommended > limit {
+		return current, limit, fmt.Sprintf("ephemeral-storage request capped at its %dMi limit", limit/(1024*1024))
+	}
+	return current, recommended, ""
+}
+
+// effectiveEphemeralStorage returns the ephemeral-storage request that applies after a
+// recommendation, which is the current one when none was made
+func effectiveEphemeralStorage(current, recommended int64) int64 {
+	if recommended == 0 {
+		return current
+	}
+	return recommended
+}
+
+// ScaleRecommendation multiplies a container's recommended CPU and memory by the given
+// factors, re-applies the configured thresholds and refreshes the savings estimate.
+// Memory is never scaled down for containers with OOM history.
+func (e *Engine) ScaleRecommendation(rec *ContainerRecommendation, cpuFactor, memoryFactor float64,
+	thresholds *optimizerv1alpha1.ResourceThresholds, reason string) {
+	if cpuFactor == 1.0 && memoryFactor == 1.0 {
+		return
+	}
+
+	rec.RecommendedCPU = e.applyThresholds(int64(float64(rec.RecommendedCPU)*cpuFactor), thresholds, "cpu")
+
+	if memoryFactor < 1.0 && rec.HasOOMHistory {
+		memoryFactor = 1.0
+	}
+	rec.RecommendedMemory = e.applyThresholds(int64(float64(rec.RecommendedMemory)*memoryFactor), thresholds, "memory")
+
+	savings := e.costCalculator.EstimateSavingsWithStorage(
+		rec.CurrentCPU, rec.RecommendedCPU,
+		rec.CurrentMemory, rec.RecommendedMemory,
+		rec.CurrentEphemeralStorage, effectiveEphemeralStorage(rec.CurrentEphemeralStorage, rec.RecommendedEphemeralStorage),
+	)
+	rec.EstimatedSavings = &savings
+
+	if reason != "" {
+		rec.Reasons = append(rec.Reasons, reason)
 	}
 }
 
@@ -732,6 +1132,11 @@ func (e *Engine) applyThresholds(value int64, thresholds *optimizerv1alpha1.Reso
 			minVal = parseMemoryToBytes(thresholds.Memory.Min)
 			maxVal = parseMemoryToBytes(thresholds.Memory.Max)
 		}
+	case "ephemeral-storage":
+		if thresholds.EphemeralStorage != nil {
+			minVal = parseMemoryToBytes(thresholds.EphemeralStorage.Min)
+			maxVal = parseMemoryToBytes(thresholds.EphemeralStorage.Max)
+		}
 	}
 
 	if minVal > 0 && value < minVal {
@@ -774,22 +1179,26 @@ func parseMemoryToBytes(memory string) int64 {
 
 	var value int64
 
-	// Try different suffixes
-	suffixes := map[string]int64{
-		"Ki": 1024,
-		"Mi": 1024 * 1024,
-		"Gi": 1024 * 1024 * 1024,
-		"Ti": 1024 * 1024 * 1024 * 1024,
-		"K":  1000,
-		"M":  1000 * 1000,
-		"G":  1000 * 1000 * 1000,
-		"T":  1000 * 1000 * 1000 * 1000,
+	// Try different suffixes. Binary suffixes go first: Sscanf ignores trailing
+	// input, so "1Gi" would otherwise also match "G".
+	suffixes := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"Ki", 1024},
+		{"Mi", 1024 * 1024},
+		{"Gi", 1024 * 1024 * 1024},
+		{"Ti", 1024 * 1024 * 1024 * 1024},
+		{"K", 1000},
+		{"M", 1000 * 1000},
+		{"G", 1000 * 1000 * 1000},
+		{"T", 1000 * 1000 * 1000 * 1000},
 	}
 
-	for suffix, multiplier := range suffixes {
-		pattern := "%d" + suffix
+	for _, s := range suffixes {
+		pattern := "%d" + s.suffix
 		if _, err := fmt.Sscanf(memory, pattern, &value); err == nil {
-			return value * multiplier
+			return value * s.multiplier
 		}
 	}
 
@@ -801,6 +1210,12 @@ func parseMemoryToBytes(memory string) int64 {
 	return 0
 }
 
+// WorkloadNameFromPod returns the name of the workload a pod belongs to, the same way
+// the engine groups pod metrics into workloads
+func WorkloadNameFromPod(podName string) string {
+	return extractWorkloadName(podName)
+}
+
 // extractWorkloadName extracts the workload name from a pod name
 // e.g., "nginx-deployment-5d7b8c7d9f-abc12" -> "nginx-deployment"
 func extractWorkloadName(podName string) string {
@@ -3,6 +3,9 @@ package recommendation
 import (
 	"math"
 	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
 )
 
 func TestCalculateChangePercent(t *testing.T) {
@@ -162,3 +165,90 @@ func TestContainerRecommendation_MaxChangePercent(t *testing.T) {
 		})
 	}
 }
+
+func TestEngine_EphemeralStorageRecommendation(t *testing.T) {
+	const mi = 1024 * 1024
+	engine := NewEngine()
+	now := time.Now()
+
+	samples := make([]containerSample, 20)
+	for i := range samples {
+		samples[i] = containerSample{
+			timestamp:               now.Add(time.Duration(i-len(samples)) * time.Minute),
+			usageCPU:                100,
+			usageMemory:             256 * mi,
+			requestCPU:              200,
+			requestMemory:           512 * mi,
+			usageEphemeralStorage:   int64(i+1) * 50 * mi, // grows to a 1000Mi peak
+			requestEphemeralStorage: 4096 * mi,
+		}
+	}
+
+	// Sized from the peak plus the safety margin
+	rec := engine.generateContainerRecommendation("app", samples, 95, 95, 1.2, 10, nil)
+	if rec == nil {
+		t.Fatal("Expected a recommendation")
+	}
+	if rec.CurrentEphemeralStorage != 4096*mi {
+		t.Errorf("Expected current ephemeral storage 4096Mi, got %d", rec.CurrentEphemeralStorage)
+	}
+	if rec.RecommendedEphemeralStorage != 1200*mi {
+		t.Errorf("Expected 1200Mi (1000Mi peak * 1.2), got %dMi", rec.RecommendedEphemeralStorage/mi)
+	}
+	if rec.EstimatedSavings.EphemeralStorageSavingsPerHour <= 0 {
+		t.Errorf("Expected ephemeral-storage savings, got %f", rec.EstimatedSavings.EphemeralStorageSavingsPerHour)
+	}
+
+	// Thresholds bound the recommendation
+	thresholds := &optimizerv1alpha1.ResourceThresholds{
+		EphemeralStorage: &optimizerv1alpha1.ResourceLimit{Max: "1Gi"},
+	}
+	rec = engine.generateContainerRecommendation("app", samples, 95, 95, 1.2, 10, thresholds)
+	if rec.RecommendedEphemeralStorage != 1024*mi {
+		t.Errorf("Expected the 1Gi threshold to apply, got %dMi", rec.RecommendedEphemeralStorage/mi)
+	}
+
+	// The request never exceeds an existing limit
+	for i := range samples {
+		samples[i].limitEphemeralStorage = 1100 * mi
+	}
+	rec = engine.generateContainerRecommendation("app", samples, 95, 95, 1.2, 10, nil)
+	if rec.RecommendedEphemeralStorage != 1100*mi || len(rec.Reasons) != 1 {
+		t.Errorf("Expected the request capped at the 1100Mi limit, got %dMi (reasons %v)",
+			rec.RecommendedEphemeralStorage/mi, rec.Reasons)
+	}
+
+	// Containers without reported usage get no recommendation
+	for i := range samples {
+		samples[i].usageEphemeralStorage = 0
+	}
+	rec = engine.generateContainerRecommendation("app", samples, 95, 95, 1.2, 10, nil)
+	if rec.RecommendedEphemeralStorage != 0 {
+		t.Errorf("Expected no ephemeral-storage recommendation without usage, got %d", rec.RecommendedEphemeralStorage)
+	}
+}
+
+func TestParseMemoryToBytes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"", 0},
+		{"512", 512},
+		{"64Ki", 64 * 1024},
+		{"256Mi", 256 * 1024 * 1024},
+		{"1Gi", 1024 * 1024 * 1024},
+		{"2Ti", 2 * 1024 * 1024 * 1024 * 1024},
+		{"500M", 500 * 1000 * 1000},
+		{"1G", 1000 * 1000 * 1000},
+	}
+
+	// Map iteration used to pick "G" for "1Gi" at random, so repeat each case
+	for i := 0; i < 20; i++ {
+		for _, tt := range tests {
+			if got := parseMemoryToBytes(tt.input); got != tt.expected {
+				t.Fatalf("parseMemoryToBytes(%q) = %d, expected %d", tt.input, got, tt.expected)
+			}
+		}
+	}
+}
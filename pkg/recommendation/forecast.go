@@ -0,0 +1,198 @@
+package recommendation
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/prediction"
+)
+
+const (
+	// DefaultForecastHorizon is how far ahead forecasts look by default
+	DefaultForecastHorizon = 24 * time.Hour
+
+	// DefaultForecastInterval is the resampling interval fed to the predictor
+	DefaultForecastInterval = time.Hour
+
+	// DefaultForecastMinIncreasePercent is the increase over the percentile target
+	// that is considered a meaningful forecast-driven raise
+	DefaultForecastMinIncreasePercent = 5.0
+)
+
+// forecastSettings holds the parsed forecast-driven sizing configuration
+type forecastSettings struct {
+	enabled            bool
+	horizon            time.Duration
+	interval           time.Duration
+	useUpperBound      bool
+	minIncreasePercent float64
+}
+
+// parseForecastSettings converts the CRD forecast config into engine settings
+func parseForecastSettings(config *optimizerv1alpha1.ForecastConfig) forecastSettings {
+	settings := forecastSettings{
+		horizon:            DefaultForecastHorizon,
+		interval:           DefaultForecastInterval,
+		useUpperBound:      true,
+		minIncreasePercent: DefaultForecastMinIncreasePercent,
+	}
+	if config == nil || !config.Enabled {
+		return settings
+	}
+
+	settings.enabled = true
+	if d, err := time.ParseDuration(config.Horizon); err == nil && d > 0 {
+		settings.horizon = d
+	}
+	if d, err := time.ParseDuration(config.Interval); err == nil && d > 0 {
+		settings.interval = d
+	}
+	if config.Target == optimizerv1alpha1.ForecastTargetPeak {
+		settings.useUpperBound = false
+	}
+	if config.MinIncreasePercent > 0 {
+		settings.minIncreasePercent = config.MinIncreasePercent
+	}
+	return settings
+}
+
+// forecastPeaks holds the highest forecasted CPU and memory over the horizon
+type forecastPeaks struct {
+	cpu        float64
+	memory     float64
+	cpuTime    time.Time
+	memoryTime time.Time
+}
+
+// forecastUsagePeaks resamples the samples, runs the workload predictor and
+// returns the peak (or upper prediction interval) over the configured horizon
+func forecastUsagePeaks(samples []UsageSample, settings forecastSettings) (*forecastPeaks, error) {
+	cpuValues, memoryValues, timestamps := resampleUsage(samples, settings.interval)
+
+	config := prediction.DefaultConfig()
+	config.SeasonalPeriod = int(24 * time.Hour / settings.interval)
+	if config.SeasonalPeriod < 2 {
+		config.SeasonalPeriod = 2
+	}
+	horizon := int(math.Ceil(float64(settings.horizon) / float64(settings.interval)))
+	if horizon < 1 {
+		horizon = 1
+	}
+
+	predictor := prediction.NewWorkloadPredictorWithConfig(config, horizon, 1.0)
+	result, err := predictor.PredictFromValues(cpuValues, memoryValues, timestamps)
+	if err != nil {
+		return nil, err
+	}
+	if result.CPUForecast == nil || result.MemoryForecast == nil {
+		return nil, fmt.Errorf("predictor could not fit usage history")
+	}
+
+	peaks := &forecastPeaks{}
+	peaks.cpu, peaks.cpuTime = peakOf(result.CPUForecast, settings.useUpperBound)
+	peaks.memory, peaks.memoryTime = peakOf(result.MemoryForecast, settings.useUpperBound)
+	return peaks, nil
+}
+
+// peakOf returns the maximum forecasted value (or upper bound) and when it occurs
+func peakOf(forecast *prediction.ForecastResult, useUpperBound bool) (float64, time.Time) {
+	var peak float64
+	var at time.Time
+	for _, f := range forecast.Forecasts {
+		value := f.Value
+		if useUpperBound {
+			value = f.UpperBound
+		}
+		if value > peak {
+			peak = value
+			at = f.Timestamp
+		}
+	}
+	return peak, at
+}
+
+// resampleUsage aggregates samples into evenly spaced buckets using the peak of each
+// bucket. Empty buckets carry the previous value forward so the series has no gaps.
+func resampleUsage(samples []UsageSample, interval time.Duration) ([]float64, []float64, []time.Time) {
+	if len(samples) == 0 {
+		return nil, nil, nil
+	}
+
+	start := samples[0].Timestamp
+	end := samples[0].Timestamp
+	for _, s := range samples {
+		if s.Timestamp.Before(start) {
+			start = s.Timestamp
+		}
+		if s.Timestamp.After(end) {
+			end = s.Timestamp
+		}
+	}
+	start = start.Truncate(interval)
+
+	numBuckets := int(end.Sub(start)/interval) + 1
+	cpuValues := make([]float64, numBuckets)
+	memoryValues := make([]float64, numBuckets)
+	filled := make([]bool, numBuckets)
+
+	for _, s := range samples {
+		i := int(s.Timestamp.Sub(start) / interval)
+		if float64(s.CPU) > cpuValues[i] {
+			cpuValues[i] = float64(s.CPU)
+		}
+		if float64(s.Memory) > memoryValues[i] {
+			memoryValues[i] = float64(s.Memory)
+		}
+		filled[i] = true
+	}
+
+	timestamps := make([]time.Time, numBuckets)
+	for i := range timestamps {
+		timestamps[i] = start.Add(time.Duration(i) * interval)
+		if !filled[i] && i > 0 {
+			cpuValues[i] = cpuValues[i-1]
+			memoryValues[i] = memoryValues[i-1]
+		}
+	}
+
+	return cpuValues, memoryValues, timestamps
+}
+
+// applyForecast raises the targets to the forecast peak (with safety margin) when it
+// exceeds them. Targets never drop below the historical percentile. A reason is
+// returned for every resource the forecast raised meaningfully.
+func applyForecast(samples []UsageSample, cpuTarget, memoryTarget int64, safetyMargin float64, settings forecastSettings) (int64, int64, []string, error) {
+	peaks, err := forecastUsagePeaks(samples, settings)
+	if err != nil {
+		return cpuTarget, memoryTarget, nil, err
+	}
+
+	bound := "peak"
+	if settings.useUpperBound {
+		bound = "upper prediction bound"
+	}
+
+	var reasons []string
+	forecastCPU := int64(peaks.cpu * safetyMargin)
+	if forecastCPU > cpuTarget {
+		if calculateChangePercent(cpuTarget, forecastCPU) >= settings.minIncreasePercent {
+			reasons = append(reasons, fmt.Sprintf("forecast raised CPU from %dm to %dm (%s %.0fm at %s within %v)",
+				cpuTarget, forecastCPU, bound, peaks.cpu, peaks.cpuTime.Format(time.RFC3339), settings.horizon))
+		}
+		cpuTarget = forecastCPU
+	}
+
+	forecastMemory := int64(peaks.memory * safetyMargin)
+	if forecastMemory > memoryTarget {
+		if calculateChangePercent(memoryTarget, forecastMemory) >= settings.minIncreasePercent {
+			reasons = append(reasons, fmt.Sprintf("forecast raised memory from %dMi to %dMi (%s %.0fMi at %s within %v)",
+				memoryTarget/(1024*1024), forecastMemory/(1024*1024), bound, peaks.memory/(1024*1024),
+				peaks.memoryTime.Format(time.RFC3339), settings.horizon))
+		}
+		memoryTarget = forecastMemory
+	}
+
+	return cpuTarget, memoryTarget, reasons, nil
+}
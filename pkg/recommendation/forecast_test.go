@@ -0,0 +1,198 @@
+package recommendation
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/models"
+)
+
+// growingUsage produces 3 days of samples with a daily cycle and a steady upward trend
+func growingUsage(slopePerHour float64) []UsageSample {
+	start := time.Now().Add(-72 * time.Hour)
+	var samples []UsageSample
+	for i := 0; i < 72*12; i++ {
+		ts := start.Add(time.Duration(i) * 5 * time.Minute)
+		hours := ts.Sub(start).Hours()
+		daily := 50 * math.Sin(2*math.Pi*hours/24)
+		samples = append(samples, UsageSample{
+			Timestamp: ts,
+			CPU:       int64(400 + slopePerHour*hours + daily),
+			Memory:    int64((512 + slopePerHour*hours) * 1024 * 1024),
+		})
+	}
+	return samples
+}
+
+func TestParseForecastSettings(t *testing.T) {
+	if s := parseForecastSettings(nil); s.enabled {
+		t.Error("Expected forecast to be disabled for nil config")
+	}
+
+	s := parseForecastSettings(&optimizerv1alpha1.ForecastConfig{Enabled: true})
+	if !s.enabled || s.horizon != DefaultForecastHorizon || s.interval != DefaultForecastInterval || !s.useUpperBound {
+		t.Errorf("Expected defaults for enabled config, got %+v", s)
+	}
+
+	s = parseForecastSettings(&optimizerv1alpha1.ForecastConfig{
+		Enabled:            true,
+		Horizon:            "12h",
+		Interval:           "30m",
+		Target:             optimizerv1alpha1.ForecastTargetPeak,
+		MinIncreasePercent: 10,
+	})
+	if s.horizon != 12*time.Hour || s.interval != 30*time.Minute || s.useUpperBound || s.minIncreasePercent != 10 {
+		t.Errorf("Expected overrides to be applied, got %+v", s)
+	}
+}
+
+func TestResampleUsage(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []UsageSample{
+		{Timestamp: base.Add(10 * time.Minute), CPU: 100, Memory: 10},
+		{Timestamp: base.Add(20 * time.Minute), CPU: 300, Memory: 5},
+		// No samples in the second hour
+		{Timestamp: base.Add(150 * time.Minute), CPU: 50, Memory: 20},
+	}
+
+	cpu, memory, timestamps := resampleUsage(samples, time.Hour)
+	if len(cpu) != 3 {
+		t.Fatalf("Expected 3 buckets, got %d", len(cpu))
+	}
+	if cpu[0] != 300 || memory[0] != 10 {
+		t.Errorf("Expected first bucket peak cpu=300 mem=10, got cpu=%.0f mem=%.0f", cpu[0], memory[0])
+	}
+	if cpu[1] != 300 || memory[1] != 10 {
+		t.Errorf("Expected empty bucket to carry previous value forward, got cpu=%.0f mem=%.0f", cpu[1], memory[1])
+	}
+	if cpu[2] != 50 {
+		t.Errorf("Expected last bucket cpu=50, got %.0f", cpu[2])
+	}
+	if !timestamps[1].Equal(base.Add(time.Hour)) {
+		t.Errorf("Expected evenly spaced timestamps, got %v", timestamps[1])
+	}
+}
+
+func TestApplyForecast_RaisesForGrowingWorkload(t *testing.T) {
+	samples := growingUsage(5)
+	settings := parseForecastSettings(&optimizerv1alpha1.ForecastConfig{
+		Enabled: true,
+		Target:  optimizerv1alpha1.ForecastTargetPeak,
+	})
+
+	_, cpuValues, memoryValues := splitUsageSamples(samples)
+	cpuTarget := int64(float64(calculatePercentile(cpuValues, 95)) * 1.2)
+	memoryTarget := int64(float64(calculatePercentile(memoryValues, 95)) * 1.2)
+
+	newCPU, newMemory, reasons, err := applyForecast(samples, cpuTarget, memoryTarget, 1.2, settings)
+	if err != nil {
+		t.Fatalf("applyForecast failed: %v", err)
+	}
+
+	if newCPU <= cpuTarget {
+		t.Errorf("Expected forecast to raise CPU above percentile target %dm, got %dm", cpuTarget, newCPU)
+	}
+	if newMemory < memoryTarget {
+		t.Errorf("Expected memory never to drop below percentile target %d, got %d", memoryTarget, newMemory)
+	}
+	if len(reasons) == 0 || !strings.Contains(reasons[0], "forecast raised CPU") {
+		t.Errorf("Expected a reason for the CPU raise, got %v", reasons)
+	}
+}
+
+func TestApplyForecast_NeverBelowPercentile(t *testing.T) {
+	samples := growingUsage(-3)
+	settings := parseForecastSettings(&optimizerv1alpha1.ForecastConfig{
+		Enabled: true,
+		Target:  optimizerv1alpha1.ForecastTargetPeak,
+	})
+
+	// Targets well above anything the declining workload will forecast
+	cpuTarget := int64(2000)
+	memoryTarget := int64(4 * 1024 * 1024 * 1024)
+
+	newCPU, newMemory, reasons, err := applyForecast(samples, cpuTarget, memoryTarget, 1.2, settings)
+	if err != nil {
+		t.Fatalf("applyForecast failed: %v", err)
+	}
+	if newCPU != cpuTarget || newMemory != memoryTarget {
+		t.Errorf("Expected targets unchanged (%d, %d), got (%d, %d)", cpuTarget, memoryTarget, newCPU, newMemory)
+	}
+	if len(reasons) != 0 {
+		t.Errorf("Expected no reasons when forecast does not raise targets, got %v", reasons)
+	}
+}
+
+func TestApplyForecast_InsufficientHistory(t *testing.T) {
+	samples := growingUsage(5)[:12*12] // 12 hours is less than two daily seasons
+	settings := parseForecastSettings(&optimizerv1alpha1.ForecastConfig{Enabled: true})
+
+	newCPU, newMemory, _, err := applyForecast(samples, 500, 1024, 1.2, settings)
+	if err == nil {
+		t.Error("Expected error with insufficient history")
+	}
+	if newCPU != 500 || newMemory != 1024 {
+		t.Errorf("Expected targets unchanged on error, got (%d, %d)", newCPU, newMemory)
+	}
+}
+
+func TestEngine_ForecastDrivenSizing(t *testing.T) {
+	var metrics []models.PodMetric
+	for _, s := range growingUsage(5) {
+		metrics = append(metrics, models.PodMetric{
+			PodName:   "batch-6f7c8d9e0-qwert",
+			Namespace: "default",
+			Timestamp: s.Timestamp,
+			Containers: []models.ContainerMetric{{
+				ContainerName: "worker",
+				UsageCPU:      s.CPU,
+				UsageMemory:   s.Memory,
+				RequestCPU:    1000,
+				RequestMemory: 2 * 1024 * 1024 * 1024,
+			}},
+		})
+	}
+	provider := &staticMetricsProvider{metrics: metrics}
+
+	newConfig := func(forecast *optimizerv1alpha1.ForecastConfig) *optimizerv1alpha1.OptimizerConfig {
+		return &optimizerv1alpha1.OptimizerConfig{
+			Spec: optimizerv1alpha1.OptimizerConfigSpec{
+				TargetNamespaces: []string{"default"},
+				Strategy:         optimizerv1alpha1.StrategyBalanced,
+				Recommendations: &optimizerv1alpha1.RecommendationConfig{
+					HistoryDuration: "96h",
+					Forecast:        forecast,
+				},
+			},
+		}
+	}
+
+	engine := NewEngine()
+	baseline, err := engine.GenerateRecommendations(provider, newConfig(nil))
+	if err != nil || len(baseline) != 1 {
+		t.Fatalf("Expected baseline recommendation, got %v (err=%v)", baseline, err)
+	}
+	forecasted, err := engine.GenerateRecommendations(provider, newConfig(&optimizerv1alpha1.ForecastConfig{Enabled: true}))
+	if err != nil || len(forecasted) != 1 {
+		t.Fatalf("Expected forecast recommendation, got %v (err=%v)", forecasted, err)
+	}
+
+	base := baseline[0].Containers[0]
+	fc := forecasted[0].Containers[0]
+	if base.ForecastApplied {
+		t.Error("Expected no forecast adjustment when forecasting is disabled")
+	}
+	if !fc.ForecastApplied {
+		t.Error("Expected forecast adjustment for a growing workload")
+	}
+	if fc.RecommendedCPU < base.RecommendedCPU || fc.RecommendedMemory < base.RecommendedMemory {
+		t.Errorf("Expected forecast recommendation (%dm, %d) to be at least the percentile recommendation (%dm, %d)",
+			fc.RecommendedCPU, fc.RecommendedMemory, base.RecommendedCPU, base.RecommendedMemory)
+	}
+	if len(fc.Reasons) == 0 {
+		t.Error("Expected reasons to be recorded when the forecast raised the target")
+	}
+}
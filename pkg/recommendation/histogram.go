@@ -0,0 +1,202 @@
+package recommendation
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// DefaultHistogramHalfLife is how long it takes for a sample's weight to halve
+	DefaultHistogramHalfLife = 24 * time.Hour
+
+	// DefaultMemoryPeakInterval is the window over which memory peaks are aggregated
+	DefaultMemoryPeakInterval = 24 * time.Hour
+
+	// histogramBucketGrowth is the ratio between consecutive bucket sizes
+	histogramBucketGrowth = 1.05
+
+	// maxDecayExponent bounds sample weights before the reference time is shifted
+	maxDecayExponent = 100.0
+)
+
+// Bucket layouts (CPU in millicores, memory in bytes)
+var (
+	cpuHistogramOptions    = histogramOptions{firstBucketSize: 10, maxValue: 1000 * 1000}
+	memoryHistogramOptions = histogramOptions{firstBucketSize: 10 * 1000 * 1000, maxValue: 1000 * 1000 * 1000 * 1000}
+)
+
+// histogramOptions describes the exponential bucket layout of a histogram
+type histogramOptions struct {
+	firstBucketSize float64
+	maxValue        float64
+}
+
+// DecayingHistogram is a histogram with exponentially growing buckets where
+// the weight of each sample decays with a configurable half-life.
+// Newer samples therefore dominate the percentile estimate.
+type DecayingHistogram struct {
+	options       histogramOptions
+	weights       []float64
+	totalWeight   float64
+	halfLife      time.Duration
+	referenceTime time.Time
+}
+
+// newDecayingHistogram creates an empty histogram with the given bucket layout and half-life
+func newDecayingHistogram(options histogramOptions, halfLife time.Duration) *DecayingHistogram {
+	if halfLife <= 0 {
+		halfLife = DefaultHistogramHalfLife
+	}
+	numBuckets := int(math.Ceil(math.Log(options.maxValue*(histogramBucketGrowth-1)/options.firstBucketSize+1)/math.Log(histogramBucketGrowth))) + 1
+	return &DecayingHistogram{
+		options:  options,
+		weights:  make([]float64, numBuckets),
+		halfLife: halfLife,
+	}
+}
+
+// NewCPUHistogram creates a decaying histogram suited for CPU millicores
+func NewCPUHistogram(halfLife time.Duration) *DecayingHistogram {
+	return newDecayingHistogram(cpuHistogramOptions, halfLife)
+}
+
+// NewMemoryHistogram creates a decaying histogram suited for memory bytes
+func NewMemoryHistogram(halfLife time.Duration) *DecayingHistogram {
+	return newDecayingHistogram(memoryHistogramOptions, halfLife)
+}
+
+// AddSample adds a value observed at the given time
+func (h *DecayingHistogram) AddSample(value float64, weight float64, timestamp time.Time) {
+	if weight <= 0 {
+		return
+	}
+	if h.referenceTime.IsZero() {
+		h.referenceTime = timestamp
+	}
+
+	exponent := timestamp.Sub(h.referenceTime).Hours() / h.halfLife.Hours()
+	if exponent > maxDecayExponent {
+		// Rebase weights so they don't overflow for long-running histograms
+		h.shiftReferenceTime(timestamp)
+		exponent = 0
+	}
+
+	decayed := weight * math.Pow(2, exponent)
+	h.weights[h.findBucket(value)] += decayed
+	h.totalWeight += decayed
+}
+
+// shiftReferenceTime moves the reference time forward and rescales all weights
+func (h *DecayingHistogram) shiftReferenceTime(newReference time.Time) {
+	factor := math.Pow(2, -newReference.Sub(h.referenceTime).Hours()/h.halfLife.Hours())
+	h.totalWeight = 0
+	for i := range h.weights {
+		h.weights[i] *= factor
+		h.totalWeight += h.weights[i]
+	}
+	h.referenceTime = newReference
+}
+
+// Percentile returns the upper bound of the bucket containing the given percentile (0-100)
+func (h *DecayingHistogram) Percentile(percentile int) float64 {
+	if h.IsEmpty() {
+		return 0
+	}
+
+	threshold := float64(percentile) / 100.0 * h.totalWeight
+	cumulative := 0.0
+	bucket := len(h.weights) - 1
+	for i, w := range h.weights {
+		cumulative += w
+		if cumulative >= threshold {
+			bucket = i
+			break
+		}
+	}
+
+	if bucket == len(h.weights)-1 {
+		return h.bucketStart(bucket)
+	}
+	return h.bucketStart(bucket + 1)
+}
+
+// IsEmpty returns true if the histogram has no weight
+func (h *DecayingHistogram) IsEmpty() bool {
+	return h.totalWeight <= 0
+}
+
+// findBucket returns the index of the bucket that holds the value
+func (h *DecayingHistogram) findBucket(value float64) int {
+	if value < h.options.firstBucketSize {
+		return 0
+	}
+	bucket := int(math.Log(value*(histogramBucketGrowth-1)/h.options.firstBucketSize+1) / math.Log(histogramBucketGrowth))
+	if bucket >= len(h.weights) {
+		return len(h.weights) - 1
+	}
+	return bucket
+}
+
+// bucketStart returns the smallest value that falls into the bucket
+func (h *DecayingHistogram) bucketStart(bucket int) float64 {
+	return h.options.firstBucketSize * (math.Pow(histogramBucketGrowth, float64(bucket)) - 1) / (histogramBucketGrowth - 1)
+}
+
+// histogramSettings holds the parameters for the decaying-histogram algorithm
+type histogramSettings struct {
+	halfLife           time.Duration
+	memoryPeakInterval time.Duration
+}
+
+// decayingHistogramPercentiles computes CPU and memory percentiles from decaying histograms.
+// CPU samples are added individually, while memory uses only the peak of each
+// aggregation interval so short-lived dips don't pull the estimate down.
+func decayingHistogramPercentiles(samples []UsageSample, cpuPercentile, memoryPercentile int, settings histogramSettings) (int64, int64) {
+	return decayingHistogramCPUPercentile(samples, cpuPercentile, settings),
+		decayingHistogramMemoryPercentile(samples, memoryPercentile, settings)
+}
+
+// decayingHistogramCPUPercentile computes the CPU percentile from a decaying histogram of every sample
+func decayingHistogramCPUPercentile(samples []UsageSample, percentile int, settings histogramSettings) int64 {
+	histogram := NewCPUHistogram(settings.halfLife)
+	for _, s := range samples {
+		histogram.AddSample(float64(s.CPU), 1.0, s.Timestamp)
+	}
+	return int64(math.Ceil(histogram.Percentile(percentile)))
+}
+
+// decayingHistogramMemoryPercentile computes the memory percentile from a decaying histogram
+// of the peak of each aggregation interval
+func decayingHistogramMemoryPercentile(samples []UsageSample, percentile int, settings histogramSettings) int64 {
+	histogram := NewMemoryHistogram(settings.halfLife)
+	for _, peak := range memoryPeaksPerInterval(samples, settings.memoryPeakInterval) {
+		histogram.AddSample(float64(peak.Memory), 1.0, peak.Timestamp)
+	}
+	return int64(math.Ceil(histogram.Percentile(percentile)))
+}
+
+// memoryPeaksPerInterval returns the sample with the highest memory usage in each interval
+func memoryPeaksPerInterval(samples []UsageSample, interval time.Duration) []UsageSample {
+	if interval <= 0 {
+		interval = DefaultMemoryPeakInterval
+	}
+
+	peaks := make(map[int64]UsageSample)
+	var order []int64
+	for _, s := range samples {
+		key := s.Timestamp.Truncate(interval).Unix()
+		peak, exists := peaks[key]
+		if !exists {
+			order = append(order, key)
+		}
+		if !exists || s.Memory > peak.Memory {
+			peaks[key] = s
+		}
+	}
+
+	result := make([]UsageSample, 0, len(order))
+	for _, key := range order {
+		result = append(result, peaks[key])
+	}
+	return result
+}
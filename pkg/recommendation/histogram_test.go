@@ -0,0 +1,271 @@
+package recommendation
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/models"
+)
+
+// staticMetricsProvider serves a fixed set of pod metrics
+type staticMetricsProvider struct {
+	metrics []models.PodMetric
+}
+
+func (p *staticMetricsProvider) GetMetricsByNamespace(namespace string, since time.Duration) []models.PodMetric {
+	cutoff := time.Now().Add(-since)
+	var result []models.PodMetric
+	for _, m := range p.metrics {
+		if m.Namespace == namespace && m.Timestamp.After(cutoff) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func (p *staticMetricsProvider) GetMetricsByWorkload(namespace, workloadName string, since time.Duration) []models.PodMetric {
+	return p.GetMetricsByNamespace(namespace, since)
+}
+
+// syntheticSamples generates one sample per interval going back the given duration
+func syntheticSamples(duration, interval time.Duration, usage func(age time.Duration, i int) (int64, int64)) []UsageSample {
+	now := time.Now()
+	var samples []UsageSample
+	i := 0
+	for age := duration; age > 0; age -= interval {
+		cpu, mem := usage(age, i)
+		samples = append(samples, UsageSample{
+			Timestamp:     now.Add(-age),
+			CPU:           cpu,
+			Memory:        mem,
+			RequestCPU:    1000,
+			RequestMemory: 2 * 1024 * 1024 * 1024,
+		})
+		i++
+	}
+	return samples
+}
+
+func TestDecayingHistogram_Empty(t *testing.T) {
+	h := NewCPUHistogram(time.Hour)
+	if !h.IsEmpty() {
+		t.Error("Expected new histogram to be empty")
+	}
+	if p := h.Percentile(95); p != 0 {
+		t.Errorf("Expected percentile of empty histogram to be 0, got %f", p)
+	}
+}
+
+func TestDecayingHistogram_BucketAccuracy(t *testing.T) {
+	h := NewCPUHistogram(24 * time.Hour)
+	now := time.Now()
+	for i := 1; i <= 1000; i++ {
+		h.AddSample(float64(i), 1.0, now)
+	}
+
+	tests := []struct {
+		percentile int
+		expected   float64
+	}{
+		{50, 500},
+		{90, 900},
+		{95, 950},
+	}
+
+	for _, tt := range tests {
+		got := h.Percentile(tt.percentile)
+		// The upper bucket bound may overshoot by at most one bucket width
+		if got < tt.expected || got > tt.expected*1.1 {
+			t.Errorf("P%d: expected ~%.0f, got %.1f", tt.percentile, tt.expected, got)
+		}
+	}
+}
+
+func TestDecayingHistogram_ShiftReferenceTimePreservesPercentile(t *testing.T) {
+	h := NewCPUHistogram(time.Hour)
+	start := time.Now().Add(-200 * time.Hour)
+
+	// Add samples spread over far more than maxDecayExponent half-lives
+	for i := 0; i < 200; i++ {
+		h.AddSample(100, 1.0, start.Add(time.Duration(i)*time.Hour))
+	}
+
+	if math.IsInf(h.totalWeight, 0) || math.IsNaN(h.totalWeight) {
+		t.Fatalf("Expected finite total weight, got %f", h.totalWeight)
+	}
+
+	reference := NewCPUHistogram(time.Hour)
+	reference.AddSample(100, 1.0, start)
+
+	if got, want := h.Percentile(95), reference.Percentile(95); got != want {
+		t.Errorf("Expected P95 %.1f after reference shift, got %.1f", want, got)
+	}
+}
+
+func TestMemoryPeaksPerInterval(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	samples := []UsageSample{
+		{Timestamp: base.Add(1 * time.Hour), Memory: 100},
+		{Timestamp: base.Add(2 * time.Hour), Memory: 300},
+		{Timestamp: base.Add(3 * time.Hour), Memory: 200},
+		{Timestamp: base.Add(25 * time.Hour), Memory: 50},
+		{Timestamp: base.Add(26 * time.Hour), Memory: 80},
+	}
+
+	peaks := memoryPeaksPerInterval(samples, 24*time.Hour)
+	if len(peaks) != 2 {
+		t.Fatalf("Expected 2 intervals, got %d", len(peaks))
+	}
+	if peaks[0].Memory != 300 {
+		t.Errorf("Expected first interval peak 300, got %d", peaks[0].Memory)
+	}
+	if peaks[1].Memory != 80 {
+		t.Errorf("Expected second interval peak 80, got %d", peaks[1].Memory)
+	}
+}
+
+// TestDecayingHistogramVsPercentile compares both recommenders on synthetic workloads
+func TestDecayingHistogramVsPercentile(t *testing.T) {
+	const mi = 1024 * 1024
+
+	tests := []struct {
+		name     string
+		samples  []UsageSample
+		settings histogramSettings
+		check    func(t *testing.T, rawCPU, rawMem, histCPU, histMem int64)
+	}{
+		{
+			name: "steady workload - both agree",
+			samples: syntheticSamples(48*time.Hour, 5*time.Minute, func(age time.Duration, i int) (int64, int64) {
+				return 400 + int64(i%5)*10, 512 * mi
+			}),
+			settings: histogramSettings{halfLife: 24 * time.Hour, memoryPeakInterval: time.Hour},
+			check: func(t *testing.T, rawCPU, rawMem, histCPU, histMem int64) {
+				if diff := math.Abs(float64(histCPU-rawCPU)) / float64(rawCPU); diff > 0.1 {
+					t.Errorf("Expected CPU within 10%% of raw percentile, raw=%d hist=%d", rawCPU, histCPU)
+				}
+				if diff := math.Abs(float64(histMem-rawMem)) / float64(rawMem); diff > 0.1 {
+					t.Errorf("Expected memory within 10%% of raw percentile, raw=%d hist=%d", rawMem, histMem)
+				}
+			},
+		},
+		{
+			name: "usage dropped recently - histogram follows quickly",
+			samples: syntheticSamples(7*24*time.Hour, 5*time.Minute, func(age time.Duration, i int) (int64, int64) {
+				if age > 24*time.Hour {
+					return 1000, 512 * mi
+				}
+				return 200, 512 * mi
+			}),
+			settings: histogramSettings{halfLife: 2 * time.Hour, memoryPeakInterval: time.Hour},
+			check: func(t *testing.T, rawCPU, rawMem, histCPU, histMem int64) {
+				if rawCPU != 1000 {
+					t.Errorf("Expected raw P95 to still reflect old usage (1000m), got %d", rawCPU)
+				}
+				if histCPU > 250 {
+					t.Errorf("Expected histogram P95 to track recent usage (~200m), got %d", histCPU)
+				}
+			},
+		},
+		{
+			name: "short daily memory spikes - peaks are retained",
+			samples: syntheticSamples(7*24*time.Hour, 5*time.Minute, func(age time.Duration, i int) (int64, int64) {
+				// One 5-minute spike to 1.5Gi every 12 hours
+				if i%144 == 0 {
+					return 300, 1536 * mi
+				}
+				return 300, 512 * mi
+			}),
+			settings: histogramSettings{halfLife: 24 * time.Hour, memoryPeakInterval: 12 * time.Hour},
+			check: func(t *testing.T, rawCPU, rawMem, histCPU, histMem int64) {
+				if rawMem > 600*mi {
+					t.Errorf("Expected raw P95 to miss rare spikes, got %dMi", rawMem/mi)
+				}
+				if histMem < 1536*mi {
+					t.Errorf("Expected histogram to keep per-interval peak (>=1536Mi), got %dMi", histMem/mi)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cpuValues := make([]int64, len(tt.samples))
+			memValues := make([]int64, len(tt.samples))
+			for i, s := range tt.samples {
+				cpuValues[i] = s.CPU
+				memValues[i] = s.Memory
+			}
+
+			rawCPU := calculatePercentile(cpuValues, 95)
+			rawMem := calculatePercentile(memValues, 95)
+			histCPU, histMem := decayingHistogramPercentiles(tt.samples, 95, 95, tt.settings)
+
+			t.Logf("raw: cpu=%dm mem=%dMi, histogram: cpu=%dm mem=%dMi",
+				rawCPU, rawMem/mi, histCPU, histMem/mi)
+			tt.check(t, rawCPU, rawMem, histCPU, histMem)
+		})
+	}
+}
+
+func TestEngine_DecayingHistogramAlgorithm(t *testing.T) {
+	now := time.Now()
+	var metrics []models.PodMetric
+	for i := 0; i < 7*24*12; i++ {
+		age := time.Duration(i) * 5 * time.Minute
+		cpu := int64(200)
+		if age > 24*time.Hour {
+			cpu = 1000
+		}
+		metrics = append(metrics, models.PodMetric{
+			PodName:   "api-7d9f8b6c5-x2k4p",
+			Namespace: "default",
+			Timestamp: now.Add(-age),
+			Containers: []models.ContainerMetric{{
+				ContainerName: "api",
+				UsageCPU:      cpu,
+				UsageMemory:   256 * 1024 * 1024,
+				RequestCPU:    1000,
+				RequestMemory: 512 * 1024 * 1024,
+			}},
+		})
+	}
+	provider := &staticMetricsProvider{metrics: metrics}
+
+	newConfig := func(algorithm optimizerv1alpha1.RecommendationAlgorithm) *optimizerv1alpha1.OptimizerConfig {
+		return &optimizerv1alpha1.OptimizerConfig{
+			Spec: optimizerv1alpha1.OptimizerConfigSpec{
+				TargetNamespaces: []string{"default"},
+				Strategy:         optimizerv1alpha1.StrategyBalanced,
+				Recommendations: &optimizerv1alpha1.RecommendationConfig{
+					HistoryDuration: "168h",
+					Algorithm:       algorithm,
+					HalfLife:        "2h",
+				},
+			},
+		}
+	}
+
+	engine := NewEngine()
+	percentileRecs, err := engine.GenerateRecommendations(provider, newConfig(optimizerv1alpha1.AlgorithmPercentile))
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	histogramRecs, err := engine.GenerateRecommendations(provider, newConfig(optimizerv1alpha1.AlgorithmDecayingHistogram))
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+
+	if len(percentileRecs) != 1 || len(histogramRecs) != 1 {
+		t.Fatalf("Expected one recommendation from each algorithm, got %d and %d", len(percentileRecs), len(histogramRecs))
+	}
+
+	percentileCPU := percentileRecs[0].Containers[0].RecommendedCPU
+	histogramCPU := histogramRecs[0].Containers[0].RecommendedCPU
+	if histogramCPU >= percentileCPU {
+		t.Errorf("Expected decaying histogram (%dm) to recommend less CPU than raw percentile (%dm) after usage dropped",
+			histogramCPU, percentileCPU)
+	}
+}
@@ -0,0 +1,45 @@
+package recommendation
+
+import (
+	"intelligent-cluster-optimizer/pkg/safety"
+)
+
+// OOMDetectorProvider adapts a safety.OOMDetector to the OOMInfoProvider interface
+// so OOMKilled history feeds into memory recommendations
+type OOMDetectorProvider struct {
+	detector *safety.OOMDetector
+}
+
+// NewOOMDetectorProvider creates an OOMInfoProvider backed by the given detector
+func NewOOMDetectorProvider(detector *safety.OOMDetector) *OOMDetectorProvider {
+	return &OOMDetectorProvider{detector: detector}
+}
+
+// GetMemoryBoostFactor returns the memory multiplier for a container (1.0 without OOM history)
+func (p *OOMDetectorProvider) GetMemoryBoostFactor(namespace, workloadName, containerName string) float64 {
+	return p.detector.GetMemoryBoostFactor(namespace, workloadName, containerName)
+}
+
+// GetOOMHistory converts the detector's history for a workload, or returns nil if it has none
+func (p *OOMDetectorProvider) GetOOMHistory(namespace, workloadName string) *OOMHistoryInfo {
+	history := p.detector.GetOOMHistory(namespace, workloadName)
+	if history == nil || len(history.ContainerOOMs) == 0 {
+		return nil
+	}
+
+	priority := p.detector.GetOOMPriority(namespace, workloadName).String()
+	info := &OOMHistoryInfo{
+		HasOOMHistory: true,
+		TotalOOMCount: history.TotalOOMs,
+		Priority:      priority,
+		ContainerOOMs: make(map[string]ContainerOOMDetails, len(history.ContainerOOMs)),
+	}
+	for name, c := range history.ContainerOOMs {
+		info.ContainerOOMs[name] = ContainerOOMDetails{
+			OOMCount:         c.OOMCount,
+			RecommendedBoost: c.RecommendedBoost,
+			Priority:         priority,
+		}
+	}
+	return info
+}
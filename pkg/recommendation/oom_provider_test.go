@@ -0,0 +1,121 @@
+package recommendation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/safety"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func oomKilledPod(name string, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{{
+				Kind: "ReplicaSet",
+				Name: "web-5c9d7f8b4",
+			}},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "web",
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "web",
+				RestartCount: restarts,
+				LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{
+						Reason:     "OOMKilled",
+						FinishedAt: metav1.NewTime(time.Now().Add(-30 * time.Minute)),
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestOOMDetectorProvider_GetOOMHistory(t *testing.T) {
+	client := fake.NewSimpleClientset(oomKilledPod("web-5c9d7f8b4-abcde", 3))
+	detector := safety.NewOOMDetector(client)
+	provider := NewOOMDetectorProvider(detector)
+
+	if info := provider.GetOOMHistory("default", "web"); info != nil {
+		t.Fatalf("Expected no history before scanning, got %+v", info)
+	}
+
+	// Scanning twice must not inflate the OOM count
+	for i := 0; i < 2; i++ {
+		if _, err := detector.CheckNamespaceForOOMs(context.Background(), "default"); err != nil {
+			t.Fatalf("CheckNamespaceForOOMs failed: %v", err)
+		}
+	}
+
+	info := provider.GetOOMHistory("default", "web")
+	if info == nil || !info.HasOOMHistory {
+		t.Fatal("Expected OOM history after scanning")
+	}
+	if info.TotalOOMCount != 3 {
+		t.Errorf("Expected total OOM count 3, got %d", info.TotalOOMCount)
+	}
+	if info.Priority != safety.OOMPriorityCritical.String() {
+		t.Errorf("Expected Critical priority for an OOM 30 minutes ago, got %s", info.Priority)
+	}
+	container, ok := info.ContainerOOMs["web"]
+	if !ok || container.RecommendedBoost != 1.5 {
+		t.Errorf("Expected container boost 1.5, got %+v", container)
+	}
+	if boost := provider.GetMemoryBoostFactor("default", "web", "web"); boost != 1.5 {
+		t.Errorf("Expected boost factor 1.5, got %.2f", boost)
+	}
+}
+
+func TestEngine_OOMHistoryNeverDownsizes(t *testing.T) {
+	client := fake.NewSimpleClientset(oomKilledPod("web-5c9d7f8b4-abcde", 1))
+	detector := safety.NewOOMDetector(client)
+	if _, err := detector.CheckNamespaceForOOMs(context.Background(), "default"); err != nil {
+		t.Fatalf("CheckNamespaceForOOMs failed: %v", err)
+	}
+
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+		},
+	}
+	provider := &staticMetricsProvider{metrics: steadyMetrics(60)}
+
+	engine := NewEngine()
+	plain, err := engine.GenerateRecommendations(provider, config)
+	if err != nil || len(plain) != 1 {
+		t.Fatalf("Expected one recommendation, got %v (err=%v)", plain, err)
+	}
+	withOOM, err := engine.GenerateRecommendationsWithOOM(provider, NewOOMDetectorProvider(detector), config)
+	if err != nil || len(withOOM) != 1 {
+		t.Fatalf("Expected one OOM-aware recommendation, got %v (err=%v)", withOOM, err)
+	}
+
+	base := plain[0].Containers[0]
+	oom := withOOM[0].Containers[0]
+	if base.RecommendedMemory >= base.CurrentMemory {
+		t.Fatalf("Expected plain recommendation to reduce memory, got %d -> %d", base.CurrentMemory, base.RecommendedMemory)
+	}
+	if !oom.HasOOMHistory {
+		t.Error("Expected OOM history on the container recommendation")
+	}
+	if oom.RecommendedMemory < oom.CurrentMemory {
+		t.Errorf("Expected OOMing container never to be downsized, got %d -> %d", oom.CurrentMemory, oom.RecommendedMemory)
+	}
+}
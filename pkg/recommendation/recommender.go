@@ -0,0 +1,275 @@
+package recommendation
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+)
+
+// ResourceType identifies a resource a recommender produces a target for
+type ResourceType string
+
+const (
+	// ResourceCPU is CPU in millicores
+	ResourceCPU ResourceType = "cpu"
+	// ResourceMemory is memory in bytes
+	ResourceMemory ResourceType = "memory"
+)
+
+// Built-in recommender names
+const (
+	RecommenderPercentile        = string(optimizerv1alpha1.AlgorithmPercentile)
+	RecommenderDecayingHistogram = string(optimizerv1alpha1.AlgorithmDecayingHistogram)
+)
+
+// UsageSample is a single usage observation of a container
+type UsageSample struct {
+	Timestamp     time.Time
+	CPU           int64 // millicores
+	Memory        int64 // bytes
+	RequestCPU    int64 // millicores
+	RequestMemory int64 // bytes
+}
+
+// RecommenderContext carries the workload identity and tuning shared by all recommenders
+type RecommenderContext struct {
+	Namespace     string
+	WorkloadName  string
+	ContainerName string
+
+	CPUPercentile    int
+	MemoryPercentile int
+	SafetyMargin     float64
+
+	// Decaying-histogram tuning
+	HalfLife           time.Duration
+	MemoryPeakInterval time.Duration
+
+	// ExpectedSampleInterval is used for gap detection when scoring confidence
+	ExpectedSampleInterval time.Duration
+}
+
+// RecommenderInput is the per-container input handed to a recommender
+type RecommenderInput struct {
+	Samples []UsageSample
+	Context RecommenderContext
+	// Resources lists the resources targets are wanted for; empty means all of them
+	Resources []ResourceType
+}
+
+// Wants reports whether a target for the given resource was asked for
+func (in *RecommenderInput) Wants(resource ResourceType) bool {
+	if len(in.Resources) == 0 {
+		return true
+	}
+	for _, r := range in.Resources {
+		if r == resource {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourceTarget is the recommended value for a single resource
+type ResourceTarget struct {
+	// Usage is the aggregated usage the target was derived from (before safety margin)
+	Usage int64
+	// Target is the recommended request
+	Target int64
+	// Confidence is a 0-100 score for this target
+	Confidence float64
+	// Reason optionally explains how the target was derived
+	Reason string
+}
+
+// RecommenderOutput holds the per-resource targets produced by a recommender
+type RecommenderOutput struct {
+	Targets map[ResourceType]ResourceTarget
+}
+
+// Recommender turns a container's usage history into resource targets
+type Recommender interface {
+	// Name returns the unique name used to select the recommender
+	Name() string
+	// Recommend produces targets for the container described by the input, at least
+	// for every resource the input asks for
+	Recommend(input *RecommenderInput) (*RecommenderOutput, error)
+}
+
+// Registry holds recommenders keyed by name
+type Registry struct {
+	mu           sync.RWMutex
+	recommenders map[string]Recommender
+}
+
+// NewRegistry creates an empty recommender registry
+func NewRegistry() *Registry {
+	return &Registry{
+		recommenders: make(map[string]Recommender),
+	}
+}
+
+// NewDefaultRegistry creates a registry with the built-in recommenders
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+	confidence := NewConfidenceCalculator()
+	r.MustRegister(NewPercentileRecommender(confidence))
+	r.MustRegister(NewDecayingHistogramRecommender(confidence))
+	return r
+}
+
+// Register adds a recommender, failing if the name is empty or already taken
+func (r *Registry) Register(rec Recommender) error {
+	name := rec.Name()
+	if name == "" {
+		return fmt.Errorf("recommender name must not be empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.recommenders[name]; exists {
+		return fmt.Errorf("recommender %q already registered", name)
+	}
+	r.recommenders[name] = rec
+	return nil
+}
+
+// MustRegister adds a recommender and panics on error
+func (r *Registry) MustRegister(rec Recommender) {
+	if err := r.Register(rec); err != nil {
+		panic(err)
+	}
+}
+
+// Get returns the recommender registered under the given name
+func (r *Registry) Get(name string) (Recommender, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	rec, ok := r.recommenders[name]
+	return rec, ok
+}
+
+// Names returns the sorted names of all registered recommenders
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.recommenders))
+	for name := range r.recommenders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PercentileRecommender recommends the raw usage percentile plus safety margin
+type PercentileRecommender struct {
+	confidenceCalculator *ConfidenceCalculator
+}
+
+// NewPercentileRecommender creates the built-in percentile recommender
+func NewPercentileRecommender(confidence *ConfidenceCalculator) *PercentileRecommender {
+	return &PercentileRecommender{confidenceCalculator: confidence}
+}
+
+// Name returns the recommender name
+func (p *PercentileRecommender) Name() string {
+	return RecommenderPercentile
+}
+
+// Recommend computes percentile-based targets for CPU and memory
+func (p *PercentileRecommender) Recommend(input *RecommenderInput) (*RecommenderOutput, error) {
+	if len(input.Samples) == 0 {
+		return nil, fmt.Errorf("no samples for container %s", input.Context.ContainerName)
+	}
+
+	timestamps, cpuValues, memoryValues := splitUsageSamples(input.Samples)
+	output := &RecommenderOutput{Targets: make(map[ResourceType]ResourceTarget)}
+	if input.Wants(ResourceCPU) {
+		cpuP := calculatePercentile(cpuValues, input.Context.CPUPercentile)
+		output.Targets[ResourceCPU] = ResourceTarget{
+			Usage:      cpuP,
+			Target:     int64(float64(cpuP) * input.Context.SafetyMargin),
+			Confidence: p.score(timestamps, cpuValues, input.Context),
+		}
+	}
+	if input.Wants(ResourceMemory) {
+		memoryP := calculatePercentile(memoryValues, input.Context.MemoryPercentile)
+		output.Targets[ResourceMemory] = ResourceTarget{
+			Usage:      memoryP,
+			Target:     int64(float64(memoryP) * input.Context.SafetyMargin),
+			Confidence: p.score(timestamps, memoryValues, input.Context),
+		}
+	}
+	return output, nil
+}
+
+func (p *PercentileRecommender) score(timestamps []time.Time, values []int64, ctx RecommenderContext) float64 {
+	return p.confidenceCalculator.CalculateFromSamples(timestamps, values, ctx.ExpectedSampleInterval).Score
+}
+
+// DecayingHistogramRecommender recommends percentiles of exponentially decaying histograms
+type DecayingHistogramRecommender struct {
+	confidenceCalculator *ConfidenceCalculator
+}
+
+// NewDecayingHistogramRecommender creates the built-in decaying-histogram recommender
+func NewDecayingHistogramRecommender(confidence *ConfidenceCalculator) *DecayingHistogramRecommender {
+	return &DecayingHistogramRecommender{confidenceCalculator: confidence}
+}
+
+// Name returns the recommender name
+func (d *DecayingHistogramRecommender) Name() string {
+	return RecommenderDecayingHistogram
+}
+
+// Recommend computes histogram-based targets for CPU and memory
+func (d *DecayingHistogramRecommender) Recommend(input *RecommenderInput) (*RecommenderOutput, error) {
+	if len(input.Samples) == 0 {
+		return nil, fmt.Errorf("no samples for container %s", input.Context.ContainerName)
+	}
+
+	timestamps, cpuValues, memoryValues := splitUsageSamples(input.Samples)
+	settings := histogramSettings{
+		halfLife:           input.Context.HalfLife,
+		memoryPeakInterval: input.Context.MemoryPeakInterval,
+	}
+
+	output := &RecommenderOutput{Targets: make(map[ResourceType]ResourceTarget)}
+	if input.Wants(ResourceCPU) {
+		cpuP := decayingHistogramCPUPercentile(input.Samples, input.Context.CPUPercentile, settings)
+		output.Targets[ResourceCPU] = ResourceTarget{
+			Usage:  cpuP,
+			Target: int64(float64(cpuP) * input.Context.SafetyMargin),
+			Confidence: d.confidenceCalculator.CalculateFromSamples(
+				timestamps, cpuValues, input.Context.ExpectedSampleInterval).Score,
+		}
+	}
+	if input.Wants(ResourceMemory) {
+		memoryP := decayingHistogramMemoryPercentile(input.Samples, input.Context.MemoryPercentile, settings)
+		output.Targets[ResourceMemory] = ResourceTarget{
+			Usage:  memoryP,
+			Target: int64(float64(memoryP) * input.Context.SafetyMargin),
+			Confidence: d.confidenceCalculator.CalculateFromSamples(
+				timestamps, memoryValues, input.Context.ExpectedSampleInterval).Score,
+		}
+	}
+	return output, nil
+}
+
+// splitUsageSamples extracts timestamps and per-resource values from samples
+func splitUsageSamples(samples []UsageSample) ([]time.Time, []int64, []int64) {
+	timestamps := make([]time.Time, len(samples))
+	cpuValues := make([]int64, len(samples))
+	memoryValues := make([]int64, len(samples))
+	for i, s := range samples {
+		timestamps[i] = s.Timestamp
+		cpuValues[i] = s.CPU
+		memoryValues[i] = s.Memory
+	}
+	return timestamps, cpuValues, memoryValues
+}
@@ -0,0 +1,219 @@
+package recommendation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/models"
+)
+
+// fixedRecommender always returns the same targets and records the resources asked for
+type fixedRecommender struct {
+	name   string
+	cpu    int64
+	memory int64
+	calls  [][]ResourceType
+}
+
+func (f *fixedRecommender) Name() string { return f.name }
+
+func (f *fixedRecommender) Recommend(input *RecommenderInput) (*RecommenderOutput, error) {
+	f.calls = append(f.calls, input.Resources)
+	return &RecommenderOutput{
+		Targets: map[ResourceType]ResourceTarget{
+			ResourceCPU:    {Usage: f.cpu, Target: f.cpu, Confidence: 100},
+			ResourceMemory: {Usage: f.memory, Target: f.memory, Confidence: 100},
+		},
+	}, nil
+}
+
+func steadyMetrics(count int) []models.PodMetric {
+	now := time.Now()
+	metrics := make([]models.PodMetric, 0, count)
+	for i := 0; i < count; i++ {
+		metrics = append(metrics, models.PodMetric{
+			PodName:   "web-5c9d7f8b4-abcde",
+			Namespace: "default",
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+			Containers: []models.ContainerMetric{{
+				ContainerName: "web",
+				UsageCPU:      300,
+				UsageMemory:   300 * 1024 * 1024,
+				RequestCPU:    1000,
+				RequestMemory: 1024 * 1024 * 1024,
+			}},
+		})
+	}
+	return metrics
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register(&fixedRecommender{name: "fixed"}); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if err := r.Register(&fixedRecommender{name: "fixed"}); err == nil {
+		t.Error("Expected error registering duplicate name")
+	}
+	if err := r.Register(&fixedRecommender{name: ""}); err == nil {
+		t.Error("Expected error registering empty name")
+	}
+
+	if _, ok := r.Get("fixed"); !ok {
+		t.Error("Expected to find registered recommender")
+	}
+	if _, ok := r.Get("missing"); ok {
+		t.Error("Expected missing recommender lookup to fail")
+	}
+}
+
+func TestDefaultRegistry_BuiltIns(t *testing.T) {
+	r := NewDefaultRegistry()
+	for _, name := range []string{RecommenderPercentile, RecommenderDecayingHistogram} {
+		if _, ok := r.Get(name); !ok {
+			t.Errorf("Expected built-in recommender %q to be registered", name)
+		}
+	}
+}
+
+func TestPercentileRecommender_Targets(t *testing.T) {
+	rec := NewPercentileRecommender(NewConfidenceCalculator())
+	samples := make([]UsageSample, 100)
+	now := time.Now()
+	for i := range samples {
+		samples[i] = UsageSample{
+			Timestamp: now.Add(-time.Duration(100-i) * 30 * time.Second),
+			CPU:       int64(i + 1),
+			Memory:    int64(i+1) * 1024 * 1024,
+		}
+	}
+
+	output, err := rec.Recommend(&RecommenderInput{
+		Samples: samples,
+		Context: RecommenderContext{
+			CPUPercentile:          90,
+			MemoryPercentile:       50,
+			SafetyMargin:           1.5,
+			ExpectedSampleInterval: 30 * time.Second,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Recommend failed: %v", err)
+	}
+
+	cpu := output.Targets[ResourceCPU]
+	if cpu.Usage != 90 || cpu.Target != 135 {
+		t.Errorf("Expected CPU usage 90 and target 135, got %d and %d", cpu.Usage, cpu.Target)
+	}
+	memory := output.Targets[ResourceMemory]
+	if memory.Usage != 50*1024*1024 {
+		t.Errorf("Expected memory usage 50Mi, got %d", memory.Usage)
+	}
+	if cpu.Confidence <= 0 || memory.Confidence <= 0 {
+		t.Errorf("Expected positive confidence, got cpu=%.1f memory=%.1f", cpu.Confidence, memory.Confidence)
+	}
+}
+
+func TestEngine_PerResourceRecommenderSelection(t *testing.T) {
+	engine := NewEngine()
+	fixed := &fixedRecommender{name: "fixed", cpu: 777, memory: 123 * 1024 * 1024}
+	if err := engine.RegisterRecommender(fixed); err != nil {
+		t.Fatalf("RegisterRecommender failed: %v", err)
+	}
+
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+			Recommendations: &optimizerv1alpha1.RecommendationConfig{
+				HistoryDuration:   "24h",
+				CPURecommender:    "fixed",
+				MemoryRecommender: RecommenderPercentile,
+			},
+		},
+	}
+
+	recs, err := engine.GenerateRecommendations(&staticMetricsProvider{metrics: steadyMetrics(60)}, config)
+	if err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	if len(recs) != 1 || len(recs[0].Containers) != 1 {
+		t.Fatalf("Expected one container recommendation, got %+v", recs)
+	}
+
+	if fmt.Sprint(fixed.calls) != "[[cpu]]" {
+		t.Errorf("Expected the CPU recommender to be asked for CPU only, got %v", fixed.calls)
+	}
+
+	c := recs[0].Containers[0]
+	if c.RecommendedCPU != 777 {
+		t.Errorf("Expected CPU from custom recommender (777m), got %dm", c.RecommendedCPU)
+	}
+	expectedMemory := int64(float64(300*1024*1024) * 1.2)
+	if c.RecommendedMemory != expectedMemory {
+		t.Errorf("Expected memory from percentile recommender (%d), got %d", expectedMemory, c.RecommendedMemory)
+	}
+}
+
+func TestEngine_SharedRecommenderRunsOnce(t *testing.T) {
+	engine := NewEngine()
+	fixed := &fixedRecommender{name: "fixed", cpu: 777, memory: 123 * 1024 * 1024}
+	if err := engine.RegisterRecommender(fixed); err != nil {
+		t.Fatalf("RegisterRecommender failed: %v", err)
+	}
+
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			Recommendations: &optimizerv1alpha1.RecommendationConfig{
+				HistoryDuration:   "24h",
+				CPURecommender:    "fixed",
+				MemoryRecommender: "fixed",
+			},
+		},
+	}
+
+	if _, err := engine.GenerateRecommendations(&staticMetricsProvider{metrics: steadyMetrics(60)}, config); err != nil {
+		t.Fatalf("GenerateRecommendations failed: %v", err)
+	}
+	if fmt.Sprint(fixed.calls) != "[[cpu memory]]" {
+		t.Errorf("Expected one call for both resources, got %v", fixed.calls)
+	}
+}
+
+func TestPercentileRecommender_OnlyRequestedResource(t *testing.T) {
+	rec := NewPercentileRecommender(NewConfidenceCalculator())
+	output, err := rec.Recommend(&RecommenderInput{
+		Samples:   []UsageSample{{Timestamp: time.Now(), CPU: 100, Memory: 1024}},
+		Context:   RecommenderContext{CPUPercentile: 95, MemoryPercentile: 95, SafetyMargin: 1.2},
+		Resources: []ResourceType{ResourceMemory},
+	})
+	if err != nil {
+		t.Fatalf("Recommend failed: %v", err)
+	}
+	if _, ok := output.Targets[ResourceCPU]; ok {
+		t.Error("Expected no CPU target when only memory was asked for")
+	}
+	if _, ok := output.Targets[ResourceMemory]; !ok {
+		t.Error("Expected a memory target")
+	}
+}
+
+func TestEngine_UnknownRecommender(t *testing.T) {
+	engine := NewEngine()
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			Recommendations: &optimizerv1alpha1.RecommendationConfig{
+				CPURecommender: "does-not-exist",
+			},
+		},
+	}
+
+	if _, err := engine.GenerateRecommendations(&staticMetricsProvider{metrics: steadyMetrics(20)}, config); err == nil {
+		t.Error("Expected error for unknown recommender")
+	}
+}
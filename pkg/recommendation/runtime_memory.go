@@ -0,0 +1,266 @@
+package recommendation
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// RuntimeJVM identifies memory settings of the Java virtual machine
+	RuntimeJVM = "jvm"
+
+	// RuntimeGo identifies the Go runtime's soft memory limit
+	RuntimeGo = "go"
+
+	// jvmMinNonHeap is the least memory a JVM needs beyond the heap for metaspace,
+	// code cache, thread stacks and GC structures
+	jvmMinNonHeap = 128 * 1024 * 1024
+
+	// jvmNonHeapRatio sizes the non-heap allowance relative to the heap
+	jvmNonHeapRatio = 0.25
+
+	// goMemLimitHeadroom covers memory the Go runtime does not count against GOMEMLIMIT
+	goMemLimitHeadroom = 1.1
+
+	// maxSafeRAMPercentage is the largest MaxRAMPercentage that leaves room for non-heap memory
+	maxSafeRAMPercentage = 85.0
+
+	// oversizedRuntimeRatio is how far the runtime floor may exceed the usage-based
+	// target before the runtime setting itself is flagged
+	oversizedRuntimeRatio = 1.5
+)
+
+// jvmOptionEnvVars are environment variables the JVM reads options from
+var jvmOptionEnvVars = []string{"JAVA_TOOL_OPTIONS", "JDK_JAVA_OPTIONS", "JAVA_OPTS"}
+
+var goMemLimitPattern = regexp.MustCompile(`^([0-9]+)(B|KiB|MiB|GiB|TiB)?$`)
+
+// RuntimeMemory describes the memory a container's runtime is configured to use
+type RuntimeMemory struct {
+	// Runtime is RuntimeJVM or RuntimeGo
+	Runtime string
+
+	// Setting is the option that was found, e.g. "-Xmx2g" or "GOMEMLIMIT=1GiB"
+	Setting string
+
+	// Bytes is the configured max heap (JVM) or soft limit (Go), 0 when relative
+	Bytes int64
+
+	// MaxRAMPercentage is the JVM heap as a percentage of the container memory limit
+	MaxRAMPercentage float64
+}
+
+// IsRuntimeMemoryEnv returns true if the environment variable can carry a runtime
+// memory setting read by ParseRuntimeMemory
+func IsRuntimeMemoryEnv(name string) bool {
+	for _, n := range jvmOptionEnvVars {
+		if name == n {
+			return true
+		}
+	}
+	return name == "_JAVA_OPTIONS" || name == "GOMEMLIMIT"
+}
+
+// ParseRuntimeMemory inspects a container's command, args and environment for JVM
+// (-Xmx, -XX:MaxHeapSize, -XX:MaxRAMPercentage) and Go (GOMEMLIMIT) memory settings.
+// Later JVM options win, as they do for the JVM itself. It returns nil if none are set.
+// Only literal env values are read; variables set through ValueFrom or EnvFrom must be
+// resolved into Value by the caller, and are otherwise treated as unset.
+func ParseRuntimeMemory(container *corev1.Container) *RuntimeMemory {
+	var options []string
+	env := make(map[string]string)
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+	}
+	for _, name := range jvmOptionEnvVars {
+		options = append(options, strings.Fields(env[name])...)
+	}
+	for _, arg := range append(append([]string{}, container.Command...), container.Args...) {
+		options = append(options, strings.Fields(arg)...)
+	}
+	// _JAVA_OPTIONS overrides the command line
+	options = append(options, strings.Fields(env["_JAVA_OPTIONS"])...)
+
+	var jvm *RuntimeMemory
+	for _, option := range options {
+		switch {
+		case strings.HasPrefix(option, "-Xmx"):
+			if bytes, ok := parseJVMSize(strings.TrimPrefix(option, "-Xmx")); ok {
+				jvm = &RuntimeMemory{Runtime: RuntimeJVM, Setting: option, Bytes: bytes}
+			}
+		case strings.HasPrefix(option, "-XX:MaxHeapSize="):
+			if bytes, ok := parseJVMSize(strings.TrimPrefix(option, "-XX:MaxHeapSize=")); ok {
+				jvm = &RuntimeMemory{Runtime: RuntimeJVM, Setting: option, Bytes: bytes}
+			}
+		case strings.HasPrefix(option, "-XX:MaxRAMPercentage="):
+			if percent, err := strconv.ParseFloat(strings.TrimPrefix(option, "-XX:MaxRAMPercentage="), 64); err == nil && percent > 0 {
+				jvm = &RuntimeMemory{Runtime: RuntimeJVM, Setting: option, MaxRAMPercentage: percent}
+			}
+		}
+	}
+	if jvm != nil {
+		return jvm
+	}
+
+	if value, ok := env["GOMEMLIMIT"]; ok {
+		if bytes, ok := parseGoMemLimit(value); ok {
+			return &RuntimeMemory{Runtime: RuntimeGo, Setting: "GOMEMLIMIT=" + value, Bytes: bytes}
+		}
+	}
+	return nil
+}
+
+// parseJVMSize parses a JVM memory size such as "512m", "2G" or "1073741824"
+func parseJVMSize(value string) (int64, bool) {
+	if value == "" {
+		return 0, false
+	}
+	multiplier := int64(1)
+	switch value[len(value)-1] {
+	case 'k', 'K':
+		multiplier = 1024
+	case 'm', 'M':
+		multiplier = 1024 * 1024
+	case 'g', 'G':
+		multiplier = 1024 * 1024 * 1024
+	case 't', 'T':
+		multiplier = 1024 * 1024 * 1024 * 1024
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n * multiplier, true
+}
+
+// parseGoMemLimit parses a GOMEMLIMIT value such as "750MiB"; "off" is not a limit
+func parseGoMemLimit(value string) (int64, bool) {
+	match := goMemLimitPattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(match[1], 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	multipliers := map[string]int64{
+		"":    1,
+		"B":   1,
+		"KiB": 1024,
+		"MiB": 1024 * 1024,
+		"GiB": 1024 * 1024 * 1024,
+		"TiB": 1024 * 1024 * 1024 * 1024,
+	}
+	return n * multipliers[match[2]], true
+}
+
+// jvmNonHeap returns the non-heap allowance for a JVM with the given heap
+func jvmNonHeap(heap int64) int64 {
+	nonHeap := int64(float64(heap) * jvmNonHeapRatio)
+	if nonHeap < jvmMinNonHeap {
+		return jvmMinNonHeap
+	}
+	return nonHeap
+}
+
+// MinimumMemory returns the least memory in bytes the container can run with under
+// its runtime settings. A MaxRAMPercentage heap follows the memory limit: with
+// limitFollowsRequest the limit moves with the request, otherwise it stays at limit.
+// It returns 0 when the settings do not bound memory (e.g. a relative heap without a limit).
+func (r *RuntimeMemory) MinimumMemory(limit int64, limitFollowsRequest bool) int64 {
+	switch {
+	case r.Runtime == RuntimeGo:
+		return int64(math.Ceil(float64(r.Bytes) * goMemLimitHeadroom))
+	case r.Bytes > 0:
+		return r.Bytes + jvmNonHeap(r.Bytes)
+	case r.MaxRAMPercentage >= 100:
+		return 0
+	case limitFollowsRequest:
+		// The heap shrinks with the limit; keep enough room for non-heap memory
+		return int64(math.Ceil(jvmMinNonHeap / (1 - r.MaxRAMPercentage/100)))
+	case limit > 0:
+		heap := int64(float64(limit) * r.MaxRAMPercentage / 100)
+		if floor := heap + jvmNonHeap(heap); floor < limit {
+			return floor
+		}
+		return limit
+	default:
+		return 0
+	}
+}
+
+// ApplyRuntimeMemoryFloor keeps the memory recommendation at or above the minimum the
+// container's runtime settings need, and records advice when the runtime setting itself
+// should change alongside the request. A fixed memory limit caps the raise. It returns
+// the settings found (nil if none).
+func (e *Engine) ApplyRuntimeMemoryFloor(rec *ContainerRecommendation, container *corev1.Container) *RuntimeMemory {
+	settings := ParseRuntimeMemory(container)
+	if settings == nil {
+		return nil
+	}
+
+	limit := rec.CurrentMemoryLimit
+	limitFollowsRequest := limit > 0 && limit == rec.CurrentMemory
+	floor := settings.MinimumMemory(limit, limitFollowsRequest)
+	usageTarget := rec.RecommendedMemory
+
+	rec.RuntimeMemoryFloor = floor
+	rec.RuntimeAdvice = runtimeAdvice(settings, floor, usageTarget, limit, limitFollowsRequest)
+
+	// A request above a fixed limit would be rejected; the advice asks for the
+	// runtime setting or the limit to change instead
+	raiseTo := floor
+	if limit > 0 && !limitFollowsRequest && raiseTo > limit {
+		raiseTo = limit
+	}
+
+	if raiseTo > rec.RecommendedMemory {
+		klog.V(3).Infof("Container %s: raising memory from %d to %d bytes, the minimum for %s",
+			rec.ContainerName, rec.RecommendedMemory, raiseTo, settings.Setting)
+		rec.RecommendedMemory = raiseTo
+		if raiseTo < floor {
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("memory raised to the %dMi limit for %s",
+				raiseTo/(1024*1024), settings.Setting))
+		} else {
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("memory kept at %dMi or more for %s",
+				floor/(1024*1024), settings.Setting))
+		}
+
+		savings := e.costCalculator.EstimateSavingsWithStorage(
+			rec.CurrentCPU, rec.RecommendedCPU,
+			rec.CurrentMemory, rec.RecommendedMemory,
+			rec.CurrentEphemeralStorage, effectiveEphemeralStorage(rec.CurrentEphemeralStorage, rec.RecommendedEphemeralStorage),
+		)
+		rec.EstimatedSavings = &savings
+	}
+	return settings
+}
+
+// runtimeAdvice explains how the runtime setting should change, or returns "" if it fits
+func runtimeAdvice(settings *RuntimeMemory, floor, usageTarget, limit int64, limitFollowsRequest bool) string {
+	const mi = 1024 * 1024
+
+	if settings.MaxRAMPercentage > maxSafeRAMPercentage {
+		return fmt.Sprintf("%s leaves little room for non-heap memory; use %.0f or lower",
+			settings.Setting, maxSafeRAMPercentage-10)
+	}
+	if limit > 0 && !limitFollowsRequest && floor > limit {
+		return fmt.Sprintf("%s needs %dMi but the memory limit is %dMi; lower the setting or raise the limit",
+			settings.Setting, floor/mi, limit/mi)
+	}
+	if settings.Bytes > 0 && usageTarget > 0 && float64(floor) > float64(usageTarget)*oversizedRuntimeRatio {
+		suggested := settings.Bytes * usageTarget / floor
+		return fmt.Sprintf("%s holds memory at %dMi while usage needs %dMi; lower it to about %dMi alongside the request",
+			settings.Setting, floor/mi, usageTarget/mi, suggested/mi)
+	}
+	return ""
+}
@@ -0,0 +1,138 @@
+package recommendation
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const mib = 1024 * 1024
+
+func TestParseRuntimeMemory(t *testing.T) {
+	tests := []struct {
+		name      string
+		container corev1.Container
+		runtime   string
+		bytes     int64
+		percent   float64
+	}{
+		{"no settings", corev1.Container{Args: []string{"--port=8080"}}, "", 0, 0},
+		{"Xmx in args", corev1.Container{
+			Command: []string{"java"}, Args: []string{"-Xms256m", "-Xmx1g", "-jar", "app.jar"},
+		}, RuntimeJVM, 1024 * mib, 0},
+		{"MaxHeapSize in JAVA_TOOL_OPTIONS", corev1.Container{
+			Env: []corev1.EnvVar{{Name: "JAVA_TOOL_OPTIONS", Value: "-XX:+UseG1GC -XX:MaxHeapSize=768M"}},
+		}, RuntimeJVM, 768 * mib, 0},
+		{"command line overrides JAVA_TOOL_OPTIONS", corev1.Container{
+			Env:     []corev1.EnvVar{{Name: "JAVA_TOOL_OPTIONS", Value: "-Xmx2g"}},
+			Command: []string{"sh", "-c", "exec java -Xmx512m -jar app.jar"},
+		}, RuntimeJVM, 512 * mib, 0},
+		{"_JAVA_OPTIONS overrides the command line", corev1.Container{
+			Env:  []corev1.EnvVar{{Name: "_JAVA_OPTIONS", Value: "-XX:MaxRAMPercentage=75.0"}},
+			Args: []string{"-Xmx512m"},
+		}, RuntimeJVM, 0, 75},
+		{"GOMEMLIMIT", corev1.Container{
+			Env: []corev1.EnvVar{{Name: "GOMEMLIMIT", Value: "750MiB"}},
+		}, RuntimeGo, 750 * mib, 0},
+		{"GOMEMLIMIT off", corev1.Container{
+			Env: []corev1.EnvVar{{Name: "GOMEMLIMIT", Value: "off"}},
+		}, "", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := ParseRuntimeMemory(&tt.container)
+			if tt.runtime == "" {
+				if settings != nil {
+					t.Fatalf("Expected no runtime settings, got %+v", settings)
+				}
+				return
+			}
+			if settings == nil {
+				t.Fatal("Expected runtime settings, got nil")
+			}
+			if settings.Runtime != tt.runtime || settings.Bytes != tt.bytes || settings.MaxRAMPercentage != tt.percent {
+				t.Errorf("Expected %s with %d bytes and %.0f%%, got %+v", tt.runtime, tt.bytes, tt.percent, settings)
+			}
+		})
+	}
+}
+
+func TestRuntimeMemory_MinimumMemory(t *testing.T) {
+	tests := []struct {
+		name                string
+		settings            RuntimeMemory
+		limit               int64
+		limitFollowsRequest bool
+		expected            int64
+	}{
+		{"small heap gets the minimum non-heap", RuntimeMemory{Runtime: RuntimeJVM, Bytes: 256 * mib}, 0, false, 384 * mib},
+		{"large heap gets a quarter on top", RuntimeMemory{Runtime: RuntimeJVM, Bytes: 2048 * mib}, 0, false, 2560 * mib},
+		{"GOMEMLIMIT plus headroom", RuntimeMemory{Runtime: RuntimeGo, Bytes: 1000 * mib}, 0, false, 1100 * mib},
+		{"percentage of a fixed limit", RuntimeMemory{Runtime: RuntimeJVM, MaxRAMPercentage: 50}, 1024 * mib, false, 640 * mib},
+		{"percentage of a limit that follows the request", RuntimeMemory{Runtime: RuntimeJVM, MaxRAMPercentage: 75}, 1024 * mib, true, 512 * mib},
+		{"percentage without a limit", RuntimeMemory{Runtime: RuntimeJVM, MaxRAMPercentage: 75}, 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.settings.MinimumMemory(tt.limit, tt.limitFollowsRequest); got != tt.expected {
+				t.Errorf("MinimumMemory() = %dMi, expected %dMi", got/mib, tt.expected/mib)
+			}
+		})
+	}
+}
+
+func TestEngine_ApplyRuntimeMemoryFloor(t *testing.T) {
+	engine := NewEngine()
+	container := &corev1.Container{Name: "app", Args: []string{"-Xmx1g"}}
+
+	// Usage alone would cut memory below the heap
+	rec := &ContainerRecommendation{
+		ContainerName:      "app",
+		CurrentMemory:      2048 * mib,
+		CurrentMemoryLimit: 2048 * mib,
+		RecommendedMemory:  600 * mib,
+	}
+	if settings := engine.ApplyRuntimeMemoryFloor(rec, container); settings == nil {
+		t.Fatal("Expected the -Xmx setting to be found")
+	}
+	if rec.RecommendedMemory != 1280*mib || rec.RuntimeMemoryFloor != 1280*mib {
+		t.Errorf("Expected memory raised to the 1280Mi floor, got %dMi", rec.RecommendedMemory/mib)
+	}
+	if rec.EstimatedSavings == nil {
+		t.Error("Expected savings to be recomputed for the raised memory")
+	}
+	if !strings.Contains(rec.RuntimeAdvice, "-Xmx1g") {
+		t.Errorf("Expected advice to lower -Xmx for a heap well above usage, got %q", rec.RuntimeAdvice)
+	}
+
+	// A heap that fits usage needs neither a raise nor advice
+	rec = &ContainerRecommendation{ContainerName: "app", CurrentMemory: 2048 * mib, RecommendedMemory: 1500 * mib}
+	engine.ApplyRuntimeMemoryFloor(rec, container)
+	if rec.RecommendedMemory != 1500*mib || rec.RuntimeAdvice != "" {
+		t.Errorf("Expected the usage-based 1500Mi to stand without advice, got %dMi (%q)",
+			rec.RecommendedMemory/mib, rec.RuntimeAdvice)
+	}
+
+	// A heap that cannot fit the fixed limit is flagged
+	rec = &ContainerRecommendation{
+		ContainerName: "app", CurrentMemory: 512 * mib, CurrentMemoryLimit: 1024 * mib, RecommendedMemory: 1100 * mib,
+	}
+	engine.ApplyRuntimeMemoryFloor(rec, container)
+	if !strings.Contains(rec.RuntimeAdvice, "memory limit is 1024Mi") {
+		t.Errorf("Expected advice about the memory limit, got %q", rec.RuntimeAdvice)
+	}
+
+	// The floor never pushes the request above a fixed limit
+	rec = &ContainerRecommendation{
+		ContainerName: "app", CurrentMemory: 512 * mib, CurrentMemoryLimit: 1024 * mib, RecommendedMemory: 600 * mib,
+	}
+	engine.ApplyRuntimeMemoryFloor(rec, container)
+	if rec.RecommendedMemory != 1024*mib {
+		t.Errorf("Expected memory capped at the 1024Mi limit, got %dMi", rec.RecommendedMemory/mib)
+	}
+	if rec.RuntimeMemoryFloor != 1280*mib || !strings.Contains(rec.RuntimeAdvice, "memory limit is 1024Mi") {
+		t.Errorf("Expected the 1280Mi floor and limit advice to be kept, got %dMi (%q)",
+			rec.RuntimeMemoryFloor/mib, rec.RuntimeAdvice)
+	}
+}
@@ -0,0 +1,100 @@
+package recommendation
+
+import (
+	"fmt"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+)
+
+const (
+	// DefaultWarmUpDuration is how long after a container start samples count as warm-up
+	DefaultWarmUpDuration = 5 * time.Minute
+
+	// DefaultStartupCPURatio is how many times the steady-state CPU recommendation
+	// the warm-up CPU must reach for startup to dominate
+	DefaultStartupCPURatio = 2.0
+)
+
+// warmUpSettings holds the parsed warm-up exclusion configuration
+type warmUpSettings struct {
+	enabled         bool
+	duration        time.Duration
+	startupCPURatio float64
+}
+
+// parseWarmUpSettings converts the CRD warm-up config into engine settings
+func parseWarmUpSettings(config *optimizerv1alpha1.WarmUpConfig) warmUpSettings {
+	settings := warmUpSettings{
+		duration:        DefaultWarmUpDuration,
+		startupCPURatio: DefaultStartupCPURatio,
+	}
+	if config == nil || !config.Enabled {
+		return settings
+	}
+
+	settings.enabled = true
+	if d, err := time.ParseDuration(config.Duration); err == nil && d >= 0 {
+		settings.duration = d
+	}
+	if config.StartupCPURatio >= 1.0 {
+		settings.startupCPURatio = config.StartupCPURatio
+	}
+	return settings
+}
+
+// isWarmUp returns true if the sample was taken within the warm-up window after its
+// container (or, without container data, its pod) started, or before it became ready.
+// Samples without lifecycle data are never treated as warm-up.
+func (s warmUpSettings) isWarmUp(sample containerSample) bool {
+	started := sample.startedAt
+	if started.IsZero() {
+		started = sample.podStartTime
+	}
+	if !started.IsZero() && sample.timestamp.Before(started.Add(s.duration)) {
+		return true
+	}
+	// A ready time from before the current start belongs to an earlier instance
+	return !sample.readyAt.IsZero() && !sample.readyAt.Before(started) && sample.timestamp.Before(sample.readyAt)
+}
+
+// splitWarmUp separates warm-up samples from steady-state samples, keeping order
+func splitWarmUp(samples []containerSample, settings warmUpSettings) (steady, warmUp []containerSample) {
+	for _, s := range samples {
+		if settings.isWarmUp(s) {
+			warmUp = append(warmUp, s)
+		} else {
+			steady = append(steady, s)
+		}
+	}
+	return steady, warmUp
+}
+
+// recommendStartupCPU sizes CPU for the warm-up phase from the peak warm-up usage plus
+// the safety margin. It returns 0 unless startup dominates, i.e. the startup CPU is at
+// least startupCPURatio times the steady-state recommendation.
+func (e *Engine) recommendStartupCPU(
+	warmUp []containerSample,
+	recommendedCPU int64,
+	safetyMargin float64,
+	thresholds *optimizerv1alpha1.ResourceThresholds,
+	settings warmUpSettings,
+) (int64, string) {
+	var peak int64
+	for _, s := range warmUp {
+		if s.usageCPU > peak {
+			peak = s.usageCPU
+		}
+	}
+	if peak == 0 || recommendedCPU == 0 {
+		return 0, ""
+	}
+
+	startupCPU := e.applyThresholds(int64(float64(peak)*safetyMargin), thresholds, "cpu")
+	ratio := float64(startupCPU) / float64(recommendedCPU)
+	if ratio < settings.startupCPURatio {
+		return 0, ""
+	}
+	return startupCPU, fmt.Sprintf("startup dominates: warm-up CPU needs %dm, %.1fx the steady-state %dm",
+		startupCPU, ratio, recommendedCPU)
+}
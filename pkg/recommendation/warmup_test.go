@@ -0,0 +1,132 @@
+package recommendation
+
+import (
+	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/models"
+)
+
+// warmingMetrics produces an hour of per-minute samples for a container that started
+// with the first sample, burning 2000m for the first 10 minutes and 200m afterwards
+func warmingMetrics() []models.PodMetric {
+	now := time.Now()
+	started := now.Add(-60 * time.Minute)
+	var metrics []models.PodMetric
+	for i := 0; i < 60; i++ {
+		ts := started.Add(time.Duration(i) * time.Minute)
+		cpu := int64(200)
+		if i < 10 {
+			cpu = 2000
+		}
+		metrics = append(metrics, models.PodMetric{
+			PodName:   "jvm-5c9d7f8b4-abcde",
+			Namespace: "default",
+			Timestamp: ts,
+			StartTime: started,
+			Containers: []models.ContainerMetric{{
+				ContainerName: "app",
+				UsageCPU:      cpu,
+				UsageMemory:   512 * 1024 * 1024,
+				RequestCPU:    1000,
+				RequestMemory: 1024 * 1024 * 1024,
+				StartedAt:     started,
+				ReadyAt:       started.Add(8 * time.Minute),
+			}},
+		})
+	}
+	return metrics
+}
+
+func warmUpConfig(warmUp *optimizerv1alpha1.WarmUpConfig) *optimizerv1alpha1.OptimizerConfig {
+	return &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			Strategy:         optimizerv1alpha1.StrategyBalanced,
+			Recommendations: &optimizerv1alpha1.RecommendationConfig{
+				HistoryDuration: "24h",
+				WarmUp:          warmUp,
+			},
+		},
+	}
+}
+
+func TestEngine_WarmUpExclusion(t *testing.T) {
+	engine := NewEngine()
+	provider := &staticMetricsProvider{metrics: warmingMetrics()}
+
+	// Without exclusion the warm-up spike drives the P95
+	recs, err := engine.GenerateRecommendations(provider, warmUpConfig(nil))
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("Expected one recommendation, got %d (err=%v)", len(recs), err)
+	}
+	if c := recs[0].Containers[0]; c.RecommendedCPU < 2000 || c.WarmUpSamplesExcluded != 0 {
+		t.Fatalf("Expected the warm-up spike to inflate CPU without exclusion, got %dm", c.RecommendedCPU)
+	}
+
+	recs, err = engine.GenerateRecommendations(provider, warmUpConfig(&optimizerv1alpha1.WarmUpConfig{
+		Enabled:  true,
+		Duration: "10m",
+	}))
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("Expected one recommendation, got %d (err=%v)", len(recs), err)
+	}
+	c := recs[0].Containers[0]
+	if c.WarmUpSamplesExcluded != 10 {
+		t.Errorf("Expected 10 warm-up samples excluded, got %d", c.WarmUpSamplesExcluded)
+	}
+	if c.RecommendedCPU != 240 {
+		t.Errorf("Expected steady-state CPU 240m (200m * 1.2), got %dm", c.RecommendedCPU)
+	}
+	if c.StartupCPU != 2400 {
+		t.Errorf("Expected startup CPU 2400m (2000m * 1.2), got %dm", c.StartupCPU)
+	}
+}
+
+func TestEngine_WarmUpKeepsSamplesWhenTooFewRemain(t *testing.T) {
+	engine := NewEngine()
+	provider := &staticMetricsProvider{metrics: warmingMetrics()}
+
+	// An hour-long warm-up window would leave no steady-state samples
+	recs, err := engine.GenerateRecommendations(provider, warmUpConfig(&optimizerv1alpha1.WarmUpConfig{
+		Enabled:  true,
+		Duration: "2h",
+	}))
+	if err != nil || len(recs) != 1 {
+		t.Fatalf("Expected one recommendation, got %d (err=%v)", len(recs), err)
+	}
+	if c := recs[0].Containers[0]; c.WarmUpSamplesExcluded != 0 || c.StartupCPU != 0 {
+		t.Errorf("Expected all samples to be kept, got %d excluded and startup CPU %dm",
+			c.WarmUpSamplesExcluded, c.StartupCPU)
+	}
+}
+
+func TestWarmUpSettings_IsWarmUp(t *testing.T) {
+	settings := parseWarmUpSettings(&optimizerv1alpha1.WarmUpConfig{Enabled: true, Duration: "5m"})
+	started := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		sample   containerSample
+		expected bool
+	}{
+		{"no lifecycle data", containerSample{timestamp: started}, false},
+		{"inside the window", containerSample{timestamp: started.Add(time.Minute), startedAt: started}, true},
+		{"after the window", containerSample{timestamp: started.Add(6 * time.Minute), startedAt: started}, false},
+		{"pod start as fallback", containerSample{timestamp: started.Add(time.Minute), podStartTime: started}, true},
+		{"not ready yet", containerSample{
+			timestamp: started.Add(8 * time.Minute), startedAt: started, readyAt: started.Add(10 * time.Minute),
+		}, true},
+		{"ready time of an earlier instance", containerSample{
+			timestamp: started.Add(8 * time.Minute), startedAt: started, readyAt: started.Add(-time.Hour),
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := settings.isWarmUp(tt.sample); got != tt.expected {
+				t.Errorf("isWarmUp() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package rollback
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// HistoryLabel marks ConfigMaps that hold rollback history
+	HistoryLabel = "optimizer.intelligent-cluster-optimizer.io/rollback-history"
+
+	// historyKindAnnotation and historyNameAnnotation identify the workload a history
+	// ConfigMap belongs to; names can exceed the 63 characters a label value allows
+	historyKindAnnotation = "optimizer.intelligent-cluster-optimizer.io/workload-kind"
+	historyNameAnnotation = "optimizer.intelligent-cluster-optimizer.io/workload-name"
+
+	historyConfigMapPrefix = "rollback-history-"
+	originalKeyPrefix      = "original."
+	maxConfigMapNameLength = 253
+)
+
+// HistoryStore persists rollback snapshots so they survive controller restarts and
+// can be read by every client
+type HistoryStore interface {
+	// Load returns the history of a workload (empty if none was recorded)
+	Load(ctx context.Context, namespace, kind, name string) (*WorkloadHistory, error)
+
+	// Update applies mutate to the latest history of a workload and stores the result;
+	// an empty history removes it. mutate may run again when a concurrent writer wins.
+	Update(ctx context.Context, namespace, kind, name string, mutate func(history *WorkloadHistory)) (*WorkloadHistory, error)
+
+	// List returns all snapshots in a namespace ("" for all namespaces) keyed by WorkloadConfig.Key
+	List(ctx context.Context, namespace string) (map[string][]WorkloadConfig, error)
+}
+
+// ConfigMapStore keeps the history of each workload in a ConfigMap next to it, with
+// one data key per container holding a JSON list of snapshots and an "original.<container>"
+// key holding the container's config before the optimizer first changed it. The ConfigMap
+// is owned by the workload, so it is garbage-collected with it.
+type ConfigMapStore struct {
+	kubeClient kubernetes.Interface
+}
+
+// NewConfigMapStore creates a history store backed by ConfigMaps
+func NewConfigMapStore(kubeClient kubernetes.Interface) *ConfigMapStore {
+	return &ConfigMapStore{kubeClient: kubeClient}
+}
+
+// historyConfigMapName returns the ConfigMap name for a workload's history, hashing
+// names that would not fit
+func historyConfigMapName(kind, name string) string {
+	cmName := historyConfigMapPrefix + strings.ToLower(kind) + "-" + name
+	if len(cmName) <= maxConfigMapNameLength {
+		return cmName
+	}
+	sum := sha256.Sum256([]byte(cmName))
+	suffix := hex.EncodeToString(sum[:])[:10]
+	return cmName[:maxConfigMapNameLength-len(suffix)-1] + "-" + suffix
+}
+
+func (s *ConfigMapStore) Load(ctx context.Context, namespace, kind, name string) (*WorkloadHistory, error) {
+	cm, err := s.kubeClient.CoreV1().ConfigMaps(namespace).Get(ctx, historyConfigMapName(kind, name), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return NewWorkloadHistory(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history ConfigMap: %v", err)
+	}
+	return decodeHistory(cm)
+}
+
+func (s *ConfigMapStore) Update(ctx context.Context, namespace, kind, name string, mutate func(history *WorkloadHistory)) (*WorkloadHistory, error) {
+	cmName := historyConfigMapName(kind, name)
+	client := s.kubeClient.CoreV1().ConfigMaps(namespace)
+
+	var history *WorkloadHistory
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		cm, err := client.Get(ctx, cmName, metav1.GetOptions{})
+		exists := err == nil
+		if err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+
+		if exists {
+			if history, err = decodeHistory(cm); err != nil {
+				return err
+			}
+		} else {
+			history = NewWorkloadHistory()
+		}
+		mutate(history)
+		data, err := encodeHistory(history)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !exists && len(data) == 0:
+			return nil
+		case !exists:
+			cm = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      cmName,
+					Namespace: namespace,
+					Labels: map[string]string{
+						HistoryLabel:                   "true",
+						"app.kubernetes.io/managed-by": "intelligent-cluster-optimizer",
+					},
+					Annotations: map[string]string{
+						historyKindAnnotation: kind,
+						historyNameAnnotation: name,
+					},
+				},
+				Data: data,
+			}
+			s.setOwner(ctx, cm, kind, name)
+			_, err = client.Create(ctx, cm, metav1.CreateOptions{})
+			return err
+		case len(data) == 0:
+			return client.Delete(ctx, cmName, metav1.DeleteOptions{Preconditions: &metav1.Preconditions{ResourceVersion: &cm.ResourceVersion}})
+		default:
+			cm.Data = data
+			if len(cm.OwnerReferences) == 0 {
+				s.setOwner(ctx, cm, kind, name)
+			}
+			_, err = client.Update(ctx, cm, metav1.UpdateOptions{})
+			return err
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// setOwner makes the workload own its history ConfigMap. Without the workload the
+// ConfigMap is left unowned.
+func (s *ConfigMapStore) setOwner(ctx context.Context, cm *corev1.ConfigMap, kind, name string) {
+	var meta metav1.Object
+	var err error
+	switch kind {
+	case "Deployment":
+		meta, err = s.kubeClient.AppsV1().Deployments(cm.Namespace).Get(ctx, name, metav1.GetOptions{})
+	case "StatefulSet":
+		meta, err = s.kubeClient.AppsV1().StatefulSets(cm.Namespace).Get(ctx, name, metav1.GetOptions{})
+	case "DaemonSet":
+		meta, err = s.kubeClient.AppsV1().DaemonSets(cm.Namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		return
+	}
+	if err != nil {
+		klog.V(4).Infof("History ConfigMap %s/%s left without owner: %v", cm.Namespace, cm.Name, err)
+		return
+	}
+	cm.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: "apps/v1",
+		Kind:       kind,
+		Name:       name,
+		UID:        meta.GetUID(),
+	}}
+}
+
+func (s *ConfigMapStore) List(ctx context.Context, namespace string) (map[string][]WorkloadConfig, error) {
+	cms, err := s.kubeClient.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: HistoryLabel + "=true",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history ConfigMaps: %v", err)
+	}
+
+	snapshots := make(map[string][]WorkloadConfig)
+	for i := range cms.Items {
+		history, err := decodeHistory(&cms.Items[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, configs := range history.Snapshots {
+			if len(configs) > 0 {
+				snapshots[configs[0].Key()] = configs
+			}
+		}
+	}
+	return snapshots, nil
+}
+
+// decodeHistory reads a workload's history from its ConfigMap
+func decodeHistory(cm *corev1.ConfigMap) (*WorkloadHistory, error) {
+	history := NewWorkloadHistory()
+	for key, data := range cm.Data {
+		if containerName, ok := strings.CutPrefix(key, originalKeyPrefix); ok {
+			var original WorkloadConfig
+			if err := json.Unmarshal([]byte(data), &original); err != nil {
+				return nil, fmt.Errorf("invalid original config for container %s in ConfigMap %s/%s: %v",
+					containerName, cm.Namespace, cm.Name, err)
+			}
+			history.Originals[containerName] = original
+			continue
+		}
+
+		var configs []WorkloadConfig
+		if err := json.Unmarshal([]byte(data), &configs); err != nil {
+			return nil, fmt.Errorf("invalid history for container %s in ConfigMap %s/%s: %v",
+				key, cm.Namespace, cm.Name, err)
+		}
+		if len(configs) > 0 {
+			history.Snapshots[key] = configs
+		}
+	}
+	return history, nil
+}
+
+// encodeHistory renders a workload's history as ConfigMap data
+func encodeHistory(history *WorkloadHistory) (map[string]string, error) {
+	data := make(map[string]string)
+	for containerName, configs := range history.Snapshots {
+		if len(configs) == 0 {
+			continue
+		}
+		encoded, err := json.Marshal(configs)
+		if err != nil {
+			return nil, err
+		}
+		data[containerName] = string(encoded)
+	}
+	for containerName, original := range history.Originals {
+		encoded, err := json.Marshal(original)
+		if err != nil {
+			return nil, err
+		}
+		data[originalKeyPrefix+containerName] = string(encoded)
+	}
+	return data, nil
+}
@@ -0,0 +1,165 @@
+package rollback
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func testDeployment(cpu string) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(cpu),
+						corev1.ResourceMemory: resource.MustParse("256Mi"),
+					},
+				},
+			}}}},
+		},
+	}
+}
+
+func setCPU(t *testing.T, client *fake.Clientset, cpu string) {
+	t.Helper()
+	if _, err := client.AppsV1().Deployments("default").Update(context.Background(), testDeployment(cpu), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+}
+
+func TestRollbackManager_HistorySharedThroughConfigMaps(t *testing.T) {
+	client := fake.NewSimpleClientset(testDeployment("100m"))
+	ctx := context.Background()
+
+	controller := NewRollbackManager(client)
+	controller.SetRetention(2)
+	for _, cpu := range []string{"200m", "300m", "400m"} {
+		if err := controller.SavePreviousConfig(ctx, "default", "Deployment", "api", "app"); err != nil {
+			t.Fatalf("SavePreviousConfig failed: %v", err)
+		}
+		setCPU(t, client, cpu)
+	}
+
+	cm, err := client.CoreV1().ConfigMaps("default").Get(ctx, "rollback-history-deployment-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected a history ConfigMap: %v", err)
+	}
+	if cm.Labels[HistoryLabel] != "true" {
+		t.Errorf("Expected the history label, got %v", cm.Labels)
+	}
+	if len(cm.OwnerReferences) != 1 || cm.OwnerReferences[0].Kind != "Deployment" || cm.OwnerReferences[0].Name != "api" {
+		t.Errorf("Expected the Deployment to own its history, got %+v", cm.OwnerReferences)
+	}
+
+	// A separate manager, e.g. optctl or a restarted controller, sees the same history
+	cli := NewRollbackManager(client)
+	if err := cli.LoadFromCluster(ctx, ""); err != nil {
+		t.Fatalf("LoadFromCluster failed: %v", err)
+	}
+	configs := cli.GetWorkloadHistory("default", "Deployment", "api", "app")
+	if len(configs) != 2 || configs[0].CPU != "200m" || configs[1].CPU != "300m" {
+		t.Fatalf("Expected the two newest snapshots (200m, 300m), got %+v", configs)
+	}
+
+	if err := cli.RollbackLastChange(ctx, "default", "Deployment", "api", "app"); err != nil {
+		t.Fatalf("RollbackLastChange failed: %v", err)
+	}
+	deploy, _ := client.AppsV1().Deployments("default").Get(ctx, "api", metav1.GetOptions{})
+	if cpu := deploy.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]; cpu.String() != "300m" {
+		t.Errorf("Expected CPU rolled back to 300m, got %s", cpu.String())
+	}
+
+	// The controller's next rollback continues from the shortened history
+	if err := controller.RollbackLastChange(ctx, "default", "Deployment", "api", "app"); err != nil {
+		t.Fatalf("RollbackLastChange failed: %v", err)
+	}
+	cm, err = client.CoreV1().ConfigMaps("default").Get(ctx, cm.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the history ConfigMap to keep the original config: %v", err)
+	}
+	if _, ok := cm.Data["app"]; ok || cm.Data["original.app"] == "" {
+		t.Errorf("Expected only the original config to remain, got keys %v", cm.Data)
+	}
+	if err := controller.RollbackLastChange(ctx, "default", "Deployment", "api", "app"); err == nil {
+		t.Error("Expected an error without saved history")
+	}
+}
+
+func TestConfigMapStore_UpdateMergesConcurrentWriter(t *testing.T) {
+	client := fake.NewSimpleClientset(testDeployment("100m"))
+	ctx := context.Background()
+	store := NewConfigMapStore(client)
+
+	if _, err := store.Update(ctx, "default", "Deployment", "api", func(history *WorkloadHistory) {
+		history.Snapshots["app"] = []WorkloadConfig{{ContainerName: "app", CPU: "100m", Revision: 1}}
+	}); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// Another replica records a sidecar snapshot between our read and our write
+	raced := false
+	client.PrependReactor("update", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if raced {
+			return false, nil, nil
+		}
+		raced = true
+		gvr := corev1.SchemeGroupVersion.WithResource("configmaps")
+		obj, err := client.Tracker().Get(gvr, "default", "rollback-history-deployment-api")
+		if err != nil {
+			t.Fatalf("Failed to read history ConfigMap: %v", err)
+		}
+		cm := obj.(*corev1.ConfigMap).DeepCopy()
+		cm.Data["sidecar"] = `[{"ContainerName":"sidecar","CPU":"50m","Revision":2}]`
+		if err := client.Tracker().Update(gvr, cm, "default"); err != nil {
+			t.Fatalf("Failed to write history ConfigMap: %v", err)
+		}
+		return true, nil, apierrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "rollback-history-deployment-api", nil)
+	})
+
+	history, err := store.Update(ctx, "default", "Deployment", "api", func(history *WorkloadHistory) {
+		revision := history.LatestRevision() + 1
+		history.Snapshots["app"] = append(history.Snapshots["app"], WorkloadConfig{ContainerName: "app", CPU: "200m", Revision: revision})
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(history.Snapshots["sidecar"]) != 1 || len(history.Snapshots["app"]) != 2 || history.Snapshots["app"][1].Revision != 3 {
+		t.Errorf("Expected the retry to keep the sidecar snapshot and number ours revision 3, got %+v", history.Snapshots)
+	}
+
+	stored, err := store.Load(ctx, "default", "Deployment", "api")
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(stored.Snapshots["sidecar"]) != 1 || len(stored.Snapshots["app"]) != 2 {
+		t.Errorf("Expected both writers' snapshots to be stored, got %+v", stored.Snapshots)
+	}
+}
+
+func TestHistoryConfigMapName(t *testing.T) {
+	if got := historyConfigMapName("StatefulSet", "redis"); got != "rollback-history-statefulset-redis" {
+		t.Errorf("Unexpected name %s", got)
+	}
+
+	long := strings.Repeat("a", 250)
+	got := historyConfigMapName("Deployment", long)
+	if len(got) > maxConfigMapNameLength {
+		t.Errorf("Expected at most %d characters, got %d", maxConfigMapNameLength, len(got))
+	}
+	if got == historyConfigMapName("Deployment", long+"b") {
+		t.Errorf("Expected distinct names for distinct workloads, both %s", got)
+	}
+}
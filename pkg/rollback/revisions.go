@@ -0,0 +1,409 @@
+package rollback
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/provenance"
+
+	"k8s.io/klog/v2"
+)
+
+// Revision is the state of all containers of a workload before one recorded change
+type Revision struct {
+	// Number identifies the change that ended this state
+	Number int64
+
+	// ChangedAt is when the workload left this state
+	ChangedAt time.Time
+
+	// ChangedContainer is the container the change applied to
+	ChangedContainer string
+
+	// Containers holds the config of each container in this state, sorted by name
+	Containers []WorkloadConfig
+}
+
+// RollbackTarget selects the state a workload is rolled back to. The zero value
+// undoes the most recent change.
+type RollbackTarget struct {
+	// Revision restores the state before the change with this number
+	Revision int64
+
+	// Time restores the state that was in effect at this time
+	Time time.Time
+
+	// Original restores the configs from before the optimizer first changed the workload
+	Original bool
+
+	// Containers limits the rollback to these containers (all when empty)
+	Containers []string
+}
+
+// ContainerChange is the change a rollback makes to one container
+type ContainerChange struct {
+	ContainerName string
+	From          WorkloadConfig
+	To            WorkloadConfig
+}
+
+// Diff describes each resource the change modifies, e.g. "cpu request: 200m -> 100m"
+func (c *ContainerChange) Diff() []string {
+	var lines []string
+	for _, field := range []struct {
+		name     string
+		from, to string
+	}{
+		{"cpu request", c.From.CPU, c.To.CPU},
+		{"memory request", c.From.Memory, c.To.Memory},
+		{"cpu limit", c.From.CPULimit, c.To.CPULimit},
+		{"memory limit", c.From.MemoryLimit, c.To.MemoryLimit},
+		{"ephemeral-storage request", c.From.EphemeralStorage, c.To.EphemeralStorage},
+	} {
+		if field.from != field.to {
+			lines = append(lines, fmt.Sprintf("%s: %s -> %s", field.name, orNone(field.from), orNone(field.to)))
+		}
+	}
+	return lines
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "<none>"
+	}
+	return value
+}
+
+// RollbackPlan is a previewed rollback of a workload
+type RollbackPlan struct {
+	Namespace string
+	Kind      string
+	Name      string
+
+	// Target describes the state being restored, e.g. "revision 3"
+	Target string
+
+	// Revision is the restored revision (0 when restoring the original configs)
+	Revision int64
+
+	// Original is set when the original configs are restored
+	Original bool
+
+	// Containers limits the rollback to these containers (all when empty)
+	Containers []string
+
+	// Changes lists the containers whose resources differ from the target
+	Changes []ContainerChange
+}
+
+// ListRevisions returns the restorable revisions of a workload, oldest first
+func (r *RollbackManager) ListRevisions(ctx context.Context, namespace, kind, name string) ([]Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history, err := r.loadWorkload(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := r.currentConfigs(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	return buildRevisions(history, current), nil
+}
+
+// currentConfigs snapshots every container of a workload as it is now
+func (r *RollbackManager) currentConfigs(ctx context.Context, namespace, kind, name string) (map[string]WorkloadConfig, error) {
+	containers, err := r.fetchContainers(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	configs := make(map[string]WorkloadConfig, len(containers))
+	for i := range containers {
+		configs[containers[i].Name] = configFromContainer(namespace, kind, name, &containers[i])
+	}
+	return configs, nil
+}
+
+// buildRevisions reconstructs the workload state before each recorded change. A
+// container's config in revision N is its first snapshot at or after N, or its current
+// config if it has not changed since.
+func buildRevisions(history *WorkloadHistory, current map[string]WorkloadConfig) []Revision {
+	changes := make(map[int64]WorkloadConfig)
+	for _, configs := range history.Snapshots {
+		for _, c := range configs {
+			changes[c.Revision] = c
+		}
+	}
+	numbers := make([]int64, 0, len(changes))
+	for n := range changes {
+		numbers = append(numbers, n)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] < numbers[j] })
+
+	names := make([]string, 0, len(current))
+	for containerName := range current {
+		names = append(names, containerName)
+	}
+	sort.Strings(names)
+
+	revisions := make([]Revision, 0, len(numbers))
+	for _, n := range numbers {
+		revision := Revision{
+			Number:           n,
+			ChangedAt:        changes[n].Timestamp,
+			ChangedContainer: changes[n].ContainerName,
+		}
+		for _, containerName := range names {
+			revision.Containers = append(revision.Containers, configAt(history.Snapshots[containerName], current[containerName], n))
+		}
+		revisions = append(revisions, revision)
+	}
+	return revisions
+}
+
+// configAt returns a container's config in revision n
+func configAt(snapshots []WorkloadConfig, current WorkloadConfig, n int64) WorkloadConfig {
+	for _, s := range snapshots {
+		if s.Revision >= n {
+			return s
+		}
+	}
+	return current
+}
+
+// PlanRollback previews rolling a workload back to the target without changing it
+func (r *RollbackManager) PlanRollback(ctx context.Context, namespace, kind, name string, target RollbackTarget) (*RollbackPlan, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	history, err := r.loadWorkload(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	current, err := r.currentConfigs(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	if target.Original && len(history.Originals) == 0 {
+		// Without saved history, fall back to the originals stamped on the workload
+		originals, err := r.stampedOriginals(ctx, namespace, kind, name)
+		if err != nil {
+			klog.Warningf("Could not read provenance of %s/%s/%s: %v", namespace, kind, name, err)
+		} else if len(originals) > 0 {
+			history = &WorkloadHistory{Snapshots: history.Snapshots, Originals: originals}
+		}
+	}
+	return planRollback(namespace, kind, name, history, current, target)
+}
+
+// stampedOriginals reads the original resources recorded in a workload's provenance annotations
+func (r *RollbackManager) stampedOriginals(ctx context.Context, namespace, kind, name string) (map[string]WorkloadConfig, error) {
+	record, err := provenance.Get(ctx, r.kubeClient, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	originals := make(map[string]WorkloadConfig, len(record.Original))
+	for containerName, resources := range record.Original {
+		originals[containerName] = WorkloadConfig{
+			Namespace:        namespace,
+			Kind:             kind,
+			Name:             name,
+			ContainerName:    containerName,
+			CPU:              resources.CPU,
+			Memory:           resources.Memory,
+			CPULimit:         resources.CPULimit,
+			MemoryLimit:      resources.MemoryLimit,
+			EphemeralStorage: resources.EphemeralStorage,
+		}
+	}
+	return originals, nil
+}
+
+func planRollback(
+	namespace, kind, name string,
+	history *WorkloadHistory,
+	current map[string]WorkloadConfig,
+	target RollbackTarget,
+) (*RollbackPlan, error) {
+	workload := namespace + "/" + kind + "/" + name
+	plan := &RollbackPlan{Namespace: namespace, Kind: kind, Name: name, Containers: target.Containers}
+	targets := make(map[string]WorkloadConfig)
+
+	if target.Original {
+		if len(history.Originals) == 0 {
+			return nil, fmt.Errorf("no original config recorded for %s", workload)
+		}
+		plan.Original = true
+		plan.Target = "original"
+		for containerName, original := range history.Originals {
+			targets[containerName] = original
+		}
+	} else {
+		revisions := buildRevisions(history, current)
+		if len(revisions) == 0 {
+			return nil, fmt.Errorf("no revisions recorded for %s", workload)
+		}
+
+		var revision *Revision
+		switch {
+		case target.Revision > 0:
+			for i := range revisions {
+				if revisions[i].Number == target.Revision {
+					revision = &revisions[i]
+				}
+			}
+			if revision == nil {
+				return nil, fmt.Errorf("revision %d of %s not found (available: %d-%d)", target.Revision, workload,
+					revisions[0].Number, revisions[len(revisions)-1].Number)
+			}
+		case !target.Time.IsZero():
+			// The state in effect at the time is the one left by the first change after it
+			for i := range revisions {
+				if revisions[i].ChangedAt.After(target.Time) {
+					revision = &revisions[i]
+					break
+				}
+			}
+			if revision == nil {
+				return nil, fmt.Errorf("%s has not changed since %s", workload, target.Time.Format(time.RFC3339))
+			}
+			if revision.Number == revisions[0].Number && revisions[0].Number > 1 {
+				klog.Warningf("%s: revisions before %d were not retained; the state at %s may be older than the one restored",
+					workload, revision.Number, target.Time.Format(time.RFC3339))
+			}
+		default:
+			revision = &revisions[len(revisions)-1]
+		}
+
+		plan.Revision = revision.Number
+		plan.Target = fmt.Sprintf("revision %d", revision.Number)
+		for _, c := range revision.Containers {
+			targets[c.ContainerName] = c
+		}
+	}
+
+	selected := make(map[string]bool)
+	for _, containerName := range target.Containers {
+		if _, ok := current[containerName]; !ok {
+			return nil, fmt.Errorf("container %s not found in %s", containerName, workload)
+		}
+		selected[containerName] = true
+	}
+
+	names := make([]string, 0, len(targets))
+	for containerName := range targets {
+		names = append(names, containerName)
+	}
+	sort.Strings(names)
+	for _, containerName := range names {
+		if len(selected) > 0 && !selected[containerName] {
+			continue
+		}
+		from, ok := current[containerName]
+		if !ok {
+			klog.Warningf("%s: container %s no longer exists; skipping it", workload, containerName)
+			continue
+		}
+		to := targets[containerName]
+		if from.SameResources(&to) {
+			continue
+		}
+		plan.Changes = append(plan.Changes, ContainerChange{ContainerName: containerName, From: from, To: to})
+	}
+	return plan, nil
+}
+
+// RollbackChanges restores the given containers of a workload to the revision before
+// the earliest of their most recent changes, in a single workload update. It returns
+// the executed plan.
+func (r *RollbackManager) RollbackChanges(ctx context.Context, namespace, kind, name string, containers []string) (*RollbackPlan, error) {
+	r.mu.Lock()
+	history, err := r.loadWorkload(ctx, namespace, kind, name)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var revision int64
+	for _, containerName := range containers {
+		snapshots := history.Snapshots[containerName]
+		if len(snapshots) == 0 {
+			return nil, fmt.Errorf("no saved config available for %s/%s/%s/%s", namespace, kind, name, containerName)
+		}
+		if latest := snapshots[len(snapshots)-1].Revision; revision == 0 || latest < revision {
+			revision = latest
+		}
+	}
+	if revision == 0 {
+		return nil, fmt.Errorf("no containers to roll back in %s/%s/%s", namespace, kind, name)
+	}
+
+	plan, err := r.PlanRollback(ctx, namespace, kind, name, RollbackTarget{Revision: revision, Containers: containers})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.ExecuteRollback(ctx, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// ExecuteRollback applies a plan to all its containers in a single workload update and
+// trims the history to the restored state: snapshots from the restored revision on are
+// dropped, and restoring the originals clears the history of the containers it covers.
+func (r *RollbackManager) ExecuteRollback(ctx context.Context, plan *RollbackPlan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	workload := plan.Namespace + "/" + plan.Kind + "/" + plan.Name
+	if len(plan.Changes) == 0 {
+		klog.Infof("%s already matches %s", workload, plan.Target)
+		return nil
+	}
+
+	configs := make([]WorkloadConfig, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		configs = append(configs, change.To)
+	}
+	klog.Infof("Rolling back %s to %s (%d containers)", workload, plan.Target, len(configs))
+	if err := r.applyConfigs(ctx, plan.Namespace, plan.Kind, plan.Name, configs); err != nil {
+		return fmt.Errorf("failed to apply rollback: %v", err)
+	}
+
+	if _, err := r.updateWorkload(ctx, plan.Namespace, plan.Kind, plan.Name, func(history *WorkloadHistory) {
+		trimmed := plan.Containers
+		if len(trimmed) == 0 {
+			for containerName := range history.Snapshots {
+				trimmed = append(trimmed, containerName)
+			}
+			for containerName := range history.Originals {
+				trimmed = append(trimmed, containerName)
+			}
+		}
+		for _, containerName := range trimmed {
+			if plan.Original {
+				delete(history.Snapshots, containerName)
+				delete(history.Originals, containerName)
+				continue
+			}
+			var kept []WorkloadConfig
+			for _, s := range history.Snapshots[containerName] {
+				if s.Revision < plan.Revision {
+					kept = append(kept, s)
+				}
+			}
+			if len(kept) == 0 {
+				delete(history.Snapshots, containerName)
+			} else {
+				history.Snapshots[containerName] = kept
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	klog.Infof("Successfully rolled back %s to %s", workload, plan.Target)
+	return nil
+}
@@ -0,0 +1,224 @@
+package rollback
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/provenance"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func twoContainerDeployment(appCPU, sidecarCPU string) *appsv1.Deployment {
+	container := func(name, cpu string) corev1.Container {
+		return corev1.Container{
+			Name: name,
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		}
+	}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{
+				container("app", appCPU), container("sidecar", sidecarCPU),
+			}}},
+		},
+	}
+}
+
+func containerCPU(t *testing.T, client *fake.Clientset, containerName string) string {
+	t.Helper()
+	deploy, err := client.AppsV1().Deployments("default").Get(context.Background(), "api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get deployment: %v", err)
+	}
+	for _, c := range deploy.Spec.Template.Spec.Containers {
+		if c.Name == containerName {
+			cpu := c.Resources.Requests[corev1.ResourceCPU]
+			return cpu.String()
+		}
+	}
+	t.Fatalf("Container %s not found", containerName)
+	return ""
+}
+
+func TestRollbackManager_RollbackToRevision(t *testing.T) {
+	client := fake.NewSimpleClientset(twoContainerDeployment("100m", "50m"))
+	manager := NewRollbackManager(client)
+	ctx := context.Background()
+
+	// Three changes: app 100m -> 200m, sidecar 50m -> 80m, app 200m -> 300m
+	for _, step := range []struct{ container, app, sidecar string }{
+		{"app", "200m", "50m"},
+		{"sidecar", "200m", "80m"},
+		{"app", "300m", "80m"},
+	} {
+		if err := manager.SavePreviousConfig(ctx, "default", "Deployment", "api", step.container); err != nil {
+			t.Fatalf("SavePreviousConfig failed: %v", err)
+		}
+		if _, err := client.AppsV1().Deployments("default").Update(ctx,
+			twoContainerDeployment(step.app, step.sidecar), metav1.UpdateOptions{}); err != nil {
+			t.Fatalf("Failed to update deployment: %v", err)
+		}
+	}
+
+	revisions, err := manager.ListRevisions(ctx, "default", "Deployment", "api")
+	if err != nil {
+		t.Fatalf("ListRevisions failed: %v", err)
+	}
+	expected := [][2]string{{"100m", "50m"}, {"200m", "50m"}, {"200m", "80m"}}
+	if len(revisions) != len(expected) {
+		t.Fatalf("Expected %d revisions, got %+v", len(expected), revisions)
+	}
+	for i, want := range expected {
+		got := revisions[i].Containers
+		if revisions[i].Number != int64(i+1) || got[0].CPU != want[0] || got[1].CPU != want[1] {
+			t.Errorf("Revision %d: expected app=%s sidecar=%s, got %+v", i+1, want[0], want[1], revisions[i])
+		}
+	}
+
+	// The plan is a preview: nothing changes until it is executed
+	plan, err := manager.PlanRollback(ctx, "default", "Deployment", "api", RollbackTarget{Revision: 2})
+	if err != nil {
+		t.Fatalf("PlanRollback failed: %v", err)
+	}
+	if len(plan.Changes) != 2 {
+		t.Fatalf("Expected both containers to change, got %+v", plan.Changes)
+	}
+	if diff := plan.Changes[0].Diff(); len(diff) != 1 || diff[0] != "cpu request: 300m -> 200m" {
+		t.Errorf("Unexpected diff for app: %v", diff)
+	}
+	if cpu := containerCPU(t, client, "app"); cpu != "300m" {
+		t.Errorf("Expected planning to leave app at 300m, got %s", cpu)
+	}
+
+	if err := manager.ExecuteRollback(ctx, plan); err != nil {
+		t.Fatalf("ExecuteRollback failed: %v", err)
+	}
+	if app, sidecar := containerCPU(t, client, "app"), containerCPU(t, client, "sidecar"); app != "200m" || sidecar != "50m" {
+		t.Errorf("Expected app=200m sidecar=50m, got app=%s sidecar=%s", app, sidecar)
+	}
+	if revisions, _ := manager.ListRevisions(ctx, "default", "Deployment", "api"); len(revisions) != 1 {
+		t.Errorf("Expected revisions from 2 on to be dropped, got %d", len(revisions))
+	}
+
+	// The originals survive revision rollbacks
+	plan, err = manager.PlanRollback(ctx, "default", "Deployment", "api", RollbackTarget{Original: true})
+	if err != nil {
+		t.Fatalf("PlanRollback failed: %v", err)
+	}
+	if err := manager.ExecuteRollback(ctx, plan); err != nil {
+		t.Fatalf("ExecuteRollback failed: %v", err)
+	}
+	if app, sidecar := containerCPU(t, client, "app"), containerCPU(t, client, "sidecar"); app != "100m" || sidecar != "50m" {
+		t.Errorf("Expected the original app=100m sidecar=50m, got app=%s sidecar=%s", app, sidecar)
+	}
+	if _, err := manager.PlanRollback(ctx, "default", "Deployment", "api", RollbackTarget{}); err == nil {
+		t.Error("Expected no revisions after restoring the originals")
+	}
+}
+
+func TestPlanRollback_ByTime(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	snapshot := func(revision int64, cpu string, at time.Time) WorkloadConfig {
+		return WorkloadConfig{ContainerName: "app", CPU: cpu, Revision: revision, Timestamp: at}
+	}
+	history := NewWorkloadHistory()
+	history.Snapshots["app"] = []WorkloadConfig{
+		snapshot(1, "100m", base),
+		snapshot(2, "200m", base.Add(time.Hour)),
+	}
+	current := map[string]WorkloadConfig{"app": {ContainerName: "app", CPU: "300m"}}
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		expected string
+	}{
+		{"before the first change", base.Add(-time.Minute), "100m"},
+		{"between the changes", base.Add(30 * time.Minute), "200m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planRollback("default", "Deployment", "api", history, current, RollbackTarget{Time: tt.at})
+			if err != nil {
+				t.Fatalf("planRollback failed: %v", err)
+			}
+			if len(plan.Changes) != 1 || plan.Changes[0].To.CPU != tt.expected {
+				t.Errorf("Expected a rollback to %s, got %+v", tt.expected, plan.Changes)
+			}
+		})
+	}
+
+	if _, err := planRollback("default", "Deployment", "api", history, current,
+		RollbackTarget{Time: base.Add(2 * time.Hour)}); err == nil {
+		t.Error("Expected an error for a time after the last change")
+	}
+}
+
+func TestPlanRollback_OriginalFromProvenance(t *testing.T) {
+	deploy := twoContainerDeployment("200m", "50m")
+	deploy.Annotations = map[string]string{
+		provenance.OriginalResourcesAnnotation: `{"app":{"cpu":"100m"}}`,
+	}
+	manager := NewRollbackManager(fake.NewSimpleClientset(deploy))
+
+	// No history was saved, e.g. it was deleted, but the workload still carries its originals
+	plan, err := manager.PlanRollback(context.Background(), "default", "Deployment", "api", RollbackTarget{Original: true})
+	if err != nil {
+		t.Fatalf("PlanRollback failed: %v", err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].ContainerName != "app" || plan.Changes[0].To.CPU != "100m" {
+		t.Errorf("Expected app to be restored to 100m, got %+v", plan.Changes)
+	}
+}
+
+func TestRollbackManager_RollbackChangesUpdatesOnce(t *testing.T) {
+	client := fake.NewSimpleClientset(twoContainerDeployment("100m", "50m"))
+	manager := NewRollbackManager(client)
+	ctx := context.Background()
+
+	// One optimization pass changes both containers: app 100m -> 200m, sidecar 50m -> 80m
+	for _, containerName := range []string{"app", "sidecar"} {
+		if err := manager.SavePreviousConfig(ctx, "default", "Deployment", "api", containerName); err != nil {
+			t.Fatalf("SavePreviousConfig failed: %v", err)
+		}
+	}
+	if _, err := client.AppsV1().Deployments("default").Update(ctx,
+		twoContainerDeployment("200m", "80m"), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+	client.ClearActions()
+
+	plan, err := manager.RollbackChanges(ctx, "default", "Deployment", "api", []string{"sidecar", "app"})
+	if err != nil {
+		t.Fatalf("RollbackChanges failed: %v", err)
+	}
+	if plan.Revision != 1 || len(plan.Changes) != 2 {
+		t.Errorf("Expected revision 1 restoring both containers, got revision %d with %+v", plan.Revision, plan.Changes)
+	}
+	if app, sidecar := containerCPU(t, client, "app"), containerCPU(t, client, "sidecar"); app != "100m" || sidecar != "50m" {
+		t.Errorf("Expected app=100m sidecar=50m, got app=%s sidecar=%s", app, sidecar)
+	}
+
+	updates := 0
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "update" && action.GetResource().Resource == "deployments" {
+			updates++
+		}
+	}
+	if updates != 1 {
+		t.Errorf("Expected a single deployment update, got %d", updates)
+	}
+
+	if _, err := manager.RollbackChanges(ctx, "default", "Deployment", "api", []string{"app"}); err == nil {
+		t.Error("Expected an error once the rolled-back revisions are dropped")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,21 +17,166 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// MaxHistoryPerWorkload is the default number of revisions kept per workload
 const MaxHistoryPerWorkload = 5
 
 type RollbackManager struct {
 	mu         sync.RWMutex
 	kubeClient kubernetes.Interface
 	history    map[string][]WorkloadConfig
+	originals  map[string]WorkloadConfig
+	store      HistoryStore
+	retention  int
 }
 
+// NewRollbackManager creates a rollback manager. With a client, snapshots are kept in
+// ConfigMaps next to each workload so every controller replica and optctl share them;
+// without one they are only kept in memory.
 func NewRollbackManager(kubeClient kubernetes.Interface) *RollbackManager {
-	return &RollbackManager{
+	r := &RollbackManager{
 		kubeClient: kubeClient,
 		history:    make(map[string][]WorkloadConfig),
+		originals:  make(map[string]WorkloadConfig),
+		retention:  MaxHistoryPerWorkload,
+	}
+	if kubeClient != nil {
+		r.store = NewConfigMapStore(kubeClient)
+	}
+	return r
+}
+
+// SetStore replaces where snapshots are persisted (nil keeps them in memory only)
+func (r *RollbackManager) SetStore(store HistoryStore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = store
+}
+
+// SetRetention sets how many revisions are kept per workload (<= 0 restores the default)
+func (r *RollbackManager) SetRetention(retention int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if retention <= 0 {
+		retention = MaxHistoryPerWorkload
+	}
+	r.retention = retention
+}
+
+// Retention returns how many revisions are kept per workload
+func (r *RollbackManager) Retention() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.retention
+}
+
+// LoadFromCluster replaces the in-memory history with the snapshots persisted in a
+// namespace ("" for all namespaces)
+func (r *RollbackManager) LoadFromCluster(ctx context.Context, namespace string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.store == nil {
+		return fmt.Errorf("no history store configured")
+	}
+	history, err := r.store.List(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	r.history = history
+	return nil
+}
+
+// loadWorkload returns a workload's history from the store, or from memory without one.
+// Callers hold r.mu.
+func (r *RollbackManager) loadWorkload(ctx context.Context, namespace, kind, name string) (*WorkloadHistory, error) {
+	if r.store != nil {
+		history, err := r.store.Load(ctx, namespace, kind, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load history for %s/%s/%s: %v", namespace, kind, name, err)
+		}
+		r.cacheWorkload(namespace, kind, name, history)
+		return history, nil
+	}
+
+	prefix := namespace + "/" + kind + "/" + name + "/"
+	history := NewWorkloadHistory()
+	for key, configs := range r.history {
+		if containerName, ok := strings.CutPrefix(key, prefix); ok && len(configs) > 0 {
+			history.Snapshots[containerName] = append([]WorkloadConfig(nil), configs...)
+		}
+	}
+	for key, original := range r.originals {
+		if containerName, ok := strings.CutPrefix(key, prefix); ok {
+			history.Originals[containerName] = original
+		}
+	}
+	return history, nil
+}
+
+// updateWorkload applies mutate to a workload's latest history and records the result
+// in the store and in memory. With a store, mutate runs on the history re-read inside
+// the store's conflict retry, so concurrent writers do not lose each other's changes.
+// Callers hold r.mu.
+func (r *RollbackManager) updateWorkload(ctx context.Context, namespace, kind, name string, mutate func(history *WorkloadHistory)) (*WorkloadHistory, error) {
+	if r.store != nil {
+		history, err := r.store.Update(ctx, namespace, kind, name, mutate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save history for %s/%s/%s: %v", namespace, kind, name, err)
+		}
+		r.cacheWorkload(namespace, kind, name, history)
+		return history, nil
+	}
+
+	history, err := r.loadWorkload(ctx, namespace, kind, name)
+	if err != nil {
+		return nil, err
+	}
+	mutate(history)
+	r.cacheWorkload(namespace, kind, name, history)
+	return history, nil
+}
+
+// dropRevision removes a container's snapshot of the given revision from the history
+func dropRevision(history *WorkloadHistory, containerName string, revision int64) {
+	var kept []WorkloadConfig
+	for _, s := range history.Snapshots[containerName] {
+		if s.Revision != revision {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		delete(history.Snapshots, containerName)
+	} else {
+		history.Snapshots[containerName] = kept
+	}
+}
+
+// cacheWorkload replaces a workload's entries in the in-memory history. Callers hold r.mu.
+func (r *RollbackManager) cacheWorkload(namespace, kind, name string, history *WorkloadHistory) {
+	prefix := namespace + "/" + kind + "/" + name + "/"
+	for key := range r.history {
+		if strings.HasPrefix(key, prefix) {
+			delete(r.history, key)
+		}
+	}
+	for key := range r.originals {
+		if strings.HasPrefix(key, prefix) {
+			delete(r.originals, key)
+		}
+	}
+	for containerName, configs := range history.Snapshots {
+		if len(configs) > 0 {
+			r.history[prefix+containerName] = configs
+		}
+	}
+	for containerName, original := range history.Originals {
+		r.originals[prefix+containerName] = original
 	}
 }
 
+// SavePreviousConfig snapshots the container's current resources as the next revision of
+// the workload, right before the optimizer changes it. The first snapshot of a container
+// is also kept as its original config. Revisions beyond the retention are dropped.
 func (r *RollbackManager) SavePreviousConfig(ctx context.Context, namespace, kind, name, containerName string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -39,17 +185,52 @@ func (r *RollbackManager) SavePreviousConfig(ctx context.Context, namespace, kin
 	if err != nil {
 		return fmt.Errorf("failed to fetch current config: %v", err)
 	}
+	return r.saveConfig(ctx, config)
+}
 
-	key := config.Key()
-	configs := r.history[key]
-	configs = append(configs, *config)
+// SaveConfig records the given resources of a container as the next revision of the
+// workload. It is used for changes already in progress when they are committed, such
+// as a canary that is promoted, whose workload no longer shows the resources before it.
+func (r *RollbackManager) SaveConfig(ctx context.Context, namespace, kind, name, containerName string, resources corev1.ResourceRequirements) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	if len(configs) > MaxHistoryPerWorkload {
-		configs = configs[len(configs)-MaxHistoryPerWorkload:]
-	}
+	config := configFromContainer(namespace, kind, name, &corev1.Container{Name: containerName, Resources: resources})
+	return r.saveConfig(ctx, &config)
+}
 
-	r.history[key] = configs
-	klog.V(3).Infof("Saved config for %s (total history: %d)", key, len(configs))
+// saveConfig appends a snapshot to the history of its workload; r.mu must be held
+func (r *RollbackManager) saveConfig(ctx context.Context, config *WorkloadConfig) error {
+	namespace, kind, name, containerName := config.Namespace, config.Kind, config.Name, config.ContainerName
+	retention := r.retention
+	_, err := r.updateWorkload(ctx, namespace, kind, name, func(history *WorkloadHistory) {
+		config.Revision = history.LatestRevision() + 1
+		history.Snapshots[containerName] = append(history.Snapshots[containerName], *config)
+		if _, ok := history.Originals[containerName]; !ok {
+			history.Originals[containerName] = *config
+		}
+
+		// Keep the newest revisions across all containers so any kept revision can be
+		// restored for the whole workload
+		oldest := config.Revision - int64(retention)
+		for c, configs := range history.Snapshots {
+			kept := configs[:0]
+			for _, s := range configs {
+				if s.Revision > oldest {
+					kept = append(kept, s)
+				}
+			}
+			if len(kept) == 0 {
+				delete(history.Snapshots, c)
+			} else {
+				history.Snapshots[c] = kept
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	klog.V(3).Infof("Saved config for %s as revision %d", config.Key(), config.Revision)
 	return nil
 }
 
@@ -58,7 +239,11 @@ func (r *RollbackManager) RollbackWorkload(ctx context.Context, namespace, kind,
 	defer r.mu.Unlock()
 
 	key := namespace + "/" + kind + "/" + name + "/" + containerName
-	configs := r.history[key]
+	history, err := r.loadWorkload(ctx, namespace, kind, name)
+	if err != nil {
+		return err
+	}
+	configs := history.Snapshots[containerName]
 
 	if len(configs) < 2 {
 		return fmt.Errorf("no previous config available for %s", key)
@@ -68,158 +253,223 @@ func (r *RollbackManager) RollbackWorkload(ctx context.Context, namespace, kind,
 
 	klog.Infof("Rolling back %s to CPU=%s Memory=%s", key, previousConfig.CPU, previousConfig.Memory)
 
-	if err := r.applyConfig(ctx, &previousConfig); err != nil {
+	if err := r.applyConfigs(ctx, namespace, kind, name, []WorkloadConfig{previousConfig}); err != nil {
 		return fmt.Errorf("failed to apply rollback: %v", err)
 	}
 
-	r.history[key] = configs[:len(configs)-1]
+	dropped := configs[len(configs)-1].Revision
+	if _, err := r.updateWorkload(ctx, namespace, kind, name, func(history *WorkloadHistory) {
+		dropRevision(history, containerName, dropped)
+	}); err != nil {
+		return err
+	}
 	klog.Infof("Successfully rolled back %s", key)
 	return nil
 }
 
-func (r *RollbackManager) fetchCurrentConfig(ctx context.Context, namespace, kind, name, containerName string) (*WorkloadConfig, error) {
-	config := &WorkloadConfig{
-		Namespace:     namespace,
-		Kind:          kind,
-		Name:          name,
-		ContainerName: containerName,
-		Timestamp:     time.Now(),
+// RollbackLastChange restores the container to the config saved right before its most
+// recent change and drops that entry from the history
+func (r *RollbackManager) RollbackLastChange(ctx context.Context, namespace, kind, name, containerName string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := namespace + "/" + kind + "/" + name + "/" + containerName
+	history, err := r.loadWorkload(ctx, namespace, kind, name)
+	if err != nil {
+		return err
+	}
+	configs := history.Snapshots[containerName]
+
+	if len(configs) == 0 {
+		return fmt.Errorf("no saved config available for %s", key)
+	}
+
+	savedConfig := configs[len(configs)-1]
+
+	klog.Infof("Rolling back %s to CPU=%s Memory=%s", key, savedConfig.CPU, savedConfig.Memory)
+
+	if err := r.applyConfigs(ctx, namespace, kind, name, []WorkloadConfig{savedConfig}); err != nil {
+		return fmt.Errorf("failed to apply rollback: %v", err)
 	}
 
-	var containers []corev1.Container
+	if _, err := r.updateWorkload(ctx, namespace, kind, name, func(history *WorkloadHistory) {
+		dropRevision(history, containerName, savedConfig.Revision)
+	}); err != nil {
+		return err
+	}
+	klog.Infof("Successfully rolled back %s", key)
+	return nil
+}
 
+// fetchContainers returns the containers of a workload's pod template
+func (r *RollbackManager) fetchContainers(ctx context.Context, namespace, kind, name string) ([]corev1.Container, error) {
 	switch kind {
 	case "Deployment":
 		deploy, err := r.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
 			return nil, err
 		}
-		containers = deploy.Spec.Template.Spec.Containers
+		return deploy.Spec.Template.Spec.Containers, nil
 
 	case "StatefulSet":
 		sts, err := r.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
 			return nil, err
 		}
-		containers = sts.Spec.Template.Spec.Containers
+		return sts.Spec.Template.Spec.Containers, nil
 
 	case "DaemonSet":
 		ds, err := r.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
 			return nil, err
 		}
-		containers = ds.Spec.Template.Spec.Containers
+		return ds.Spec.Template.Spec.Containers, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported kind: %s", kind)
 	}
-
-	for _, container := range containers {
-		if container.Name == containerName {
-			if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
-				config.CPU = cpu.String()
-			}
-			if mem, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
-				config.Memory = mem.String()
-			}
-			return config, nil
-		}
-	}
-
-	return nil, fmt.Errorf("container %s not found", containerName)
-}
-
-func (r *RollbackManager) applyConfig(ctx context.Context, config *WorkloadConfig) error {
-	switch config.Kind {
-	case "Deployment":
-		return r.rollbackDeployment(ctx, config)
-	case "StatefulSet":
-		return r.rollbackStatefulSet(ctx, config)
-	case "DaemonSet":
-		return r.rollbackDaemonSet(ctx, config)
-	default:
-		return fmt.Errorf("unsupported kind: %s", config.Kind)
-	}
 }
 
-func (r *RollbackManager) rollbackDeployment(ctx context.Context, config *WorkloadConfig) error {
-	deploy, err := r.kubeClient.AppsV1().Deployments(config.Namespace).Get(ctx, config.Name, metav1.GetOptions{})
+func (r *RollbackManager) fetchCurrentConfig(ctx context.Context, namespace, kind, name, containerName string) (*WorkloadConfig, error) {
+	containers, err := r.fetchContainers(ctx, namespace, kind, name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for i := range deploy.Spec.Template.Spec.Containers {
-		if deploy.Spec.Template.Spec.Containers[i].Name == config.ContainerName {
-			if err := r.updateContainerResources(&deploy.Spec.Template.Spec.Containers[i], config); err != nil {
-				return err
-			}
-			break
+	for i := range containers {
+		if containers[i].Name == containerName {
+			config := configFromContainer(namespace, kind, name, &containers[i])
+			return &config, nil
 		}
 	}
 
-	_, err = r.kubeClient.AppsV1().Deployments(config.Namespace).Update(ctx, deploy, metav1.UpdateOptions{})
-	return err
+	return nil, fmt.Errorf("container %s not found", containerName)
 }
 
-func (r *RollbackManager) rollbackStatefulSet(ctx context.Context, config *WorkloadConfig) error {
-	sts, err := r.kubeClient.AppsV1().StatefulSets(config.Namespace).Get(ctx, config.Name, metav1.GetOptions{})
-	if err != nil {
-		return err
+// configFromContainer snapshots the resources of a container
+func configFromContainer(namespace, kind, name string, container *corev1.Container) WorkloadConfig {
+	config := WorkloadConfig{
+		Namespace:     namespace,
+		Kind:          kind,
+		Name:          name,
+		ContainerName: container.Name,
+		Timestamp:     time.Now(),
+	}
+	if cpu, ok := container.Resources.Requests[corev1.ResourceCPU]; ok {
+		config.CPU = cpu.String()
+	}
+	if mem, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+		config.Memory = mem.String()
 	}
+	if limit, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+		config.MemoryLimit = limit.String()
+	}
+	if limit, ok := container.Resources.Limits[corev1.ResourceCPU]; ok {
+		config.CPULimit = limit.String()
+	}
+	if storage, ok := container.Resources.Requests[corev1.ResourceEphemeralStorage]; ok {
+		config.EphemeralStorage = storage.String()
+	}
+	return config
+}
 
-	for i := range sts.Spec.Template.Spec.Containers {
-		if sts.Spec.Template.Spec.Containers[i].Name == config.ContainerName {
-			if err := r.updateContainerResources(&sts.Spec.Template.Spec.Containers[i], config); err != nil {
-				return err
+// applyConfigs restores the given container configs of a workload in a single update,
+// so all containers roll out together
+func (r *RollbackManager) applyConfigs(ctx context.Context, namespace, kind, name string, configs []WorkloadConfig) error {
+	update := func(containers []corev1.Container) error {
+		for _, config := range configs {
+			found := false
+			for i := range containers {
+				if containers[i].Name == config.ContainerName {
+					if err := r.updateContainerResources(&containers[i], &config); err != nil {
+						return err
+					}
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("container %s not found", config.ContainerName)
 			}
-			break
 		}
+		return nil
 	}
 
-	_, err = r.kubeClient.AppsV1().StatefulSets(config.Namespace).Update(ctx, sts, metav1.UpdateOptions{})
-	return err
-}
+	switch kind {
+	case "Deployment":
+		deploy, err := r.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := update(deploy.Spec.Template.Spec.Containers); err != nil {
+			return err
+		}
+		_, err = r.kubeClient.AppsV1().Deployments(namespace).Update(ctx, deploy, metav1.UpdateOptions{})
+		return err
 
-func (r *RollbackManager) rollbackDaemonSet(ctx context.Context, config *WorkloadConfig) error {
-	ds, err := r.kubeClient.AppsV1().DaemonSets(config.Namespace).Get(ctx, config.Name, metav1.GetOptions{})
-	if err != nil {
+	case "StatefulSet":
+		sts, err := r.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if err := update(sts.Spec.Template.Spec.Containers); err != nil {
+			return err
+		}
+		_, err = r.kubeClient.AppsV1().StatefulSets(namespace).Update(ctx, sts, metav1.UpdateOptions{})
 		return err
-	}
 
-	for i := range ds.Spec.Template.Spec.Containers {
-		if ds.Spec.Template.Spec.Containers[i].Name == config.ContainerName {
-			if err := r.updateContainerResources(&ds.Spec.Template.Spec.Containers[i], config); err != nil {
-				return err
-			}
-			break
+	case "DaemonSet":
+		ds, err := r.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
 		}
-	}
+		if err := update(ds.Spec.Template.Spec.Containers); err != nil {
+			return err
+		}
+		_, err = r.kubeClient.AppsV1().DaemonSets(namespace).Update(ctx, ds, metav1.UpdateOptions{})
+		return err
 
-	_, err = r.kubeClient.AppsV1().DaemonSets(config.Namespace).Update(ctx, ds, metav1.UpdateOptions{})
-	return err
+	default:
+		return fmt.Errorf("unsupported kind: %s", kind)
+	}
 }
 
+// updateContainerResources sets the container's requests and limits to the snapshot.
+// Resources the snapshot does not have were unset when it was taken and are removed.
 func (r *RollbackManager) updateContainerResources(container *corev1.Container, config *WorkloadConfig) error {
 	if container.Resources.Requests == nil {
 		container.Resources.Requests = corev1.ResourceList{}
 	}
-
-	if config.CPU != "" {
-		cpuQuantity, err := resource.ParseQuantity(config.CPU)
-		if err != nil {
-			return fmt.Errorf("invalid CPU quantity: %v", err)
-		}
-		container.Resources.Requests[corev1.ResourceCPU] = cpuQuantity
+	if container.Resources.Limits == nil {
+		container.Resources.Limits = corev1.ResourceList{}
 	}
 
-	if config.Memory != "" {
-		memQuantity, err := resource.ParseQuantity(config.Memory)
+	for _, field := range []struct {
+		value string
+		list  corev1.ResourceList
+		name  corev1.ResourceName
+		desc  string
+	}{
+		{config.CPU, container.Resources.Requests, corev1.ResourceCPU, "CPU"},
+		{config.Memory, container.Resources.Requests, corev1.ResourceMemory, "memory"},
+		{config.MemoryLimit, container.Resources.Limits, corev1.ResourceMemory, "memory limit"},
+		{config.CPULimit, container.Resources.Limits, corev1.ResourceCPU, "CPU limit"},
+		{config.EphemeralStorage, container.Resources.Requests, corev1.ResourceEphemeralStorage, "ephemeral-storage"},
+	} {
+		if field.value == "" {
+			delete(field.list, field.name)
+			continue
+		}
+		quantity, err := resource.ParseQuantity(field.value)
 		if err != nil {
-			return fmt.Errorf("invalid memory quantity: %v", err)
+			return fmt.Errorf("invalid %s quantity: %v", field.desc, err)
 		}
-		container.Resources.Requests[corev1.ResourceMemory] = memQuantity
+		field.list[field.name] = quantity
 	}
 
+	if len(container.Resources.Limits) == 0 {
+		container.Resources.Limits = nil
+	}
 	return nil
 }
 
@@ -5,15 +5,62 @@ import (
 )
 
 type WorkloadConfig struct {
-	Namespace     string
-	Kind          string
-	Name          string
-	ContainerName string
-	CPU           string
-	Memory        string
-	Timestamp     time.Time
+	Namespace        string
+	Kind             string
+	Name             string
+	ContainerName    string
+	CPU              string
+	Memory           string
+	MemoryLimit      string
+	CPULimit         string
+	EphemeralStorage string
+	Timestamp        time.Time
+
+	// Revision numbers the change this snapshot preceded, counted per workload
+	Revision int64
 }
 
 func (w *WorkloadConfig) Key() string {
 	return w.Namespace + "/" + w.Kind + "/" + w.Name + "/" + w.ContainerName
 }
+
+// SameResources reports whether two snapshots hold the same requests and limits
+func (w *WorkloadConfig) SameResources(other *WorkloadConfig) bool {
+	return w.CPU == other.CPU && w.Memory == other.Memory && w.CPULimit == other.CPULimit &&
+		w.MemoryLimit == other.MemoryLimit && w.EphemeralStorage == other.EphemeralStorage
+}
+
+// WorkloadHistory holds the rollback snapshots of all containers of a workload
+type WorkloadHistory struct {
+	// Snapshots maps container names to the configs saved before each change, oldest first
+	Snapshots map[string][]WorkloadConfig
+
+	// Originals maps container names to their configs before the optimizer first changed them
+	Originals map[string]WorkloadConfig
+}
+
+// NewWorkloadHistory returns an empty history
+func NewWorkloadHistory() *WorkloadHistory {
+	return &WorkloadHistory{
+		Snapshots: make(map[string][]WorkloadConfig),
+		Originals: make(map[string]WorkloadConfig),
+	}
+}
+
+// LatestRevision returns the highest revision recorded for the workload, 0 if none
+func (h *WorkloadHistory) LatestRevision() int64 {
+	var latest int64
+	for _, configs := range h.Snapshots {
+		for _, c := range configs {
+			if c.Revision > latest {
+				latest = c.Revision
+			}
+		}
+	}
+	return latest
+}
+
+// IsEmpty reports whether the history holds neither snapshots nor originals
+func (h *WorkloadHistory) IsEmpty() bool {
+	return len(h.Snapshots) == 0 && len(h.Originals) == 0
+}
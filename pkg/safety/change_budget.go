@@ -0,0 +1,316 @@
+package safety
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// BudgetLimits bound how many workloads may be changed; zero means unlimited
+type BudgetLimits struct {
+	// MaxConcurrentRollouts is how many workloads may be rolling out changes at once
+	MaxConcurrentRollouts int
+
+	// MaxChangesPerHour is how many workloads may be changed in any hour
+	MaxChangesPerHour int
+}
+
+// IsUnlimited reports whether the limits allow any number of changes
+func (l BudgetLimits) IsUnlimited() bool {
+	return l.MaxConcurrentRollouts <= 0 && l.MaxChangesPerHour <= 0
+}
+
+// Budget limits that were exhausted, used as metric labels
+const (
+	BudgetLimitGlobalConcurrent    = "global_concurrent"
+	BudgetLimitGlobalHourly        = "global_hourly"
+	BudgetLimitNamespaceConcurrent = "namespace_concurrent"
+	BudgetLimitNamespaceHourly     = "namespace_hourly"
+)
+
+// BudgetWorkload identifies a workload that consumes the change budget
+type BudgetWorkload struct {
+	Namespace string
+	Kind      string
+	Name      string
+}
+
+// BudgetRank orders changes competing for the budget: by Primary, then by Secondary,
+// higher first
+type BudgetRank struct {
+	Primary   float64
+	Secondary float64
+}
+
+// Outranks reports whether a change of this rank goes before one of the other rank
+func (r BudgetRank) Outranks(other BudgetRank) bool {
+	if r.Primary != other.Primary {
+		return r.Primary > other.Primary
+	}
+	return r.Secondary > other.Secondary
+}
+
+// BudgetCandidate is a change deferred by the budget and its rank
+type BudgetCandidate struct {
+	Workload BudgetWorkload
+	Rank     BudgetRank
+}
+
+// BudgetWaitingTTL is how long deferred changes reported by an owner keep their claim on
+// the budget; owners report them again on every pass
+const BudgetWaitingTTL = 15 * time.Minute
+
+// BudgetRollout is a workload rollout in flight and when it started
+type BudgetRollout struct {
+	Workload  BudgetWorkload
+	StartedAt time.Time
+}
+
+// BudgetDecision is the outcome of asking the change budget for a change
+type BudgetDecision struct {
+	Allowed bool
+
+	// Acquired reports whether the change consumed budget; changes to a workload already
+	// rolling out do not
+	Acquired bool
+
+	// Limit names the exhausted limit when the change is not allowed
+	Limit string
+
+	// Message explains the decision
+	Message string
+}
+
+type budgetWaiting struct {
+	candidates map[BudgetWorkload]BudgetRank
+	at         time.Time
+}
+
+type budgetChange struct {
+	owner    string
+	workload BudgetWorkload
+	at       time.Time
+}
+
+// ChangeBudget limits how many workloads are changed at once and per hour, across the
+// whole cluster and within each namespace. It is shared by every OptimizerConfig, each of
+// which is an owner that reports its in-flight rollouts and asks for each new change.
+// Owners also report the changes the budget deferred, so budget that frees up goes to the
+// highest-ranked waiting change of any owner rather than to whichever owner asks first.
+type ChangeBudget struct {
+	mu        sync.Mutex
+	global    BudgetLimits
+	namespace BudgetLimits
+
+	inFlight map[string]map[BudgetWorkload]bool // owner -> workloads rolling out
+	waiting  map[string]budgetWaiting           // owner -> changes deferred on its last pass
+	changes  []budgetChange                     // changes within the last hour, oldest first
+}
+
+// NewChangeBudget creates a budget with cluster-wide limits and limits applied to each namespace
+func NewChangeBudget(global, perNamespace BudgetLimits) *ChangeBudget {
+	return &ChangeBudget{
+		global:    global,
+		namespace: perNamespace,
+		inFlight:  make(map[string]map[BudgetWorkload]bool),
+		waiting:   make(map[string]budgetWaiting),
+	}
+}
+
+// Limits returns the cluster-wide and per-namespace limits
+func (b *ChangeBudget) Limits() (global, perNamespace BudgetLimits) {
+	return b.global, b.namespace
+}
+
+// SyncInFlight replaces the rollouts an owner has in flight. Rollouts the budget has not
+// seen, such as those recorded in status before a controller restart, count as changes
+// made when they started.
+func (b *ChangeBudget) SyncInFlight(owner string, rollouts []BudgetRollout, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.pruneChanges(now)
+	workloads := make(map[BudgetWorkload]bool, len(rollouts))
+	for _, rollout := range rollouts {
+		workloads[rollout.Workload] = true
+		if !b.hasChange(owner, rollout.Workload) && now.Sub(rollout.StartedAt) < time.Hour {
+			b.insertChange(budgetChange{owner: owner, workload: rollout.Workload, at: rollout.StartedAt})
+		}
+	}
+	if len(workloads) == 0 {
+		delete(b.inFlight, owner)
+		return
+	}
+	b.inFlight[owner] = workloads
+}
+
+// SetWaiting replaces the changes an owner had deferred by the budget on its last pass.
+// Until the owner reports again or BudgetWaitingTTL passes, they hold back budget from
+// lower-ranked changes of other owners.
+func (b *ChangeBudget) SetWaiting(owner string, candidates []BudgetCandidate, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(candidates) == 0 {
+		delete(b.waiting, owner)
+		return
+	}
+	waiting := budgetWaiting{candidates: make(map[BudgetWorkload]BudgetRank, len(candidates)), at: now}
+	for _, candidate := range candidates {
+		if rank, ok := waiting.candidates[candidate.Workload]; !ok || candidate.Rank.Outranks(rank) {
+			waiting.candidates[candidate.Workload] = candidate.Rank
+		}
+	}
+	b.waiting[owner] = waiting
+}
+
+// Acquire asks to change a workload of the given rank. An allowed change counts against
+// every limit until its rollout is no longer reported in flight and an hour has passed.
+// Budget left over is first kept for higher-ranked changes other owners have waiting.
+// Further changes to a workload the owner already has in flight are always allowed.
+func (b *ChangeBudget) Acquire(owner string, workload BudgetWorkload, rank BudgetRank, now time.Time) BudgetDecision {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.inFlight[owner][workload] {
+		return BudgetDecision{Allowed: true, Message: "workload already rolling out"}
+	}
+
+	b.pruneChanges(now)
+	globalRollouts, namespaceRollouts := b.countInFlight(workload.Namespace)
+	globalChanges, namespaceChanges := b.countChanges(workload.Namespace)
+	globalAhead, namespaceAhead := b.countWaitingAhead(owner, workload, rank, now)
+
+	checks := []struct {
+		limit, used, ahead int
+		name               string
+		describe           string
+	}{
+		{b.global.MaxConcurrentRollouts, globalRollouts, globalAhead, BudgetLimitGlobalConcurrent, "cluster-wide concurrent rollouts"},
+		{b.namespace.MaxConcurrentRollouts, namespaceRollouts, namespaceAhead, BudgetLimitNamespaceConcurrent, "concurrent rollouts in namespace " + workload.Namespace},
+		{b.global.MaxChangesPerHour, globalChanges, globalAhead, BudgetLimitGlobalHourly, "cluster-wide changes in the last hour"},
+		{b.namespace.MaxChangesPerHour, namespaceChanges, namespaceAhead, BudgetLimitNamespaceHourly, "changes in the last hour in namespace " + workload.Namespace},
+	}
+	for _, check := range checks {
+		if check.limit <= 0 {
+			continue
+		}
+		if check.used >= check.limit {
+			return BudgetDecision{
+				Limit:   check.name,
+				Message: fmt.Sprintf("change budget exhausted: %d of %d %s", check.used, check.limit, check.describe),
+			}
+		}
+		if check.used+check.ahead >= check.limit {
+			return BudgetDecision{
+				Limit: check.name,
+				Message: fmt.Sprintf("change budget reserved: %d of %d %s used, %d higher-ranked changes waiting",
+					check.used, check.limit, check.describe, check.ahead),
+			}
+		}
+	}
+
+	if b.inFlight[owner] == nil {
+		b.inFlight[owner] = make(map[BudgetWorkload]bool)
+	}
+	b.inFlight[owner][workload] = true
+	b.insertChange(budgetChange{owner: owner, workload: workload, at: now})
+	if waiting, ok := b.waiting[owner]; ok {
+		delete(waiting.candidates, workload)
+	}
+	return BudgetDecision{Allowed: true, Acquired: true, Message: "within the change budget"}
+}
+
+// Release returns a change that was acquired but not applied
+func (b *ChangeBudget) Release(owner string, workload BudgetWorkload) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.inFlight[owner], workload)
+	for i := len(b.changes) - 1; i >= 0; i-- {
+		if c := b.changes[i]; c.owner == owner && c.workload == workload {
+			b.changes = append(b.changes[:i], b.changes[i+1:]...)
+			return
+		}
+	}
+}
+
+// countInFlight counts distinct workloads rolling out cluster-wide and in a namespace
+func (b *ChangeBudget) countInFlight(namespace string) (global, inNamespace int) {
+	seen := make(map[BudgetWorkload]bool)
+	for _, workloads := range b.inFlight {
+		for workload := range workloads {
+			if seen[workload] {
+				continue
+			}
+			seen[workload] = true
+			global++
+			if workload.Namespace == namespace {
+				inNamespace++
+			}
+		}
+	}
+	return global, inNamespace
+}
+
+// countWaitingAhead counts the changes other owners have waiting that outrank a change,
+// cluster-wide and in its namespace. An owner ranks its own changes itself.
+func (b *ChangeBudget) countWaitingAhead(owner string, workload BudgetWorkload, rank BudgetRank, now time.Time) (global, inNamespace int) {
+	seen := map[BudgetWorkload]bool{workload: true}
+	for other, waiting := range b.waiting {
+		if other == owner || now.Sub(waiting.at) > BudgetWaitingTTL {
+			continue
+		}
+		for candidate, candidateRank := range waiting.candidates {
+			if seen[candidate] || !candidateRank.Outranks(rank) {
+				continue
+			}
+			seen[candidate] = true
+			global++
+			if candidate.Namespace == workload.Namespace {
+				inNamespace++
+			}
+		}
+	}
+	return global, inNamespace
+}
+
+func (b *ChangeBudget) countChanges(namespace string) (global, inNamespace int) {
+	for _, c := range b.changes {
+		global++
+		if c.workload.Namespace == namespace {
+			inNamespace++
+		}
+	}
+	return global, inNamespace
+}
+
+func (b *ChangeBudget) hasChange(owner string, workload BudgetWorkload) bool {
+	for _, c := range b.changes {
+		if c.owner == owner && c.workload == workload {
+			return true
+		}
+	}
+	return false
+}
+
+// insertChange keeps changes ordered by time
+func (b *ChangeBudget) insertChange(change budgetChange) {
+	i := len(b.changes)
+	for i > 0 && b.changes[i-1].at.After(change.at) {
+		i--
+	}
+	b.changes = append(b.changes, budgetChange{})
+	copy(b.changes[i+1:], b.changes[i:])
+	b.changes[i] = change
+}
+
+// pruneChanges drops changes older than an hour
+func (b *ChangeBudget) pruneChanges(now time.Time) {
+	cutoff := now.Add(-time.Hour)
+	i := 0
+	for i < len(b.changes) && !b.changes[i].at.After(cutoff) {
+		i++
+	}
+	b.changes = b.changes[i:]
+}
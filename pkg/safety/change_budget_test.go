@@ -0,0 +1,173 @@
+package safety
+
+import (
+	"testing"
+	"time"
+)
+
+func budgetWorkload(namespace, name string) BudgetWorkload {
+	return BudgetWorkload{Namespace: namespace, Kind: "Deployment", Name: name}
+}
+
+func TestChangeBudget_Unlimited(t *testing.T) {
+	budget := NewChangeBudget(BudgetLimits{}, BudgetLimits{})
+	now := time.Now()
+
+	for i := 0; i < 10; i++ {
+		decision := budget.Acquire("default/config", budgetWorkload("default", string(rune('a'+i))), BudgetRank{}, now)
+		if !decision.Allowed || !decision.Acquired {
+			t.Fatalf("Expected change %d to be allowed by an unlimited budget, got %+v", i, decision)
+		}
+	}
+}
+
+func TestChangeBudget_GlobalConcurrentLimit(t *testing.T) {
+	budget := NewChangeBudget(BudgetLimits{MaxConcurrentRollouts: 2}, BudgetLimits{})
+	now := time.Now()
+
+	budget.Acquire("default/a", budgetWorkload("default", "api"), BudgetRank{}, now)
+	budget.Acquire("team/b", budgetWorkload("team", "web"), BudgetRank{}, now)
+
+	decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{}, now)
+	if decision.Allowed {
+		t.Fatal("Expected third concurrent rollout to be denied")
+	}
+	if decision.Limit != BudgetLimitGlobalConcurrent {
+		t.Errorf("Expected limit %s, got %s", BudgetLimitGlobalConcurrent, decision.Limit)
+	}
+
+	// A rollout completing frees its concurrency slot
+	budget.SyncInFlight("team/b", nil, now)
+	if decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{}, now); !decision.Allowed {
+		t.Errorf("Expected change to be allowed once a rollout completed, got %s", decision.Message)
+	}
+}
+
+func TestChangeBudget_NamespaceLimits(t *testing.T) {
+	budget := NewChangeBudget(BudgetLimits{}, BudgetLimits{MaxConcurrentRollouts: 1})
+	now := time.Now()
+
+	budget.Acquire("default/a", budgetWorkload("default", "api"), BudgetRank{}, now)
+
+	decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{}, now)
+	if decision.Allowed || decision.Limit != BudgetLimitNamespaceConcurrent {
+		t.Errorf("Expected namespace concurrency limit to deny change, got %+v", decision)
+	}
+
+	if decision := budget.Acquire("default/a", budgetWorkload("team", "web"), BudgetRank{}, now); !decision.Allowed {
+		t.Errorf("Expected change in another namespace to be allowed, got %s", decision.Message)
+	}
+}
+
+func TestChangeBudget_HourlyLimit(t *testing.T) {
+	budget := NewChangeBudget(BudgetLimits{MaxChangesPerHour: 1}, BudgetLimits{})
+	now := time.Now()
+
+	budget.Acquire("default/a", budgetWorkload("default", "api"), BudgetRank{}, now)
+	budget.SyncInFlight("default/a", nil, now)
+
+	decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{}, now.Add(30*time.Minute))
+	if decision.Allowed || decision.Limit != BudgetLimitGlobalHourly {
+		t.Errorf("Expected hourly limit to deny change, got %+v", decision)
+	}
+
+	if decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{}, now.Add(61*time.Minute)); !decision.Allowed {
+		t.Errorf("Expected change to be allowed after an hour, got %s", decision.Message)
+	}
+}
+
+func TestChangeBudget_InFlightWorkloadReused(t *testing.T) {
+	budget := NewChangeBudget(BudgetLimits{MaxConcurrentRollouts: 1, MaxChangesPerHour: 1}, BudgetLimits{})
+	now := time.Now()
+
+	budget.Acquire("default/a", budgetWorkload("default", "api"), BudgetRank{}, now)
+
+	decision := budget.Acquire("default/a", budgetWorkload("default", "api"), BudgetRank{}, now)
+	if !decision.Allowed {
+		t.Fatalf("Expected another container of an in-flight workload to be allowed, got %s", decision.Message)
+	}
+	if decision.Acquired {
+		t.Error("Expected in-flight workload not to consume budget again")
+	}
+}
+
+func TestChangeBudget_Release(t *testing.T) {
+	budget := NewChangeBudget(BudgetLimits{MaxConcurrentRollouts: 1, MaxChangesPerHour: 1}, BudgetLimits{})
+	now := time.Now()
+
+	budget.Acquire("default/a", budgetWorkload("default", "api"), BudgetRank{}, now)
+	budget.Release("default/a", budgetWorkload("default", "api"))
+
+	if decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{}, now); !decision.Allowed {
+		t.Errorf("Expected released change to return its budget, got %s", decision.Message)
+	}
+}
+
+func TestChangeBudget_SyncSeedsRestartedRollouts(t *testing.T) {
+	budget := NewChangeBudget(BudgetLimits{MaxChangesPerHour: 2}, BudgetLimits{})
+	now := time.Now()
+
+	budget.SyncInFlight("default/a", []BudgetRollout{
+		{Workload: budgetWorkload("default", "api"), StartedAt: now.Add(-10 * time.Minute)},
+		{Workload: budgetWorkload("default", "old"), StartedAt: now.Add(-2 * time.Hour)},
+	}, now)
+	// Syncing the same rollouts again must not count them twice
+	budget.SyncInFlight("default/a", []BudgetRollout{
+		{Workload: budgetWorkload("default", "api"), StartedAt: now.Add(-10 * time.Minute)},
+		{Workload: budgetWorkload("default", "old"), StartedAt: now.Add(-2 * time.Hour)},
+	}, now)
+
+	if decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{}, now); !decision.Allowed {
+		t.Fatalf("Expected one change left in the hour, got %s", decision.Message)
+	}
+	if decision := budget.Acquire("default/a", budgetWorkload("default", "web"), BudgetRank{}, now); decision.Allowed {
+		t.Error("Expected hourly limit to count the rollout recorded before the restart")
+	}
+}
+
+func TestChangeBudget_KeepsRoomForHigherRankedWaiting(t *testing.T) {
+	budget := NewChangeBudget(BudgetLimits{MaxConcurrentRollouts: 2}, BudgetLimits{})
+	now := time.Now()
+
+	// team/b deferred two changes on its last pass; one slot is taken
+	budget.Acquire("default/a", budgetWorkload("default", "api"), BudgetRank{Primary: 100}, now)
+	budget.SetWaiting("team/b", []BudgetCandidate{
+		{Workload: budgetWorkload("team", "web"), Rank: BudgetRank{Primary: 50}},
+		{Workload: budgetWorkload("team", "cache"), Rank: BudgetRank{Primary: 5}},
+	}, now)
+
+	decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{Primary: 10}, now)
+	if decision.Allowed || decision.Limit != BudgetLimitGlobalConcurrent {
+		t.Fatalf("Expected the last slot to be kept for the higher-ranked waiting change, got %+v", decision)
+	}
+	if decision := budget.Acquire("default/a", budgetWorkload("default", "batch"), BudgetRank{Primary: 60}, now); !decision.Allowed {
+		t.Errorf("Expected a change outranking every waiting one to be allowed, got %s", decision.Message)
+	}
+
+	// The owner of the waiting changes ranks its own changes and takes what is free
+	budget.SyncInFlight("default/a", nil, now)
+	if decision := budget.Acquire("team/b", budgetWorkload("team", "cache"), BudgetRank{Primary: 5}, now); !decision.Allowed {
+		t.Errorf("Expected team/b to use the budget for its own changes, got %s", decision.Message)
+	}
+
+	// Waiting changes stop holding budget once their owner stops reporting them
+	budget.SyncInFlight("team/b", nil, now)
+	later := now.Add(BudgetWaitingTTL + time.Minute)
+	budget.SetWaiting("team/b", []BudgetCandidate{{Workload: budgetWorkload("team", "web"), Rank: BudgetRank{Primary: 50}}}, now)
+	budget.Acquire("default/a", budgetWorkload("default", "api"), BudgetRank{Primary: 100}, later)
+	if decision := budget.Acquire("default/a", budgetWorkload("default", "worker"), BudgetRank{Primary: 10}, later); !decision.Allowed {
+		t.Errorf("Expected expired waiting changes to free the budget, got %s", decision.Message)
+	}
+}
+
+func TestBudgetRank_Outranks(t *testing.T) {
+	if !(BudgetRank{Primary: 2}).Outranks(BudgetRank{Primary: 1, Secondary: 9}) {
+		t.Error("Expected Primary to decide first")
+	}
+	if !(BudgetRank{Primary: 1, Secondary: 2}).Outranks(BudgetRank{Primary: 1, Secondary: 1}) {
+		t.Error("Expected Secondary to break ties")
+	}
+	if (BudgetRank{Primary: 1}).Outranks(BudgetRank{Primary: 1}) {
+		t.Error("Expected equal ranks not to outrank each other")
+	}
+}
@@ -1,7 +1,6 @@
 package safety
 
 import (
-	"sync"
 	"time"
 
 	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
@@ -10,112 +9,148 @@ import (
 	"k8s.io/klog/v2"
 )
 
+// Circuit breaker defaults for settings the spec leaves unset
+const (
+	DefaultErrorThreshold          = 5
+	DefaultNamespaceErrorThreshold = 10
+	DefaultSuccessThreshold        = 3
+	DefaultCircuitTimeout          = 5 * time.Minute
+	DefaultMaxCircuitTimeout       = time.Hour
+)
+
+// CircuitBreaker opens the circuit of a workload or namespace after consecutive failed
+// changes, so the optimizer stops changing it while the rest of the config keeps being
+// optimized. An open circuit lets a trial change through (half-open) once its backoff
+// has elapsed; the backoff doubles every time the circuit opens again without closing.
+// The circuits themselves are kept in OptimizerConfig status so they survive restarts.
 type CircuitBreaker struct {
-	// mu protects concurrent access to circuit breaker state in config.Status
-	mu sync.Mutex
+	errorThreshold          int
+	namespaceErrorThreshold int
+	successThreshold        int
+	timeout                 time.Duration
+	maxTimeout              time.Duration
 }
 
-func NewCircuitBreaker() *CircuitBreaker {
-	return &CircuitBreaker{}
+// NewCircuitBreaker creates a circuit breaker with the settings of a config's spec
+func NewCircuitBreaker(spec *optimizerv1alpha1.CircuitBreakerConfig) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		errorThreshold:          DefaultErrorThreshold,
+		namespaceErrorThreshold: DefaultNamespaceErrorThreshold,
+		successThreshold:        DefaultSuccessThreshold,
+		timeout:                 DefaultCircuitTimeout,
+		maxTimeout:              DefaultMaxCircuitTimeout,
+	}
+	if spec == nil {
+		return cb
+	}
+	if spec.ErrorThreshold > 0 {
+		cb.errorThreshold = spec.ErrorThreshold
+	}
+	if spec.NamespaceErrorThreshold > 0 {
+		cb.namespaceErrorThreshold = spec.NamespaceErrorThreshold
+	}
+	if spec.SuccessThreshold > 0 {
+		cb.successThreshold = spec.SuccessThreshold
+	}
+	if timeout, err := time.ParseDuration(spec.Timeout); err == nil && timeout > 0 {
+		cb.timeout = timeout
+	}
+	if maxTimeout, err := time.ParseDuration(spec.MaxTimeout); err == nil && maxTimeout > 0 {
+		cb.maxTimeout = maxTimeout
+	}
+	if cb.maxTimeout < cb.timeout {
+		cb.maxTimeout = cb.timeout
+	}
+	return cb
 }
 
-func (cb *CircuitBreaker) ShouldAllow(config *optimizerv1alpha1.OptimizerConfig) bool {
-	if config.Spec.CircuitBreaker == nil || !config.Spec.CircuitBreaker.Enabled {
-		return true
+// Backoff is how long a circuit stays open after its given trip since it last closed
+func (cb *CircuitBreaker) Backoff(trips int) time.Duration {
+	backoff := cb.timeout
+	for i := 1; i < trips && backoff < cb.maxTimeout; i++ {
+		backoff *= 2
 	}
-
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
-	if config.Status.CircuitState == optimizerv1alpha1.CircuitStateOpen {
-		timeout, err := time.ParseDuration(config.Spec.CircuitBreaker.Timeout)
-		if err != nil {
-			timeout = 5 * time.Minute
-		}
-
-		if config.Status.LastUpdateTime != nil {
-			elapsed := time.Since(config.Status.LastUpdateTime.Time)
-			if elapsed >= timeout {
-				config.Status.CircuitState = optimizerv1alpha1.CircuitStateHalfOpen
-				config.Status.ConsecutiveErrors = 0
-				config.Status.ConsecutiveSuccesses = 0
-				klog.Infof("Circuit breaker entering half-open state for %s/%s after %v timeout", config.Namespace, config.Name, timeout)
-				return true
-			}
-		}
-		return false
+	if backoff > cb.maxTimeout {
+		backoff = cb.maxTimeout
 	}
-
-	return true
+	return backoff
 }
 
-func (cb *CircuitBreaker) RecordSuccess(config *optimizerv1alpha1.OptimizerConfig) (stateChanged bool) {
-	if config.Spec.CircuitBreaker == nil || !config.Spec.CircuitBreaker.Enabled {
-		return false
+// Allow reports whether a change may go through a circuit. An open circuit whose backoff
+// has elapsed moves to half-open and lets trial changes through.
+func (cb *CircuitBreaker) Allow(circuit *optimizerv1alpha1.CircuitBreakerStatus, now time.Time) (allowed, stateChanged bool) {
+	if circuit.State != optimizerv1alpha1.CircuitStateOpen {
+		return true, false
 	}
-
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
-	config.Status.ConsecutiveErrors = 0
-	config.Status.ConsecutiveSuccesses++
-	config.Status.TotalUpdatesApplied++
-
-	previousState := config.Status.CircuitState
-
-	if config.Status.CircuitState == optimizerv1alpha1.CircuitStateHalfOpen {
-		successThreshold := config.Spec.CircuitBreaker.SuccessThreshold
-		if successThreshold == 0 {
-			successThreshold = 3
-		}
-
-		if config.Status.ConsecutiveSuccesses >= successThreshold {
-			config.Status.CircuitState = optimizerv1alpha1.CircuitStateClosed
-			config.Status.ConsecutiveSuccesses = 0
-			klog.Infof("Circuit breaker closed for %s/%s after %d consecutive successes",
-				config.Namespace, config.Name, successThreshold)
-			return true
-		}
+	if circuit.RetryAt != nil && now.Before(circuit.RetryAt.Time) {
+		return false, false
 	}
 
-	now := metav1.NewTime(time.Now())
-	config.Status.LastUpdateTime = &now
-
-	return config.Status.CircuitState != previousState
+	circuit.State = optimizerv1alpha1.CircuitStateHalfOpen
+	circuit.ConsecutiveSuccesses = 0
+	klog.Infof("Circuit breaker entering half-open state for %s after %d trip(s)", CircuitName(circuit), circuit.Trips)
+	return true, true
 }
 
-func (cb *CircuitBreaker) RecordFailure(config *optimizerv1alpha1.OptimizerConfig, err error) (stateChanged bool) {
-	if config.Spec.CircuitBreaker == nil || !config.Spec.CircuitBreaker.Enabled {
+// RecordSuccess records a verified change. A half-open circuit closes after enough
+// consecutive verified changes.
+func (cb *CircuitBreaker) RecordSuccess(circuit *optimizerv1alpha1.CircuitBreakerStatus) (stateChanged bool) {
+	circuit.ConsecutiveErrors = 0
+	if circuit.State != optimizerv1alpha1.CircuitStateHalfOpen {
 		return false
 	}
 
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
-	config.Status.ConsecutiveSuccesses = 0
-	config.Status.ConsecutiveErrors++
-	config.Status.TotalUpdatesFailed++
-
-	previousState := config.Status.CircuitState
+	circuit.ConsecutiveSuccesses++
+	if circuit.ConsecutiveSuccesses < cb.successThreshold {
+		return false
+	}
+	klog.Infof("Circuit breaker closed for %s after %d consecutive successes", CircuitName(circuit), circuit.ConsecutiveSuccesses)
+	circuit.State = optimizerv1alpha1.CircuitStateClosed
+	circuit.ConsecutiveSuccesses = 0
+	circuit.Trips = 0
+	circuit.OpenedAt = nil
+	circuit.RetryAt = nil
+	circuit.LastError = ""
+	return true
+}
 
-	errorThreshold := config.Spec.CircuitBreaker.ErrorThreshold
-	if errorThreshold == 0 {
-		errorThreshold = 5
+// RecordFailure records a failed change. A closed circuit opens once the scope's error
+// threshold is reached; a half-open circuit opens again on its first failure with a
+// doubled backoff.
+func (cb *CircuitBreaker) RecordFailure(circuit *optimizerv1alpha1.CircuitBreakerStatus, err error, now time.Time) (stateChanged bool) {
+	circuit.ConsecutiveSuccesses = 0
+	circuit.ConsecutiveErrors++
+	if err != nil {
+		circuit.LastError = err.Error()
 	}
 
-	if config.Status.ConsecutiveErrors >= errorThreshold {
-		if config.Status.CircuitState != optimizerv1alpha1.CircuitStateOpen {
-			config.Status.CircuitState = optimizerv1alpha1.CircuitStateOpen
-			klog.Warningf("Circuit breaker opened for %s/%s after %d consecutive errors: %v",
-				config.Namespace, config.Name, config.Status.ConsecutiveErrors, err)
-			return true
+	switch circuit.State {
+	case optimizerv1alpha1.CircuitStateOpen:
+		return false
+	case optimizerv1alpha1.CircuitStateHalfOpen:
+	default:
+		circuit.State = optimizerv1alpha1.CircuitStateClosed
+		if circuit.ConsecutiveErrors < cb.threshold(circuit.Scope) {
+			return false
 		}
 	}
 
-	now := metav1.NewTime(time.Now())
-	config.Status.LastUpdateTime = &now
+	circuit.Trips++
+	backoff := cb.Backoff(circuit.Trips)
+	opened, retry := metav1.NewTime(now), metav1.NewTime(now.Add(backoff))
+	circuit.State = optimizerv1alpha1.CircuitStateOpen
+	circuit.OpenedAt = &opened
+	circuit.RetryAt = &retry
+	klog.Warningf("Circuit breaker opened for %s after %d consecutive errors, retrying in %v: %v",
+		CircuitName(circuit), circuit.ConsecutiveErrors, backoff, err)
+	return true
+}
 
-	return config.Status.CircuitState != previousState
+func (cb *CircuitBreaker) threshold(scope optimizerv1alpha1.CircuitScope) int {
+	if scope == optimizerv1alpha1.CircuitScopeNamespace {
+		return cb.namespaceErrorThreshold
+	}
+	return cb.errorThreshold
 }
 
 func (cb *CircuitBreaker) GetStateName(state optimizerv1alpha1.CircuitState) string {
@@ -130,3 +165,11 @@ func (cb *CircuitBreaker) GetStateName(state optimizerv1alpha1.CircuitState) str
 		return "Unknown"
 	}
 }
+
+// CircuitName identifies a circuit in logs and events
+func CircuitName(circuit *optimizerv1alpha1.CircuitBreakerStatus) string {
+	if circuit.Scope == optimizerv1alpha1.CircuitScopeNamespace {
+		return "namespace " + circuit.Namespace
+	}
+	return circuit.Namespace + "/" + circuit.Kind + "/" + circuit.Name
+}
@@ -0,0 +1,126 @@
+package safety
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+)
+
+func workloadCircuit() *optimizerv1alpha1.CircuitBreakerStatus {
+	return &optimizerv1alpha1.CircuitBreakerStatus{
+		Scope:     optimizerv1alpha1.CircuitScopeWorkload,
+		Namespace: "default",
+		Kind:      "Deployment",
+		Name:      "api",
+		State:     optimizerv1alpha1.CircuitStateClosed,
+	}
+}
+
+func TestCircuitBreaker_Defaults(t *testing.T) {
+	cb := NewCircuitBreaker(nil)
+	if cb.errorThreshold != DefaultErrorThreshold || cb.namespaceErrorThreshold != DefaultNamespaceErrorThreshold ||
+		cb.successThreshold != DefaultSuccessThreshold || cb.timeout != DefaultCircuitTimeout || cb.maxTimeout != DefaultMaxCircuitTimeout {
+		t.Errorf("Expected default settings, got %+v", cb)
+	}
+}
+
+func TestCircuitBreaker_Backoff(t *testing.T) {
+	cb := NewCircuitBreaker(&optimizerv1alpha1.CircuitBreakerConfig{Timeout: "5m", MaxTimeout: "30m"})
+
+	tests := []struct {
+		trips int
+		want  time.Duration
+	}{
+		{1, 5 * time.Minute},
+		{2, 10 * time.Minute},
+		{3, 20 * time.Minute},
+		{4, 30 * time.Minute},
+		{10, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := cb.Backoff(tt.trips); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.trips, got, tt.want)
+		}
+	}
+}
+
+func TestCircuitBreaker_OpensAtThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(&optimizerv1alpha1.CircuitBreakerConfig{ErrorThreshold: 3, Timeout: "5m"})
+	circuit := workloadCircuit()
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if cb.RecordFailure(circuit, errors.New("rollout failed"), now) {
+			t.Fatalf("Expected circuit to stay closed after %d failures", i+1)
+		}
+	}
+	if !cb.RecordFailure(circuit, errors.New("rollout failed"), now) {
+		t.Fatal("Expected circuit to open at the error threshold")
+	}
+	if circuit.State != optimizerv1alpha1.CircuitStateOpen || circuit.Trips != 1 {
+		t.Errorf("Expected open circuit with 1 trip, got %s with %d trips", circuit.State, circuit.Trips)
+	}
+	if !circuit.RetryAt.Time.Equal(now.Add(5 * time.Minute)) {
+		t.Errorf("Expected retry after 5m, got %v", circuit.RetryAt.Time.Sub(now))
+	}
+	if circuit.LastError != "rollout failed" {
+		t.Errorf("Expected last error to be recorded, got %q", circuit.LastError)
+	}
+	if allowed, _ := cb.Allow(circuit, now.Add(time.Minute)); allowed {
+		t.Error("Expected open circuit to block changes before its backoff elapsed")
+	}
+}
+
+func TestCircuitBreaker_NamespaceThreshold(t *testing.T) {
+	cb := NewCircuitBreaker(&optimizerv1alpha1.CircuitBreakerConfig{ErrorThreshold: 1, NamespaceErrorThreshold: 2})
+	circuit := &optimizerv1alpha1.CircuitBreakerStatus{Scope: optimizerv1alpha1.CircuitScopeNamespace, Namespace: "default"}
+
+	if cb.RecordFailure(circuit, errors.New("failed"), time.Now()) {
+		t.Error("Expected namespace circuit to use the namespace error threshold")
+	}
+	if !cb.RecordFailure(circuit, errors.New("failed"), time.Now()) {
+		t.Error("Expected namespace circuit to open after 2 failures")
+	}
+}
+
+func TestCircuitBreaker_HalfOpenReopensWithDoubledBackoff(t *testing.T) {
+	cb := NewCircuitBreaker(&optimizerv1alpha1.CircuitBreakerConfig{ErrorThreshold: 1, Timeout: "5m"})
+	circuit := workloadCircuit()
+	now := time.Now()
+
+	cb.RecordFailure(circuit, errors.New("failed"), now)
+
+	now = now.Add(5 * time.Minute)
+	allowed, changed := cb.Allow(circuit, now)
+	if !allowed || !changed || circuit.State != optimizerv1alpha1.CircuitStateHalfOpen {
+		t.Fatalf("Expected circuit to go half-open after its backoff, got allowed=%v state=%s", allowed, circuit.State)
+	}
+
+	if !cb.RecordFailure(circuit, errors.New("failed again"), now) {
+		t.Fatal("Expected a failed trial to reopen the circuit")
+	}
+	if circuit.Trips != 2 || !circuit.RetryAt.Time.Equal(now.Add(10*time.Minute)) {
+		t.Errorf("Expected second trip with a 10m backoff, got %d trips retrying after %v", circuit.Trips, circuit.RetryAt.Time.Sub(now))
+	}
+}
+
+func TestCircuitBreaker_HalfOpenCloses(t *testing.T) {
+	cb := NewCircuitBreaker(&optimizerv1alpha1.CircuitBreakerConfig{ErrorThreshold: 1, SuccessThreshold: 2, Timeout: "5m"})
+	circuit := workloadCircuit()
+	now := time.Now()
+
+	cb.RecordFailure(circuit, errors.New("failed"), now)
+	cb.Allow(circuit, now.Add(5*time.Minute))
+
+	if cb.RecordSuccess(circuit) {
+		t.Fatal("Expected circuit to stay half-open below the success threshold")
+	}
+	if !cb.RecordSuccess(circuit) {
+		t.Fatal("Expected circuit to close at the success threshold")
+	}
+	if circuit.State != optimizerv1alpha1.CircuitStateClosed || circuit.Trips != 0 || circuit.RetryAt != nil {
+		t.Errorf("Expected closed circuit with its backoff reset, got %+v", circuit)
+	}
+}
@@ -0,0 +1,370 @@
+package safety
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// NodeFitRequest describes the new requests of one container of a workload
+type NodeFitRequest struct {
+	Namespace     string
+	Kind          string
+	Name          string
+	ContainerName string
+	CPU           int64 // millicores
+	Memory        int64 // bytes
+}
+
+// NodeFitResult reports whether a workload's pods still schedule with the new requests
+type NodeFitResult struct {
+	// Fits is true when the pod fits the allocatable resources of a node it can run on
+	// (every such node for DaemonSets)
+	Fits bool
+
+	// CPU and Memory are the largest container requests that fit, in millicores and bytes.
+	// They equal the requested values when Fits is true.
+	CPU    int64
+	Memory int64
+
+	// EligibleNodes passed the node selector, affinity and taint checks
+	EligibleNodes int
+	// FeasibleNodes are eligible nodes whose allocatable fits the new pod size
+	FeasibleNodes int
+
+	Message string
+}
+
+// NodeFitChecker simulates scheduler fit of a resized pod against node allocatable
+type NodeFitChecker struct {
+	kubeClient kubernetes.Interface
+}
+
+func NewNodeFitChecker(kubeClient kubernetes.Interface) *NodeFitChecker {
+	return &NodeFitChecker{
+		kubeClient: kubeClient,
+	}
+}
+
+// CheckFit checks whether the workload's pod, with the container resized to the requested
+// CPU and memory, fits on a node it may be scheduled to. Like the scheduler, the pod's
+// request is the larger of its init containers and the sum of its containers, plus the
+// pod overhead. Nodes are filtered by readiness, cordoning, node selector, required node
+// affinity and NoSchedule/NoExecute taints.
+// When the pod does not fit, the result carries the largest requests that would.
+func (n *NodeFitChecker) CheckFit(ctx context.Context, req NodeFitRequest) (*NodeFitResult, error) {
+	podSpec, err := n.getPodSpec(ctx, req.Namespace, req.Kind, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pod template: %v", err)
+	}
+
+	others, initPeak, found := podRequests(podSpec, req.ContainerName)
+	if !found {
+		return nil, fmt.Errorf("container %s not found in %s/%s", req.ContainerName, req.Kind, req.Name)
+	}
+
+	nodeList, err := n.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	result := &NodeFitResult{CPU: req.CPU, Memory: req.Memory}
+	if len(nodeList.Items) == 0 {
+		result.Fits = true
+		result.Message = "No nodes listed, skipping node fit check"
+		return result, nil
+	}
+
+	daemonSet := req.Kind == "DaemonSet"
+	var bestScore float64
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if !isNodeEligible(node, podSpec) {
+			continue
+		}
+		result.EligibleNodes++
+
+		allocatable := node.Status.Allocatable
+		allowedCPU := allowedRequest(allocatable.Cpu().MilliValue(), others.cpu, initPeak.cpu)
+		allowedMemory := allowedRequest(allocatable.Memory().Value(), others.memory, initPeak.memory)
+		if req.CPU <= allowedCPU && req.Memory <= allowedMemory {
+			result.FeasibleNodes++
+		}
+
+		cpu, memory := min(req.CPU, allowedCPU), min(req.Memory, allowedMemory)
+		if daemonSet {
+			// A DaemonSet pod must fit every node it runs on, so clamp to the smallest
+			if result.EligibleNodes == 1 || cpu < result.CPU {
+				result.CPU = cpu
+			}
+			if result.EligibleNodes == 1 || memory < result.Memory {
+				result.Memory = memory
+			}
+			continue
+		}
+
+		// Otherwise clamp to the node that keeps the most of the requested size
+		score := fitRatio(cpu, req.CPU) + fitRatio(memory, req.Memory)
+		if result.EligibleNodes == 1 || score > bestScore {
+			bestScore = score
+			result.CPU, result.Memory = cpu, memory
+		}
+	}
+
+	fits := result.FeasibleNodes > 0
+	if daemonSet {
+		fits = result.FeasibleNodes == result.EligibleNodes
+	}
+
+	switch {
+	case result.EligibleNodes == 0:
+		result.CPU, result.Memory = 0, 0
+		result.Message = fmt.Sprintf("No node matches the node selector, affinity and tolerations of %s/%s", req.Kind, req.Name)
+	case fits:
+		result.Fits = true
+		result.CPU, result.Memory = req.CPU, req.Memory
+		result.Message = fmt.Sprintf("Pod fits %d of %d eligible nodes", result.FeasibleNodes, result.EligibleNodes)
+	default:
+		result.CPU, result.Memory = max(result.CPU, 0), max(result.Memory, 0)
+		result.Message = fmt.Sprintf("Requests cpu=%dm memory=%d fit %d of %d eligible nodes; largest fit is cpu=%dm memory=%d",
+			req.CPU, req.Memory, result.FeasibleNodes, result.EligibleNodes, result.CPU, result.Memory)
+	}
+
+	if !result.Fits {
+		klog.V(3).Infof("Node fit check failed for %s/%s/%s container=%s: %s",
+			req.Namespace, req.Kind, req.Name, req.ContainerName, result.Message)
+	}
+	return result, nil
+}
+
+// podResources holds CPU millicores and memory bytes
+type podResources struct {
+	cpu    int64
+	memory int64
+}
+
+func (p *podResources) add(requests corev1.ResourceList) {
+	p.cpu += requests.Cpu().MilliValue()
+	p.memory += requests.Memory().Value()
+}
+
+// podRequests splits the pod's effective request around the named container: others is
+// what the pod requests next to it while running - the other containers, sidecar init
+// containers and the pod overhead - and initPeak is the largest request of the init phase,
+// overhead included. found is false when the pod has no such container.
+func podRequests(podSpec *corev1.PodSpec, containerName string) (others, initPeak podResources, found bool) {
+	// Sidecars (init containers that restart always) keep running next to the init
+	// containers after them and next to the app containers
+	var sidecars podResources
+	for _, container := range podSpec.InitContainers {
+		step := sidecars
+		step.add(container.Resources.Requests)
+		if container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			sidecars = step
+		}
+		initPeak.cpu, initPeak.memory = max(initPeak.cpu, step.cpu), max(initPeak.memory, step.memory)
+	}
+
+	others = sidecars
+	for _, container := range podSpec.Containers {
+		if container.Name == containerName {
+			found = true
+			continue
+		}
+		others.add(container.Resources.Requests)
+	}
+
+	others.add(podSpec.Overhead)
+	initPeak.add(podSpec.Overhead)
+	return others, initPeak, found
+}
+
+// allowedRequest returns the largest container request that fits the allocatable next to
+// the others, or -1 when the init phase alone exceeds it
+func allowedRequest(allocatable, others, initPeak int64) int64 {
+	if initPeak > allocatable {
+		return -1
+	}
+	return allocatable - others
+}
+
+func (n *NodeFitChecker) getPodSpec(ctx context.Context, namespace, kind, name string) (*corev1.PodSpec, error) {
+	switch kind {
+	case "Deployment":
+		deploy, err := n.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &deploy.Spec.Template.Spec, nil
+
+	case "StatefulSet":
+		sts, err := n.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &sts.Spec.Template.Spec, nil
+
+	case "DaemonSet":
+		ds, err := n.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return &ds.Spec.Template.Spec, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s", kind)
+	}
+}
+
+// fitRatio returns the share of the requested amount that was kept
+func fitRatio(kept, requested int64) float64 {
+	if requested <= 0 {
+		return 1
+	}
+	if kept <= 0 {
+		return 0
+	}
+	return float64(kept) / float64(requested)
+}
+
+// isNodeEligible reports whether the scheduler could place the pod on the node,
+// ignoring resources
+func isNodeEligible(node *corev1.Node, podSpec *corev1.PodSpec) bool {
+	if node.Spec.Unschedulable || !isNodeReady(node) {
+		return false
+	}
+
+	for key, value := range podSpec.NodeSelector {
+		if node.Labels[key] != value {
+			return false
+		}
+	}
+
+	if affinity := podSpec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil &&
+			!matchesNodeSelectorTerms(node, required.NodeSelectorTerms) {
+			return false
+		}
+	}
+
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(podSpec.Tolerations, taint) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeSelectorTerms evaluates required node affinity; terms are ORed and the
+// label expressions and field requirements within a term are ANDed
+func matchesNodeSelectorTerms(node *corev1.Node, terms []corev1.NodeSelectorTerm) bool {
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		matched := true
+		for _, expr := range term.MatchExpressions {
+			if !matchesNodeSelectorRequirement(node.Labels, expr) {
+				matched = false
+				break
+			}
+		}
+		for _, field := range term.MatchFields {
+			if !matched {
+				break
+			}
+			matched = matchesNodeFieldRequirement(node, field)
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeFieldRequirement evaluates a field requirement; like the scheduler, only
+// metadata.name with In or NotIn is supported
+func matchesNodeFieldRequirement(node *corev1.Node, field corev1.NodeSelectorRequirement) bool {
+	if field.Key != metav1.ObjectNameField {
+		return false
+	}
+	switch field.Operator {
+	case corev1.NodeSelectorOpIn:
+		return containsValue(field.Values, node.Name)
+	case corev1.NodeSelectorOpNotIn:
+		return !containsValue(field.Values, node.Name)
+	default:
+		return false
+	}
+}
+
+func matchesNodeSelectorRequirement(labels map[string]string, expr corev1.NodeSelectorRequirement) bool {
+	value, exists := labels[expr.Key]
+	switch expr.Operator {
+	case corev1.NodeSelectorOpIn:
+		return exists && containsValue(expr.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !exists || !containsValue(expr.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return exists
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		// Like the scheduler, compare the label and the single value as integers; a
+		// missing or non-integer label never matches
+		if !exists || len(expr.Values) != 1 {
+			return false
+		}
+		labelValue, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		bound, err := strconv.ParseInt(expr.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if expr.Operator == corev1.NodeSelectorOpGt {
+			return labelValue > bound
+		}
+		return labelValue < bound
+	default:
+		return false
+	}
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package safety
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const gi = 1024 * 1024 * 1024
+
+func TestNodeFitChecker_Fits(t *testing.T) {
+	deploy := createFitTestDeployment(nil, nil)
+	client := fake.NewSimpleClientset(deploy,
+		createTestNode("small", "2", "4Gi", nil, nil),
+		createTestNode("large", "8", "32Gi", nil, nil))
+	checker := NewNodeFitChecker(client)
+
+	result, err := checker.CheckFit(context.Background(), NodeFitRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 4000, Memory: 16 * gi,
+	})
+	if err != nil {
+		t.Fatalf("CheckFit failed: %v", err)
+	}
+
+	if !result.Fits {
+		t.Errorf("Expected pod to fit the large node: %s", result.Message)
+	}
+	if result.FeasibleNodes != 1 || result.EligibleNodes != 2 {
+		t.Errorf("Expected 1 of 2 nodes feasible, got %d of %d", result.FeasibleNodes, result.EligibleNodes)
+	}
+}
+
+func TestNodeFitChecker_ClampsToLargestNode(t *testing.T) {
+	deploy := createFitTestDeployment(nil, nil)
+	client := fake.NewSimpleClientset(deploy,
+		createTestNode("small", "2", "4Gi", nil, nil),
+		createTestNode("large", "8", "32Gi", nil, nil))
+	checker := NewNodeFitChecker(client)
+
+	result, err := checker.CheckFit(context.Background(), NodeFitRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 4000, Memory: 64 * gi,
+	})
+	if err != nil {
+		t.Fatalf("CheckFit failed: %v", err)
+	}
+
+	if result.Fits {
+		t.Fatal("Expected 64Gi not to fit any node")
+	}
+	// The sidecar requests 1Gi, leaving 31Gi on the large node
+	if result.CPU != 4000 || result.Memory != 31*gi {
+		t.Errorf("Expected clamp to cpu=4000m memory=31Gi, got cpu=%dm memory=%d", result.CPU, result.Memory)
+	}
+}
+
+func TestNodeFitChecker_TaintsAndSelectors(t *testing.T) {
+	gpuTaint := []corev1.Taint{{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}}
+	deploy := createFitTestDeployment(map[string]string{"pool": "general"}, nil)
+	client := fake.NewSimpleClientset(deploy,
+		createTestNode("general", "2", "4Gi", map[string]string{"pool": "general"}, nil),
+		createTestNode("gpu", "8", "32Gi", map[string]string{"pool": "general"}, gpuTaint),
+		createTestNode("batch", "8", "32Gi", map[string]string{"pool": "batch"}, nil))
+	checker := NewNodeFitChecker(client)
+
+	req := NodeFitRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 1000, Memory: 8 * gi,
+	}
+	result, err := checker.CheckFit(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CheckFit failed: %v", err)
+	}
+	if result.Fits || result.EligibleNodes != 1 {
+		t.Errorf("Expected only the untainted general node to be eligible and too small, got fits=%v eligible=%d",
+			result.Fits, result.EligibleNodes)
+	}
+
+	// Tolerating the taint makes the larger node eligible
+	deploy.Spec.Template.Spec.Tolerations = []corev1.Toleration{{
+		Key: "gpu", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule,
+	}}
+	if _, err := client.AppsV1().Deployments("default").Update(context.Background(), deploy, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+	result, err = checker.CheckFit(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CheckFit failed: %v", err)
+	}
+	if !result.Fits || result.EligibleNodes != 2 {
+		t.Errorf("Expected pod to fit the tolerated node, got fits=%v eligible=%d", result.Fits, result.EligibleNodes)
+	}
+}
+
+func TestNodeFitChecker_NodeAffinity(t *testing.T) {
+	affinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+			NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+				MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"},
+				}},
+			}},
+		},
+	}}
+	deploy := createFitTestDeployment(nil, affinity)
+	client := fake.NewSimpleClientset(deploy,
+		createTestNode("zone-b", "8", "32Gi", map[string]string{"zone": "b"}, nil))
+	checker := NewNodeFitChecker(client)
+
+	result, err := checker.CheckFit(context.Background(), NodeFitRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 500, Memory: gi,
+	})
+	if err != nil {
+		t.Fatalf("CheckFit failed: %v", err)
+	}
+	if result.Fits || result.EligibleNodes != 0 {
+		t.Errorf("Expected no eligible nodes outside zone a, got fits=%v eligible=%d", result.Fits, result.EligibleNodes)
+	}
+}
+
+func TestNodeFitChecker_InitContainersAndOverhead(t *testing.T) {
+	sidecar := corev1.ContainerRestartPolicyAlways
+	deploy := createFitTestDeployment(nil, nil)
+	deploy.Spec.Template.Spec.Overhead = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")}
+	deploy.Spec.Template.Spec.InitContainers = []corev1.Container{
+		{
+			Name: "proxy", RestartPolicy: &sidecar,
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}},
+		},
+		{
+			Name:      "migrate",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("6Gi")}},
+		},
+	}
+	client := fake.NewSimpleClientset(deploy,
+		createTestNode("small", "8", "6Gi", nil, nil),
+		createTestNode("large", "8", "32Gi", nil, nil))
+	checker := NewNodeFitChecker(client)
+
+	// The small node would hold a 2Gi app container, but not the init phase
+	result, err := checker.CheckFit(context.Background(), NodeFitRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 500, Memory: 2 * gi,
+	})
+	if err != nil {
+		t.Fatalf("CheckFit failed: %v", err)
+	}
+	if !result.Fits || result.FeasibleNodes != 1 {
+		t.Errorf("Expected the pod to fit only the large node, got fits=%v feasible=%d", result.Fits, result.FeasibleNodes)
+	}
+
+	result, err = checker.CheckFit(context.Background(), NodeFitRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 500, Memory: 64 * gi,
+	})
+	if err != nil {
+		t.Fatalf("CheckFit failed: %v", err)
+	}
+	// The sidecar container, the proxy sidecar and the overhead leave 29.5Gi on the large node
+	if result.Fits || result.Memory != 59*gi/2 {
+		t.Errorf("Expected clamp to memory=29.5Gi, got fits=%v memory=%d", result.Fits, result.Memory)
+	}
+}
+
+func TestMatchesNodeSelectorTerms_MatchFields(t *testing.T) {
+	node := createTestNode("node-a", "8", "32Gi", map[string]string{"zone": "a"}, nil)
+	tests := []struct {
+		name string
+		term corev1.NodeSelectorTerm
+		want bool
+	}{
+		{"name in", corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+			{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a"}},
+		}}, true},
+		{"name not in", corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+			{Key: "metadata.name", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"node-a"}},
+		}}, false},
+		{"fields and expressions ANDed", corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"b"}}},
+			MatchFields:      []corev1.NodeSelectorRequirement{{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a"}}},
+		}, false},
+		{"unsupported field", corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+			{Key: "spec.providerID", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-a"}},
+		}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesNodeSelectorTerms(node, []corev1.NodeSelectorTerm{tt.term}); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestMatchesNodeSelectorRequirement_GtLt(t *testing.T) {
+	labels := map[string]string{"cpu-generation": "5", "zone": "a"}
+	tests := []struct {
+		name string
+		expr corev1.NodeSelectorRequirement
+		want bool
+	}{
+		{"greater than lower bound", corev1.NodeSelectorRequirement{Key: "cpu-generation", Operator: corev1.NodeSelectorOpGt, Values: []string{"4"}}, true},
+		{"not greater than itself", corev1.NodeSelectorRequirement{Key: "cpu-generation", Operator: corev1.NodeSelectorOpGt, Values: []string{"5"}}, false},
+		{"less than upper bound", corev1.NodeSelectorRequirement{Key: "cpu-generation", Operator: corev1.NodeSelectorOpLt, Values: []string{"6"}}, true},
+		{"not less than lower value", corev1.NodeSelectorRequirement{Key: "cpu-generation", Operator: corev1.NodeSelectorOpLt, Values: []string{"3"}}, false},
+		{"missing label", corev1.NodeSelectorRequirement{Key: "gpu-count", Operator: corev1.NodeSelectorOpGt, Values: []string{"0"}}, false},
+		{"non-integer label", corev1.NodeSelectorRequirement{Key: "zone", Operator: corev1.NodeSelectorOpLt, Values: []string{"9"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesNodeSelectorRequirement(labels, tt.expr); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func createFitTestDeployment(nodeSelector map[string]string, affinity *corev1.Affinity) *appsv1.Deployment {
+	deploy := createTestDeployment("api", "default", 1, 1, map[string]string{"app": "api"})
+	deploy.Spec.Template.Spec = corev1.PodSpec{
+		NodeSelector: nodeSelector,
+		Affinity:     affinity,
+		Containers: []corev1.Container{
+			{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("500m"),
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			},
+			{
+				Name: "sidecar",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceMemory: resource.MustParse("1Gi"),
+				}},
+			},
+		},
+	}
+	return deploy
+}
+
+func createTestNode(name, cpu, memory string, labels map[string]string, taints []corev1.Taint) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
@@ -180,7 +180,7 @@ func (d *OOMDetector) checkContainerForOOM(status corev1.ContainerStatus, pod *c
 	}
 
 	// Calculate recommended boost based on restart count
-	boost := calculateMemoryBoost(status.RestartCount)
+	boost := CalculateMemoryBoost(status.RestartCount)
 
 	klog.V(4).Infof("OOM detected: pod=%s/%s container=%s restarts=%d lastOOM=%v",
 		pod.Namespace, pod.Name, status.Name, status.RestartCount, lastOOMTime)
@@ -195,8 +195,8 @@ func (d *OOMDetector) checkContainerForOOM(status corev1.ContainerStatus, pod *c
 	}
 }
 
-// calculateMemoryBoost determines how much to increase memory based on OOM frequency
-func calculateMemoryBoost(restartCount int32) float64 {
+// CalculateMemoryBoost determines how much to increase memory based on OOM frequency
+func CalculateMemoryBoost(restartCount int32) float64 {
 	switch {
 	case restartCount >= 10:
 		return 2.0 // Double memory for very frequent OOMs
@@ -246,11 +246,16 @@ func (d *OOMDetector) updateOOMHistory(namespace, workloadName, workloadKind str
 			existing.RestartCount = c.RestartCount
 			existing.RecommendedBoost = c.RecommendedBoost
 		}
-		history.TotalOOMs += c.OOMCount
 		if c.LastOOMTime.After(history.LastOOMTime) {
 			history.LastOOMTime = c.LastOOMTime
 		}
 	}
+
+	// Recompute the total so repeated scans of the same pods do not inflate it
+	history.TotalOOMs = 0
+	for _, c := range history.ContainerOOMs {
+		history.TotalOOMs += c.OOMCount
+	}
 }
 
 // calculatePriority determines how urgent the OOM situation is
@@ -297,16 +302,40 @@ func (d *OOMDetector) getRecommendedAction(result *OOMCheckResult) string {
 	}
 }
 
-// GetOOMHistory returns the OOM history for a specific workload
+// GetOOMHistory returns a copy of the OOM history for a specific workload
 func (d *OOMDetector) GetOOMHistory(namespace, workloadName string) *OOMHistory {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
 
 	key := namespace + "/" + workloadName
-	if history, ok := d.oomHistory[key]; ok {
-		return history
+	history, ok := d.oomHistory[key]
+	if !ok {
+		return nil
 	}
-	return nil
+
+	historyCopy := *history
+	historyCopy.ContainerOOMs = make(map[string]*ContainerOOMInfo, len(history.ContainerOOMs))
+	for name, c := range history.ContainerOOMs {
+		containerCopy := *c
+		historyCopy.ContainerOOMs[name] = &containerCopy
+	}
+	return &historyCopy
+}
+
+// GetOOMPriority returns how urgently a workload needs memory based on its OOM history
+func (d *OOMDetector) GetOOMPriority(namespace, workloadName string) OOMPriority {
+	history := d.GetOOMHistory(namespace, workloadName)
+	if history == nil {
+		return OOMPriorityNone
+	}
+
+	return d.calculatePriority(&OOMCheckResult{
+		HasOOMHistory: true,
+		WorkloadName:  workloadName,
+		Namespace:     namespace,
+		TotalOOMCount: history.TotalOOMs,
+		LastOOMTime:   history.LastOOMTime,
+	})
 }
 
 // GetMemoryBoostFactor returns the recommended memory multiplier for a container
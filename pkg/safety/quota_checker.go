@@ -0,0 +1,588 @@
+package safety
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// QuotaRequest describes the new requests of one container of a workload
+type QuotaRequest struct {
+	Namespace     string
+	Kind          string
+	Name          string
+	ContainerName string
+	CPU           int64 // millicores
+	Memory        int64 // bytes
+
+	// EphemeralStorage is the new ephemeral-storage request in bytes, 0 when it is not changed
+	EphemeralStorage int64
+
+	// CPULimit and MemoryLimit are the new limits when they move with the requests (for
+	// example to keep a Guaranteed QoS class), 0 when the limits are not changed
+	CPULimit    int64
+	MemoryLimit int64
+
+	// Reserved is the quota usage of changes approved earlier in the same pass; it is
+	// taken off the headroom the quotas' status reports. Nil when there is none.
+	Reserved QuotaReservations
+}
+
+// QuotaReservations holds the quota usage of approved changes that ResourceQuota status
+// does not show yet, by namespace, quota and quota resource (millicores for CPU)
+type QuotaReservations map[string]map[string]map[corev1.ResourceName]int64
+
+// Reserve adds the rollout peak of a change that passed the check with the result's values
+func (r QuotaReservations) Reserve(namespace string, result *QuotaResult) {
+	for quota, usage := range result.Usage {
+		if r[namespace] == nil {
+			r[namespace] = make(map[string]map[corev1.ResourceName]int64)
+		}
+		if r[namespace][quota] == nil {
+			r[namespace][quota] = make(map[corev1.ResourceName]int64)
+		}
+		for name, value := range usage {
+			r[namespace][quota][name] += value
+		}
+	}
+}
+
+func (r QuotaReservations) reserved(namespace, quota string, name corev1.ResourceName) int64 {
+	return r[namespace][quota][name]
+}
+
+// QuotaResult reports whether a change passes the namespace's LimitRanges and ResourceQuotas
+type QuotaResult struct {
+	// Fits is true when the requested values pass every LimitRange and quota,
+	// including the headroom needed for surge pods during the rollout
+	Fits bool
+
+	// Feasible is false when no request size can pass, for example when a LimitRange
+	// minimum exceeds its maximum or the quota has no room for the surge pods at all
+	Feasible bool
+
+	// CPU, Memory and EphemeralStorage are the requested values moved into the allowed
+	// range, in millicores and bytes. They equal the requested values when Fits is true.
+	// Limits that move with the requests move by the same amount.
+	CPU              int64
+	Memory           int64
+	EphemeralStorage int64
+
+	// Violations lists each constraint the requested values break
+	Violations []string
+
+	// Usage is the extra quota usage at the peak of a rollout with the result's values,
+	// by quota and quota resource, for QuotaReservations.Reserve
+	Usage map[string]map[corev1.ResourceName]int64
+
+	Message string
+}
+
+// QuotaChecker validates resource changes against namespace ResourceQuotas and LimitRanges
+type QuotaChecker struct {
+	kubeClient kubernetes.Interface
+}
+
+func NewQuotaChecker(kubeClient kubernetes.Interface) *QuotaChecker {
+	return &QuotaChecker{
+		kubeClient: kubeClient,
+	}
+}
+
+// bounds is the allowed range for one container request
+type bounds struct {
+	lo, hi int64
+}
+
+func unbounded() bounds {
+	return bounds{lo: 0, hi: math.MaxInt64}
+}
+
+func (b *bounds) atLeast(v int64) {
+	b.lo = max(b.lo, v)
+}
+
+func (b *bounds) atMost(v int64) {
+	b.hi = min(b.hi, v)
+}
+
+func (b *bounds) intersect(o bounds) {
+	b.atLeast(o.lo)
+	b.atMost(o.hi)
+}
+
+func (b bounds) contains(v int64) bool {
+	return v >= b.lo && v <= b.hi
+}
+
+func (b bounds) clamp(v int64) int64 {
+	return max(b.lo, min(v, b.hi))
+}
+
+// workloadShape is what a rollout of the workload needs from the namespace
+type workloadShape struct {
+	podSpec  *corev1.PodSpec
+	replicas int64
+	surge    int64
+}
+
+// quotaDimension is one resource of the container checked against LimitRanges and quotas
+type quotaDimension struct {
+	resource      corev1.ResourceName
+	requestQuotas []corev1.ResourceName // quota resources counting requests
+	limitQuota    corev1.ResourceName   // quota resource counting limits
+	milli         bool
+
+	request      int64 // requested value
+	current      int64 // current request
+	other        int64 // requests of the other containers of the pod
+	limit        int64 // limit after the change, 0 when there is none
+	currentLimit int64 // current limit, 0 when there is none
+	otherLimits  int64 // limits of the other containers of the pod
+	podLimit     int64 // sum of the current limits over the pod's containers
+
+	// limitFollows is set when the limit moves with the request, keeping limit - request fixed
+	limitFollows bool
+
+	allowed bounds // allowed range for the request
+}
+
+// limitOffset is how far the limit sits above the request when it moves with the request
+func (d *quotaDimension) limitOffset() int64 {
+	return d.limit - d.request
+}
+
+// limitAtMost bounds the request so a limit that moves with it stays at or below v
+func (d *quotaDimension) limitAtMost(b *bounds, v int64) {
+	if d.limitFollows {
+		b.atMost(v - d.limitOffset())
+	}
+}
+
+func (d *quotaDimension) changed() bool {
+	return d.request != d.current || (d.limitFollows && d.limit != d.currentLimit)
+}
+
+func newQuotaDimension(resourceName corev1.ResourceName, milli bool, podSpec *corev1.PodSpec,
+	containerName string, request, limit int64) *quotaDimension {
+	d := &quotaDimension{
+		resource:      resourceName,
+		requestQuotas: []corev1.ResourceName{corev1.ResourceName("requests." + string(resourceName)), resourceName},
+		limitQuota:    corev1.ResourceName("limits." + string(resourceName)),
+		milli:         milli,
+		request:       request,
+		allowed:       unbounded(),
+	}
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		var req, lim int64
+		if q, ok := c.Resources.Requests[resourceName]; ok {
+			req = quantityValue(q, milli)
+		}
+		if q, ok := c.Resources.Limits[resourceName]; ok {
+			lim = quantityValue(q, milli)
+		}
+		d.podLimit += lim
+		if c.Name == containerName {
+			d.current, d.currentLimit = req, lim
+			continue
+		}
+		d.other += req
+		d.otherLimits += lim
+	}
+	if request == 0 {
+		// Not changed by this recommendation
+		d.request = d.current
+	}
+	d.limit = d.currentLimit
+	if limit > 0 {
+		d.limit = limit
+		d.limitFollows = true
+	}
+	return d
+}
+
+// CheckQuota checks the container's new requests against Container and Pod LimitRanges
+// and against every ResourceQuota that applies to the workload's pods, for CPU, memory
+// and ephemeral storage. Quota headroom must cover the peak of the rollout, while surge
+// pods run next to the old ones. Limits that move with the requests are checked too.
+func (q *QuotaChecker) CheckQuota(ctx context.Context, req QuotaRequest) (*QuotaResult, error) {
+	shape, err := q.getWorkloadShape(ctx, req.Namespace, req.Kind, req.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get workload: %v", err)
+	}
+
+	found := false
+	for i := range shape.podSpec.Containers {
+		if shape.podSpec.Containers[i].Name == req.ContainerName {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("container %s not found in %s/%s", req.ContainerName, req.Kind, req.Name)
+	}
+
+	cpu := newQuotaDimension(corev1.ResourceCPU, true, shape.podSpec, req.ContainerName, req.CPU, req.CPULimit)
+	memory := newQuotaDimension(corev1.ResourceMemory, false, shape.podSpec, req.ContainerName, req.Memory, req.MemoryLimit)
+	storage := newQuotaDimension(corev1.ResourceEphemeralStorage, false, shape.podSpec, req.ContainerName, req.EphemeralStorage, 0)
+	dimensions := []*quotaDimension{cpu, memory, storage}
+
+	var violations, blockers []string
+
+	limitRanges, err := q.kubeClient.CoreV1().LimitRanges(req.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list LimitRanges: %v", err)
+	}
+	for _, lr := range limitRanges.Items {
+		violated := false
+		for _, d := range dimensions {
+			b := unbounded()
+			for _, item := range lr.Spec.Limits {
+				switch item.Type {
+				case corev1.LimitTypeContainer:
+					applyLimitRangeItem(&b, item, d, 0, 0)
+				case corev1.LimitTypePod:
+					// Pod limits apply to the sum over containers
+					applyLimitRangeItem(&b, item, d, d.other, d.otherLimits)
+				}
+			}
+			if !b.contains(d.request) {
+				violated = true
+			}
+			d.allowed.intersect(b)
+		}
+		if violated {
+			violations = append(violations, fmt.Sprintf("LimitRange %s", lr.Name))
+		}
+	}
+
+	quotas, err := q.kubeClient.CoreV1().ResourceQuotas(req.Namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ResourceQuotas: %v", err)
+	}
+	// Headroom left by the quota's usage and by the changes approved before this one
+	headroomOf := func(quota *corev1.ResourceQuota, name corev1.ResourceName, milli bool) (int64, bool) {
+		headroom, ok := quotaHeadroom(quota, name, milli)
+		return headroom - req.Reserved.reserved(req.Namespace, quota.Name, name), ok
+	}
+	var applied []*corev1.ResourceQuota
+	for i := range quotas.Items {
+		quota := &quotas.Items[i]
+		if !quotaApplies(quota, shape.podSpec) {
+			continue
+		}
+		applied = append(applied, quota)
+
+		violated := false
+		for _, d := range dimensions {
+			b := unbounded()
+			if d.changed() {
+				for _, name := range d.requestQuotas {
+					if headroom, ok := headroomOf(quota, name, d.milli); ok {
+						b.atMost(d.current + maxQuotaDelta(headroom, shape, d.other+d.current))
+					}
+				}
+				if headroom, ok := headroomOf(quota, d.limitQuota, d.milli); ok {
+					d.limitAtMost(&b, d.currentLimit+maxQuotaDelta(headroom, shape, d.podLimit))
+				}
+			}
+			if !b.contains(d.request) {
+				violated = true
+			}
+			d.allowed.intersect(b)
+
+			// Surge pods need room for their limits whatever the new requests are
+			if headroom, ok := headroomOf(quota, d.limitQuota, d.milli); ok && shape.surge > 0 && headroom < shape.surge*d.podLimit {
+				blockers = append(blockers, fmt.Sprintf("ResourceQuota %s has no %s headroom for surge pods", quota.Name, d.limitQuota))
+			}
+		}
+		if violated {
+			violations = append(violations, fmt.Sprintf("ResourceQuota %s", quota.Name))
+		}
+
+		// Surge pods need room in the pod count whatever the new requests are
+		if shape.surge == 0 {
+			continue
+		}
+		if headroom, ok := headroomOf(quota, corev1.ResourcePods, false); ok && headroom < shape.surge {
+			blockers = append(blockers, fmt.Sprintf("ResourceQuota %s has room for %d of %d surge pods", quota.Name, max(headroom, 0), shape.surge))
+		}
+	}
+
+	result := &QuotaResult{
+		CPU:        cpu.allowed.clamp(cpu.request),
+		Memory:     memory.allowed.clamp(memory.request),
+		Violations: append(violations, blockers...),
+		Feasible:   len(blockers) == 0,
+	}
+	if req.EphemeralStorage > 0 {
+		result.EphemeralStorage = storage.allowed.clamp(storage.request)
+	}
+	result.Fits = result.Feasible
+	for _, d := range dimensions {
+		if d.allowed.lo > d.allowed.hi {
+			result.Feasible, result.Fits = false, false
+		}
+		if d.allowed.clamp(d.request) != d.request {
+			result.Fits = false
+		}
+	}
+
+	result.Usage = rolloutUsage(applied, shape, dimensions)
+
+	switch {
+	case result.Fits:
+		result.Message = fmt.Sprintf("Requests fit %d LimitRanges and %d ResourceQuotas", len(limitRanges.Items), len(quotas.Items))
+	case len(blockers) > 0:
+		result.Message = strings.Join(blockers, "; ")
+	case !result.Feasible:
+		result.Message = fmt.Sprintf("No request size satisfies %s", strings.Join(violations, ", "))
+	default:
+		result.Message = fmt.Sprintf("Requests %s violate %s; allowed is %s",
+			describeRequests(dimensions, func(d *quotaDimension) int64 { return d.request }),
+			strings.Join(violations, ", "),
+			describeRequests(dimensions, func(d *quotaDimension) int64 { return d.allowed.clamp(d.request) }))
+	}
+
+	if !result.Fits {
+		klog.V(3).Infof("Quota check failed for %s/%s/%s container=%s: %s",
+			req.Namespace, req.Kind, req.Name, req.ContainerName, result.Message)
+	}
+	return result, nil
+}
+
+// describeRequests formats the changed requests of the checked resources
+func describeRequests(dimensions []*quotaDimension, value func(d *quotaDimension) int64) string {
+	var parts []string
+	for _, d := range dimensions {
+		if d.resource == corev1.ResourceEphemeralStorage && !d.changed() {
+			continue
+		}
+		if d.milli {
+			parts = append(parts, fmt.Sprintf("%s=%dm", d.resource, value(d)))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s=%d", d.resource, value(d)))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (q *QuotaChecker) getWorkloadShape(ctx context.Context, namespace, kind, name string) (*workloadShape, error) {
+	switch kind {
+	case "Deployment":
+		deploy, err := q.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		replicas := int64(1)
+		if deploy.Spec.Replicas != nil {
+			replicas = int64(*deploy.Spec.Replicas)
+		}
+		return &workloadShape{
+			podSpec:  &deploy.Spec.Template.Spec,
+			replicas: replicas,
+			surge:    deploymentSurge(deploy, replicas),
+		}, nil
+
+	case "StatefulSet":
+		sts, err := q.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		replicas := int64(1)
+		if sts.Spec.Replicas != nil {
+			replicas = int64(*sts.Spec.Replicas)
+		}
+		// StatefulSet pods are replaced in place, so there is no surge
+		return &workloadShape{podSpec: &sts.Spec.Template.Spec, replicas: replicas}, nil
+
+	case "DaemonSet":
+		ds, err := q.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		replicas := int64(ds.Status.DesiredNumberScheduled)
+		var surge int64
+		if rolling := ds.Spec.UpdateStrategy.RollingUpdate; rolling != nil && rolling.MaxSurge != nil {
+			surge = scaledSurge(rolling.MaxSurge, replicas)
+		}
+		return &workloadShape{podSpec: &ds.Spec.Template.Spec, replicas: replicas, surge: surge}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s", kind)
+	}
+}
+
+// deploymentSurge returns how many extra pods a rolling update creates, 25% by default
+func deploymentSurge(deploy *appsv1.Deployment, replicas int64) int64 {
+	if deploy.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType {
+		return 0
+	}
+	maxSurge := intstr.FromString("25%")
+	if rolling := deploy.Spec.Strategy.RollingUpdate; rolling != nil && rolling.MaxSurge != nil {
+		maxSurge = *rolling.MaxSurge
+	}
+	return scaledSurge(&maxSurge, replicas)
+}
+
+func scaledSurge(maxSurge *intstr.IntOrString, replicas int64) int64 {
+	surge, err := intstr.GetScaledValueFromIntOrPercent(maxSurge, int(replicas), true)
+	if err != nil {
+		return 0
+	}
+	return int64(surge)
+}
+
+// applyLimitRangeItem narrows the allowed container request using one LimitRange item.
+// other and otherLimits are what the rest of the pod requests and limits (for Pod items).
+// Maximums bound the limit as well when it moves with the request.
+func applyLimitRangeItem(b *bounds, item corev1.LimitRangeItem, d *quotaDimension, other, otherLimits int64) {
+	if q, ok := item.Min[d.resource]; ok {
+		b.atLeast(quantityValue(q, d.milli) - other)
+	}
+	if q, ok := item.Max[d.resource]; ok {
+		b.atMost(quantityValue(q, d.milli) - other)
+		d.limitAtMost(b, quantityValue(q, d.milli)-otherLimits)
+	}
+	if q, ok := item.MaxLimitRequestRatio[d.resource]; ok && d.limit > 0 && q.MilliValue() > 0 && !d.limitFollows {
+		// limit/request must not exceed the ratio
+		b.atLeast(int64(math.Ceil(float64(d.limit) * 1000 / float64(q.MilliValue()))))
+	}
+}
+
+// quotaHeadroom returns hard minus used for a quota resource, if the quota tracks it
+func quotaHeadroom(quota *corev1.ResourceQuota, name corev1.ResourceName, milli bool) (int64, bool) {
+	hard, ok := quota.Status.Hard[name]
+	if !ok {
+		if hard, ok = quota.Spec.Hard[name]; !ok {
+			return 0, false
+		}
+	}
+	used := quota.Status.Used[name]
+	return quantityValue(hard, milli) - quantityValue(used, milli), true
+}
+
+// maxQuotaDelta returns the largest per-container change whose rollout peak fits the headroom.
+// With r replicas and s surge pods of pod size p, usage peaks either when all r pods are new
+// next to s old ones (r*d + s*p) or when the first s new pods start (s*(p+d)).
+func maxQuotaDelta(headroom int64, shape *workloadShape, podSize int64) int64 {
+	if shape.replicas == 0 {
+		return math.MaxInt64 / 2
+	}
+	delta := floorDiv(headroom-shape.surge*podSize, shape.replicas)
+	if shape.surge > 0 {
+		delta = min(delta, floorDiv(headroom, shape.surge)-podSize)
+	}
+	return delta
+}
+
+// rolloutUsage returns how much each quota's usage rises at the peak of a rollout with
+// the allowed requests, and with limits that move along with them
+func rolloutUsage(quotas []*corev1.ResourceQuota, shape *workloadShape, dimensions []*quotaDimension) map[string]map[corev1.ResourceName]int64 {
+	usage := make(map[string]map[corev1.ResourceName]int64)
+	for _, quota := range quotas {
+		for _, d := range dimensions {
+			if !d.changed() {
+				continue
+			}
+			request := d.allowed.clamp(d.request)
+			peaks := map[corev1.ResourceName]int64{}
+			for _, name := range d.requestQuotas {
+				peaks[name] = rolloutPeak(shape, d.other+d.current, request-d.current)
+			}
+			if d.limitFollows {
+				peaks[d.limitQuota] = rolloutPeak(shape, d.podLimit, request+d.limitOffset()-d.currentLimit)
+			}
+			for name, peak := range peaks {
+				if _, ok := quotaHeadroom(quota, name, d.milli); !ok || peak <= 0 {
+					continue
+				}
+				if usage[quota.Name] == nil {
+					usage[quota.Name] = make(map[corev1.ResourceName]int64)
+				}
+				usage[quota.Name][name] += peak
+			}
+		}
+	}
+	return usage
+}
+
+// rolloutPeak is the usage rise at the peak of a rollout changing a container by delta,
+// the inverse of maxQuotaDelta
+func rolloutPeak(shape *workloadShape, podSize, delta int64) int64 {
+	peak := shape.replicas*delta + shape.surge*podSize
+	if shape.surge > 0 {
+		peak = max(peak, shape.surge*(podSize+delta))
+	}
+	return peak
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		q--
+	}
+	return q
+}
+
+func quantityValue(q resource.Quantity, milli bool) int64 {
+	if milli {
+		return q.MilliValue()
+	}
+	return q.Value()
+}
+
+// quotaApplies evaluates the quota's scopes against the workload's pods. Pods changed by
+// the optimizer always have requests, so they never count as BestEffort.
+func quotaApplies(quota *corev1.ResourceQuota, podSpec *corev1.PodSpec) bool {
+	for _, scope := range quota.Spec.Scopes {
+		if !podMatchesScope(scope, corev1.ScopeSelectorOpExists, nil, podSpec) {
+			return false
+		}
+	}
+	if selector := quota.Spec.ScopeSelector; selector != nil {
+		for _, expr := range selector.MatchExpressions {
+			if !podMatchesScope(expr.ScopeName, expr.Operator, expr.Values, podSpec) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func podMatchesScope(scope corev1.ResourceQuotaScope, op corev1.ScopeSelectorOperator, values []string, podSpec *corev1.PodSpec) bool {
+	switch scope {
+	case corev1.ResourceQuotaScopeBestEffort:
+		return false
+	case corev1.ResourceQuotaScopeNotBestEffort:
+		return true
+	case corev1.ResourceQuotaScopeTerminating:
+		return podSpec.ActiveDeadlineSeconds != nil
+	case corev1.ResourceQuotaScopeNotTerminating:
+		return podSpec.ActiveDeadlineSeconds == nil
+	case corev1.ResourceQuotaScopePriorityClass:
+		switch op {
+		case corev1.ScopeSelectorOpIn:
+			return containsValue(values, podSpec.PriorityClassName)
+		case corev1.ScopeSelectorOpNotIn:
+			return !containsValue(values, podSpec.PriorityClassName)
+		case corev1.ScopeSelectorOpDoesNotExist:
+			return podSpec.PriorityClassName == ""
+		default:
+			return podSpec.PriorityClassName != ""
+		}
+	default:
+		// Be conservative with scopes we cannot evaluate
+		return true
+	}
+}
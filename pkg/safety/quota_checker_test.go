@@ -0,0 +1,294 @@
+package safety
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+const mi = 1024 * 1024
+
+func TestQuotaChecker_LimitRangeClamps(t *testing.T) {
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "container-limits", Namespace: "default"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypeContainer,
+			Min:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+			Max:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+		}}},
+	}
+	client := fake.NewSimpleClientset(createQuotaTestDeployment(2), limitRange)
+	checker := NewQuotaChecker(client)
+
+	result, err := checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 50, Memory: 2 * gi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+
+	if result.Fits || !result.Feasible {
+		t.Fatalf("Expected a feasible violation, got fits=%v feasible=%v", result.Fits, result.Feasible)
+	}
+	if result.CPU != 100 || result.Memory != gi {
+		t.Errorf("Expected clamp to cpu=100m memory=1Gi, got cpu=%dm memory=%d", result.CPU, result.Memory)
+	}
+	if len(result.Violations) != 1 || result.Violations[0] != "LimitRange container-limits" {
+		t.Errorf("Expected the LimitRange to be reported, got %v", result.Violations)
+	}
+}
+
+func TestQuotaChecker_QuotaHeadroomCoversSurge(t *testing.T) {
+	quota := createTestQuota("compute", corev1.ResourceList{
+		corev1.ResourceRequestsCPU: resource.MustParse("4"),
+	}, corev1.ResourceList{
+		corev1.ResourceRequestsCPU: resource.MustParse("2"),
+	})
+	client := fake.NewSimpleClientset(createQuotaTestDeployment(4), quota)
+	checker := NewQuotaChecker(client)
+
+	result, err := checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 1000, Memory: 256 * mi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+
+	// 2 cores of headroom must hold 4 replicas raised by d plus one surge pod of 500m:
+	// 4d + 500m <= 2000m, so the container can grow by at most 375m
+	if result.Fits {
+		t.Fatal("Expected 1000m per replica to exceed the quota")
+	}
+	if result.CPU != 875 {
+		t.Errorf("Expected CPU clamped to 875m, got %dm", result.CPU)
+	}
+
+	// A reduction fits
+	result, err = checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 250, Memory: 256 * mi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if !result.Fits {
+		t.Errorf("Expected a reduction to fit: %s", result.Message)
+	}
+}
+
+func TestQuotaChecker_ReservedHeadroom(t *testing.T) {
+	quota := createTestQuota("compute", corev1.ResourceList{
+		corev1.ResourceRequestsCPU: resource.MustParse("4"),
+	}, corev1.ResourceList{
+		corev1.ResourceRequestsCPU: resource.MustParse("2"),
+	})
+	client := fake.NewSimpleClientset(createQuotaTestDeployment(4), quota)
+	checker := NewQuotaChecker(client)
+	reserved := QuotaReservations{}
+
+	req := QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 750, Memory: 256 * mi, Reserved: reserved,
+	}
+	result, err := checker.CheckQuota(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if !result.Fits {
+		t.Fatalf("Expected the first change to fit: %s", result.Message)
+	}
+	reserved.Reserve("default", result)
+
+	// 4 replicas raised by 250m next to a 500m surge pod take 1500m of the 2 cores
+	if got := reserved["default"]["compute"][corev1.ResourceRequestsCPU]; got != 1500 {
+		t.Errorf("Expected 1500m reserved, got %dm", got)
+	}
+
+	// The same change again only has the 500m left, which the surge pod alone takes
+	result, err = checker.CheckQuota(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if result.Fits || result.CPU != 500 {
+		t.Errorf("Expected the second change to be clamped to the current 500m, got fits=%v cpu=%dm", result.Fits, result.CPU)
+	}
+}
+
+func TestQuotaChecker_NoRoomForSurgePods(t *testing.T) {
+	quota := createTestQuota("pods", corev1.ResourceList{
+		corev1.ResourcePods: resource.MustParse("4"),
+	}, corev1.ResourceList{
+		corev1.ResourcePods: resource.MustParse("4"),
+	})
+	// BestEffort-scoped quotas never apply to pods with requests
+	bestEffort := createTestQuota("best-effort", corev1.ResourceList{
+		corev1.ResourceRequestsMemory: resource.MustParse("1Mi"),
+	}, nil)
+	bestEffort.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+
+	client := fake.NewSimpleClientset(createQuotaTestDeployment(4), quota, bestEffort)
+	checker := NewQuotaChecker(client)
+
+	result, err := checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 250, Memory: 256 * mi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+
+	if result.Feasible {
+		t.Errorf("Expected a full pod quota to block the rollout, got %s", result.Message)
+	}
+	if len(result.Violations) != 1 {
+		t.Errorf("Expected only the pod quota to be reported, got %v", result.Violations)
+	}
+
+	// Recreate rollouts do not surge
+	deploy, _ := client.AppsV1().Deployments("default").Get(context.Background(), "api", metav1.GetOptions{})
+	deploy.Spec.Strategy.Type = appsv1.RecreateDeploymentStrategyType
+	if _, err := client.AppsV1().Deployments("default").Update(context.Background(), deploy, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update deployment: %v", err)
+	}
+	result, err = checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 250, Memory: 256 * mi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if !result.Fits {
+		t.Errorf("Expected a Recreate rollout to fit: %s", result.Message)
+	}
+}
+
+func TestQuotaChecker_EphemeralStorage(t *testing.T) {
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "storage-limits", Namespace: "default"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypeContainer,
+			Max:  corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("1Gi")},
+		}}},
+	}
+	quota := createTestQuota("storage", corev1.ResourceList{
+		corev1.ResourceRequestsEphemeralStorage: resource.MustParse("4Gi"),
+	}, corev1.ResourceList{
+		corev1.ResourceRequestsEphemeralStorage: resource.MustParse("2Gi"),
+	})
+	deploy := createQuotaTestDeployment(2)
+	deploy.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceEphemeralStorage] = resource.MustParse("512Mi")
+	client := fake.NewSimpleClientset(deploy, limitRange, quota)
+	checker := NewQuotaChecker(client)
+
+	// 2Gi of headroom lets the quota alone grow the request to 1280Mi (2d + 512Mi <= 2Gi);
+	// the LimitRange caps it at 1Gi
+	result, err := checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 500, Memory: 256 * mi, EphemeralStorage: 3 * gi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if result.Fits || !result.Feasible {
+		t.Fatalf("Expected a feasible violation, got fits=%v feasible=%v", result.Fits, result.Feasible)
+	}
+	if result.EphemeralStorage != gi {
+		t.Errorf("Expected ephemeral storage clamped to 1Gi, got %d", result.EphemeralStorage)
+	}
+	if len(result.Violations) != 2 {
+		t.Errorf("Expected the LimitRange and the quota to be reported, got %v", result.Violations)
+	}
+
+	// Unchanged ephemeral storage is not checked
+	result, err = checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 500, Memory: 256 * mi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if !result.Fits || result.EphemeralStorage != 0 {
+		t.Errorf("Expected an unchanged request to fit, got %s", result.Message)
+	}
+}
+
+func TestQuotaChecker_MovingLimits(t *testing.T) {
+	limitRange := &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "container-limits", Namespace: "default"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+			Type: corev1.LimitTypeContainer,
+			Max:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+		}}},
+	}
+	quota := createTestQuota("limits", corev1.ResourceList{
+		corev1.ResourceLimitsMemory: resource.MustParse("2Gi"),
+	}, corev1.ResourceList{
+		corev1.ResourceLimitsMemory: resource.MustParse("1Gi"),
+	})
+	deploy := createQuotaTestDeployment(1)
+	deploy.Spec.Template.Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("512Mi"),
+	}
+	client := fake.NewSimpleClientset(deploy, limitRange, quota)
+	checker := NewQuotaChecker(client)
+
+	// Requests fit on their own; the limits moving with them do not. 1Gi of limit headroom
+	// holds the resized pod next to one surge pod of 512Mi, d + 512Mi <= 1Gi, so the limit
+	// can reach 1Gi and the request 256Mi below it
+	result, err := checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 1500, Memory: 1024 * mi, CPULimit: 2500, MemoryLimit: 1280 * mi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if result.Fits {
+		t.Fatal("Expected the moving limits to exceed the LimitRange and quota")
+	}
+	if result.CPU != 1000 {
+		t.Errorf("Expected CPU clamped so the limit stays at 2 cores, got %dm", result.CPU)
+	}
+	if result.Memory != 768*mi {
+		t.Errorf("Expected memory clamped to 768Mi, got %d", result.Memory)
+	}
+
+	// The same requests fit while the limits stay where they are
+	result, err = checker.CheckQuota(context.Background(), QuotaRequest{
+		Namespace: "default", Kind: "Deployment", Name: "api", ContainerName: "app",
+		CPU: 1000, Memory: 512 * mi,
+	})
+	if err != nil {
+		t.Fatalf("CheckQuota failed: %v", err)
+	}
+	if !result.Fits {
+		t.Errorf("Expected fixed limits to fit: %s", result.Message)
+	}
+}
+
+func createQuotaTestDeployment(replicas int32) *appsv1.Deployment {
+	deploy := createTestDeployment("api", "default", replicas, replicas, map[string]string{"app": "api"})
+	deploy.Spec.Template.Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+			corev1.ResourceMemory: resource.MustParse("256Mi"),
+		}},
+	}}
+	return deploy
+}
+
+func createTestQuota(name string, hard, used corev1.ResourceList) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+		Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
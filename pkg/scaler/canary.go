@@ -0,0 +1,536 @@
+package scaler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/models"
+	"intelligent-cluster-optimizer/pkg/tuning"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// CanaryLabel marks the pods of a Deployment's canary ReplicaSet; its value is the Deployment name
+	CanaryLabel = "optimizer.intelligent-cluster-optimizer.io/canary"
+
+	// DefaultCanarySoakPeriod is how long canary pods are observed when no soak period is set
+	DefaultCanarySoakPeriod = 10 * time.Minute
+
+	canaryReplicaSetSuffix = "-optimizer-canary"
+
+	// canaryReadyTimeout is how long after the soak period canary pods may take to become ready
+	canaryReadyTimeout = 5 * time.Minute
+)
+
+// ErrCanaryNotApplicable is returned when a workload cannot be canaried; the change is
+// then rolled out to all pods instead
+var ErrCanaryNotApplicable = errors.New("canary not applicable")
+
+// CanaryConfig configures a canary rollout
+type CanaryConfig struct {
+	// Replicas is the number of pods that get the change first (default 1)
+	Replicas int32
+
+	// SoakPeriod is how long canary pods are compared against the baseline
+	SoakPeriod time.Duration
+
+	// MaxRestarts is how many more restarts per pod the canary pods may have than the baseline
+	MaxRestarts int32
+}
+
+// MetricsSource provides the usage samples used to detect CPU throttling
+type MetricsSource interface {
+	GetMetricsByWorkload(namespace, workloadName string, since time.Duration) []models.PodMetric
+}
+
+// Canary is a canary rollout in progress
+type Canary struct {
+	Request   ScaleRequest
+	Config    CanaryConfig
+	StartedAt time.Time
+
+	// Partition is the StatefulSet partition that exposes the canary pods, and
+	// OriginalPartition the one restored on promotion or abort
+	Partition         int32
+	OriginalPartition int32
+
+	// PreviousResources are the container's resources before the change; a StatefulSet
+	// gets them back on abort
+	PreviousResources corev1.ResourceRequirements
+
+	// ReplicaSet is the name of the Deployment's canary ReplicaSet
+	ReplicaSet string
+
+	// BaselineRestarts maps baseline pods to their restart count when the canary started
+	BaselineRestarts map[string]int32
+}
+
+// CanaryVerdict is the outcome of a canary analysis
+type CanaryVerdict string
+
+const (
+	// CanaryPending means the soak period has not elapsed and nothing failed yet
+	CanaryPending CanaryVerdict = "Pending"
+	// CanaryPassed means the canary pods were as healthy as the baseline for the soak period
+	CanaryPassed CanaryVerdict = "Passed"
+	// CanaryFailed means the canary pods were less healthy than the baseline
+	CanaryFailed CanaryVerdict = "Failed"
+)
+
+// PodGroupHealth summarizes the health of the canary or baseline pods during the soak period
+type PodGroupHealth struct {
+	Pods      int
+	Ready     int
+	Restarts  int32
+	OOMKills  int
+	Throttled bool
+}
+
+func (h PodGroupHealth) perPod(n int32) float64 {
+	if h.Pods == 0 {
+		return 0
+	}
+	return float64(n) / float64(h.Pods)
+}
+
+// CanaryAnalysis compares canary pods against baseline pods
+type CanaryAnalysis struct {
+	Verdict  CanaryVerdict
+	Canary   PodGroupHealth
+	Baseline PodGroupHealth
+	Reasons  []string
+}
+
+// SetMetricsSource sets where canary analysis reads usage samples from; without one
+// throttling is not compared
+func (v *VerticalScaler) SetMetricsSource(source MetricsSource) {
+	v.metricsSource = source
+}
+
+// StartCanary applies the change to the canary pods only: the highest StatefulSet
+// ordinals through the rolling update partition, or the pods of a temporary ReplicaSet
+// next to a Deployment's own
+func (v *VerticalScaler) StartCanary(ctx context.Context, req *ScaleRequest) (*Canary, error) {
+	config := CanaryConfig{Replicas: 1, SoakPeriod: DefaultCanarySoakPeriod}
+	if req.Canary != nil {
+		config = *req.Canary
+		if config.Replicas <= 0 {
+			config.Replicas = 1
+		}
+		if config.SoakPeriod <= 0 {
+			config.SoakPeriod = DefaultCanarySoakPeriod
+		}
+	}
+	canary := &Canary{Request: *req, Config: config, StartedAt: time.Now()}
+
+	switch req.WorkloadKind {
+	case "Deployment":
+		if err := v.startDeploymentCanary(ctx, canary); err != nil {
+			return nil, err
+		}
+	case "StatefulSet":
+		if err := v.startStatefulSetCanary(ctx, canary); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s pods cannot be updated selectively", ErrCanaryNotApplicable, req.WorkloadKind)
+	}
+
+	_, baseline, err := v.canaryPods(ctx, canary)
+	if err != nil {
+		return nil, err
+	}
+	canary.BaselineRestarts = make(map[string]int32, len(baseline))
+	for i := range baseline {
+		canary.BaselineRestarts[baseline[i].Name] = podRestarts(&baseline[i])
+	}
+
+	klog.Infof("Started canary of %s/%s/%s with %d pods for %s", req.Namespace, req.WorkloadKind, req.WorkloadName,
+		config.Replicas, config.SoakPeriod)
+	return canary, nil
+}
+
+func (v *VerticalScaler) startDeploymentCanary(ctx context.Context, canary *Canary) error {
+	req := &canary.Request
+	deploy, err := v.kubeClient.AppsV1().Deployments(req.Namespace).Get(ctx, req.WorkloadName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if deploy.Spec.Replicas != nil && *deploy.Spec.Replicas == 0 {
+		return fmt.Errorf("%w: deployment is scaled to zero", ErrCanaryNotApplicable)
+	}
+
+	for _, c := range deploy.Spec.Template.Spec.Containers {
+		if c.Name == req.ContainerName {
+			canary.PreviousResources = *c.Resources.DeepCopy()
+		}
+	}
+	template := deploy.Spec.Template.DeepCopy()
+	if err := v.updatePodResources(&template.Spec, req); err != nil {
+		return err
+	}
+	if template.Labels == nil {
+		template.Labels = map[string]string{}
+	}
+	template.Labels[CanaryLabel] = deploy.Name
+
+	// The canary ReplicaSet's own labels do not match the Deployment selector, so the
+	// Deployment does not adopt it; its pods keep the Deployment's labels and get traffic
+	selector := deploy.Spec.Selector.DeepCopy()
+	if selector.MatchLabels == nil {
+		selector.MatchLabels = map[string]string{}
+	}
+	selector.MatchLabels[CanaryLabel] = deploy.Name
+
+	replicas := canary.Config.Replicas
+	rs := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      deploy.Name + canaryReplicaSetSuffix,
+			Namespace: deploy.Namespace,
+			Labels: map[string]string{
+				CanaryLabel:                    deploy.Name,
+				"app.kubernetes.io/managed-by": "intelligent-cluster-optimizer",
+			},
+			// Owned for garbage collection only; the Deployment must not manage it
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: appsv1.SchemeGroupVersion.String(),
+				Kind:       "Deployment",
+				Name:       deploy.Name,
+				UID:        deploy.UID,
+			}},
+		},
+		Spec: appsv1.ReplicaSetSpec{
+			Replicas: &replicas,
+			Selector: selector,
+			Template: *template,
+		},
+	}
+	canary.ReplicaSet = rs.Name
+	_, err = v.kubeClient.AppsV1().ReplicaSets(req.Namespace).Create(ctx, rs, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		// Left behind by an earlier canary whose cleanup did not finish; its pods run an
+		// unknown template, so it is replaced rather than adopted
+		klog.Warningf("Replacing leftover canary replicaset %s/%s", req.Namespace, rs.Name)
+		if err := v.deleteCanaryReplicaSet(ctx, canary); err != nil {
+			return err
+		}
+		_, err = v.kubeClient.AppsV1().ReplicaSets(req.Namespace).Create(ctx, rs, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create canary replicaset: %v", err)
+	}
+
+	v.recordEvent(deploy, corev1.EventTypeNormal, "CanaryStarted",
+		fmt.Sprintf("Started canary with %d pods for %s", replicas, canary.Config.SoakPeriod))
+	return nil
+}
+
+func (v *VerticalScaler) startStatefulSetCanary(ctx context.Context, canary *Canary) error {
+	req := &canary.Request
+	sts, err := v.kubeClient.AppsV1().StatefulSets(req.Namespace).Get(ctx, req.WorkloadName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+		return fmt.Errorf("%w: statefulset uses the OnDelete update strategy", ErrCanaryNotApplicable)
+	}
+
+	replicas := int32(1)
+	if sts.Spec.Replicas != nil {
+		replicas = *sts.Spec.Replicas
+	}
+	var original int32
+	if rolling := sts.Spec.UpdateStrategy.RollingUpdate; rolling != nil && rolling.Partition != nil {
+		original = *rolling.Partition
+	}
+	partition := replicas - canary.Config.Replicas
+	if partition < 1 || partition < original {
+		return fmt.Errorf("%w: %d replicas leave no baseline for %d canary pods", ErrCanaryNotApplicable,
+			replicas-original, canary.Config.Replicas)
+	}
+
+	for _, c := range sts.Spec.Template.Spec.Containers {
+		if c.Name == req.ContainerName {
+			canary.PreviousResources = *c.Resources.DeepCopy()
+		}
+	}
+	if err := v.updatePodResources(&sts.Spec.Template.Spec, req); err != nil {
+		return err
+	}
+	setPartition(sts, partition)
+
+	if _, err := v.kubeClient.AppsV1().StatefulSets(req.Namespace).Update(ctx, sts, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update statefulset: %v", err)
+	}
+	canary.Partition = partition
+	canary.OriginalPartition = original
+
+	v.recordEvent(sts, corev1.EventTypeNormal, "CanaryStarted",
+		fmt.Sprintf("Started canary on ordinals %d and up for %s", partition, canary.Config.SoakPeriod))
+	return nil
+}
+
+func setPartition(sts *appsv1.StatefulSet, partition int32) {
+	sts.Spec.UpdateStrategy.Type = appsv1.RollingUpdateStatefulSetStrategyType
+	if sts.Spec.UpdateStrategy.RollingUpdate == nil {
+		sts.Spec.UpdateStrategy.RollingUpdate = &appsv1.RollingUpdateStatefulSetStrategy{}
+	}
+	sts.Spec.UpdateStrategy.RollingUpdate.Partition = &partition
+}
+
+// AnalyzeCanary compares the restarts, OOM kills, CPU throttling and readiness of the
+// canary pods against the baseline pods since the canary started
+func (v *VerticalScaler) AnalyzeCanary(ctx context.Context, canary *Canary, now time.Time) (*CanaryAnalysis, error) {
+	canaryPods, baselinePods, err := v.canaryPods(ctx, canary)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &CanaryAnalysis{
+		Canary:   v.groupHealth(canary, canaryPods, nil),
+		Baseline: v.groupHealth(canary, baselinePods, canary.BaselineRestarts),
+	}
+	c, b := analysis.Canary, analysis.Baseline
+
+	if c.OOMKills > 0 && c.perPod(int32(c.OOMKills)) > b.perPod(int32(b.OOMKills)) {
+		analysis.Reasons = append(analysis.Reasons,
+			fmt.Sprintf("canary pods were OOM killed %d times (baseline %d)", c.OOMKills, b.OOMKills))
+	}
+	if extra := c.perPod(c.Restarts) - b.perPod(b.Restarts); extra > float64(canary.Config.MaxRestarts) {
+		analysis.Reasons = append(analysis.Reasons,
+			fmt.Sprintf("canary pods restarted %.1f times per pod more than the baseline (max %d)", extra, canary.Config.MaxRestarts))
+	}
+	if c.Throttled && !b.Throttled {
+		analysis.Reasons = append(analysis.Reasons, "canary pods are CPU throttled and the baseline is not")
+	}
+	if len(analysis.Reasons) > 0 {
+		analysis.Verdict = CanaryFailed
+		return analysis, nil
+	}
+
+	if now.Sub(canary.StartedAt) < canary.Config.SoakPeriod {
+		analysis.Verdict = CanaryPending
+		return analysis, nil
+	}
+
+	// After the soak period the canary pods must be as ready as the baseline
+	if readyRatio(c, canary.Config.Replicas) < readyRatio(b, 0) {
+		if now.Sub(canary.StartedAt) < canary.Config.SoakPeriod+canaryReadyTimeout {
+			analysis.Verdict = CanaryPending
+			return analysis, nil
+		}
+		analysis.Reasons = append(analysis.Reasons,
+			fmt.Sprintf("%d of %d canary pods ready (baseline %d of %d)", c.Ready, canary.Config.Replicas, b.Ready, b.Pods))
+		analysis.Verdict = CanaryFailed
+		return analysis, nil
+	}
+
+	analysis.Verdict = CanaryPassed
+	return analysis, nil
+}
+
+// readyRatio is the share of ready pods, counting at least the expected number of pods
+func readyRatio(h PodGroupHealth, expected int32) float64 {
+	pods := h.Pods
+	if int(expected) > pods {
+		pods = int(expected)
+	}
+	if pods == 0 {
+		return 1
+	}
+	return float64(h.Ready) / float64(pods)
+}
+
+// canaryPods lists the canary and baseline pods of a canary
+func (v *VerticalScaler) canaryPods(ctx context.Context, canary *Canary) ([]corev1.Pod, []corev1.Pod, error) {
+	req := &canary.Request
+
+	var selector *metav1.LabelSelector
+	switch req.WorkloadKind {
+	case "Deployment":
+		deploy, err := v.kubeClient.AppsV1().Deployments(req.Namespace).Get(ctx, req.WorkloadName, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		selector = deploy.Spec.Selector
+	case "StatefulSet":
+		sts, err := v.kubeClient.AppsV1().StatefulSets(req.Namespace).Get(ctx, req.WorkloadName, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, err
+		}
+		selector = sts.Spec.Selector
+	default:
+		return nil, nil, fmt.Errorf("unsupported workload kind: %s", req.WorkloadKind)
+	}
+
+	podSelector, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid selector: %v", err)
+	}
+	pods, err := v.kubeClient.CoreV1().Pods(req.Namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector.String()})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var canaryPods, baselinePods []corev1.Pod
+	for _, pod := range pods.Items {
+		if isCanaryPod(canary, &pod) {
+			canaryPods = append(canaryPods, pod)
+		} else {
+			baselinePods = append(baselinePods, pod)
+		}
+	}
+
+	return canaryPods, baselinePods, nil
+}
+
+func isCanaryPod(canary *Canary, pod *corev1.Pod) bool {
+	if canary.Request.WorkloadKind == "StatefulSet" {
+		ordinal, ok := podOrdinal(canary.Request.WorkloadName, pod.Name)
+		return ok && ordinal >= canary.Partition
+	}
+	return pod.Labels[CanaryLabel] == canary.Request.WorkloadName
+}
+
+// podOrdinal parses the ordinal of a StatefulSet pod named <statefulset>-<ordinal>
+func podOrdinal(statefulSet, podName string) (int32, bool) {
+	suffix, ok := strings.CutPrefix(podName, statefulSet+"-")
+	if !ok {
+		return 0, false
+	}
+	var ordinal int32
+	if _, err := fmt.Sscanf(suffix, "%d", &ordinal); err != nil || fmt.Sprint(ordinal) != suffix {
+		return 0, false
+	}
+	return ordinal, true
+}
+
+// groupHealth summarizes pods since the canary started; restarts are counted from
+// the given counts when known
+func (v *VerticalScaler) groupHealth(canary *Canary, pods []corev1.Pod, restartsAtStart map[string]int32) PodGroupHealth {
+	health := PodGroupHealth{Pods: len(pods)}
+	podNames := make(map[string]bool, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		podNames[pod.Name] = true
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				health.Ready++
+			}
+		}
+		restarts := podRestarts(pod) - restartsAtStart[pod.Name]
+		if restarts > 0 {
+			health.Restarts += restarts
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if terminated := status.LastTerminationState.Terminated; terminated != nil &&
+				terminated.Reason == "OOMKilled" && terminated.FinishedAt.After(canary.StartedAt) {
+				health.OOMKills++
+			}
+		}
+	}
+
+	if v.metricsSource != nil && len(pods) > 0 {
+		var samples []models.PodMetric
+		for _, sample := range v.metricsSource.GetMetricsByWorkload(canary.Request.Namespace, canary.Request.WorkloadName,
+			time.Since(canary.StartedAt)) {
+			if podNames[sample.PodName] {
+				samples = append(samples, sample)
+			}
+		}
+		health.Throttled = tuning.IsThrottled(samples, canary.Request.ContainerName)
+	}
+	return health
+}
+
+func podRestarts(pod *corev1.Pod) int32 {
+	var restarts int32
+	for _, status := range pod.Status.ContainerStatuses {
+		restarts += status.RestartCount
+	}
+	return restarts
+}
+
+// PromoteCanary starts rolling the change out to all pods and removes the canary; the
+// rollout is followed with RolloutStatus
+func (v *VerticalScaler) PromoteCanary(ctx context.Context, canary *Canary) error {
+	req := &canary.Request
+	switch req.WorkloadKind {
+	case "Deployment":
+		// The canary pods are extra capacity, so they can go as soon as the Deployment starts updating
+		if err := v.ApplyRollingUpdate(ctx, req); err != nil {
+			return err
+		}
+		if err := v.deleteCanaryReplicaSet(ctx, canary); err != nil {
+			return err
+		}
+	case "StatefulSet":
+		sts, err := v.kubeClient.AppsV1().StatefulSets(req.Namespace).Get(ctx, req.WorkloadName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		setPartition(sts, canary.OriginalPartition)
+		if _, err := v.kubeClient.AppsV1().StatefulSets(req.Namespace).Update(ctx, sts, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update statefulset: %v", err)
+		}
+		v.recordEvent(sts, corev1.EventTypeNormal, "CanaryPromoted", "Canary passed; rolling out to all pods")
+	default:
+		return fmt.Errorf("unsupported workload kind: %s", req.WorkloadKind)
+	}
+
+	klog.Infof("Promoted canary of %s/%s/%s", req.Namespace, req.WorkloadKind, req.WorkloadName)
+	return nil
+}
+
+// AbortCanary reverts the canary pods to the workload's previous resources
+func (v *VerticalScaler) AbortCanary(ctx context.Context, canary *Canary, reason string) error {
+	req := &canary.Request
+	switch req.WorkloadKind {
+	case "Deployment":
+		if err := v.deleteCanaryReplicaSet(ctx, canary); err != nil {
+			return err
+		}
+		if deploy, err := v.kubeClient.AppsV1().Deployments(req.Namespace).Get(ctx, req.WorkloadName, metav1.GetOptions{}); err == nil {
+			v.recordEvent(deploy, corev1.EventTypeWarning, "CanaryAborted", fmt.Sprintf("Canary aborted: %s", reason))
+		}
+	case "StatefulSet":
+		sts, err := v.kubeClient.AppsV1().StatefulSets(req.Namespace).Get(ctx, req.WorkloadName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		// With the template restored, the canary pods are rolled back to the current revision
+		for i := range sts.Spec.Template.Spec.Containers {
+			if sts.Spec.Template.Spec.Containers[i].Name == req.ContainerName {
+				sts.Spec.Template.Spec.Containers[i].Resources = *canary.PreviousResources.DeepCopy()
+			}
+		}
+		setPartition(sts, canary.OriginalPartition)
+		if _, err := v.kubeClient.AppsV1().StatefulSets(req.Namespace).Update(ctx, sts, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update statefulset: %v", err)
+		}
+		v.recordEvent(sts, corev1.EventTypeWarning, "CanaryAborted", fmt.Sprintf("Canary aborted: %s", reason))
+	default:
+		return fmt.Errorf("unsupported workload kind: %s", req.WorkloadKind)
+	}
+
+	klog.Warningf("Aborted canary of %s/%s/%s: %s", req.Namespace, req.WorkloadKind, req.WorkloadName, reason)
+	return nil
+}
+
+func (v *VerticalScaler) deleteCanaryReplicaSet(ctx context.Context, canary *Canary) error {
+	propagation := metav1.DeletePropagationBackground
+	err := v.kubeClient.AppsV1().ReplicaSets(canary.Request.Namespace).Delete(ctx, canary.ReplicaSet,
+		metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete canary replicaset: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package scaler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/models"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var canaryLabels = map[string]string{"app": "db"}
+
+func canaryTemplate(cpu string) corev1.PodTemplateSpec {
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: canaryLabels},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		}}},
+	}
+}
+
+func canaryPod(name string, podLabels map[string]string, ready bool, restarts int32) *corev1.Pod {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: podLabels},
+		Status: corev1.PodStatus{
+			Conditions:        []corev1.PodCondition{{Type: corev1.PodReady, Status: status}},
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "app", RestartCount: restarts}},
+		},
+	}
+}
+
+func testStatefulSet() *appsv1.StatefulSet {
+	replicas := int32(3)
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: canaryLabels},
+			Template: canaryTemplate("500m"),
+		},
+		Status: appsv1.StatefulSetStatus{UpdatedReplicas: 3, ReadyReplicas: 3},
+	}
+}
+
+func statefulSetCPU(t *testing.T, client *fake.Clientset) (string, int32) {
+	t.Helper()
+	sts, err := client.AppsV1().StatefulSets("default").Get(context.Background(), "db", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get statefulset: %v", err)
+	}
+	var partition int32
+	if rolling := sts.Spec.UpdateStrategy.RollingUpdate; rolling != nil && rolling.Partition != nil {
+		partition = *rolling.Partition
+	}
+	cpu := sts.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]
+	return cpu.String(), partition
+}
+
+func TestCanary_StatefulSetPartition(t *testing.T) {
+	client := fake.NewSimpleClientset(testStatefulSet(),
+		canaryPod("db-0", canaryLabels, true, 0),
+		canaryPod("db-1", canaryLabels, true, 2),
+		canaryPod("db-2", canaryLabels, true, 0))
+	v := NewVerticalScaler(client, nil)
+	ctx := context.Background()
+	req := &ScaleRequest{Namespace: "default", WorkloadKind: "StatefulSet", WorkloadName: "db", ContainerName: "app", NewCPU: "300m"}
+
+	canary, err := v.StartCanary(ctx, req)
+	if err != nil {
+		t.Fatalf("StartCanary failed: %v", err)
+	}
+	if cpu, partition := statefulSetCPU(t, client); cpu != "300m" || partition != 2 {
+		t.Errorf("Expected the template at 300m with partition 2, got %s and %d", cpu, partition)
+	}
+	canaryPods, baselinePods, err := v.canaryPods(ctx, canary)
+	if err != nil {
+		t.Fatalf("canaryPods failed: %v", err)
+	}
+	if len(canaryPods) != 1 || canaryPods[0].Name != "db-2" || len(baselinePods) != 2 {
+		t.Errorf("Expected db-2 as the only canary pod, got %d canary and %d baseline pods", len(canaryPods), len(baselinePods))
+	}
+	if canary.BaselineRestarts["db-1"] != 2 {
+		t.Errorf("Expected baseline restarts to be recorded at start, got %v", canary.BaselineRestarts)
+	}
+
+	if err := v.AbortCanary(ctx, canary, "test"); err != nil {
+		t.Fatalf("AbortCanary failed: %v", err)
+	}
+	if cpu, partition := statefulSetCPU(t, client); cpu != "500m" || partition != 0 {
+		t.Errorf("Expected the template restored to 500m with partition 0, got %s and %d", cpu, partition)
+	}
+
+	canary, err = v.StartCanary(ctx, req)
+	if err != nil {
+		t.Fatalf("StartCanary failed: %v", err)
+	}
+	if err := v.PromoteCanary(ctx, canary); err != nil {
+		t.Fatalf("PromoteCanary failed: %v", err)
+	}
+	if cpu, partition := statefulSetCPU(t, client); cpu != "300m" || partition != 0 {
+		t.Errorf("Expected 300m rolled out to all ordinals, got %s with partition %d", cpu, partition)
+	}
+}
+
+func TestCanary_StatefulSetTooSmall(t *testing.T) {
+	sts := testStatefulSet()
+	replicas := int32(1)
+	sts.Spec.Replicas = &replicas
+	v := NewVerticalScaler(fake.NewSimpleClientset(sts), nil)
+
+	_, err := v.StartCanary(context.Background(), &ScaleRequest{
+		Namespace: "default", WorkloadKind: "StatefulSet", WorkloadName: "db", ContainerName: "app", NewCPU: "300m",
+	})
+	if !errors.Is(err, ErrCanaryNotApplicable) {
+		t.Errorf("Expected a single replica to leave no baseline, got %v", err)
+	}
+}
+
+func TestCanary_DeploymentReplicaSet(t *testing.T) {
+	replicas := int32(2)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: canaryLabels},
+			Template: canaryTemplate("500m"),
+		},
+	}
+	client := fake.NewSimpleClientset(deploy)
+	v := NewVerticalScaler(client, nil)
+	ctx := context.Background()
+
+	canary, err := v.StartCanary(ctx, &ScaleRequest{
+		Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "db", ContainerName: "app", NewCPU: "300m",
+		Canary: &CanaryConfig{Replicas: 1, SoakPeriod: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("StartCanary failed: %v", err)
+	}
+
+	rs, err := client.AppsV1().ReplicaSets("default").Get(ctx, canary.ReplicaSet, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected a canary ReplicaSet: %v", err)
+	}
+	if rs.Labels["app"] != "" {
+		t.Errorf("Canary ReplicaSet labels must not match the Deployment selector, got %v", rs.Labels)
+	}
+	if rs.Spec.Template.Labels["app"] != "db" || rs.Spec.Template.Labels[CanaryLabel] != "db" {
+		t.Errorf("Expected canary pods to keep the Deployment labels, got %v", rs.Spec.Template.Labels)
+	}
+	if cpu := rs.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]; cpu.String() != "300m" {
+		t.Errorf("Expected canary pods at 300m, got %s", cpu.String())
+	}
+	current, _ := client.AppsV1().Deployments("default").Get(ctx, "db", metav1.GetOptions{})
+	if cpu := current.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]; cpu.String() != "500m" {
+		t.Errorf("Expected the Deployment untouched during the canary, got %s", cpu.String())
+	}
+
+	if err := v.AbortCanary(ctx, canary, "test"); err != nil {
+		t.Fatalf("AbortCanary failed: %v", err)
+	}
+	if _, err := client.AppsV1().ReplicaSets("default").Get(ctx, canary.ReplicaSet, metav1.GetOptions{}); err == nil {
+		t.Error("Expected the canary ReplicaSet to be deleted")
+	}
+}
+
+func TestCanary_DeploymentReplacesLeftoverReplicaSet(t *testing.T) {
+	replicas := int32(2)
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: canaryLabels},
+			Template: canaryTemplate("500m"),
+		},
+	}
+	// An earlier canary with another change whose cleanup never ran
+	leftover := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db" + canaryReplicaSetSuffix, Namespace: "default"},
+		Spec:       appsv1.ReplicaSetSpec{Replicas: &replicas, Template: canaryTemplate("800m")},
+	}
+	client := fake.NewSimpleClientset(deploy, leftover)
+	v := NewVerticalScaler(client, nil)
+	ctx := context.Background()
+
+	canary, err := v.StartCanary(ctx, &ScaleRequest{
+		Namespace: "default", WorkloadKind: "Deployment", WorkloadName: "db", ContainerName: "app", NewCPU: "300m",
+		Canary: &CanaryConfig{Replicas: 1, SoakPeriod: time.Minute},
+	})
+	if err != nil {
+		t.Fatalf("StartCanary failed: %v", err)
+	}
+	rs, err := client.AppsV1().ReplicaSets("default").Get(ctx, canary.ReplicaSet, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected a canary ReplicaSet: %v", err)
+	}
+	if cpu := rs.Spec.Template.Spec.Containers[0].Resources.Requests[corev1.ResourceCPU]; cpu.String() != "300m" || *rs.Spec.Replicas != 1 {
+		t.Errorf("Expected the leftover replaced by 1 canary pod at 300m, got %d at %s", *rs.Spec.Replicas, cpu.String())
+	}
+}
+
+type fakeMetrics []models.PodMetric
+
+func (f fakeMetrics) GetMetricsByWorkload(namespace, workloadName string, since time.Duration) []models.PodMetric {
+	return f
+}
+
+func TestAnalyzeCanary(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	oomKilled := func(pod *corev1.Pod) *corev1.Pod {
+		pod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{
+			Reason:     "OOMKilled",
+			FinishedAt: metav1.NewTime(time.Now()),
+		}
+		return pod
+	}
+	throttled := fakeMetrics{{
+		PodName: "db-2",
+		Containers: []models.ContainerMetric{{
+			ContainerName: "app", UsageCPU: 300, LimitCPU: 300,
+		}},
+	}}
+
+	tests := []struct {
+		name     string
+		canary   *corev1.Pod
+		baseline int32
+		elapsed  time.Duration
+		metrics  fakeMetrics
+		expected CanaryVerdict
+	}{
+		{"healthy during soak", canaryPod("db-2", canaryLabels, true, 0), 0, time.Minute, nil, CanaryPending},
+		{"healthy after soak", canaryPod("db-2", canaryLabels, true, 0), 0, 11 * time.Minute, nil, CanaryPassed},
+		{"OOM killed", oomKilled(canaryPod("db-2", canaryLabels, true, 1)), 0, time.Minute, nil, CanaryFailed},
+		{"restarting", canaryPod("db-2", canaryLabels, true, 2), 0, time.Minute, nil, CanaryFailed},
+		{"restarting like the baseline", canaryPod("db-2", canaryLabels, true, 2), 2, 11 * time.Minute, nil, CanaryPassed},
+		{"throttled", canaryPod("db-2", canaryLabels, true, 0), 0, time.Minute, throttled, CanaryFailed},
+		{"not ready", canaryPod("db-2", canaryLabels, false, 0), 0, 16 * time.Minute, nil, CanaryFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(testStatefulSet(),
+				canaryPod("db-0", canaryLabels, true, tt.baseline),
+				canaryPod("db-1", canaryLabels, true, tt.baseline),
+				tt.canary)
+			v := NewVerticalScaler(client, nil)
+			if tt.metrics != nil {
+				v.SetMetricsSource(tt.metrics)
+			}
+			canary := &Canary{
+				Request:          ScaleRequest{Namespace: "default", WorkloadKind: "StatefulSet", WorkloadName: "db", ContainerName: "app"},
+				Config:           CanaryConfig{Replicas: 1, SoakPeriod: 10 * time.Minute},
+				StartedAt:        start,
+				Partition:        2,
+				BaselineRestarts: map[string]int32{"db-0": 0, "db-1": 0},
+			}
+
+			analysis, err := v.AnalyzeCanary(context.Background(), canary, start.Add(tt.elapsed))
+			if err != nil {
+				t.Fatalf("AnalyzeCanary failed: %v", err)
+			}
+			if analysis.Verdict != tt.expected {
+				t.Errorf("Expected %s, got %s (%v)", tt.expected, analysis.Verdict, analysis.Reasons)
+			}
+			if tt.expected == CanaryFailed && len(analysis.Reasons) == 0 {
+				t.Error("Expected a reason for the failure")
+			}
+		})
+	}
+}
+
+func TestPodOrdinal(t *testing.T) {
+	for podName, expected := range map[string]int32{"db-0": 0, "db-12": 12} {
+		if ordinal, ok := podOrdinal("db", podName); !ok || ordinal != expected {
+			t.Errorf("podOrdinal(%s) = %d, %v; expected %d", podName, ordinal, ok, expected)
+		}
+	}
+	for _, podName := range []string{"db-canary-0", "db-01", "other-1", "db-x"} {
+		if _, ok := podOrdinal("db", podName); ok {
+			t.Errorf("Expected %s not to be an ordinal of db", podName)
+		}
+	}
+}
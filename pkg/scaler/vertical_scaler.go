@@ -5,13 +5,13 @@ import (
 	"fmt"
 	"time"
 
+	"intelligent-cluster-optimizer/pkg/qos"
 	"intelligent-cluster-optimizer/pkg/safety"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
@@ -21,6 +21,7 @@ type VerticalScaler struct {
 	kubeClient    kubernetes.Interface
 	pdbChecker    *safety.PDBChecker
 	eventRecorder record.EventRecorder
+	metricsSource MetricsSource
 }
 
 type ScaleRequest struct {
@@ -30,7 +31,17 @@ type ScaleRequest struct {
 	ContainerName string
 	NewCPU        string
 	NewMemory     string
-	Strategy      UpdateStrategy
+	// NewMemoryLimit and NewCPULimit also set the limits when non-empty
+	NewMemoryLimit string
+	NewCPULimit    string
+	// NewEphemeralStorage sets the ephemeral-storage request when non-empty
+	NewEphemeralStorage string
+	// PreserveQoS keeps the pod's QoS class: limits of a Guaranteed pod follow the
+	// new requests, and any other class change is refused
+	PreserveQoS bool
+	Strategy    UpdateStrategy
+	// Canary configures StartCanary (defaults when nil)
+	Canary *CanaryConfig
 }
 
 type UpdateStrategy string
@@ -122,19 +133,8 @@ func (v *VerticalScaler) inPlaceUpdateDeployment(ctx context.Context, req *Scale
 		return err
 	}
 
-	updated := false
-	for i := range deploy.Spec.Template.Spec.Containers {
-		if deploy.Spec.Template.Spec.Containers[i].Name == req.ContainerName {
-			if err := v.updateContainerResources(&deploy.Spec.Template.Spec.Containers[i], req); err != nil {
-				return err
-			}
-			updated = true
-			break
-		}
-	}
-
-	if !updated {
-		return fmt.Errorf("container %s not found", req.ContainerName)
+	if err := v.updatePodResources(&deploy.Spec.Template.Spec, req); err != nil {
+		return err
 	}
 
 	_, err = v.kubeClient.AppsV1().Deployments(req.Namespace).Update(ctx, deploy, metav1.UpdateOptions{})
@@ -153,19 +153,8 @@ func (v *VerticalScaler) inPlaceUpdateStatefulSet(ctx context.Context, req *Scal
 		return err
 	}
 
-	updated := false
-	for i := range sts.Spec.Template.Spec.Containers {
-		if sts.Spec.Template.Spec.Containers[i].Name == req.ContainerName {
-			if err := v.updateContainerResources(&sts.Spec.Template.Spec.Containers[i], req); err != nil {
-				return err
-			}
-			updated = true
-			break
-		}
-	}
-
-	if !updated {
-		return fmt.Errorf("container %s not found", req.ContainerName)
+	if err := v.updatePodResources(&sts.Spec.Template.Spec, req); err != nil {
+		return err
 	}
 
 	_, err = v.kubeClient.AppsV1().StatefulSets(req.Namespace).Update(ctx, sts, metav1.UpdateOptions{})
@@ -184,19 +173,8 @@ func (v *VerticalScaler) inPlaceUpdateDaemonSet(ctx context.Context, req *ScaleR
 		return err
 	}
 
-	updated := false
-	for i := range ds.Spec.Template.Spec.Containers {
-		if ds.Spec.Template.Spec.Containers[i].Name == req.ContainerName {
-			if err := v.updateContainerResources(&ds.Spec.Template.Spec.Containers[i], req); err != nil {
-				return err
-			}
-			updated = true
-			break
-		}
-	}
-
-	if !updated {
-		return fmt.Errorf("container %s not found", req.ContainerName)
+	if err := v.updatePodResources(&ds.Spec.Template.Spec, req); err != nil {
+		return err
 	}
 
 	_, err = v.kubeClient.AppsV1().DaemonSets(req.Namespace).Update(ctx, ds, metav1.UpdateOptions{})
@@ -217,13 +195,8 @@ func (v *VerticalScaler) rollingUpdateDeployment(ctx context.Context, req *Scale
 
 	v.recordEvent(deploy, corev1.EventTypeNormal, "VerticalScaleStarted", "Starting rolling update for vertical scaling")
 
-	for i := range deploy.Spec.Template.Spec.Containers {
-		if deploy.Spec.Template.Spec.Containers[i].Name == req.ContainerName {
-			if err := v.updateContainerResources(&deploy.Spec.Template.Spec.Containers[i], req); err != nil {
-				return err
-			}
-			break
-		}
+	if err := v.updatePodResources(&deploy.Spec.Template.Spec, req); err != nil {
+		return err
 	}
 
 	_, err = v.kubeClient.AppsV1().Deployments(req.Namespace).Update(ctx, deploy, metav1.UpdateOptions{})
@@ -231,13 +204,7 @@ func (v *VerticalScaler) rollingUpdateDeployment(ctx context.Context, req *Scale
 		return fmt.Errorf("failed to update deployment: %v", err)
 	}
 
-	if err := v.waitForRolloutComplete(ctx, req.Namespace, req.WorkloadName, "Deployment"); err != nil {
-		v.recordEvent(deploy, corev1.EventTypeWarning, "VerticalScaleFailed", fmt.Sprintf("Rollout failed: %v", err))
-		return err
-	}
-
-	v.recordEvent(deploy, corev1.EventTypeNormal, "VerticalScaleComplete", "Rolling update completed successfully")
-	klog.Infof("Successfully completed rolling update for Deployment %s/%s", req.Namespace, req.WorkloadName)
+	klog.Infof("Started rolling update for Deployment %s/%s", req.Namespace, req.WorkloadName)
 	return nil
 }
 
@@ -249,13 +216,8 @@ func (v *VerticalScaler) rollingUpdateStatefulSet(ctx context.Context, req *Scal
 
 	v.recordEvent(sts, corev1.EventTypeNormal, "VerticalScaleStarted", "Starting rolling update for vertical scaling")
 
-	for i := range sts.Spec.Template.Spec.Containers {
-		if sts.Spec.Template.Spec.Containers[i].Name == req.ContainerName {
-			if err := v.updateContainerResources(&sts.Spec.Template.Spec.Containers[i], req); err != nil {
-				return err
-			}
-			break
-		}
+	if err := v.updatePodResources(&sts.Spec.Template.Spec, req); err != nil {
+		return err
 	}
 
 	_, err = v.kubeClient.AppsV1().StatefulSets(req.Namespace).Update(ctx, sts, metav1.UpdateOptions{})
@@ -263,34 +225,7 @@ func (v *VerticalScaler) rollingUpdateStatefulSet(ctx context.Context, req *Scal
 		return fmt.Errorf("failed to update statefulset: %v", err)
 	}
 
-	replicas := int32(1)
-	if sts.Spec.Replicas != nil {
-		replicas = *sts.Spec.Replicas
-	}
-
-	for i := int32(0); i < replicas; i++ {
-		podName := fmt.Sprintf("%s-%d", req.WorkloadName, i)
-		klog.V(3).Infof("Waiting for pod %s to be ready", podName)
-
-		if err := v.waitForPodReady(ctx, req.Namespace, podName); err != nil {
-			v.recordEvent(sts, corev1.EventTypeWarning, "VerticalScaleFailed", fmt.Sprintf("Pod %s failed to become ready: %v", podName, err))
-			return err
-		}
-
-		if pdbResult.HasPDB {
-			checkResult, err := v.pdbChecker.CheckPDBSafety(ctx, req.Namespace, req.WorkloadKind, req.WorkloadName, 1)
-			if err != nil {
-				return err
-			}
-			if !checkResult.IsSafe {
-				klog.Warningf("PDB violation detected, pausing rollout")
-				time.Sleep(30 * time.Second)
-			}
-		}
-	}
-
-	v.recordEvent(sts, corev1.EventTypeNormal, "VerticalScaleComplete", "Rolling update completed successfully")
-	klog.Infof("Successfully completed rolling update for StatefulSet %s/%s", req.Namespace, req.WorkloadName)
+	klog.Infof("Started rolling update for StatefulSet %s/%s", req.Namespace, req.WorkloadName)
 	return nil
 }
 
@@ -302,13 +237,8 @@ func (v *VerticalScaler) rollingUpdateDaemonSet(ctx context.Context, req *ScaleR
 
 	v.recordEvent(ds, corev1.EventTypeNormal, "VerticalScaleStarted", "Starting rolling update for vertical scaling")
 
-	for i := range ds.Spec.Template.Spec.Containers {
-		if ds.Spec.Template.Spec.Containers[i].Name == req.ContainerName {
-			if err := v.updateContainerResources(&ds.Spec.Template.Spec.Containers[i], req); err != nil {
-				return err
-			}
-			break
-		}
+	if err := v.updatePodResources(&ds.Spec.Template.Spec, req); err != nil {
+		return err
 	}
 
 	_, err = v.kubeClient.AppsV1().DaemonSets(req.Namespace).Update(ctx, ds, metav1.UpdateOptions{})
@@ -316,14 +246,45 @@ func (v *VerticalScaler) rollingUpdateDaemonSet(ctx context.Context, req *ScaleR
 		return fmt.Errorf("failed to update daemonset: %v", err)
 	}
 
-	if err := v.waitForRolloutComplete(ctx, req.Namespace, req.WorkloadName, "DaemonSet"); err != nil {
-		v.recordEvent(ds, corev1.EventTypeWarning, "VerticalScaleFailed", fmt.Sprintf("Rollout failed: %v", err))
-		return err
+	klog.Infof("Started rolling update for DaemonSet %s/%s", req.Namespace, req.WorkloadName)
+	return nil
+}
+
+// updatePodResources resizes the requested container of a pod template. With PreserveQoS,
+// a Guaranteed pod's limits are moved to the new requests, and the update is refused
+// when the pod would still end up in another QoS class.
+func (v *VerticalScaler) updatePodResources(podSpec *corev1.PodSpec, req *ScaleRequest) error {
+	before := qos.PodClass(podSpec)
+
+	for i := range podSpec.Containers {
+		container := &podSpec.Containers[i]
+		if container.Name != req.ContainerName {
+			continue
+		}
+		if err := v.updateContainerResources(container, req); err != nil {
+			return err
+		}
+		if !req.PreserveQoS {
+			return nil
+		}
+
+		if before == corev1.PodQOSGuaranteed {
+			if container.Resources.Limits == nil {
+				container.Resources.Limits = corev1.ResourceList{}
+			}
+			for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+				if request, ok := container.Resources.Requests[name]; ok {
+					container.Resources.Limits[name] = request
+				}
+			}
+		}
+		if after := qos.PodClass(podSpec); after != before {
+			return fmt.Errorf("update would change the pod QoS class from %s to %s", before, after)
+		}
+		return nil
 	}
 
-	v.recordEvent(ds, corev1.EventTypeNormal, "VerticalScaleComplete", "Rolling update completed successfully")
-	klog.Infof("Successfully completed rolling update for DaemonSet %s/%s", req.Namespace, req.WorkloadName)
-	return nil
+	return fmt.Errorf("container %s not found", req.ContainerName)
 }
 
 func (v *VerticalScaler) updateContainerResources(container *corev1.Container, req *ScaleRequest) error {
@@ -349,58 +310,142 @@ func (v *VerticalScaler) updateContainerResources(container *corev1.Container, r
 		klog.V(3).Infof("Updated memory request to %s", req.NewMemory)
 	}
 
+	if req.NewEphemeralStorage != "" {
+		storageQuantity, err := resource.ParseQuantity(req.NewEphemeralStorage)
+		if err != nil {
+			return fmt.Errorf("invalid ephemeral-storage quantity %s: %v", req.NewEphemeralStorage, err)
+		}
+		container.Resources.Requests[corev1.ResourceEphemeralStorage] = storageQuantity
+		klog.V(3).Infof("Updated ephemeral-storage request to %s", req.NewEphemeralStorage)
+	}
+
+	if req.NewMemoryLimit != "" {
+		limitQuantity, err := resource.ParseQuantity(req.NewMemoryLimit)
+		if err != nil {
+			return fmt.Errorf("invalid memory limit quantity %s: %v", req.NewMemoryLimit, err)
+		}
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		container.Resources.Limits[corev1.ResourceMemory] = limitQuantity
+		klog.V(3).Infof("Updated memory limit to %s", req.NewMemoryLimit)
+	}
+
+	if req.NewCPULimit != "" {
+		limitQuantity, err := resource.ParseQuantity(req.NewCPULimit)
+		if err != nil {
+			return fmt.Errorf("invalid CPU limit quantity %s: %v", req.NewCPULimit, err)
+		}
+		if container.Resources.Limits == nil {
+			container.Resources.Limits = corev1.ResourceList{}
+		}
+		container.Resources.Limits[corev1.ResourceCPU] = limitQuantity
+		klog.V(3).Infof("Updated CPU limit to %s", req.NewCPULimit)
+	}
+
 	return nil
 }
 
-func (v *VerticalScaler) waitForRolloutComplete(ctx context.Context, namespace, name, kind string) error {
-	timeout := 5 * time.Minute
-	interval := 5 * time.Second
+// RolloutState is the progress of a rolling update
+type RolloutState string
 
-	return wait.PollUntilContextTimeout(ctx, interval, timeout, true, func(ctx context.Context) (bool, error) {
-		switch kind {
-		case "Deployment":
-			deploy, err := v.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
-			if err != nil {
-				return false, err
-			}
-			return v.isDeploymentReady(deploy), nil
+const (
+	// RolloutInProgress means pods are still being replaced
+	RolloutInProgress RolloutState = "InProgress"
+	// RolloutComplete means all pods run the current template and are available
+	RolloutComplete RolloutState = "Complete"
+	// RolloutFailed means the rollout made no progress within its deadline
+	RolloutFailed RolloutState = "Failed"
+)
 
-		case "DaemonSet":
-			ds, err := v.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
-			if err != nil {
-				return false, err
-			}
-			return v.isDaemonSetReady(ds), nil
+// DefaultProgressDeadline is how long a StatefulSet or DaemonSet rollout may take, matching
+// the default progressDeadlineSeconds of Deployments
+const DefaultProgressDeadline = 10 * time.Minute
 
-		default:
-			return false, fmt.Errorf("unsupported kind for rollout: %s", kind)
-		}
-	})
+// RolloutStatus is the progress of a rolling update at one point in time
+type RolloutStatus struct {
+	State   RolloutState
+	Message string
 }
 
-func (v *VerticalScaler) waitForPodReady(ctx context.Context, namespace, podName string) error {
-	timeout := 3 * time.Minute
-	interval := 5 * time.Second
+// RolloutStatus reads the progress of a rolling update started at the given time without
+// waiting for it. A Deployment fails once its controller reports that progressDeadlineSeconds
+// was exceeded; StatefulSets and DaemonSets have no deadline of their own and fail when
+// they are not complete DefaultProgressDeadline after the start.
+func (v *VerticalScaler) RolloutStatus(ctx context.Context, namespace, kind, name string, startedAt, now time.Time) (*RolloutStatus, error) {
+	var status *RolloutStatus
+	switch kind {
+	case "Deployment":
+		deploy, err := v.kubeClient.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if v.isDeploymentReady(deploy) {
+			return &RolloutStatus{State: RolloutComplete, Message: "rollout complete"}, nil
+		}
+		for _, condition := range deploy.Status.Conditions {
+			if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse &&
+				condition.Reason == "ProgressDeadlineExceeded" {
+				return &RolloutStatus{State: RolloutFailed, Message: condition.Message}, nil
+			}
+		}
+		return &RolloutStatus{
+			State: RolloutInProgress,
+			Message: fmt.Sprintf("%d of %d pods updated, %d available", deploy.Status.UpdatedReplicas,
+				replicasOf(deploy.Spec.Replicas), deploy.Status.AvailableReplicas),
+		}, nil
 
-	return wait.PollUntilContextTimeout(ctx, interval, timeout, true, func(ctx context.Context) (bool, error) {
-		pod, err := v.kubeClient.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	case "StatefulSet":
+		sts, err := v.kubeClient.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
 		if err != nil {
-			return false, nil
+			return nil, err
+		}
+		if v.isStatefulSetReady(sts) {
+			return &RolloutStatus{State: RolloutComplete, Message: "rollout complete"}, nil
+		}
+		status = &RolloutStatus{
+			State: RolloutInProgress,
+			Message: fmt.Sprintf("%d of %d pods updated, %d ready", sts.Status.UpdatedReplicas,
+				replicasOf(sts.Spec.Replicas), sts.Status.ReadyReplicas),
 		}
 
-		for _, condition := range pod.Status.Conditions {
-			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
-				return true, nil
-			}
+	case "DaemonSet":
+		ds, err := v.kubeClient.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
 		}
-		return false, nil
-	})
+		if v.isDaemonSetReady(ds) {
+			return &RolloutStatus{State: RolloutComplete, Message: "rollout complete"}, nil
+		}
+		status = &RolloutStatus{
+			State: RolloutInProgress,
+			Message: fmt.Sprintf("%d of %d pods updated, %d available", ds.Status.UpdatedNumberScheduled,
+				ds.Status.DesiredNumberScheduled, ds.Status.NumberAvailable),
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported kind for rollout: %s", kind)
+	}
+
+	if now.Sub(startedAt) > DefaultProgressDeadline {
+		status.State = RolloutFailed
+		status.Message = fmt.Sprintf("rollout did not complete within %s: %s", DefaultProgressDeadline, status.Message)
+	}
+	return status, nil
+}
+
+func replicasOf(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
 }
 
 func (v *VerticalScaler) isDeploymentReady(deploy *appsv1.Deployment) bool {
-	return deploy.Status.UpdatedReplicas == *deploy.Spec.Replicas &&
-		deploy.Status.Replicas == *deploy.Spec.Replicas &&
-		deploy.Status.AvailableReplicas == *deploy.Spec.Replicas &&
+	replicas := replicasOf(deploy.Spec.Replicas)
+	return deploy.Status.UpdatedReplicas == replicas &&
+		deploy.Status.Replicas == replicas &&
+		deploy.Status.AvailableReplicas == replicas &&
 		deploy.Status.ObservedGeneration >= deploy.Generation
 }
 
@@ -410,6 +455,13 @@ func (v *VerticalScaler) isDaemonSetReady(ds *appsv1.DaemonSet) bool {
 		ds.Status.ObservedGeneration >= ds.Generation
 }
 
+func (v *VerticalScaler) isStatefulSetReady(sts *appsv1.StatefulSet) bool {
+	replicas := replicasOf(sts.Spec.Replicas)
+	return sts.Status.UpdatedReplicas == replicas &&
+		sts.Status.ReadyReplicas == replicas &&
+		sts.Status.ObservedGeneration >= sts.Generation
+}
+
 func (v *VerticalScaler) recordEvent(obj interface{}, eventType, reason, message string) {
 	if v.eventRecorder == nil {
 		return
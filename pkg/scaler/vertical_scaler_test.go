@@ -0,0 +1,114 @@
+package scaler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRolloutStatus(t *testing.T) {
+	replicas := int32(3)
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		object    runtime.Object
+		kind      string
+		startedAt time.Time
+		expected  RolloutState
+	}{
+		{
+			name: "deployment complete",
+			object: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     appsv1.DeploymentStatus{Replicas: 3, UpdatedReplicas: 3, AvailableReplicas: 3},
+			},
+			kind:      "Deployment",
+			startedAt: now,
+			expected:  RolloutComplete,
+		},
+		{
+			name: "deployment in progress",
+			object: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     appsv1.DeploymentStatus{Replicas: 4, UpdatedReplicas: 1, AvailableReplicas: 3},
+			},
+			kind:      "Deployment",
+			startedAt: now.Add(-time.Hour),
+			expected:  RolloutInProgress,
+		},
+		{
+			name: "deployment past progressDeadlineSeconds",
+			object: &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					Replicas: 4, UpdatedReplicas: 1, AvailableReplicas: 3,
+					Conditions: []appsv1.DeploymentCondition{{
+						Type:   appsv1.DeploymentProgressing,
+						Status: corev1.ConditionFalse,
+						Reason: "ProgressDeadlineExceeded",
+					}},
+				},
+			},
+			kind:      "Deployment",
+			startedAt: now,
+			expected:  RolloutFailed,
+		},
+		{
+			name: "statefulset within the default deadline",
+			object: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+				Status:     appsv1.StatefulSetStatus{UpdatedReplicas: 1, ReadyReplicas: 2},
+			},
+			kind:      "StatefulSet",
+			startedAt: now.Add(-time.Minute),
+			expected:  RolloutInProgress,
+		},
+		{
+			name: "statefulset past the default deadline",
+			object: &appsv1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+				Status:     appsv1.StatefulSetStatus{UpdatedReplicas: 1, ReadyReplicas: 2},
+			},
+			kind:      "StatefulSet",
+			startedAt: now.Add(-DefaultProgressDeadline - time.Minute),
+			expected:  RolloutFailed,
+		},
+		{
+			name: "daemonset complete",
+			object: &appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "default"},
+				Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 5, UpdatedNumberScheduled: 5, NumberAvailable: 5},
+			},
+			kind:      "DaemonSet",
+			startedAt: now.Add(-time.Hour),
+			expected:  RolloutComplete,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewVerticalScaler(fake.NewSimpleClientset(tt.object), nil)
+			name := tt.object.(metav1.Object).GetName()
+
+			status, err := v.RolloutStatus(context.Background(), "default", tt.kind, name, tt.startedAt, now)
+			if err != nil {
+				t.Fatalf("RolloutStatus failed: %v", err)
+			}
+			if status.State != tt.expected {
+				t.Errorf("Expected %s, got %s (%s)", tt.expected, status.State, status.Message)
+			}
+		})
+	}
+}
@@ -74,8 +74,9 @@ func (m *MaintenanceWindowChecker) isInWindow(window optimizerv1alpha1.Maintenan
 		return false
 	}
 
+	// Next returns the zero time for schedules that never fire, such as February 30th
 	lastStart := schedule.Next(nowInTz.Add(-duration - time.Minute))
-	for lastStart.Before(nowInTz) {
+	for !lastStart.IsZero() && lastStart.Before(nowInTz) {
 		windowEnd := lastStart.Add(duration)
 		if nowInTz.After(lastStart) && nowInTz.Before(windowEnd) {
 			klog.V(4).Infof("Currently in maintenance window: %s - %s", lastStart.Format(time.RFC3339), windowEnd.Format(time.RFC3339))
@@ -103,6 +104,9 @@ func (m *MaintenanceWindowChecker) getNextWindowStart(window optimizerv1alpha1.M
 	}
 
 	next := schedule.Next(nowInTz)
+	if next.IsZero() {
+		return nil
+	}
 	return &next
 }
 
@@ -114,9 +118,13 @@ func (m *MaintenanceWindowChecker) getLocation(timezone string) (*time.Location,
 }
 
 func (m *MaintenanceWindowChecker) ValidateMaintenanceWindow(window optimizerv1alpha1.MaintenanceWindow) error {
-	if _, err := m.parser.Parse(window.Schedule); err != nil {
+	schedule, err := m.parser.Parse(window.Schedule)
+	if err != nil {
 		return fmt.Errorf("invalid cron schedule %s: %v", window.Schedule, err)
 	}
+	if schedule.Next(time.Now()).IsZero() {
+		return fmt.Errorf("cron schedule %s never fires", window.Schedule)
+	}
 
 	if _, err := time.ParseDuration(window.Duration); err != nil {
 		return fmt.Errorf("invalid duration %s: %v", window.Duration, err)
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/timepattern"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/klog/v2"
+)
+
+// BaseProfileName is reported when no schedule entry is active
+const BaseProfileName = "base"
+
+// TimeProfile is the resource profile in effect at a point in time
+type TimeProfile struct {
+	// Name is the schedule entry name, or BaseProfileName
+	Name string
+
+	// CPUMultiplier and MemoryMultiplier scale the base recommendation
+	CPUMultiplier    float64
+	MemoryMultiplier float64
+
+	// Start is when the active entry started (zero for the base profile)
+	Start time.Time
+
+	// NextSwitch is the next time any schedule entry starts or ends
+	NextSwitch *time.Time
+}
+
+// IsBase returns true when no schedule entry is active
+func (p *TimeProfile) IsBase() bool {
+	return p.Name == BaseProfileName
+}
+
+// TimeProfileScheduler resolves which time-of-day schedule entry is active
+type TimeProfileScheduler struct {
+	parser cron.Parser
+}
+
+// NewTimeProfileScheduler creates a scheduler for timepattern schedule entries
+func NewTimeProfileScheduler() *TimeProfileScheduler {
+	return &TimeProfileScheduler{
+		parser: cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow),
+	}
+}
+
+// ActiveProfile returns the profile in effect at now. When entries overlap the one
+// that started most recently wins; when none is active the base profile (1.0x) applies.
+func (s *TimeProfileScheduler) ActiveProfile(entries []timepattern.ScheduleEntry, now time.Time, location *time.Location) *TimeProfile {
+	if location == nil {
+		location = time.UTC
+	}
+	nowInTz := now.In(location)
+
+	profile := &TimeProfile{
+		Name:             BaseProfileName,
+		CPUMultiplier:    1.0,
+		MemoryMultiplier: 1.0,
+	}
+
+	var nextSwitch time.Time
+	considerSwitch := func(t time.Time) {
+		if t.After(nowInTz) && (nextSwitch.IsZero() || t.Before(nextSwitch)) {
+			nextSwitch = t
+		}
+	}
+
+	for _, entry := range entries {
+		schedule, err := s.parser.Parse(entry.CronSchedule)
+		if err != nil {
+			klog.Warningf("Invalid cron schedule %s for time profile %s: %v", entry.CronSchedule, entry.Name, err)
+			continue
+		}
+
+		considerSwitch(schedule.Next(nowInTz))
+
+		start, active := s.lastStart(schedule, entry.Duration, nowInTz)
+		if !active {
+			continue
+		}
+		considerSwitch(start.Add(entry.Duration))
+
+		if profile.IsBase() || start.After(profile.Start) {
+			profile.Name = entry.Name
+			profile.CPUMultiplier = entry.CPUMultiplier
+			profile.MemoryMultiplier = entry.MemoryMultiplier
+			profile.Start = start
+		}
+	}
+
+	if !nextSwitch.IsZero() {
+		profile.NextSwitch = &nextSwitch
+	}
+	return profile
+}
+
+// lastStart returns the most recent start of the schedule that is still running at now
+func (s *TimeProfileScheduler) lastStart(schedule cron.Schedule, duration time.Duration, now time.Time) (time.Time, bool) {
+	if duration <= 0 {
+		return time.Time{}, false
+	}
+
+	// Next returns the zero time for schedules that never fire, such as February 30th
+	var active time.Time
+	start := schedule.Next(now.Add(-duration - time.Minute))
+	for !start.IsZero() && !start.After(now) {
+		if now.Before(start.Add(duration)) {
+			active = start
+		}
+		start = schedule.Next(start)
+	}
+	return active, !active.IsZero()
+}
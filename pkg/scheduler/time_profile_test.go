@@ -0,0 +1,106 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"intelligent-cluster-optimizer/pkg/timepattern"
+)
+
+func businessHoursEntries() []timepattern.ScheduleEntry {
+	return []timepattern.ScheduleEntry{
+		{Name: "business-hours", CronSchedule: "0 8 * * 1-5", Duration: 10 * time.Hour, CPUMultiplier: 1.0, MemoryMultiplier: 1.0},
+		{Name: "off-hours", CronSchedule: "0 18 * * 1-5", Duration: 14 * time.Hour, CPUMultiplier: 0.5, MemoryMultiplier: 0.7},
+		{Name: "weekend", CronSchedule: "0 0 * * 0,6", Duration: 24 * time.Hour, CPUMultiplier: 0.3, MemoryMultiplier: 0.5},
+	}
+}
+
+func TestTimeProfileScheduler_ActiveProfile(t *testing.T) {
+	s := NewTimeProfileScheduler()
+	entries := businessHoursEntries()
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		profile    string
+		nextSwitch time.Time
+	}{
+		{
+			name:       "weekday morning",
+			now:        time.Date(2024, 1, 3, 10, 0, 0, 0, time.UTC), // Wednesday
+			profile:    "business-hours",
+			nextSwitch: time.Date(2024, 1, 3, 18, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "weekday evening",
+			now:        time.Date(2024, 1, 3, 20, 30, 0, 0, time.UTC),
+			profile:    "off-hours",
+			nextSwitch: time.Date(2024, 1, 4, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "overlap prefers most recent start",
+			now:        time.Date(2024, 1, 6, 2, 0, 0, 0, time.UTC), // Saturday, Friday off-hours still running
+			profile:    "weekend",
+			nextSwitch: time.Date(2024, 1, 6, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "gap falls back to base",
+			now:        time.Date(2024, 1, 8, 3, 0, 0, 0, time.UTC), // Monday before business hours
+			profile:    BaseProfileName,
+			nextSwitch: time.Date(2024, 1, 8, 8, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := s.ActiveProfile(entries, tt.now, time.UTC)
+			if p.Name != tt.profile {
+				t.Errorf("Expected profile %s, got %s", tt.profile, p.Name)
+			}
+			if p.NextSwitch == nil || !p.NextSwitch.Equal(tt.nextSwitch) {
+				t.Errorf("Expected next switch at %v, got %v", tt.nextSwitch, p.NextSwitch)
+			}
+		})
+	}
+}
+
+func TestTimeProfileScheduler_ScheduleThatNeverFires(t *testing.T) {
+	entries := []timepattern.ScheduleEntry{
+		{Name: "february-30", CronSchedule: "0 0 30 2 *", Duration: time.Hour, CPUMultiplier: 2.0, MemoryMultiplier: 2.0},
+	}
+
+	done := make(chan *TimeProfile, 1)
+	go func() { done <- NewTimeProfileScheduler().ActiveProfile(entries, time.Now(), time.UTC) }()
+	select {
+	case p := <-done:
+		if !p.IsBase() || p.NextSwitch != nil {
+			t.Errorf("Expected the base profile without a next switch, got %+v", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ActiveProfile did not return for a schedule that never fires")
+	}
+}
+
+func TestTimeProfileScheduler_BaseMultipliers(t *testing.T) {
+	p := NewTimeProfileScheduler().ActiveProfile(nil, time.Now(), nil)
+	if !p.IsBase() || p.CPUMultiplier != 1.0 || p.MemoryMultiplier != 1.0 {
+		t.Errorf("Expected base profile with 1.0x multipliers, got %+v", p)
+	}
+	if p.NextSwitch != nil {
+		t.Errorf("Expected no next switch without entries, got %v", p.NextSwitch)
+	}
+}
+
+func TestTimeProfileScheduler_Timezone(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	// 14:00 UTC on a Wednesday in January is 09:00 in New York
+	now := time.Date(2024, 1, 3, 14, 0, 0, 0, time.UTC)
+	p := NewTimeProfileScheduler().ActiveProfile(businessHoursEntries(), now, location)
+	if p.Name != "business-hours" {
+		t.Errorf("Expected business-hours in New York, got %s", p.Name)
+	}
+}
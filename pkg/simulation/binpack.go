@@ -0,0 +1,343 @@
+package simulation
+
+import (
+	"sort"
+	"strings"
+
+	"intelligent-cluster-optimizer/pkg/recommendation"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultPoolLabels are the node labels that identify a node pool, in priority order.
+// Nodes without any of them are grouped into the "default" pool.
+var DefaultPoolLabels = []string{
+	"karpenter.sh/nodepool",
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"kubernetes.azure.com/agentpool",
+	"node.kubernetes.io/instance-type",
+}
+
+// DefaultPool is the pool of nodes that carry none of the pool labels
+const DefaultPool = "default"
+
+// Simulator re-packs the pods of a cluster onto its nodes with a first-fit-decreasing
+// scheduler model to estimate how many nodes a set of recommendations would free.
+// Pods are only moved within their current node pool. DaemonSet and static pods stay
+// on their node and go away with it.
+type Simulator struct {
+	// PoolLabels are the node labels checked, in order, to find a node's pool
+	PoolLabels []string
+}
+
+// NewSimulator creates a simulator that groups nodes by DefaultPoolLabels
+func NewSimulator() *Simulator {
+	return &Simulator{
+		PoolLabels: DefaultPoolLabels,
+	}
+}
+
+// PoolResult is the packing outcome for one node pool
+type PoolResult struct {
+	Pool string `json:"pool"`
+
+	// Nodes is the number of schedulable nodes in the pool
+	Nodes int `json:"nodes"`
+
+	// NodesAfterRepack is the nodes needed to re-pack pods at their current requests
+	NodesAfterRepack int `json:"nodesAfterRepack"`
+
+	// NodesAfterRecommendations is the nodes needed with the recommended requests
+	NodesAfterRecommendations int `json:"nodesAfterRecommendations"`
+
+	// ReclaimableNodes is NodesAfterRepack minus NodesAfterRecommendations: the nodes the
+	// recommendations free beyond what re-packing at current requests already would
+	ReclaimableNodes int `json:"reclaimableNodes"`
+
+	// UnschedulablePods could not be placed with the recommended requests
+	UnschedulablePods int `json:"unschedulablePods"`
+
+	// CPUFragmentation and MemoryFragmentation measure how scattered the free capacity of
+	// the remaining nodes is, from 0 (all free capacity on one node) towards 1
+	CPUFragmentation    float64 `json:"cpuFragmentation"`
+	MemoryFragmentation float64 `json:"memoryFragmentation"`
+}
+
+// Result is the packing outcome for the whole cluster
+type Result struct {
+	Pools                     []PoolResult `json:"pools"`
+	Nodes                     int          `json:"nodes"`
+	NodesAfterRepack          int          `json:"nodesAfterRepack"`
+	NodesAfterRecommendations int          `json:"nodesAfterRecommendations"`
+	ReclaimableNodes          int          `json:"reclaimableNodes"`
+	UnschedulablePods         int          `json:"unschedulablePods"`
+}
+
+type resources struct {
+	cpu    int64 // millicores
+	memory int64 // bytes
+}
+
+func (r resources) add(o resources) resources {
+	return resources{cpu: r.cpu + o.cpu, memory: r.memory + o.memory}
+}
+
+func (r resources) fits(o resources, capacity resources) bool {
+	return r.cpu+o.cpu <= capacity.cpu && r.memory+o.memory <= capacity.memory
+}
+
+type simNode struct {
+	name        string
+	allocatable resources
+	used        resources
+	movable     int
+}
+
+type simPod struct {
+	key         string
+	node        string
+	current     resources
+	recommended resources
+	pinned      bool
+}
+
+// containerOverrides maps namespace/kind/name to the recommended requests per container
+type containerOverrides map[string]map[string]resources
+
+// Simulate re-packs pods twice, at their current and at their recommended requests,
+// and reports the nodes needed per pool. Cordoned nodes and pods that are not running
+// on a listed node are ignored.
+func (s *Simulator) Simulate(nodes []corev1.Node, pods []corev1.Pod, recs []recommendation.WorkloadRecommendation) *Result {
+	overrides := make(containerOverrides)
+	for _, rec := range recs {
+		key := rec.Namespace + "/" + rec.WorkloadKind + "/" + rec.WorkloadName
+		containers := make(map[string]resources, len(rec.Containers))
+		for _, c := range rec.Containers {
+			containers[c.ContainerName] = resources{cpu: c.RecommendedCPU, memory: c.RecommendedMemory}
+		}
+		overrides[key] = containers
+	}
+
+	poolNodes := make(map[string][]corev1.Node)
+	nodePool := make(map[string]string)
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			continue
+		}
+		pool := s.poolOf(&node)
+		poolNodes[pool] = append(poolNodes[pool], node)
+		nodePool[node.Name] = pool
+	}
+
+	poolPods := make(map[string][]simPod)
+	for i := range pods {
+		pod := &pods[i]
+		pool, ok := nodePool[pod.Spec.NodeName]
+		if !ok || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		poolPods[pool] = append(poolPods[pool], newSimPod(pod, overrides))
+	}
+
+	result := &Result{}
+	for pool, members := range poolNodes {
+		repacked, _ := pack(members, poolPods[pool], false)
+		packed, unschedulable := pack(members, poolPods[pool], true)
+
+		used := usedNodes(packed)
+		pr := PoolResult{
+			Pool:                      pool,
+			Nodes:                     len(members),
+			NodesAfterRepack:          len(usedNodes(repacked)),
+			NodesAfterRecommendations: len(used),
+			UnschedulablePods:         unschedulable,
+			CPUFragmentation: fragmentation(used, func(n *simNode) int64 {
+				return n.allocatable.cpu - n.used.cpu
+			}),
+			MemoryFragmentation: fragmentation(used, func(n *simNode) int64 {
+				return n.allocatable.memory - n.used.memory
+			}),
+		}
+		pr.ReclaimableNodes = max(pr.NodesAfterRepack-pr.NodesAfterRecommendations, 0)
+
+		result.Pools = append(result.Pools, pr)
+		result.Nodes += pr.Nodes
+		result.NodesAfterRepack += pr.NodesAfterRepack
+		result.NodesAfterRecommendations += pr.NodesAfterRecommendations
+		result.ReclaimableNodes += pr.ReclaimableNodes
+		result.UnschedulablePods += pr.UnschedulablePods
+	}
+
+	sort.Slice(result.Pools, func(i, j int) bool {
+		return result.Pools[i].Pool < result.Pools[j].Pool
+	})
+	return result
+}
+
+// poolOf returns the value of the first pool label set on the node
+func (s *Simulator) poolOf(node *corev1.Node) string {
+	for _, label := range s.PoolLabels {
+		if value := node.Labels[label]; value != "" {
+			return value
+		}
+	}
+	return DefaultPool
+}
+
+func newSimPod(pod *corev1.Pod, overrides containerOverrides) simPod {
+	kind, name := podOwner(pod)
+	sp := simPod{
+		key:    pod.Namespace + "/" + pod.Name,
+		node:   pod.Spec.NodeName,
+		pinned: kind == "DaemonSet" || kind == "Node",
+	}
+
+	containers := overrides[pod.Namespace+"/"+kind+"/"+name]
+	for _, c := range pod.Spec.Containers {
+		requested := resources{
+			cpu:    c.Resources.Requests.Cpu().MilliValue(),
+			memory: c.Resources.Requests.Memory().Value(),
+		}
+		sp.current = sp.current.add(requested)
+		if override, ok := containers[c.Name]; ok {
+			requested = override
+		}
+		sp.recommended = sp.recommended.add(requested)
+	}
+
+	// Init containers run one at a time before the app containers
+	for _, c := range pod.Spec.InitContainers {
+		sp.current.cpu = max(sp.current.cpu, c.Resources.Requests.Cpu().MilliValue())
+		sp.current.memory = max(sp.current.memory, c.Resources.Requests.Memory().Value())
+		sp.recommended.cpu = max(sp.recommended.cpu, c.Resources.Requests.Cpu().MilliValue())
+		sp.recommended.memory = max(sp.recommended.memory, c.Resources.Requests.Memory().Value())
+	}
+	return sp
+}
+
+// podOwner resolves the workload that controls a pod. Static pods are owned by their Node.
+func podOwner(pod *corev1.Pod) (kind, name string) {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Controller == nil || !*owner.Controller {
+			continue
+		}
+		if owner.Kind == "ReplicaSet" {
+			if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(owner.Name, "-"+hash) {
+				return "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+			}
+		}
+		return owner.Kind, owner.Name
+	}
+	return "", pod.Name
+}
+
+// pack places pinned pods on their node and the remaining pods first-fit-decreasing
+// onto the largest nodes first. It returns the resulting nodes and how many pods did not fit.
+func pack(nodes []corev1.Node, pods []simPod, recommended bool) ([]*simNode, int) {
+	size := func(p simPod) resources {
+		if recommended {
+			return p.recommended
+		}
+		return p.current
+	}
+
+	simNodes := make([]*simNode, 0, len(nodes))
+	byName := make(map[string]*simNode, len(nodes))
+	var largest resources
+	for _, node := range nodes {
+		sn := &simNode{
+			name: node.Name,
+			allocatable: resources{
+				cpu:    node.Status.Allocatable.Cpu().MilliValue(),
+				memory: node.Status.Allocatable.Memory().Value(),
+			},
+		}
+		largest.cpu = max(largest.cpu, sn.allocatable.cpu)
+		largest.memory = max(largest.memory, sn.allocatable.memory)
+		simNodes = append(simNodes, sn)
+		byName[sn.name] = sn
+	}
+
+	var movable []simPod
+	for _, p := range pods {
+		if p.pinned {
+			byName[p.node].used = byName[p.node].used.add(size(p))
+			continue
+		}
+		movable = append(movable, p)
+	}
+
+	sort.SliceStable(simNodes, func(i, j int) bool {
+		a, b := simNodes[i].allocatable, simNodes[j].allocatable
+		if a.cpu != b.cpu {
+			return a.cpu > b.cpu
+		}
+		if a.memory != b.memory {
+			return a.memory > b.memory
+		}
+		return simNodes[i].name < simNodes[j].name
+	})
+
+	// Decreasing by the dominant share of the largest node
+	share := func(r resources) float64 {
+		var s float64
+		if largest.cpu > 0 {
+			s = float64(r.cpu) / float64(largest.cpu)
+		}
+		if largest.memory > 0 {
+			s = max(s, float64(r.memory)/float64(largest.memory))
+		}
+		return s
+	}
+	sort.SliceStable(movable, func(i, j int) bool {
+		si, sj := share(size(movable[i])), share(size(movable[j]))
+		if si != sj {
+			return si > sj
+		}
+		return movable[i].key < movable[j].key
+	})
+
+	unschedulable := 0
+	for _, p := range movable {
+		placed := false
+		for _, n := range simNodes {
+			if n.used.fits(size(p), n.allocatable) {
+				n.used = n.used.add(size(p))
+				n.movable++
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			unschedulable++
+		}
+	}
+	return simNodes, unschedulable
+}
+
+// usedNodes returns the nodes that still host a movable pod; nodes left with only
+// pinned pods can be removed
+func usedNodes(nodes []*simNode) []*simNode {
+	var used []*simNode
+	for _, n := range nodes {
+		if n.movable > 0 {
+			used = append(used, n)
+		}
+	}
+	return used
+}
+
+// fragmentation is 1 - largest free block / total free capacity over the given nodes
+func fragmentation(nodes []*simNode, free func(*simNode) int64) float64 {
+	var total, largest int64
+	for _, n := range nodes {
+		f := max(free(n), 0)
+		total += f
+		largest = max(largest, f)
+	}
+	if total == 0 {
+		return 0
+	}
+	return 1 - float64(largest)/float64(total)
+}
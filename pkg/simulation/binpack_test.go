@@ -0,0 +1,155 @@
+package simulation
+
+import (
+	"testing"
+
+	"intelligent-cluster-optimizer/pkg/recommendation"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const gi = 1024 * 1024 * 1024
+
+func testNode(name, pool, cpu, memory string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"cloud.google.com/gke-nodepool": pool},
+		},
+		Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}},
+	}
+}
+
+func testPod(name, node, ownerKind, ownerName, cpu, memory string) corev1.Pod {
+	controller := true
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{
+			NodeName: node,
+			Containers: []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if ownerKind != "" {
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: ownerKind, Name: ownerName, Controller: &controller}}
+	}
+	if ownerKind == "ReplicaSet" {
+		pod.Labels = map[string]string{"pod-template-hash": "abc12"}
+	}
+	return pod
+}
+
+func TestSimulate_RecommendationsFreeNodes(t *testing.T) {
+	nodes := []corev1.Node{
+		testNode("node-1", "general", "4", "16Gi"),
+		testNode("node-2", "general", "4", "16Gi"),
+		testNode("node-3", "general", "4", "16Gi"),
+		testNode("node-4", "general", "4", "16Gi"),
+	}
+	// Three replicas over-requesting 3 cores each need one node apiece; the idle node-4 is
+	// freed by re-packing alone and is not credited to the recommendations
+	pods := []corev1.Pod{
+		testPod("api-1", "node-1", "ReplicaSet", "api-abc12", "3", "4Gi"),
+		testPod("api-2", "node-2", "ReplicaSet", "api-abc12", "3", "4Gi"),
+		testPod("api-3", "node-3", "ReplicaSet", "api-abc12", "3", "4Gi"),
+		testPod("logs-1", "node-1", "DaemonSet", "logs", "100m", "128Mi"),
+		testPod("logs-2", "node-2", "DaemonSet", "logs", "100m", "128Mi"),
+		testPod("logs-3", "node-3", "DaemonSet", "logs", "100m", "128Mi"),
+	}
+	recs := []recommendation.WorkloadRecommendation{{
+		Namespace:    "default",
+		WorkloadKind: "Deployment",
+		WorkloadName: "api",
+		Containers: []recommendation.ContainerRecommendation{{
+			ContainerName:     "app",
+			RecommendedCPU:    1000,
+			RecommendedMemory: 2 * gi,
+		}},
+	}}
+
+	result := NewSimulator().Simulate(nodes, pods, recs)
+
+	if len(result.Pools) != 1 || result.Pools[0].Pool != "general" {
+		t.Fatalf("Expected a single general pool, got %+v", result.Pools)
+	}
+	pool := result.Pools[0]
+	if pool.Nodes != 4 || pool.NodesAfterRepack != 3 {
+		t.Errorf("Expected 3 of 4 nodes needed at current requests, got %d of %d", pool.NodesAfterRepack, pool.Nodes)
+	}
+	if pool.NodesAfterRecommendations != 1 || pool.ReclaimableNodes != 2 {
+		t.Errorf("Expected 1 node needed and 2 reclaimable, got %d needed and %d reclaimable",
+			pool.NodesAfterRecommendations, pool.ReclaimableNodes)
+	}
+	if result.ReclaimableNodes != 2 || result.UnschedulablePods != 0 {
+		t.Errorf("Expected 2 reclaimable nodes and no unschedulable pods, got %+v", result)
+	}
+}
+
+func TestSimulate_PodsStayInTheirPool(t *testing.T) {
+	nodes := []corev1.Node{
+		testNode("general-1", "general", "4", "16Gi"),
+		testNode("batch-1", "batch", "16", "64Gi"),
+	}
+	pods := []corev1.Pod{
+		testPod("api-1", "general-1", "ReplicaSet", "api-abc12", "1", "1Gi"),
+		testPod("job-1", "batch-1", "", "", "1", "1Gi"),
+	}
+
+	result := NewSimulator().Simulate(nodes, pods, nil)
+
+	if len(result.Pools) != 2 {
+		t.Fatalf("Expected 2 pools, got %d", len(result.Pools))
+	}
+	for _, pool := range result.Pools {
+		if pool.NodesAfterRecommendations != 1 || pool.ReclaimableNodes != 0 {
+			t.Errorf("Pool %s: expected 1 node kept and none reclaimable, got %+v", pool.Pool, pool)
+		}
+	}
+}
+
+func TestSimulate_UnschedulableAndFragmentation(t *testing.T) {
+	nodes := []corev1.Node{
+		testNode("node-1", "general", "4", "16Gi"),
+		testNode("node-2", "general", "4", "16Gi"),
+	}
+	pods := []corev1.Pod{
+		testPod("a-1", "node-1", "StatefulSet", "a", "3", "2Gi"),
+		testPod("b-1", "node-2", "StatefulSet", "b", "3", "2Gi"),
+	}
+	recs := []recommendation.WorkloadRecommendation{{
+		Namespace: "default", WorkloadKind: "StatefulSet", WorkloadName: "b",
+		Containers: []recommendation.ContainerRecommendation{{
+			ContainerName: "app", RecommendedCPU: 8000, RecommendedMemory: 2 * gi,
+		}},
+	}}
+
+	result := NewSimulator().Simulate(nodes, pods, recs)
+	pool := result.Pools[0]
+
+	if pool.UnschedulablePods != 1 {
+		t.Errorf("Expected the 8-core pod to be unschedulable, got %d", pool.UnschedulablePods)
+	}
+	if pool.NodesAfterRecommendations != 1 {
+		t.Errorf("Expected 1 node used, got %d", pool.NodesAfterRecommendations)
+	}
+	if pool.CPUFragmentation != 0 {
+		t.Errorf("Expected no fragmentation on a single node, got %.2f", pool.CPUFragmentation)
+	}
+
+	// Free capacity spread evenly over two nodes is half fragmented
+	spread := NewSimulator().Simulate(nodes, pods[:2], nil)
+	if got := spread.Pools[0].CPUFragmentation; got != 0.5 {
+		t.Errorf("Expected CPU fragmentation 0.5, got %.2f", got)
+	}
+}
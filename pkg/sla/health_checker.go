@@ -2,6 +2,7 @@ package sla
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -62,7 +63,47 @@ func (h *DefaultHealthChecker) CheckHealth(metrics []Metric, slas []SLADefinitio
 		}
 	}
 
-	// Calculate health score
+	h.score(result)
+	return result, nil
+}
+
+// CheckSignals performs a health check where each SLA is judged only on the metrics of
+// its own signal, so signals with different units can be checked together. Signals are
+// point-in-time observations, so no outliers are detected.
+func (h *DefaultHealthChecker) CheckSignals(signals []Signal) (*HealthCheckResult, error) {
+	result := &HealthCheckResult{
+		Timestamp:  time.Now(),
+		Violations: []SLAViolation{},
+		Outliers:   []ControlChartPoint{},
+		Message:    "System is healthy",
+	}
+
+	for _, signal := range signals {
+		if err := h.monitor.AddSLA(signal.SLA); err != nil {
+			return nil, fmt.Errorf("failed to add SLA %s: %w", signal.SLA.Name, err)
+		}
+		violations, err := h.monitor.CheckSLA(signal.SLA.Name, signal.Metrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check SLA %s: %w", signal.SLA.Name, err)
+		}
+		result.Violations = append(result.Violations, violations...)
+		result.Metrics = append(result.Metrics, signal.Metrics...)
+	}
+
+	h.score(result)
+	if len(result.Violations) > 0 {
+		names := make([]string, 0, len(result.Violations))
+		for _, violation := range result.Violations {
+			names = append(names, violation.SLA.Name)
+		}
+		result.Message = fmt.Sprintf("%s (%s)", result.Message, strings.Join(names, ", "))
+	}
+	return result, nil
+}
+
+// score sets the health score, verdict and message from the violations and outliers
+func (h *DefaultHealthChecker) score(result *HealthCheckResult) {
+	violations := result.Violations
 	result.Score = h.calculateHealthScore(violations, result.Outliers)
 	result.IsHealthy = result.Score >= 70.0 // Healthy threshold
 
@@ -76,8 +117,6 @@ func (h *DefaultHealthChecker) CheckHealth(metrics []Metric, slas []SLADefinitio
 	} else if len(violations) > 0 || len(result.Outliers) > 0 {
 		result.Message = fmt.Sprintf("System healthy with warnings: %d violations, %d outliers", len(violations), len(result.Outliers))
 	}
-
-	return result, nil
 }
 
 // PreOptimizationCheck performs health check before optimization
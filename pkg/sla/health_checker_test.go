@@ -465,3 +465,29 @@ func TestHealthChecker_ViolationTracking(t *testing.T) {
 	t.Logf("Violations resolved: %d, Violations added: %d",
 		len(impact.ViolationsResolved), len(impact.ViolationsAdded))
 }
+
+func TestHealthChecker_CheckSignals(t *testing.T) {
+	checker := NewHealthChecker()
+
+	now := time.Now()
+	// Readiness per pod and an error rate in percent must not be judged together
+	readiness := Signal{
+		SLA:     SLADefinition{Name: "readiness", Type: SLATypeAvailability, Target: 100, Threshold: 10, Window: time.Minute},
+		Metrics: []Metric{{Timestamp: now, Value: 1}, {Timestamp: now, Value: 1}, {Timestamp: now, Value: 0}},
+	}
+	errors := Signal{
+		SLA:     SLADefinition{Name: "errors", Type: SLATypeErrorRate, Target: 0, Threshold: 10, Window: time.Minute},
+		Metrics: []Metric{{Timestamp: now, Value: 5}},
+	}
+
+	result, err := checker.CheckSignals([]Signal{readiness, errors})
+	if err != nil {
+		t.Fatalf("Failed to check signals: %v", err)
+	}
+	if len(result.Violations) != 1 || result.Violations[0].SLA.Name != "readiness" {
+		t.Fatalf("Expected only the readiness SLA to be violated, got %+v", result.Violations)
+	}
+	if result.Score >= 100 || !result.IsHealthy {
+		t.Errorf("Expected a healthy result with a reduced score, got score=%.1f healthy=%v", result.Score, result.IsHealthy)
+	}
+}
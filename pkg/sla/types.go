@@ -146,6 +146,15 @@ type HealthCheckResult struct {
 	Message string
 }
 
+// Signal is one health signal: an SLA and the metrics it is judged on
+type Signal struct {
+	// SLA the metrics must meet
+	SLA SLADefinition
+
+	// Metrics observed for the signal
+	Metrics []Metric
+}
+
 // OptimizationImpact represents the impact of an optimization
 type OptimizationImpact struct {
 	// PreOptimization health check before optimization
@@ -211,6 +220,9 @@ type HealthChecker interface {
 	// PostOptimizationCheck performs health check after optimization
 	PostOptimizationCheck(metrics []Metric) (*HealthCheckResult, error)
 
+	// CheckSignals performs a health check where each SLA is judged on its own metrics
+	CheckSignals(signals []Signal) (*HealthCheckResult, error)
+
 	// CompareHealth compares pre and post optimization health
 	CompareHealth(pre, post *HealthCheckResult) (*OptimizationImpact, error)
 }
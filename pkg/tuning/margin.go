@@ -0,0 +1,305 @@
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/models"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultObservationWindow is how long a workload must run without incidents
+	// for an observation window to count as good
+	DefaultObservationWindow = 6 * time.Hour
+
+	// DefaultTightenAfter is how many consecutive good windows tighten the margin
+	DefaultTightenAfter = 3
+
+	// DefaultStep is how much the margin tightens per adjustment. Incidents widen
+	// the margin by twice the step so it backs off faster than it tightens.
+	DefaultStep = 0.05
+
+	// throttleUsageRatio is how close CPU usage must be to the limit for a sample
+	// to count as throttled
+	throttleUsageRatio = 0.95
+
+	// throttledSampleRatio is the share of throttled samples that counts as an incident
+	throttledSampleRatio = 0.1
+)
+
+// Policy bounds and paces the adjustments of learned margins
+type Policy struct {
+	MinMargin         float64
+	MaxMargin         float64
+	ObservationWindow time.Duration
+	TightenAfter      int
+	Step              float64
+}
+
+// NewPolicy builds the tuning policy from the config's MarginTuning settings with
+// the given bounds (typically from the resolved profile)
+func NewPolicy(cfg *optimizerv1alpha1.MarginTuningConfig, minMargin, maxMargin float64) Policy {
+	policy := Policy{
+		MinMargin:         minMargin,
+		MaxMargin:         maxMargin,
+		ObservationWindow: DefaultObservationWindow,
+		TightenAfter:      DefaultTightenAfter,
+		Step:              DefaultStep,
+	}
+	if cfg != nil {
+		if d, err := time.ParseDuration(cfg.ObservationWindow); err == nil && d > 0 {
+			policy.ObservationWindow = d
+		}
+		if cfg.TightenAfter > 0 {
+			policy.TightenAfter = int(cfg.TightenAfter)
+		}
+		if cfg.Step > 0 {
+			policy.Step = cfg.Step
+		}
+	}
+
+	if policy.MinMargin < 1.0 {
+		policy.MinMargin = 1.0
+	}
+	if policy.MaxMargin < policy.MinMargin {
+		policy.MaxMargin = policy.MinMargin
+	}
+	return policy
+}
+
+// Clamp keeps a margin within the policy bounds
+func (p Policy) Clamp(margin float64) float64 {
+	return math.Min(math.Max(margin, p.MinMargin), p.MaxMargin)
+}
+
+// Outcome is what was observed for a workload since its observation window started
+type Outcome struct {
+	OOMKills    int
+	Restarts    int
+	Throttled   bool
+	SLADegraded bool
+}
+
+// IsIncident returns true if the outcome should widen the margin
+func (o Outcome) IsIncident() bool {
+	return o.OOMKills > 0 || o.Restarts > 0 || o.Throttled || o.SLADegraded
+}
+
+// String describes the outcome, e.g. "2 OOM kills, CPU throttling"
+func (o Outcome) String() string {
+	var parts []string
+	if o.OOMKills > 0 {
+		parts = append(parts, fmt.Sprintf("%d OOM kill%s", o.OOMKills, plural(o.OOMKills)))
+	}
+	if o.Restarts > 0 {
+		parts = append(parts, fmt.Sprintf("%d restart%s", o.Restarts, plural(o.Restarts)))
+	}
+	if o.Throttled {
+		parts = append(parts, "CPU throttling")
+	}
+	if o.SLADegraded {
+		parts = append(parts, "SLA degradation")
+	}
+	if len(parts) == 0 {
+		return "good"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+// IsThrottled reports whether enough samples ran at their CPU limit to assume the
+// container was throttled. Samples without a CPU limit are ignored.
+func IsThrottled(metrics []models.PodMetric, containerName string) bool {
+	var limited, throttled int
+	for _, pm := range metrics {
+		for _, c := range pm.Containers {
+			if c.LimitCPU <= 0 || (containerName != "" && c.ContainerName != containerName) {
+				continue
+			}
+			limited++
+			if float64(c.UsageCPU) >= float64(c.LimitCPU)*throttleUsageRatio {
+				throttled++
+			}
+		}
+	}
+	return limited > 0 && float64(throttled)/float64(limited) >= throttledSampleRatio
+}
+
+// Adjustment describes a change to a workload's learned margin
+type Adjustment struct {
+	Namespace    string
+	WorkloadName string
+	OldMargin    float64
+	NewMargin    float64
+	Outcome      Outcome
+}
+
+// Widened returns true if the margin grew
+func (a *Adjustment) Widened() bool {
+	return a.NewMargin > a.OldMargin
+}
+
+// MarginTuner maintains per-workload safety margins in OptimizerConfig status.
+// Each applied change starts an observation window; incidents widen the margin
+// right away and consecutive good windows tighten it.
+type MarginTuner struct {
+	// mu protects concurrent access to learned margins in config.Status
+	mu sync.Mutex
+}
+
+// NewMarginTuner creates a margin tuner
+func NewMarginTuner() *MarginTuner {
+	return &MarginTuner{}
+}
+
+// Margin returns the learned margin for the workload, or base when nothing has been
+// learned yet, clamped to the policy bounds
+func (t *MarginTuner) Margin(config *optimizerv1alpha1.OptimizerConfig, namespace, workloadName string, base float64, policy Policy) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if entry := findMargin(config, namespace, workloadName); entry != nil {
+		return policy.Clamp(entry.SafetyMargin)
+	}
+	return policy.Clamp(base)
+}
+
+// RecordApplied starts a new observation window for a workload whose resources were
+// just changed using the given margin
+func (t *MarginTuner) RecordApplied(config *optimizerv1alpha1.OptimizerConfig, namespace, kind, workloadName string, margin float64, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := findMargin(config, namespace, workloadName)
+	if entry == nil {
+		config.Status.LearnedMargins = append(config.Status.LearnedMargins, optimizerv1alpha1.LearnedSafetyMargin{
+			Namespace:    namespace,
+			WorkloadName: workloadName,
+			SafetyMargin: margin,
+		})
+		entry = &config.Status.LearnedMargins[len(config.Status.LearnedMargins)-1]
+	}
+	entry.WorkloadKind = kind
+	since := metav1.NewTime(now)
+	entry.ObservingSince = &since
+}
+
+// Observing returns the workloads with an open observation window
+func (t *MarginTuner) Observing(config *optimizerv1alpha1.OptimizerConfig) []optimizerv1alpha1.LearnedSafetyMargin {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var observing []optimizerv1alpha1.LearnedSafetyMargin
+	for _, entry := range config.Status.LearnedMargins {
+		if entry.ObservingSince != nil {
+			observing = append(observing, *entry.DeepCopy())
+		}
+	}
+	return observing
+}
+
+// Forget drops the learned margin of a workload that no longer exists
+func (t *MarginTuner) Forget(config *optimizerv1alpha1.OptimizerConfig, namespace, workloadName string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	margins := config.Status.LearnedMargins[:0]
+	for _, entry := range config.Status.LearnedMargins {
+		if entry.Namespace != namespace || entry.WorkloadName != workloadName {
+			margins = append(margins, entry)
+		}
+	}
+	config.Status.LearnedMargins = margins
+}
+
+// Observe folds an outcome into the workload's learned margin. An incident widens
+// the margin and restarts the window so the same events are not counted twice. A
+// window that elapses without incidents counts as good, and TightenAfter good
+// windows in a row tighten the margin. It returns the adjustment made, if any.
+func (t *MarginTuner) Observe(config *optimizerv1alpha1.OptimizerConfig, namespace, workloadName string, outcome Outcome, policy Policy, now time.Time) *Adjustment {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry := findMargin(config, namespace, workloadName)
+	if entry == nil || entry.ObservingSince == nil {
+		return nil
+	}
+
+	oldMargin := entry.SafetyMargin
+	newMargin := oldMargin
+	since := metav1.NewTime(now)
+
+	if outcome.IsIncident() {
+		entry.Incidents++
+		entry.GoodWindows = 0
+		entry.LastOutcome = outcome.String()
+		entry.ObservingSince = &since
+		newMargin = policy.Clamp(oldMargin + 2*policy.Step)
+	} else if now.Sub(entry.ObservingSince.Time) >= policy.ObservationWindow {
+		entry.GoodWindows++
+		entry.LastOutcome = outcome.String()
+		entry.ObservingSince = &since
+		if int(entry.GoodWindows) >= policy.TightenAfter {
+			entry.GoodWindows = 0
+			newMargin = policy.Clamp(oldMargin - policy.Step)
+		}
+	}
+
+	// Keep two decimals so status stays readable
+	newMargin = math.Round(newMargin*100) / 100
+	if newMargin == oldMargin {
+		return nil
+	}
+	entry.SafetyMargin = newMargin
+	entry.LastAdjusted = &since
+
+	return &Adjustment{
+		Namespace:    namespace,
+		WorkloadName: workloadName,
+		OldMargin:    oldMargin,
+		NewMargin:    newMargin,
+		Outcome:      outcome,
+	}
+}
+
+// Provider returns a provider of the learned margins in the config's status for the
+// recommendation engine
+func (t *MarginTuner) Provider(config *optimizerv1alpha1.OptimizerConfig, policy Policy) *MarginProvider {
+	return &MarginProvider{tuner: t, config: config, policy: policy}
+}
+
+// MarginProvider supplies learned per-workload margins to the recommendation engine
+type MarginProvider struct {
+	tuner  *MarginTuner
+	config *optimizerv1alpha1.OptimizerConfig
+	policy Policy
+}
+
+// GetSafetyMargin returns the learned margin for the workload, or base clamped to the
+// policy bounds
+func (p *MarginProvider) GetSafetyMargin(namespace, workloadName string, base float64) float64 {
+	return p.tuner.Margin(p.config, namespace, workloadName, base, p.policy)
+}
+
+// findMargin returns the learned margin entry for the workload (nil if none)
+func findMargin(config *optimizerv1alpha1.OptimizerConfig, namespace, workloadName string) *optimizerv1alpha1.LearnedSafetyMargin {
+	for i := range config.Status.LearnedMargins {
+		entry := &config.Status.LearnedMargins[i]
+		if entry.Namespace == namespace && entry.WorkloadName == workloadName {
+			return entry
+		}
+	}
+	return nil
+}
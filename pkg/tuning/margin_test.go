@@ -0,0 +1,119 @@
+package tuning
+
+import (
+	"testing"
+	"time"
+
+	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/models"
+)
+
+func testPolicy() Policy {
+	return NewPolicy(&optimizerv1alpha1.MarginTuningConfig{
+		Enabled:           true,
+		ObservationWindow: "1h",
+		TightenAfter:      2,
+		Step:              0.1,
+	}, 1.1, 1.5)
+}
+
+func TestMarginTuner_TightensAfterGoodWindows(t *testing.T) {
+	tuner := NewMarginTuner()
+	config := &optimizerv1alpha1.OptimizerConfig{}
+	policy := testPolicy()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tuner.RecordApplied(config, "default", "Deployment", "web", 1.3, start)
+
+	// Nothing happens until a window has elapsed
+	if adj := tuner.Observe(config, "default", "web", Outcome{}, policy, start.Add(30*time.Minute)); adj != nil {
+		t.Fatalf("Expected no adjustment inside the window, got %+v", adj)
+	}
+	if adj := tuner.Observe(config, "default", "web", Outcome{}, policy, start.Add(time.Hour)); adj != nil {
+		t.Fatalf("Expected the first good window not to tighten yet, got %+v", adj)
+	}
+
+	adj := tuner.Observe(config, "default", "web", Outcome{}, policy, start.Add(2*time.Hour))
+	if adj == nil || adj.Widened() || adj.NewMargin != 1.2 {
+		t.Fatalf("Expected the margin to tighten to 1.20 after two good windows, got %+v", adj)
+	}
+	if got := tuner.Margin(config, "default", "web", 1.3, policy); got != 1.2 {
+		t.Errorf("Expected learned margin 1.20, got %.2f", got)
+	}
+
+	// Tightening stops at the lower bound
+	tuner.Observe(config, "default", "web", Outcome{}, policy, start.Add(3*time.Hour))
+	tuner.Observe(config, "default", "web", Outcome{}, policy, start.Add(4*time.Hour))
+	tuner.Observe(config, "default", "web", Outcome{}, policy, start.Add(5*time.Hour))
+	tuner.Observe(config, "default", "web", Outcome{}, policy, start.Add(6*time.Hour))
+	if got := tuner.Margin(config, "default", "web", 1.3, policy); got != 1.1 {
+		t.Errorf("Expected the margin to stop at the 1.10 lower bound, got %.2f", got)
+	}
+}
+
+func TestMarginTuner_WidensOnIncident(t *testing.T) {
+	tuner := NewMarginTuner()
+	config := &optimizerv1alpha1.OptimizerConfig{}
+	policy := testPolicy()
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	tuner.RecordApplied(config, "default", "Deployment", "web", 1.2, start)
+	tuner.Observe(config, "default", "web", Outcome{}, policy, start.Add(time.Hour))
+
+	adj := tuner.Observe(config, "default", "web", Outcome{OOMKills: 1}, policy, start.Add(90*time.Minute))
+	if adj == nil || !adj.Widened() || adj.NewMargin != 1.4 {
+		t.Fatalf("Expected an OOM kill to widen the margin by twice the step to 1.40, got %+v", adj)
+	}
+
+	entry := config.Status.LearnedMargins[0]
+	if entry.GoodWindows != 0 || entry.Incidents != 1 || entry.LastOutcome != "1 OOM kill" {
+		t.Errorf("Expected the good streak to reset and the incident to be recorded, got %+v", entry)
+	}
+
+	// Widening stops at the upper bound
+	tuner.Observe(config, "default", "web", Outcome{SLADegraded: true}, policy, start.Add(2*time.Hour))
+	if got := tuner.Margin(config, "default", "web", 1.2, policy); got != 1.5 {
+		t.Errorf("Expected the margin to stop at the 1.50 upper bound, got %.2f", got)
+	}
+}
+
+func TestMarginTuner_MarginWithoutHistory(t *testing.T) {
+	tuner := NewMarginTuner()
+	config := &optimizerv1alpha1.OptimizerConfig{}
+	policy := testPolicy()
+
+	provider := tuner.Provider(config, policy)
+	if got := provider.GetSafetyMargin("default", "web", 1.3); got != 1.3 {
+		t.Errorf("Expected the configured margin without history, got %.2f", got)
+	}
+	if got := provider.GetSafetyMargin("default", "web", 2.0); got != 1.5 {
+		t.Errorf("Expected the configured margin to be clamped to the bounds, got %.2f", got)
+	}
+	if adj := tuner.Observe(config, "default", "web", Outcome{OOMKills: 3}, policy, time.Now()); adj != nil {
+		t.Errorf("Expected no adjustment for a workload that was never changed, got %+v", adj)
+	}
+}
+
+func TestIsThrottled(t *testing.T) {
+	sample := func(usage int64) models.PodMetric {
+		return models.PodMetric{Containers: []models.ContainerMetric{{
+			ContainerName: "web", UsageCPU: usage, LimitCPU: 500,
+		}}}
+	}
+
+	var metrics []models.PodMetric
+	for i := 0; i < 20; i++ {
+		metrics = append(metrics, sample(200))
+	}
+	if IsThrottled(metrics, "web") {
+		t.Error("Expected usage well below the limit not to count as throttling")
+	}
+
+	metrics = append(metrics, sample(490), sample(500), sample(500))
+	if !IsThrottled(metrics, "web") {
+		t.Error("Expected frequent usage at the limit to count as throttling")
+	}
+	if IsThrottled(metrics, "sidecar") {
+		t.Error("Expected other containers to be ignored")
+	}
+}
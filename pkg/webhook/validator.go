@@ -8,6 +8,7 @@ import (
 	"time"
 
 	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/recommendation"
 
 	"github.com/robfig/cron/v3"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -22,13 +23,21 @@ type ValidatorInterface interface {
 
 // OptimizerConfigValidator validates OptimizerConfig resources
 type OptimizerConfigValidator struct {
-	cronParser cron.Parser
+	cronParser   cron.Parser
+	recommenders *recommendation.Registry
 }
 
-// NewValidator creates a new OptimizerConfig validator
+// NewValidator creates a new OptimizerConfig validator that accepts the built-in recommenders
 func NewValidator() ValidatorInterface {
+	return NewValidatorWithRegistry(recommendation.NewDefaultRegistry())
+}
+
+// NewValidatorWithRegistry creates a validator that accepts every recommender in the
+// registry, normally the recommendation engine's own
+func NewValidatorWithRegistry(recommenders *recommendation.Registry) ValidatorInterface {
 	return &OptimizerConfigValidator{
-		cronParser: cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow),
+		cronParser:   cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow),
+		recommenders: recommenders,
 	}
 }
 
@@ -152,10 +161,13 @@ func (v *OptimizerConfigValidator) validateMaintenanceWindows(config *optimizerv
 			return fmt.Errorf("maintenanceWindows[%d].schedule cannot be empty", i)
 		}
 
-		_, err := v.cronParser.Parse(window.Schedule)
+		schedule, err := v.cronParser.Parse(window.Schedule)
 		if err != nil {
 			return fmt.Errorf("maintenanceWindows[%d].schedule has invalid cron expression '%s': %w", i, window.Schedule, err)
 		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("maintenanceWindows[%d].schedule '%s' never fires", i, window.Schedule)
+		}
 
 		// Validate duration format
 		if window.Duration == "" {
@@ -269,6 +281,18 @@ func (v *OptimizerConfigValidator) validateRecommendationConfig(config *optimize
 		}
 	}
 
+	// Validate recommender names
+	for _, r := range []struct{ field, name string }{
+		{"cpuRecommender", rec.CPURecommender},
+		{"memoryRecommender", rec.MemoryRecommender},
+	} {
+		field, name := r.field, r.name
+		if _, ok := v.recommenders.Get(name); name != "" && !ok {
+			return fmt.Errorf("recommendations.%s must be a registered recommender (%s), got %q", field,
+				strings.Join(v.recommenders.Names(), ", "), name)
+		}
+	}
+
 	// Validate HistoryDuration
 	if rec.HistoryDuration != "" {
 		duration, err := time.ParseDuration(rec.HistoryDuration)
@@ -285,7 +309,29 @@ func (v *OptimizerConfigValidator) validateRecommendationConfig(config *optimize
 
 // validateUpdateStrategy validates update strategy configuration
 func (v *OptimizerConfigValidator) validateUpdateStrategy(config *optimizerv1alpha1.OptimizerConfig) error {
-	if config.Spec.UpdateStrategy == nil || config.Spec.UpdateStrategy.RollingUpdate == nil {
+	if config.Spec.UpdateStrategy == nil {
+		return nil
+	}
+
+	if canary := config.Spec.UpdateStrategy.Canary; canary != nil {
+		if canary.Replicas < 0 {
+			return fmt.Errorf("updateStrategy.canary.replicas must be non-negative, got %d", canary.Replicas)
+		}
+		if canary.MaxRestarts < 0 {
+			return fmt.Errorf("updateStrategy.canary.maxRestarts must be non-negative, got %d", canary.MaxRestarts)
+		}
+		if canary.SoakPeriod != "" {
+			soak, err := time.ParseDuration(canary.SoakPeriod)
+			if err != nil {
+				return fmt.Errorf("updateStrategy.canary.soakPeriod has invalid duration format: %w", err)
+			}
+			if soak <= 0 {
+				return fmt.Errorf("updateStrategy.canary.soakPeriod must be positive, got %s", canary.SoakPeriod)
+			}
+		}
+	}
+
+	if config.Spec.UpdateStrategy.RollingUpdate == nil {
 		return nil
 	}
 
@@ -350,6 +396,13 @@ func (v *OptimizerConfigValidator) validateCircuitBreaker(config *optimizerv1alp
 		}
 	}
 
+	// Validate NamespaceErrorThreshold
+	if cb.NamespaceErrorThreshold != 0 {
+		if cb.NamespaceErrorThreshold < 1 || cb.NamespaceErrorThreshold > 100 {
+			return fmt.Errorf("circuitBreaker.namespaceErrorThreshold must be between 1 and 100, got %d", cb.NamespaceErrorThreshold)
+		}
+	}
+
 	// Validate SuccessThreshold
 	if cb.SuccessThreshold != 0 {
 		if cb.SuccessThreshold < 1 || cb.SuccessThreshold > 10 {
@@ -358,8 +411,10 @@ func (v *OptimizerConfigValidator) validateCircuitBreaker(config *optimizerv1alp
 	}
 
 	// Validate Timeout
+	timeout := 5 * time.Minute
 	if cb.Timeout != "" {
-		timeout, err := time.ParseDuration(cb.Timeout)
+		var err error
+		timeout, err = time.ParseDuration(cb.Timeout)
 		if err != nil {
 			return fmt.Errorf("circuitBreaker.timeout has invalid format '%s': %w", cb.Timeout, err)
 		}
@@ -368,6 +423,17 @@ func (v *OptimizerConfigValidator) validateCircuitBreaker(config *optimizerv1alp
 		}
 	}
 
+	// Validate MaxTimeout
+	if cb.MaxTimeout != "" {
+		maxTimeout, err := time.ParseDuration(cb.MaxTimeout)
+		if err != nil {
+			return fmt.Errorf("circuitBreaker.maxTimeout has invalid format '%s': %w", cb.MaxTimeout, err)
+		}
+		if maxTimeout < timeout {
+			return fmt.Errorf("circuitBreaker.maxTimeout (%s) must not be shorter than timeout (%s)", maxTimeout, timeout)
+		}
+	}
+
 	return nil
 }
 
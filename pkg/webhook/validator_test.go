@@ -1,9 +1,11 @@
 package webhook
 
 import (
+	"strings"
 	"testing"
 
 	optimizerv1alpha1 "intelligent-cluster-optimizer/pkg/apis/optimizer/v1alpha1"
+	"intelligent-cluster-optimizer/pkg/recommendation"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -107,6 +109,16 @@ func TestValidator_ValidateMaintenanceWindows(t *testing.T) {
 			},
 			shouldError: true,
 		},
+		{
+			name: "schedule that never fires",
+			windows: []optimizerv1alpha1.MaintenanceWindow{
+				{
+					Schedule: "0 0 30 2 *", // February 30th
+					Duration: "2h",
+				},
+			},
+			shouldError: true,
+		},
 		{
 			name: "invalid duration format",
 			windows: []optimizerv1alpha1.MaintenanceWindow{
@@ -295,6 +307,21 @@ func TestValidator_ValidateRecommendationConfig(t *testing.T) {
 			},
 			shouldError: true,
 		},
+		{
+			name: "built-in recommenders per resource",
+			config: &optimizerv1alpha1.RecommendationConfig{
+				CPURecommender:    "decaying-histogram",
+				MemoryRecommender: "percentile",
+			},
+			shouldError: false,
+		},
+		{
+			name: "unknown memory recommender",
+			config: &optimizerv1alpha1.RecommendationConfig{
+				MemoryRecommender: "vpa",
+			},
+			shouldError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -317,6 +344,36 @@ func TestValidator_ValidateRecommendationConfig(t *testing.T) {
 	}
 }
 
+type stubRecommender struct{}
+
+func (stubRecommender) Name() string { return "vpa" }
+
+func (stubRecommender) Recommend(input *recommendation.RecommenderInput) (*recommendation.RecommenderOutput, error) {
+	return &recommendation.RecommenderOutput{}, nil
+}
+
+func TestValidator_AcceptsRegisteredRecommenders(t *testing.T) {
+	registry := recommendation.NewDefaultRegistry()
+	registry.MustRegister(stubRecommender{})
+	validator := NewValidatorWithRegistry(registry)
+
+	config := &optimizerv1alpha1.OptimizerConfig{
+		Spec: optimizerv1alpha1.OptimizerConfigSpec{
+			TargetNamespaces: []string{"default"},
+			Recommendations:  &optimizerv1alpha1.RecommendationConfig{MemoryRecommender: "vpa"},
+		},
+	}
+	if err := validator.ValidateCreate(config); err != nil {
+		t.Errorf("Expected a registered custom recommender to be accepted, got %v", err)
+	}
+
+	config.Spec.Recommendations.CPURecommender = "unknown"
+	err := validator.ValidateCreate(config)
+	if err == nil || !strings.Contains(err.Error(), "vpa") {
+		t.Errorf("Expected an error listing the registered recommenders, got %v", err)
+	}
+}
+
 func TestValidator_ValidateProfileOverrides(t *testing.T) {
 	validator := NewValidator()
 
@@ -427,6 +484,22 @@ func TestValidator_ValidateUpdateStrategy(t *testing.T) {
 			},
 			shouldError: true,
 		},
+		{
+			name: "valid canary",
+			strategy: &optimizerv1alpha1.UpdateStrategy{
+				Type:   optimizerv1alpha1.UpdateStrategyCanary,
+				Canary: &optimizerv1alpha1.CanaryConfig{Replicas: 1, SoakPeriod: "10m"},
+			},
+			shouldError: false,
+		},
+		{
+			name: "invalid canary soak period",
+			strategy: &optimizerv1alpha1.UpdateStrategy{
+				Type:   optimizerv1alpha1.UpdateStrategyCanary,
+				Canary: &optimizerv1alpha1.CanaryConfig{SoakPeriod: "ten minutes"},
+			},
+			shouldError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -488,6 +561,30 @@ func TestValidator_ValidateCircuitBreaker(t *testing.T) {
 			},
 			shouldError: true,
 		},
+		{
+			name: "valid namespace threshold and max timeout",
+			cb: &optimizerv1alpha1.CircuitBreakerConfig{
+				NamespaceErrorThreshold: 10,
+				Timeout:                 "5m",
+				MaxTimeout:              "1h",
+			},
+			shouldError: false,
+		},
+		{
+			name: "invalid namespace error threshold too high",
+			cb: &optimizerv1alpha1.CircuitBreakerConfig{
+				NamespaceErrorThreshold: 150,
+			},
+			shouldError: true,
+		},
+		{
+			name: "invalid max timeout shorter than timeout",
+			cb: &optimizerv1alpha1.CircuitBreakerConfig{
+				Timeout:    "10m",
+				MaxTimeout: "5m",
+			},
+			shouldError: true,
+		},
 	}
 
 	for _, tt := range tests {